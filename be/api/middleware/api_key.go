@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIKeyHeader carries the API key of machine clients
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator resolves the key sent by a client, it fails for unknown,
+// revoked and expired keys
+type APIKeyAuthenticator interface {
+	Authenticate(rawKey string) (entity.APIKey, error)
+}
+
+var apiKeys APIKeyAuthenticator
+
+// SetAPIKeyAuthenticator makes JWTOrAPIKey accept API keys, call it once at
+// startup. Without it only tokens are accepted
+func SetAPIKeyAuthenticator(auth APIKeyAuthenticator) {
+	apiKeys = auth
+}
+
+// JWTOrAPIKey accepts an API key holding the scope in the X-API-Key header in
+// place of a user token. The request then acts as the key's owner with only
+// the key's scopes as permissions, and counts against the key's own per
+// minute limit. Requests without the header go through JWTMiddleware
+func JWTOrAPIKey(limiter repository.RateLimitRepository, scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withToken := JWTMiddleware(next)
+
+		return func(c echo.Context) error {
+			rawKey := c.Request().Header.Get(APIKeyHeader)
+			if rawKey == "" {
+				return withToken(c)
+			}
+			if apiKeys == nil {
+				return jwtErrorHandler(c, echo.ErrUnauthorized)
+			}
+
+			key, err := apiKeys.Authenticate(rawKey)
+			if err != nil {
+				log.Printf("rejected api key: %v", err)
+				return jwtErrorHandler(c, echo.ErrUnauthorized)
+			}
+			if !key.HasScope(scope) {
+				return utils.ForbiddenResponse(c, "api key is missing scope "+scope)
+			}
+
+			if os.Getenv("RATE_LIMIT_DISABLED") != "true" {
+				bucket := fmt.Sprintf("ratelimit:apikey:%d", key.Id)
+				res, err := limiter.Allow(bucket, key.RateLimitPerMinute, time.Minute)
+				if err != nil {
+					log.Printf("failed check rate limit %s %s", bucket, err)
+				} else {
+					setRateLimitHeaders(c, res)
+					if !res.Allowed {
+						return utils.TooManyRequestsResponse(c, "too many requests, try again later", res.ResetIn)
+					}
+				}
+			}
+
+			c.Set("user_id", uint(key.UserId))
+			c.Set("api_key_id", key.Id)
+			c.Set("is_admin", false)
+			c.Set("roles", []string{})
+			c.Set("permissions", key.ScopeList())
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubAPIKeys map[string]entity.APIKey
+
+func (s stubAPIKeys) Authenticate(rawKey string) (entity.APIKey, error) {
+	key, ok := s[rawKey]
+	if !ok {
+		return entity.APIKey{}, errors.New("invalid api key")
+	}
+	return key, nil
+}
+
+func TestJWTOrAPIKey(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+	SetRevocationList(nil)
+
+	SetAPIKeyAuthenticator(stubAPIKeys{
+		"ydr_partner": {Id: 1, UserId: 7, Scopes: "final_donations.read", RateLimitPerMinute: 2},
+		"ydr_other":   {Id: 2, UserId: 8, Scopes: "auctions.read", RateLimitPerMinute: 2},
+	})
+	t.Cleanup(func() { SetAPIKeyAuthenticator(nil) })
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	limiter := repository.NewRateLimitRepository(client, context.Background())
+
+	var userID uint
+	var permissions []string
+	e := echo.New()
+	e.GET("/donations/final", func(c echo.Context) error {
+		userID, _ = utils.GetUserID(c)
+		permissions, _ = c.Get("permissions").([]string)
+		return c.NoContent(http.StatusOK)
+	}, JWTOrAPIKey(limiter, utils.ScopeReadFinalDonations))
+
+	call := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/donations/final", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call("", "").Code, "without a key a token is required")
+	assert.Equal(t, http.StatusUnauthorized, call(APIKeyHeader, "ydr_unknown").Code)
+	assert.Equal(t, http.StatusForbidden, call(APIKeyHeader, "ydr_other").Code, "keys only reach routes of their scopes")
+
+	rec := call(APIKeyHeader, "ydr_partner")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", rec.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, uint(7), userID, "the request acts as the key's owner")
+	assert.Equal(t, []string{utils.ScopeReadFinalDonations}, permissions, "with only the key's scopes")
+
+	assert.Equal(t, http.StatusOK, call(APIKeyHeader, "ydr_partner").Code)
+	assert.Equal(t, http.StatusTooManyRequests, call(APIKeyHeader, "ydr_partner").Code, "every key has its own limit")
+
+	token, err := utils.GenerateJwtToken("d@example.com", 7, []string{"donor"}, []string{utils.PermReviewDonations}, false, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call(echo.HeaderAuthorization, "Bearer "+token).Code, "tokens still work")
+}
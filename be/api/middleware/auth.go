@@ -1,15 +1,28 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
+
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
 
 	"github.com/golang-jwt/jwt/v5"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 )
 
-// JWTMiddleware validates JWT and stores claims into context
+var revocationList repository.TokenRevocationRepository
+
+// SetRevocationList makes JWTMiddleware reject revoked access tokens, call it once at startup
+func SetRevocationList(list repository.TokenRevocationRepository) {
+	revocationList = list
+}
+
+// JWTMiddleware validates JWT, rejects revoked tokens and stores claims into context
 func JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	jwtSecretKey := os.Getenv("SECRET_KEY")
 	config := echojwt.Config{
@@ -29,6 +42,11 @@ func JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return jwtErrorHandler(c, echo.ErrUnauthorized)
 		}
 
+		// every token we issue has a jti, without one it could not be revoked on its own
+		if jti, _ := claims["jti"].(string); jti == "" || revoked(claims) {
+			return jwtErrorHandler(c, echo.ErrUnauthorized)
+		}
+
 		if id, ok := claims["id"].(float64); ok {
 			c.Set("user_id", uint(id))
 		}
@@ -41,11 +59,68 @@ func JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		if email, ok := claims["email"].(string); ok {
 			c.Set("email", email)
 		}
+		roles := stringClaims(claims["roles"])
+		c.Set("roles", roles)
+		c.Set("permissions", stringClaims(claims["permissions"]))
+
+		if authTime, ok := claims["auth_time"].(float64); ok {
+			c.Set("auth_time", time.Unix(int64(authTime), 0))
+		}
+
+		mfa, _ := claims["mfa"].(bool)
+		c.Set("mfa", mfa)
+		if !mfa && utils.MFARequired(roles) && !mfaExempt(c.Path()) {
+			return utils.ForbiddenResponse(c, "two-factor authentication is required for this account, set it up at /users/me/2fa and log in again")
+		}
 
 		return next(c)
 	})
 }
 
+// mfaExempt lists the routes an account that must use two-factor
+// authentication can reach without it, enough to set it up and log out
+func mfaExempt(path string) bool {
+	return strings.HasPrefix(path, "/users/me") || path == "/auth/logout"
+}
+
+// revoked checks the token's jti and the user's revoke-all time
+func revoked(claims jwt.MapClaims) bool {
+	if revocationList == nil {
+		return false
+	}
+
+	jti, _ := claims["jti"].(string)
+	id, _ := claims["id"].(float64)
+	// tokens issued before iat_ms existed only have iat in whole seconds, which
+	// puts them at the start of their second
+	var issuedAt time.Time
+	if ms, ok := claims["iat_ms"].(float64); ok {
+		issuedAt = time.UnixMilli(int64(ms))
+	} else if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		issuedAt = iat.Time
+	}
+
+	isRevoked, err := revocationList.IsRevoked(jti, int(id), issuedAt)
+	if err != nil {
+		// fail closed, a token we cannot check may be a revoked one
+		log.Printf("failed check token revocation %s", err)
+		return true
+	}
+	return isRevoked
+}
+
+// stringClaims reads a JSON array claim, tokens issued before it existed have none
+func stringClaims(v interface{}) []string {
+	items, _ := v.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 func jwtErrorHandler(c echo.Context, err error) error {
 	return c.JSON(http.StatusUnauthorized, map[string]interface{}{
 		"message": "you are unauthorized",
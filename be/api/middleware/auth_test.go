@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTMiddleware_Revocation(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	list := repository.NewTokenRevocationRepository(client, context.Background())
+	SetRevocationList(list)
+	t.Cleanup(func() { SetRevocationList(nil) })
+
+	e := echo.New()
+	e.GET("/me", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, JWTMiddleware)
+
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	token, err := utils.GenerateJwtToken("test@example.com", 1, []string{"donor"}, nil, false, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call(token))
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	jti := parsed.Claims.(jwt.MapClaims)["jti"].(string)
+
+	require.NoError(t, list.RevokeToken(jti, time.Minute))
+	assert.Equal(t, http.StatusUnauthorized, call(token), "revoked tokens stop working immediately")
+
+	other, err := utils.GenerateJwtToken("test@example.com", 1, []string{"donor"}, nil, false, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call(other))
+
+	// other was issued before the revoke-all, most likely in the same second
+	require.NoError(t, list.RevokeUser(1, time.Now().Add(time.Millisecond), time.Minute))
+	assert.Equal(t, http.StatusUnauthorized, call(other), "revoking the user stops all of their tokens")
+
+	require.NoError(t, list.RevokeUser(2, time.Now(), time.Minute))
+	fresh, err := utils.GenerateJwtToken("test2@example.com", 2, []string{"donor"}, nil, false, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call(fresh), "a token issued right after a revoke-all works")
+
+	withoutJti, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":  float64(3),
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, call(withoutJti), "tokens without a jti are rejected")
+}
+
+func TestJWTMiddleware_MFARequired(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+	SetRevocationList(nil)
+
+	e := echo.New()
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/admin/users", ok, JWTMiddleware)
+	e.POST("/users/me/2fa/setup", ok, JWTMiddleware)
+
+	call := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	withoutMFA, err := utils.GenerateJwtToken("admin@example.com", 1, []string{"admin"}, nil, false, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, call(http.MethodGet, "/admin/users", withoutMFA))
+	assert.Equal(t, http.StatusOK, call(http.MethodPost, "/users/me/2fa/setup", withoutMFA), "two-factor setup stays reachable")
+
+	withMFA, err := utils.GenerateJwtToken("admin@example.com", 1, []string{"admin"}, nil, true, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/admin/users", withMFA))
+
+	donor, err := utils.GenerateJwtToken("donor@example.com", 2, []string{"donor"}, nil, false, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/admin/users", donor))
+
+	t.Setenv("MFA_REQUIRED_ROLES", "admin,auctioneer")
+	auctioneer, err := utils.GenerateJwtToken("a@example.com", 3, []string{"auctioneer"}, nil, false, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, call(http.MethodGet, "/admin/users", auctioneer))
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	IdempotencyHeader         = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultIdempotencyTTLHours is how long a response is replayed for its key
+	DefaultIdempotencyTTLHours = 24
+
+	// idempotencyLockTTL frees the key if the first request dies before finishing,
+	// a request still running extends it every idempotencyLockRefresh
+	idempotencyLockTTL = time.Minute
+	maxIdempotencyKey  = 255
+)
+
+var idempotencyLockRefresh = idempotencyLockTTL / 3
+
+// Idempotency replays the first response to a request retried with the same
+// Idempotency-Key header instead of running the handler again. Keys are scoped
+// to the user and the request path, requests without the header pass through
+// and server errors are not kept so the client can retry them
+func Idempotency(store repository.IdempotencyRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(IdempotencyHeader)
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKey {
+				return utils.BadRequestResponse(c, fmt.Sprintf("%s must be at most %d characters", IdempotencyHeader, maxIdempotencyKey))
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return utils.BadRequestResponse(c, err.Error())
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+
+			userID, _ := utils.GetUserID(c)
+			storeKey := fmt.Sprintf("idempotency:%d:%s:%s:%s", userID, c.Request().Method, c.Request().URL.Path, key)
+
+			existing, reserved, err := store.Reserve(storeKey, fingerprint, idempotencyLockTTL)
+			if errors.Is(err, repository.ErrIdempotencyKeyBusy) {
+				return utils.ConflictResponse(c, "a request with this "+IdempotencyHeader+" is still in progress")
+			}
+			if err != nil {
+				c.Logger().Errorf("idempotency reserve error: %v", err)
+				return utils.InternalServerErrorResponse(c, "internal server error")
+			}
+
+			if !reserved {
+				switch {
+				case existing == nil:
+					return utils.ConflictResponse(c, "a request with this "+IdempotencyHeader+" is still in progress")
+				case existing.Fingerprint != fingerprint:
+					return utils.UnprocessableEntityResponse(c, IdempotencyHeader+" was already used with a different request")
+				case !existing.Completed:
+					return utils.ConflictResponse(c, "a request with this "+IdempotencyHeader+" is still in progress")
+				}
+
+				c.Response().Header().Set(IdempotencyReplayedHeader, "true")
+				return c.Blob(existing.StatusCode, existing.ContentType, existing.Body)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			// a slow handler (e.g. waiting on the payment gateway) must not lose
+			// the key, a retry would run the request a second time
+			stop := keepReserved(store, storeKey, fingerprint)
+			err = next(c)
+			stop()
+			if err != nil {
+				_ = store.Release(storeKey)
+				return err
+			}
+
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError {
+				_ = store.Release(storeKey)
+				return nil
+			}
+
+			err = store.Save(storeKey, repository.IdempotentResponse{
+				Fingerprint: fingerprint,
+				StatusCode:  status,
+				ContentType: c.Response().Header().Get(echo.HeaderContentType),
+				Body:        recorder.body.Bytes(),
+			}, idempotencyTTL())
+			if err != nil {
+				c.Logger().Errorf("idempotency save error: %v", err)
+			}
+
+			return nil
+		}
+	}
+}
+
+// keepReserved extends the reservation until stop is called, stop returns once
+// the last extension is done so it cannot land after the response is saved
+func keepReserved(store repository.IdempotencyRepository, key, fingerprint string) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyLockRefresh)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = store.Extend(key, fingerprint, idempotencyLockTTL)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// responseRecorder copies the response body as it is written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func idempotencyTTL() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = DefaultIdempotencyTTLHours
+	}
+	return time.Duration(hours) * time.Hour
+}
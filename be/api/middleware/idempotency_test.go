@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := repository.NewIdempotencyRepository(client, context.Background())
+
+	calls := 0
+	status := http.StatusCreated
+	e := echo.New()
+	e.POST("/payments/:auctionId", func(c echo.Context) error {
+		calls++
+		if status >= http.StatusInternalServerError {
+			return utils.InternalServerErrorResponse(c, "gateway down")
+		}
+		return utils.CreatedResponse(c, "create", map[string]int{"call": calls})
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", uint(1))
+			return next(c)
+		}
+	}, Idempotency(store))
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/payments/1", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(IdempotencyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("key-1", `{"payment_type":"qris"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, 1, calls)
+
+	t.Run("retry replays the first response", func(t *testing.T) {
+		retry := send("key-1", `{"payment_type":"qris"}`)
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, "true", retry.Header().Get(IdempotencyReplayedHeader))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("same key with another body is rejected", func(t *testing.T) {
+		rec := send("key-1", `{"payment_type":"gopay"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("request still in progress", func(t *testing.T) {
+		_, _, err := store.Reserve("idempotency:1:POST:/payments/1:key-2", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", idempotencyLockTTL)
+		assert.NoError(t, err)
+		rec := send("key-2", "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("without a key every request runs", func(t *testing.T) {
+		send("", `{}`)
+		send("", `{}`)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("server errors can be retried", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		assert.Equal(t, http.StatusInternalServerError, send("key-3", `{}`).Code)
+		status = http.StatusCreated
+		rec := send("key-3", `{}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(IdempotencyReplayedHeader))
+		assert.Equal(t, 5, calls)
+	})
+}
+
+// busyStore loses every race for the key
+type busyStore struct {
+	repository.IdempotencyRepository
+}
+
+func (busyStore) Reserve(key, fingerprint string, lockTTL time.Duration) (*repository.IdempotentResponse, bool, error) {
+	return nil, false, repository.ErrIdempotencyKeyBusy
+}
+
+func TestIdempotency_BusyKey(t *testing.T) {
+	calls := 0
+	e := echo.New()
+	e.POST("/payments/:auctionId", func(c echo.Context) error {
+		calls++
+		return utils.CreatedResponse(c, "create", nil)
+	}, Idempotency(busyStore{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/payments/1", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyHeader, "key-1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestIdempotency_SlowRequestKeepsKey(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := repository.NewIdempotencyRepository(client, context.Background())
+
+	refresh := idempotencyLockRefresh
+	idempotencyLockRefresh = 10 * time.Millisecond
+	t.Cleanup(func() { idempotencyLockRefresh = refresh })
+
+	storeKey := "idempotency:1:POST:/payments/1:key-1"
+	e := echo.New()
+	e.POST("/payments/:auctionId", func(c echo.Context) error {
+		// the gateway takes longer than the lock TTL
+		for i := 0; i < 3; i++ {
+			mr.FastForward(idempotencyLockTTL / 2)
+			time.Sleep(50 * time.Millisecond)
+		}
+		assert.True(t, mr.Exists(storeKey), "lock expired while the handler was running")
+		return utils.CreatedResponse(c, "create", nil)
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", uint(1))
+			return next(c)
+		}
+	}, Idempotency(store))
+
+	req := httptest.NewRequest(http.MethodPost, "/payments/1", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyHeader, "key-1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, idempotencyTTL(), mr.TTL(storeKey))
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"milestone3/be/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePermission ensures the token grants every given permission, use it after JWTMiddleware
+func RequirePermission(permissions ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, p := range permissions {
+				if !utils.HasPermission(c, p) {
+					return utils.ForbiddenResponse(c, "missing permission "+p)
+				}
+			}
+			return next(c)
+		}
+	}
+}
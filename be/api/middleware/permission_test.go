@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/utils"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirePermission(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	e := echo.New()
+	e.POST("/auction/items", func(c echo.Context) error { return c.NoContent(http.StatusCreated) },
+		JWTMiddleware, RequirePermission(utils.PermManageAuctions))
+
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/auction/items", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	auctioneer, err := utils.GenerateJwtToken("a@example.com", 1, []string{"auctioneer"}, []string{utils.PermManageAuctions}, false, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, call(auctioneer))
+
+	donor, err := utils.GenerateJwtToken("d@example.com", 2, []string{"donor", "bidder"}, []string{utils.PermCreateDonations, utils.PermPlaceBids}, false, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, call(donor))
+
+	// a role name alone grants nothing, only the permissions in the token count
+	admin, err := utils.GenerateJwtToken("x@example.com", 3, []string{"admin"}, nil, true, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, call(admin))
+}
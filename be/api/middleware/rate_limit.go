@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// RateLimitRule is one bucket, counted per client IP or per signed in user
+type RateLimitRule struct {
+	scope  string
+	limit  int
+	window time.Duration
+}
+
+// PerIP counts requests by client IP
+func PerIP(limit int, window time.Duration) RateLimitRule {
+	return RateLimitRule{scope: "ip", limit: limit, window: window}
+}
+
+// PerUser counts requests by the user of the token, it needs JWTMiddleware
+// to run first and is skipped for anonymous requests
+func PerUser(limit int, window time.Duration) RateLimitRule {
+	return RateLimitRule{scope: "user", limit: limit, window: window}
+}
+
+// RateLimit rejects requests with 429 once any rule's bucket is used up. Every
+// response gets X-RateLimit-* headers for the bucket closest to its limit.
+// Buckets are shared by every route using the same name. When redis is down
+// requests are let through, RATE_LIMIT_DISABLED=true turns it off (load tests)
+func RateLimit(store repository.RateLimitRepository, name string, rules ...RateLimitRule) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if os.Getenv("RATE_LIMIT_DISABLED") == "true" {
+				return next(c)
+			}
+
+			var tightest *repository.RateLimitResult
+			for _, rule := range rules {
+				id, ok := rule.subject(c)
+				if !ok {
+					continue
+				}
+
+				key := fmt.Sprintf("ratelimit:%s:%s:%s:%d", name, rule.scope, id, rule.window.Milliseconds())
+				res, err := store.Allow(key, rule.limit, rule.window)
+				if err != nil {
+					log.Printf("failed check rate limit %s %s", key, err)
+					continue
+				}
+
+				if !res.Allowed {
+					setRateLimitHeaders(c, res)
+					return utils.TooManyRequestsResponse(c, "too many requests, try again later", res.ResetIn)
+				}
+				if tightest == nil || res.Remaining < tightest.Remaining {
+					r := res
+					tightest = &r
+				}
+			}
+
+			if tightest != nil {
+				setRateLimitHeaders(c, *tightest)
+			}
+			return next(c)
+		}
+	}
+}
+
+func (r RateLimitRule) subject(c echo.Context) (string, bool) {
+	if r.scope == "user" {
+		userID, ok := utils.GetUserID(c)
+		if !ok || userID == 0 {
+			return "", false
+		}
+		return strconv.FormatUint(uint64(userID), 10), true
+	}
+	return c.RealIP(), true
+}
+
+func setRateLimitHeaders(c echo.Context, res repository.RateLimitResult) {
+	h := c.Response().Header()
+	h.Set(RateLimitLimitHeader, strconv.Itoa(res.Limit))
+	h.Set(RateLimitRemainingHeader, strconv.Itoa(res.Remaining))
+	h.Set(RateLimitResetHeader, strconv.Itoa(int(math.Ceil(res.ResetIn.Seconds()))))
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := repository.NewRateLimitRepository(client, context.Background())
+
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	ok := func(c echo.Context) error { return utils.SuccessResponse(c, "ok", nil) }
+	asUser := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if id := c.Request().Header.Get("X-Test-User"); id == "1" {
+				c.Set("user_id", uint(1))
+			} else if id == "2" {
+				c.Set("user_id", uint(2))
+			}
+			return next(c)
+		}
+	}
+	e.POST("/login", ok, RateLimit(store, "login", PerIP(2, time.Minute)))
+	e.POST("/bid", ok, asUser, RateLimit(store, "bid", PerUser(2, time.Minute), PerIP(3, time.Minute)))
+
+	send := func(path, ip, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set(echo.HeaderXForwardedFor, ip)
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("headers count down and 429 after the limit", func(t *testing.T) {
+		first := send("/login", "1.1.1.1", "")
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, "2", first.Header().Get(RateLimitLimitHeader))
+		assert.Equal(t, "1", first.Header().Get(RateLimitRemainingHeader))
+		assert.Equal(t, "60", first.Header().Get(RateLimitResetHeader))
+
+		assert.Equal(t, http.StatusOK, send("/login", "1.1.1.1", "").Code)
+
+		blocked := send("/login", "1.1.1.1", "")
+		assert.Equal(t, http.StatusTooManyRequests, blocked.Code)
+		assert.Equal(t, "60", blocked.Header().Get("Retry-After"))
+		assert.Equal(t, "0", blocked.Header().Get(RateLimitRemainingHeader))
+
+		assert.Equal(t, http.StatusOK, send("/login", "2.2.2.2", "").Code, "other IPs have their own bucket")
+	})
+
+	t.Run("spoofed forwarded entries do not get a new bucket", func(t *testing.T) {
+		assert.Equal(t, http.StatusTooManyRequests, send("/login", "9.9.9.9, 1.1.1.1", "").Code)
+	})
+
+	t.Run("per user and per IP buckets both apply", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send("/bid", "3.3.3.3", "1").Code)
+		rec := send("/bid", "3.3.3.3", "1")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get(RateLimitRemainingHeader), "the user bucket is the tightest")
+
+		assert.Equal(t, http.StatusTooManyRequests, send("/bid", "4.4.4.4", "1").Code, "the user bucket follows the user across IPs")
+
+		// a second account on the same IP fills the IP bucket
+		assert.Equal(t, http.StatusOK, send("/bid", "3.3.3.3", "2").Code)
+		assert.Equal(t, http.StatusTooManyRequests, send("/bid", "3.3.3.3", "2").Code)
+	})
+
+	t.Run("window resets", func(t *testing.T) {
+		mr.FastForward(time.Minute)
+		assert.Equal(t, http.StatusOK, send("/login", "1.1.1.1", "").Code)
+	})
+
+	t.Run("disabled by env", func(t *testing.T) {
+		t.Setenv("RATE_LIMIT_DISABLED", "true")
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, send("/login", "5.5.5.5", "").Code)
+		}
+	})
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Allow(string, int, time.Duration) (repository.RateLimitResult, error) {
+	return repository.RateLimitResult{}, errors.New("redis down")
+}
+
+func TestRateLimit_FailsOpen(t *testing.T) {
+	e := echo.New()
+	e.POST("/login", func(c echo.Context) error { return utils.SuccessResponse(c, "ok", nil) }, RateLimit(failingRateLimitStore{}, "login", PerIP(1, time.Minute)))
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(RateLimitLimitHeader))
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"milestone3/be/api/middleware"
 	"milestone3/be/internal/controller"
+	"milestone3/be/internal/utils"
 )
 
 func (r *EchoRouter) RegisterAdminRoutes(adminCtrl *controller.AdminController) {
@@ -11,6 +12,29 @@ func (r *EchoRouter) RegisterAdminRoutes(adminCtrl *controller.AdminController)
 	adminRoutes.Use(middleware.LoggingMiddleware)
 
 	//admin endpoint
-	adminRoutes.GET("/dashboard", adminCtrl.AdminDashboard)
+	adminRoutes.GET("/dashboard", adminCtrl.AdminDashboard, middleware.RequirePermission(utils.PermViewDashboard))
 	// adminRoutes.GET("/reports", adminCtrl.AdminReport)
+}
+
+func (r *EchoRouter) RegisterRoleRoutes(roleCtrl *controller.RoleController) {
+	roleRoutes := r.echo.Group("admin")
+	roleRoutes.Use(middleware.JWTMiddleware)
+	roleRoutes.Use(middleware.LoggingMiddleware)
+	roleRoutes.Use(middleware.RequirePermission(utils.PermManageUsers))
+
+	roleRoutes.GET("/roles", roleCtrl.GetRoles)
+	roleRoutes.GET("/users/:id/roles", roleCtrl.GetUserRoles)
+	roleRoutes.PUT("/users/:id/roles", roleCtrl.SetUserRoles)
+}
+
+func (r *EchoRouter) RegisterAPIKeyRoutes(apiKeyCtrl *controller.APIKeyController) {
+	keyRoutes := r.echo.Group("admin/api-keys")
+	keyRoutes.Use(middleware.JWTMiddleware)
+	keyRoutes.Use(middleware.LoggingMiddleware)
+	keyRoutes.Use(middleware.RequirePermission(utils.PermManageUsers))
+
+	keyRoutes.POST("", apiKeyCtrl.CreateAPIKey)
+	keyRoutes.GET("", apiKeyCtrl.GetAPIKeys)
+	keyRoutes.GET("/:id/usage", apiKeyCtrl.GetAPIKeyUsage)
+	keyRoutes.DELETE("/:id", apiKeyCtrl.RevokeAPIKey)
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package routes
 import (
 	"milestone3/be/api/middleware" // import admin middleware
 	"milestone3/be/internal/controller"
+	"milestone3/be/internal/utils"
 )
 
 func (r *EchoRouter) RegisterArticleRoutes(articleCtrl *controller.ArticleController) {
@@ -12,10 +13,10 @@ func (r *EchoRouter) RegisterArticleRoutes(articleCtrl *controller.ArticleContro
 	articleRoutes.GET("", articleCtrl.GetAllArticles)
 	articleRoutes.GET("/:id", articleCtrl.GetArticleByID)
 
-	// admin-only
+	// writers only
 	admin := articleRoutes.Group("")
 	admin.Use(middleware.JWTMiddleware)
-	admin.Use(middleware.RequireAdmin)
+	admin.Use(middleware.RequirePermission(utils.PermManageArticles))
 
 	admin.POST("", articleCtrl.CreateArticle)
 	admin.PUT("/:id", articleCtrl.UpdateArticle)
@@ -3,28 +3,33 @@ package routes
 import (
 	"milestone3/be/api/middleware"
 	"milestone3/be/internal/controller"
+	"milestone3/be/internal/utils"
 )
 
 func (r *EchoRouter) RegisterAuctionRoutes(auctionCtrl *controller.AuctionController) {
 	g := r.echo.Group("/auction/items")
-	g.Use(middleware.JWTMiddleware)
 	g.Use(middleware.LoggingMiddleware)
 
-	g.GET("", auctionCtrl.GetAllAuctionItems)
-	g.GET("/:id", auctionCtrl.GetAuctionItemByID)
-	g.POST("", auctionCtrl.CreateAuctionItem)
-	g.PATCH("/:id", auctionCtrl.UpdateAuctionItem)
-	g.DELETE("/:id", auctionCtrl.DeleteAuctionItem)
+	read := r.jwtOrAPIKey(utils.ScopeReadAuctions)
+	manage := middleware.RequirePermission(utils.PermManageAuctions)
+
+	g.GET("", auctionCtrl.GetAllAuctionItems, read)
+	g.GET("/:id", auctionCtrl.GetAuctionItemByID, read)
+	g.POST("", auctionCtrl.CreateAuctionItem, middleware.JWTMiddleware, manage)
+	g.PATCH("/:id", auctionCtrl.UpdateAuctionItem, middleware.JWTMiddleware, manage)
+	g.DELETE("/:id", auctionCtrl.DeleteAuctionItem, middleware.JWTMiddleware, manage)
 }
 
 func (r *EchoRouter) RegisterAuctionSessionRoutes(sessionCtrl *controller.AuctionSessionController) {
 	g := r.echo.Group("/auction/sessions")
-	g.Use(middleware.JWTMiddleware)
 	g.Use(middleware.LoggingMiddleware)
 
-	g.GET("", sessionCtrl.GetAllAuctionSessions)
-	g.GET("/:id", sessionCtrl.GetAuctionSessionByID)
-	g.POST("", sessionCtrl.CreateAuctionSession)
-	g.PUT("/:id", sessionCtrl.UpdateAuctionSession)
-	g.DELETE("/:id", sessionCtrl.DeleteAuctionSession)
+	read := r.jwtOrAPIKey(utils.ScopeReadAuctions)
+	manage := middleware.RequirePermission(utils.PermManageAuctions)
+
+	g.GET("", sessionCtrl.GetAllAuctionSessions, read)
+	g.GET("/:id", sessionCtrl.GetAuctionSessionByID, read)
+	g.POST("", sessionCtrl.CreateAuctionSession, middleware.JWTMiddleware, manage)
+	g.PUT("/:id", sessionCtrl.UpdateAuctionSession, middleware.JWTMiddleware, manage)
+	g.DELETE("/:id", sessionCtrl.DeleteAuctionSession, middleware.JWTMiddleware, manage)
 }
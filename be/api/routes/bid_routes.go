@@ -1,8 +1,11 @@
 package routes
 
 import (
+	"time"
+
 	"milestone3/be/api/middleware"
 	"milestone3/be/internal/controller"
+	"milestone3/be/internal/utils"
 )
 
 func (r *EchoRouter) RegisterBidRoutes(bidCtrl *controller.BidController) {
@@ -11,6 +14,20 @@ func (r *EchoRouter) RegisterBidRoutes(bidCtrl *controller.BidController) {
 	g.Use(middleware.JWTMiddleware)
 	g.Use(middleware.LoggingMiddleware)
 
-	g.POST("/:sessionID/items/:itemID/bid", bidCtrl.PlaceBid)
+	// bidding is throttled per user, and per IP against many accounts behind one client
+	bidLimit := r.rateLimit("bid", middleware.PerUser(30, time.Minute), middleware.PerIP(120, time.Minute))
+
+	g.POST("/:sessionID/items/:itemID/bid", bidCtrl.PlaceBid, middleware.RequirePermission(utils.PermPlaceBids), bidLimit, middleware.Idempotency(r.idempotencyRepo))
+	g.POST("/:sessionID/items/:itemID/max-bid", bidCtrl.SetMaxBid, middleware.RequirePermission(utils.PermPlaceBids), bidLimit)
+	g.POST("/:sessionID/items/:itemID/buy-now", bidCtrl.BuyNow, middleware.RequirePermission(utils.PermPlaceBids), bidLimit, middleware.Idempotency(r.idempotencyRepo))
 	g.GET("/:sessionID/items/:itemID/highest-bid", bidCtrl.GetHighestBid)
+	g.GET("/:sessionID/items/:itemID/bids", bidCtrl.GetBidHistory)
+	g.GET("/:sessionID/items/:itemID/stream", bidCtrl.StreamBids)
+
+	me := r.echo.Group("/auction/bids")
+
+	me.Use(middleware.JWTMiddleware)
+	me.Use(middleware.LoggingMiddleware)
+
+	me.GET("/me", bidCtrl.GetMyBids)
 }
@@ -3,6 +3,7 @@ package routes
 import (
 	"milestone3/be/api/middleware"
 	"milestone3/be/internal/controller"
+	"milestone3/be/internal/utils"
 )
 
 func (r *EchoRouter) RegisterDonationRoutes(donationCtrl *controller.DonationController) {
@@ -11,8 +12,9 @@ func (r *EchoRouter) RegisterDonationRoutes(donationCtrl *controller.DonationCon
 
 	donationRoutes.GET("", donationCtrl.GetAllDonations)
 	donationRoutes.GET("/:id", donationCtrl.GetDonationByID)
-	donationRoutes.POST("", donationCtrl.CreateDonation)
+	donationRoutes.GET("/:id/history", donationCtrl.GetDonationStatusHistory)
+	donationRoutes.POST("", donationCtrl.CreateDonation, middleware.RequirePermission(utils.PermCreateDonations))
 	donationRoutes.PUT("/:id", donationCtrl.UpdateDonation)
-	donationRoutes.PATCH("/:id", donationCtrl.PatchDonation)
+	donationRoutes.PATCH("/:id", donationCtrl.PatchDonation, middleware.RequirePermission(utils.PermReviewDonations))
 	donationRoutes.DELETE("/:id", donationCtrl.DeleteDonation)
 }
@@ -3,14 +3,15 @@ package routes
 import (
 	"milestone3/be/api/middleware"
 	"milestone3/be/internal/controller"
+	"milestone3/be/internal/utils"
 )
 
 func (r *EchoRouter) RegisterFinalDonationRoutes(finalDonationCtrl *controller.FinalDonationController) {
 	finalDonationRoutes := r.echo.Group("/donations/final")
-	finalDonationRoutes.Use(middleware.JWTMiddleware)
 
-	finalDonationRoutes.GET("", finalDonationCtrl.GetAllFinalDonations)
-	finalDonationRoutes.GET("/me", finalDonationCtrl.GetMyFinalDonations)
-	finalDonationRoutes.GET("/user/:user_id", finalDonationCtrl.GetAllFinalDonationsByUserID)
-	finalDonationRoutes.POST("/notes", finalDonationCtrl.UpdateNotes)
+	// partners read every final donation with an API key
+	finalDonationRoutes.GET("", finalDonationCtrl.GetAllFinalDonations, r.jwtOrAPIKey(utils.ScopeReadFinalDonations))
+	finalDonationRoutes.GET("/me", finalDonationCtrl.GetMyFinalDonations, middleware.JWTMiddleware)
+	finalDonationRoutes.GET("/user/:user_id", finalDonationCtrl.GetAllFinalDonationsByUserID, middleware.JWTMiddleware, middleware.RequirePermission(utils.PermReviewDonations))
+	finalDonationRoutes.POST("/notes", finalDonationCtrl.UpdateNotes, middleware.JWTMiddleware)
 }
@@ -1,8 +1,11 @@
 package routes
 
 import (
+	"time"
+
 	"milestone3/be/api/middleware"
 	"milestone3/be/internal/controller"
+	"milestone3/be/internal/utils"
 )
 
 func (r *EchoRouter) RegisterPaymentRoutes(paymentCtrl *controller.PaymentController) {
@@ -11,8 +14,15 @@ func (r *EchoRouter) RegisterPaymentRoutes(paymentCtrl *controller.PaymentContro
 	paymentRoutes.Use(middleware.LoggingMiddleware)
 
 	//payment endpoint
-	paymentRoutes.POST("/:auctionId", paymentCtrl.CreatePayment)
+	paymentRoutes.POST("/:auctionId", paymentCtrl.CreatePayment, middleware.RequirePermission(utils.PermPlaceBids), r.rateLimit("payment", middleware.PerUser(10, time.Minute)), middleware.Idempotency(r.idempotencyRepo))
 	paymentRoutes.GET("/status/:id", paymentCtrl.CheckPaymentStatusMidtrans)
+	paymentRoutes.GET("/me", paymentCtrl.GetMyPayments)
 	paymentRoutes.GET("/:id", paymentCtrl.GetPaymentById)
-	paymentRoutes.GET("", paymentCtrl.GetAllPayment)
+	paymentRoutes.GET("", paymentCtrl.GetAllPayment, middleware.RequirePermission(utils.PermManagePayments))
+	paymentRoutes.POST("/:id/cancel", paymentCtrl.CancelPayment, middleware.RequirePermission(utils.PermManagePayments))
+	paymentRoutes.POST("/:id/refunds", paymentCtrl.RefundPayment, middleware.RequirePermission(utils.PermManagePayments))
+	paymentRoutes.POST("/reconciliation", paymentCtrl.ReconcilePayments, middleware.RequirePermission(utils.PermManagePayments))
+
+	// called by Midtrans, authenticated by the notification signature instead of a JWT
+	r.echo.POST("/payments/notifications", paymentCtrl.HandleNotification, middleware.LoggingMiddleware)
 }
\ No newline at end of file
@@ -1,7 +1,9 @@
 package routes
 
 import (
+	"milestone3/be/api/middleware"
 	"milestone3/be/internal/controller"
+	"milestone3/be/internal/repository"
 
 	"github.com/labstack/echo/v4"
 )
@@ -10,25 +12,40 @@ type Router interface {
 	RegisterArticleRoutes(articleCtrl *controller.ArticleController)
 	RegisterDonationRoutes(donationCtrl *controller.DonationController)
 	RegisterUserRoutes(userCtrl *controller.UserController)
+	RegisterOIDCRoutes(oidcCtrl *controller.OIDCController)
 	RegisterPaymentRoutes(paymentCtrl *controller.PaymentController)
 	// RegisterBiddingRoutes(biddingCtrl *controller.BiddingController)
 	RegisterFinalDonationRoutes(finalDonationCtrl *controller.FinalDonationController)
 	// RegisterAuthRoutes(authCtrl *controller.AuthController)
 	RegisterAuctionRoutes(auctionCtrl *controller.AuctionController)
 	RegisterAdminRoutes(adminCtrl *controller.AdminController)
+	RegisterRoleRoutes(roleCtrl *controller.RoleController)
+	RegisterAPIKeyRoutes(apiKeyCtrl *controller.APIKeyController)
 	RegisterAuctionSessionRoutes(sessionCtrl *controller.AuctionSessionController)
 	RegisterBidRoutes(bidCtrl *controller.BidController)
 }
 
 type EchoRouter struct {
-	echo *echo.Echo
+	echo            *echo.Echo
+	idempotencyRepo repository.IdempotencyRepository
+	rateLimitRepo   repository.RateLimitRepository
 }
 
-func NewRouter(e *echo.Echo) Router {
-	return &EchoRouter{echo: e}
+func NewRouter(e *echo.Echo, idempotencyRepo repository.IdempotencyRepository, rateLimitRepo repository.RateLimitRepository) Router {
+	return &EchoRouter{echo: e, idempotencyRepo: idempotencyRepo, rateLimitRepo: rateLimitRepo}
+}
+
+// rateLimit builds the limiter for a group of routes, routes with the same name share buckets
+func (r *EchoRouter) rateLimit(name string, rules ...middleware.RateLimitRule) echo.MiddlewareFunc {
+	return middleware.RateLimit(r.rateLimitRepo, name, rules...)
+}
+
+// jwtOrAPIKey opens a read-only route to API keys holding the scope
+func (r *EchoRouter) jwtOrAPIKey(scope string) echo.MiddlewareFunc {
+	return middleware.JWTOrAPIKey(r.rateLimitRepo, scope)
 }
 
 // Example injection method in main:
-//  router := routes.NewRouter(e)
+//  router := routes.NewRouter(e, idempotencyRepo, rateLimitRepo)
 //  router.RegisterArticleRoutes(articleCtrl)
 //  router.RegisterDonationRoutes(donationCtrl)
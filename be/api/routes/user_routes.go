@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"time"
+
 	"milestone3/be/api/middleware"
 	"milestone3/be/internal/controller"
 )
@@ -8,8 +10,43 @@ import (
 func (r *EchoRouter) RegisterUserRoutes(userCtrl *controller.UserController) {
 	userRoutes := r.echo.Group("auth")
 	userRoutes.Use(middleware.LoggingMiddleware)
+	userRoutes.Use(r.rateLimit("auth", middleware.PerIP(60, time.Minute)))
 
 	// auth endpoint
-	userRoutes.POST("/register", userCtrl.CreateUser)
-	userRoutes.POST("/login", userCtrl.LoginUser)
+	userRoutes.POST("/register", userCtrl.CreateUser, r.rateLimit("register", middleware.PerIP(5, time.Hour)))
+	userRoutes.POST("/login", userCtrl.LoginUser, r.rateLimit("login", middleware.PerIP(10, time.Minute)))
+	userRoutes.POST("/login/mfa", userCtrl.LoginMFA, r.rateLimit("login", middleware.PerIP(10, time.Minute)))
+	userRoutes.POST("/refresh", userCtrl.RefreshToken)
+	userRoutes.POST("/logout", userCtrl.Logout, middleware.JWTMiddleware)
+	userRoutes.GET("/verify", userCtrl.VerifyEmail)
+	userRoutes.POST("/forgot-password", userCtrl.ForgotPassword, r.rateLimit("forgot-password", middleware.PerIP(5, 15*time.Minute)))
+	userRoutes.POST("/reset-password", userCtrl.ResetPassword)
+
+	// account of the signed in user
+	me := r.echo.Group("/users/me")
+	me.Use(middleware.JWTMiddleware)
+	me.Use(middleware.LoggingMiddleware)
+
+	me.GET("", userCtrl.GetProfile)
+	me.PATCH("", userCtrl.UpdateProfile)
+	me.DELETE("", userCtrl.DeleteAccount)
+	me.PUT("/password", userCtrl.ChangePassword)
+	me.POST("/deactivate", userCtrl.DeactivateAccount)
+
+	me.GET("/2fa", userCtrl.GetTwoFactorStatus)
+	me.POST("/2fa/setup", userCtrl.SetupTOTP)
+	me.POST("/2fa/enable", userCtrl.EnableTOTP)
+	me.POST("/2fa/disable", userCtrl.DisableTOTP)
+	me.POST("/2fa/recovery-codes", userCtrl.RegenerateRecoveryCodes)
+}
+
+// RegisterOIDCRoutes adds sign in with OpenID Connect providers next to the password login
+func (r *EchoRouter) RegisterOIDCRoutes(oidcCtrl *controller.OIDCController) {
+	oidcRoutes := r.echo.Group("auth/oidc")
+	oidcRoutes.Use(middleware.LoggingMiddleware)
+	oidcRoutes.Use(r.rateLimit("auth", middleware.PerIP(60, time.Minute)))
+
+	oidcRoutes.POST("/link", oidcCtrl.OIDCLink, r.rateLimit("login", middleware.PerIP(10, time.Minute)))
+	oidcRoutes.GET("/:provider", oidcCtrl.OIDCLogin)
+	oidcRoutes.GET("/:provider/callback", oidcCtrl.OIDCCallback, r.rateLimit("login", middleware.PerIP(10, time.Minute)))
 }
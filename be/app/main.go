@@ -20,6 +20,11 @@
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description Partner API key, only accepted by read-only routes matching the key's scopes.
+
 package main
 
 import (
@@ -27,12 +32,15 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"strings"
 
 	"cloud.google.com/go/storage"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/midtrans/midtrans-go"
 	"github.com/swaggo/echo-swagger"
 
+	"milestone3/be/api/middleware"
 	"milestone3/be/api/routes"
 	"milestone3/be/config"
 	"milestone3/be/internal/controller"
@@ -78,6 +86,81 @@ func main() {
 		log.Println("PRIVATE_BUCKET NOT SET")
 	}
 
+	// payment gateway, PAYMENT_GATEWAY=fake runs without a Midtrans account
+	var paymentGateway repository.PaymentGateway
+	var fakeGateway *repository.FakePaymentGateway
+	if os.Getenv("PAYMENT_GATEWAY") == "fake" {
+		fakeGateway = repository.NewFakePaymentGateway(os.Getenv("PAYMENT_FAKE_AUTO_SETTLE") == "true")
+		paymentGateway = fakeGateway
+		log.Println("PAYMENT_GATEWAY fake, no real charges are made")
+	} else {
+		midtransEnv := midtrans.Sandbox
+		if os.Getenv("MIDTRANS_ENV") == "production" {
+			midtransEnv = midtrans.Production
+		}
+		paymentGateway = repository.NewMidtransGateway(os.Getenv("MIDTRANS_SERVER_KEY"), midtransEnv, os.Getenv("MIDTRANS_BASE_URL"))
+	}
+
+	// password reset emails link to the frontend, the API only takes the new password
+	if os.Getenv("PASSWORD_RESET_URL") == "" {
+		log.Println("PASSWORD_RESET_URL not set, forgot password answers 503 until it points at the frontend page that asks for the new password")
+	}
+
+	// mailer, MAILER=smtp sends real emails, anything else writes them to MAIL_LOG_FILE or the log
+	var mailer repository.Mailer
+	if os.Getenv("MAILER") == "smtp" {
+		mailer = repository.NewSMTPMailer(os.Getenv("SMTP_HOST"), os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+	} else {
+		mailer = repository.NewFileMailer(os.Getenv("MAIL_LOG_FILE"), os.Getenv("MAIL_FROM"))
+		log.Println("MAILER log, emails are not delivered")
+	}
+
+	// social login, OIDC_PROVIDERS lists the providers (e.g. google), each one
+	// configured with OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and
+	// _REDIRECT_URL. The fake provider is served by this API for local development,
+	// it vouches for any email so it also needs OIDC_FAKE_ENABLED=true and never
+	// starts with APP_ENV=production
+	appBaseURL := strings.TrimSuffix(os.Getenv("APP_BASE_URL"), "/")
+	var oidcProviders []repository.OIDCProvider
+	var fakeOIDC *repository.FakeOIDCServer
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		env := func(key string) string { return os.Getenv("OIDC_" + strings.ToUpper(name) + "_" + key) }
+		issuer, clientId, redirectURL := env("ISSUER"), env("CLIENT_ID"), env("REDIRECT_URL")
+		if redirectURL == "" {
+			redirectURL = appBaseURL + "/auth/oidc/" + name + "/callback"
+		}
+
+		switch {
+		case name == "fake":
+			if os.Getenv("APP_ENV") == "production" {
+				log.Fatal("OIDC fake provider cannot run with APP_ENV=production, remove it from OIDC_PROVIDERS")
+			}
+			if os.Getenv("OIDC_FAKE_ENABLED") != "true" {
+				log.Println("OIDC fake skipped, set OIDC_FAKE_ENABLED=true to sign in without a provider")
+				continue
+			}
+			server, err := repository.NewFakeOIDCServer(appBaseURL + "/oidc-fake")
+			if err != nil {
+				log.Fatalf("failed to create fake oidc provider: %v", err)
+			}
+			fakeOIDC, issuer, clientId = server, server.Issuer(), "fake-client"
+			log.Println("OIDC fake provider, anyone can sign in as any email")
+		case name == "google" && issuer == "":
+			issuer = "https://accounts.google.com"
+		}
+
+		if issuer == "" || clientId == "" {
+			log.Printf("OIDC %s skipped, issuer and client id are required", name)
+			continue
+		}
+		oidcProviders = append(oidcProviders, repository.NewOIDCProvider(name, issuer, clientId, env("CLIENT_SECRET"), redirectURL))
+	}
+
 	// repositories
 	userRepo := repository.NewUserRepo(db, ctx)
 	articleRepo := repository.NewArticleRepo(db)
@@ -90,26 +173,53 @@ func main() {
 	bidRepo := repository.NewBidRepository(db)
 	redisClient := config.ConnectRedis(ctx)
 	redisRepo := repository.NewBidRedisRepository(redisClient, ctx)
+	bidEventRepo := repository.NewBidEventRepository(redisClient, ctx)
+	idempotencyRepo := repository.NewIdempotencyRepository(redisClient, ctx)
+	rateLimitRepo := repository.NewRateLimitRepository(redisClient, ctx)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(redisClient, ctx)
+	refreshTokenRepo := repository.NewRefreshTokenRepo(db, ctx)
+	roleRepo := repository.NewRoleRepo(db, ctx)
+	userIdentityRepo := repository.NewUserIdentityRepo(db, ctx)
+	apiKeyRepo := repository.NewAPIKeyRepo(db, ctx)
+	apiKeyUsageRepo := repository.NewAPIKeyUsageRepository(redisClient, ctx)
+	oidcStateRepo := repository.NewOIDCStateRepository(redisClient, ctx)
+	tokenRevocationRepo := repository.NewTokenRevocationRepository(redisClient, ctx)
+	photoURLCache := repository.NewPhotoURLCache(redisClient, ctx)
 	aiRepo := repository.NewAIRepository(logger, os.Getenv("GEMINI_API_KEY"))
 
 	// services
-	userSvc := service.NewUserService(userRepo)
+	userSvc := service.NewUserService(userRepo, refreshTokenRepo, tokenRevocationRepo, mailer, loginAttemptRepo)
+	roleSvc := service.NewRoleService(roleRepo, userRepo, tokenRevocationRepo)
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo, userRepo, apiKeyUsageRepo)
+	oidcSvc := service.NewOIDCService(userSvc, userIdentityRepo, oidcStateRepo, oidcProviders...)
 	articleSvc := service.NewArticleService(articleRepo)
-	donationSvc := service.NewDonationService(donationRepo, gcpPrivateRepo)
+	photoURLSigner := service.NewPhotoURLSigner(gcpPrivateRepo, photoURLCache)
+	donationSvc := service.NewDonationService(donationRepo, gcpPrivateRepo, aiRepo, photoURLSigner)
 	finalDonationSvc := service.NewFinalDonationService(finalDonationRepo, donationRepo)
-	paymentSvc := service.NewPaymentService(paymentRepo)
+	paymentSvc := service.NewPaymentService(paymentRepo, paymentGateway, bidRepo, auctionItemRepo)
+	if fakeGateway != nil {
+		// settling over HTTP applies the status right away, like a Midtrans notification
+		fakeGateway.OnStatusChange(func(orderId string) {
+			if _, err := paymentSvc.CheckPaymentStatusMidtrans(orderId, 0, true); err != nil {
+				log.Printf("failed apply fake gateway status of %s: %v", orderId, err)
+			}
+		})
+	}
 	adminSvc := service.NewAdminService(adminRepo)
-	auctionSvc := service.NewAuctionItemService(auctionItemRepo, aiRepo, logger)
+	auctionSvc := service.NewAuctionItemService(auctionItemRepo, aiRepo, photoURLSigner, logger)
 	auctionSessionSvc := service.NewAuctionSessionService(auctionSessionRepo, logger)
-	bidSvc := service.NewBidService(redisRepo, bidRepo, auctionItemRepo, auctionSessionRepo, logger)
+	bidSvc := service.NewBidService(redisRepo, bidRepo, auctionItemRepo, auctionSessionRepo, bidEventRepo, paymentSvc, logger)
 
-	// bid scheduler (now also handles auction auto-start)
-	bidScheduler := scheduler.NewBidScheduler(bidSvc, auctionSvc, logger)
+	// bid scheduler (now also handles auction auto-start and payment deadlines)
+	bidScheduler := scheduler.NewBidScheduler(bidSvc, auctionSvc, paymentSvc, logger)
 	bidScheduler.Start()
 
 	// controllers
 	userCtrl := controller.NewUserController(validate, userSvc)
+	oidcCtrl := controller.NewOIDCController(validate, oidcSvc)
 	adminCtrl := controller.NewAdminController(adminSvc)
+	roleCtrl := controller.NewRoleController(validate, roleSvc)
+	apiKeyCtrl := controller.NewAPIKeyController(validate, apiKeySvc)
 	articleCtrl := controller.NewArticleController(articleSvc, gcpPublicRepo)
 
 	var donationCtrl *controller.DonationController
@@ -125,18 +235,32 @@ func main() {
 	bidCtrl := controller.NewBidController(bidSvc, auctionSessionSvc, validate)
 
 	// echo + router
+	middleware.SetRevocationList(tokenRevocationRepo)
+	middleware.SetAPIKeyAuthenticator(apiKeySvc)
 	e := echo.New()
-	router := routes.NewRouter(e)
+	// the client IP is the right-most untrusted X-Forwarded-For entry, the ones
+	// before it are sent by the client and would let it dodge per-IP rate limits
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	router := routes.NewRouter(e, idempotencyRepo, rateLimitRepo)
 
 	// Swagger route
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	router.RegisterUserRoutes(userCtrl)
+	router.RegisterOIDCRoutes(oidcCtrl)
+	if fakeOIDC != nil {
+		e.Any("/oidc-fake/*", echo.WrapHandler(fakeOIDC))
+	}
 	router.RegisterArticleRoutes(articleCtrl)
 	router.RegisterDonationRoutes(donationCtrl)
 	router.RegisterFinalDonationRoutes(finalDonationCtrl)
 	router.RegisterPaymentRoutes(paymentCtrl)
+	if fakeGateway != nil {
+		e.POST("/payment-fake/*", echo.WrapHandler(fakeGateway))
+	}
 	router.RegisterAdminRoutes(adminCtrl)
+	router.RegisterRoleRoutes(roleCtrl)
+	router.RegisterAPIKeyRoutes(apiKeyCtrl)
 	router.RegisterAuctionRoutes(auctionCtrl)
 	router.RegisterAuctionSessionRoutes(auctionSessionCtrl)
 	router.RegisterBidRoutes(bidCtrl)
@@ -4,7 +4,6 @@ import (
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/utils"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
@@ -34,14 +33,6 @@ func NewAdminController(as AdminService) *AdminController {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /admin/dashboard [get]
 func (ac *AdminController) AdminDashboard(c echo.Context) error {
-	user := c.Get("user").(*jwt.Token)
-	claim := user.Claims.(jwt.MapClaims)
-	role := claim["role"].(string)
-
-	if role != "admin" {
-		return utils.ForbiddenResponse(c, "forbidden request")
-	}
-	
 	resp, err := ac.adminService.AdminDashboard()
 	if err != nil {
 		return utils.InternalServerErrorResponse(c, "internal server error")
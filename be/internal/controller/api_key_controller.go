@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/service"
+	"milestone3/be/internal/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(actorId int, req dto.CreateAPIKeyRequest) (res dto.CreatedAPIKeyResponse, err error)
+	GetAPIKeys(ownerId int) (res []dto.APIKeyResponse, err error)
+	GetAPIKeyUsage(id, days int) (res dto.APIKeyUsageResponse, err error)
+	RevokeAPIKey(id, actorId int) error
+}
+
+type APIKeyController struct {
+	apiKeyService APIKeyService
+	validate      *validator.Validate
+}
+
+func NewAPIKeyController(validate *validator.Validate, ks APIKeyService) *APIKeyController {
+	return &APIKeyController{validate: validate, apiKeyService: ks}
+}
+
+// CreateAPIKey godoc
+// @Summary Issue API key
+// @Description Issue a key that acts for the owner with the given scopes (final_donations.read, auctions.read). The key is only shown in this response
+// @Tags Your Donate Rise API - Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key body dto.CreateAPIKeyRequest true "Owner, name, scopes and optional limits"
+// @Success 201 {object} utils.SuccessResponseData{data=dto.CreatedAPIKeyResponse} "api key created"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload, unknown scope or deactivated owner"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - users.manage permission required"
+// @Failure 404 {object} utils.ErrorResponse "Owner not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/api-keys [post]
+func (kc *APIKeyController) CreateAPIKey(c echo.Context) error {
+	actorId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.CreateAPIKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := kc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	resp, err := kc.apiKeyService.CreateAPIKey(int(actorId), *req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			return utils.NotFoundResponse(c, err.Error())
+		case errors.Is(err, service.ErrUnknownScope), errors.Is(err, service.ErrAPIKeyOwnerInactive):
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.CreatedResponse(c, "api key created, store it now as it is not shown again", resp)
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description List API keys with their request counts, revoked keys included
+// @Tags Your Donate Rise API - Admin
+// @Produce json
+// @Security BearerAuth
+// @Param owner_id query int false "Only the keys of this user"
+// @Success 200 {object} utils.SuccessResponseData{data=[]dto.APIKeyResponse} "ok"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid owner ID"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - users.manage permission required"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/api-keys [get]
+func (kc *APIKeyController) GetAPIKeys(c echo.Context) error {
+	ownerId := 0
+	if param := c.QueryParam("owner_id"); param != "" {
+		id, err := strconv.Atoi(param)
+		if err != nil || id <= 0 {
+			return utils.BadRequestResponse(c, "invalid owner id")
+		}
+		ownerId = id
+	}
+
+	resp, err := kc.apiKeyService.GetAPIKeys(ownerId)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "ok", resp)
+}
+
+// GetAPIKeyUsage godoc
+// @Summary API key usage
+// @Description Requests made with the key per day (UTC), oldest first
+// @Tags Your Donate Rise API - Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Param days query int false "Number of days up to today (default: 30, max: 90)"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.APIKeyUsageResponse} "ok"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid API key ID"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - users.manage permission required"
+// @Failure 404 {object} utils.ErrorResponse "API key not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/api-keys/{id}/usage [get]
+func (kc *APIKeyController) GetAPIKeyUsage(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid api key id")
+	}
+	days, _ := strconv.Atoi(c.QueryParam("days"))
+
+	resp, err := kc.apiKeyService.GetAPIKeyUsage(id, days)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "ok", resp)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke API key
+// @Description Stop the key immediately, it stays listed with its usage
+// @Tags Your Donate Rise API - Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} utils.SuccessResponseData "api key revoked"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid API key ID"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - users.manage permission required"
+// @Failure 404 {object} utils.ErrorResponse "API key not found"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Already revoked"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/api-keys/{id} [delete]
+func (kc *APIKeyController) RevokeAPIKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid api key id")
+	}
+
+	actorId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	if err := kc.apiKeyService.RevokeAPIKey(id, int(actorId)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrAPIKeyNotFound):
+			return utils.NotFoundResponse(c, err.Error())
+		case errors.Is(err, service.ErrAPIKeyRevoked):
+			return utils.ConflictResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "api key revoked", nil)
+}
@@ -116,10 +116,6 @@ func (h *ArticleController) GetArticleByID(c echo.Context) error {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /articles [post]
 func (h *ArticleController) CreateArticle(c echo.Context) error {
-	if !utils.IsAdmin(c) {
-		return utils.ForbiddenResponse(c, "admin only")
-	}
-
 	contentType := c.Request().Header.Get("Content-Type")
 	var payload dto.ArticleDTO
 
@@ -224,9 +220,6 @@ func (h *ArticleController) CreateArticle(c echo.Context) error {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /articles/{id} [put]
 func (h *ArticleController) UpdateArticle(c echo.Context) error {
-	if !utils.IsAdmin(c) {
-		return utils.ForbiddenResponse(c, "admin only")
-	}
 	var payload dto.ArticleDTO
 	if err := c.Bind(&payload); err != nil {
 		return utils.BadRequestResponse(c, "invalid payload")
@@ -262,9 +255,6 @@ func (h *ArticleController) UpdateArticle(c echo.Context) error {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /articles/{id} [delete]
 func (h *ArticleController) DeleteArticle(c echo.Context) error {
-	if !utils.IsAdmin(c) {
-		return utils.ForbiddenResponse(c, "admin only")
-	}
 	idParam := c.Param("id")
 	id64, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
@@ -39,22 +39,6 @@ func getUserIDFromTokenItem(c echo.Context) (int64, error) {
 	return int64(userIDFloat), nil
 }
 
-// Helper function to check if user is admin from JWT token
-func isAdminFromTokenItem(c echo.Context) bool {
-	token := c.Get("user")
-	if token == nil {
-		return false
-	}
-
-	claims, ok := token.(*jwt.Token).Claims.(jwt.MapClaims)
-	if !ok {
-		return false
-	}
-
-	role, ok := claims["role"].(string)
-	return ok && role == "admin"
-}
-
 // CreateAuctionItem godoc
 // @Summary Create new auction item
 // @Description Create a new auction item from verified donation
@@ -67,14 +51,10 @@ func isAdminFromTokenItem(c echo.Context) bool {
 // @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload"
 // @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
 // @Failure 403 {object} utils.ErrorResponse "Forbidden - Admin access required"
+// @Failure 409 {object} utils.ErrorResponse "Donation already has an auction item"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /auction/items [post]
 func (h *AuctionController) CreateAuctionItem(c echo.Context) error {
-	// Check if user is admin
-	if !isAdminFromTokenItem(c) {
-		return utils.ForbiddenResponse(c, "only admin can create auction items")
-	}
-
 	// Get user ID from JWT token
 	userID, err := getUserIDFromTokenItem(c)
 	if err != nil {
@@ -93,6 +73,12 @@ func (h *AuctionController) CreateAuctionItem(c echo.Context) error {
 	payload.UserID = userID
 	createdItem, err := h.svc.Create(&payload)
 	if err != nil {
+		if err == service.ErrInvalidBuyNowPrice {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		if err == service.ErrDonationAuctioned {
+			return utils.ConflictResponse(c, err.Error())
+		}
 		return utils.InternalServerErrorResponse(c, "failed creating auction item")
 	}
 
@@ -101,15 +87,16 @@ func (h *AuctionController) CreateAuctionItem(c echo.Context) error {
 
 // GetAllAuctionItems godoc
 // @Summary Get all auction items
-// @Description Retrieve all available auction items
+// @Description Retrieve all available auction items, the reserve price is only included for admins
 // @Tags Your Donate Rise API - Auction Items
 // @Accept json
 // @Produce json
+// @Security APIKeyAuth
 // @Success 200 {object} utils.SuccessResponseData "auction items retrieved successfully"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /auction/items [get]
 func (h *AuctionController) GetAllAuctionItems(c echo.Context) error {
-	items, err := h.svc.GetAll()
+	items, err := h.svc.GetAll(utils.HasPermission(c, utils.PermManageAuctions))
 	if err != nil {
 		return utils.InternalServerErrorResponse(c, "failed retrieving auction items")
 	}
@@ -118,10 +105,11 @@ func (h *AuctionController) GetAllAuctionItems(c echo.Context) error {
 
 // GetAuctionItemByID godoc
 // @Summary Get auction item by ID
-// @Description Retrieve a specific auction item by its ID
+// @Description Retrieve a specific auction item by its ID, the reserve price is only included for admins
 // @Tags Your Donate Rise API - Auction Items
 // @Accept json
 // @Produce json
+// @Security APIKeyAuth
 // @Param id path int true "Auction Item ID"
 // @Success 200 {object} utils.SuccessResponseData "auction item retrieved successfully"
 // @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid auction item ID"
@@ -136,7 +124,7 @@ func (h *AuctionController) GetAuctionItemByID(c echo.Context) error {
 		return utils.BadRequestResponse(c, "invalid auction item ID")
 	}
 
-	item, err := h.svc.GetByID(id)
+	item, err := h.svc.GetByID(id, utils.HasPermission(c, utils.PermManageAuctions))
 	if err != nil {
 		switch err {
 		case service.ErrAuctionNotFoundID:
@@ -166,10 +154,6 @@ func (h *AuctionController) GetAuctionItemByID(c echo.Context) error {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /auction/items/{id} [put]
 func (h *AuctionController) UpdateAuctionItem(c echo.Context) error {
-	if !isAdminFromTokenItem(c) {
-		return utils.ForbiddenResponse(c, "only admin can update auction items")
-	}
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -194,9 +178,9 @@ func (h *AuctionController) UpdateAuctionItem(c echo.Context) error {
 			return utils.NotFoundResponse(c, err.Error())
 		case service.ErrAuctionFinished:
 			return utils.ConflictResponse(c, err.Error())
-		case service.ErrActiveSession:
+		case service.ErrActiveSession, service.ErrDonationAuctioned:
 			return utils.ConflictResponse(c, err.Error())
-		case service.ErrInvalidAuction:
+		case service.ErrInvalidAuction, service.ErrInvalidBuyNowPrice:
 			return utils.BadRequestResponse(c, err.Error())
 		default:
 			return utils.InternalServerErrorResponse(c, "failed updating auction item")
@@ -222,10 +206,6 @@ func (h *AuctionController) UpdateAuctionItem(c echo.Context) error {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /auction/items/{id} [delete]
 func (h *AuctionController) DeleteAuctionItem(c echo.Context) error {
-	if !isAdminFromTokenItem(c) {
-		return utils.ForbiddenResponse(c, "only admin can delete auction items")
-	}
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -7,7 +7,6 @@ import (
 	"strconv"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
@@ -20,21 +19,6 @@ func NewAuctionSessionController(s service.AuctionSessionService, validate *vali
 	return &AuctionSessionController{svc: s, validate: validate}
 }
 
-func isAdminFromTokenSession(c echo.Context) bool {
-	token := c.Get("user")
-	if token == nil {
-		return false
-	}
-
-	claims, ok := token.(*jwt.Token).Claims.(jwt.MapClaims)
-	if !ok {
-		return false
-	}
-
-	role, ok := claims["role"].(string)
-	return ok && role == "admin"
-}
-
 // CreateAuctionSession godoc
 // @Summary Create new auction session
 // @Description Create a new auction session with start and end times
@@ -50,10 +34,6 @@ func isAdminFromTokenSession(c echo.Context) bool {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /auction/sessions [post]
 func (h *AuctionSessionController) CreateAuctionSession(c echo.Context) error {
-	if !isAdminFromTokenSession(c) {
-		return utils.ForbiddenResponse(c, "only admin can create auction sessions")
-	}
-
 	var payload dto.AuctionSessionDTO
 	if err := c.Bind(&payload); err != nil {
 		return utils.BadRequestResponse(c, "invalid payload")
@@ -86,6 +66,7 @@ func (h *AuctionSessionController) CreateAuctionSession(c echo.Context) error {
 // @Tags Your Donate Rise API - Auction Sessions
 // @Accept json
 // @Produce json
+// @Security APIKeyAuth
 // @Param id path int true "Auction Session ID"
 // @Success 200 {object} utils.SuccessResponseData "auction session retrieved successfully"
 // @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid auction session ID"
@@ -118,6 +99,7 @@ func (h *AuctionSessionController) GetAuctionSessionByID(c echo.Context) error {
 // @Tags Your Donate Rise API - Auction Sessions
 // @Accept json
 // @Produce json
+// @Security APIKeyAuth
 // @Success 200 {object} utils.SuccessResponseData "auction sessions retrieved successfully"
 // @Failure 404 {object} utils.ErrorResponse "No auction sessions found"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
@@ -153,10 +135,6 @@ func (h *AuctionSessionController) GetAllAuctionSessions(c echo.Context) error {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /auction/sessions/{id} [put]
 func (h *AuctionSessionController) UpdateAuctionSession(c echo.Context) error {
-	if !isAdminFromTokenSession(c) {
-		return utils.ForbiddenResponse(c, "only admin can update auction sessions")
-	}
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -209,10 +187,6 @@ func (h *AuctionSessionController) UpdateAuctionSession(c echo.Context) error {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /auction/sessions/{id} [delete]
 func (h *AuctionSessionController) DeleteAuctionSession(c echo.Context) error {
-	if !isAdminFromTokenSession(c) {
-		return utils.ForbiddenResponse(c, "only admin can delete auction sessions")
-	}
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
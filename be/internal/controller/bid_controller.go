@@ -1,10 +1,14 @@
 package controller
 
 import (
+	"encoding/json"
+	"fmt"
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/service"
 	"milestone3/be/internal/utils"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v5"
@@ -50,6 +54,7 @@ func getUserIDFromToken(c echo.Context) (int64, error) {
 // @Param sessionID path int true "Auction Session ID"
 // @Param itemID path int true "Auction Item ID"
 // @Param bid body dto.BidDTO true "Bid amount"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
 // @Success 200 {object} utils.SuccessResponseData "bid placed successfully"
 // @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid parameters or bid too low"
 // @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
@@ -115,8 +120,6 @@ func (h *BidController) PlaceBid(c echo.Context) error {
 			return utils.NotFoundResponse(c, err.Error())
 		case service.ErrInvalidAuction:
 			return utils.ConflictResponse(c, err.Error())
-		case service.ErrDuplicateBid:
-			return utils.ConflictResponse(c, err.Error())
 		case service.ErrAlreadyHighestBidder:
 			return utils.ConflictResponse(c, "you are already the highest bidder")
 		default:
@@ -129,9 +132,136 @@ func (h *BidController) PlaceBid(c echo.Context) error {
 	return utils.SuccessResponse(c, "bid placed successfully", nil)
 }
 
+// SetMaxBid godoc
+// @Summary Set maximum (proxy) bid on auction item
+// @Description Register the highest amount the system may bid on your behalf. Whenever you are outbid the system raises your bid by the minimum increment until your maximum is reached.
+// @Tags Your Donate Rise API - Bidding
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param sessionID path int true "Auction Session ID"
+// @Param itemID path int true "Auction Item ID"
+// @Param bid body dto.MaxBidDTO true "Maximum bid amount"
+// @Success 200 {object} dto.MaxBidResultDTO "max bid set successfully"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid parameters or maximum too low"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 404 {object} utils.ErrorResponse "Auction session or item not found"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Invalid auction state"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auction/sessions/{sessionID}/items/{itemID}/max-bid [post]
+func (h *BidController) SetMaxBid(c echo.Context) error {
+	sessionIDStr := c.Param("sessionID")
+	itemIDStr := c.Param("itemID")
+
+	sessionID, err := strconv.ParseInt(sessionIDStr, 10, 64)
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid sessionID")
+	}
+
+	itemID, err := strconv.ParseInt(itemIDStr, 10, 64)
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid itemID")
+	}
+
+	var payload dto.MaxBidDTO
+	if err = c.Bind(&payload); err != nil {
+		return utils.BadRequestResponse(c, "invalid payload")
+	}
+
+	if err = h.validate.Struct(payload); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	session, err := h.sessionSvc.GetByID(sessionID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "auction session not found")
+	}
+
+	resp, err := h.svc.SetMaxBid(sessionID, itemID, userID, payload.MaxAmount, session.EndTime)
+	if err != nil {
+		c.Logger().Errorf("SetMaxBid error: %v", err)
+		switch err {
+		case service.ErrBidTooLow, service.ErrInvalidBidding:
+			return utils.BadRequestResponse(c, err.Error())
+		case service.ErrAuctionNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrInvalidAuction:
+			return utils.ConflictResponse(c, err.Error())
+		default:
+			return utils.InternalServerErrorResponse(c, "failed setting max bid")
+		}
+	}
+
+	return utils.SuccessResponse(c, "max bid set successfully", resp)
+}
+
+// BuyNow godoc
+// @Summary Buy auction item now
+// @Description Buy an ongoing item at its buy now price, ending its auction immediately as long as no bid has reached that price. The payment is created right away.
+// @Tags Your Donate Rise API - Bidding
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param sessionID path int true "Auction Session ID"
+// @Param itemID path int true "Auction Item ID"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
+// @Success 200 {object} dto.BuyNowResultDTO "item bought successfully"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid parameters or item has no buy now price"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 404 {object} utils.ErrorResponse "Auction session or item not found"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Bids reached the buy now price or invalid auction state"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auction/sessions/{sessionID}/items/{itemID}/buy-now [post]
+func (h *BidController) BuyNow(c echo.Context) error {
+	sessionIDStr := c.Param("sessionID")
+	itemIDStr := c.Param("itemID")
+
+	sessionID, err := strconv.ParseInt(sessionIDStr, 10, 64)
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid sessionID")
+	}
+
+	itemID, err := strconv.ParseInt(itemIDStr, 10, 64)
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid itemID")
+	}
+
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	session, err := h.sessionSvc.GetByID(sessionID)
+	if err != nil {
+		return utils.NotFoundResponse(c, "auction session not found")
+	}
+
+	resp, err := h.svc.BuyNow(sessionID, itemID, userID, session.EndTime)
+	if err != nil {
+		c.Logger().Errorf("BuyNow error: %v", err)
+		switch err {
+		case service.ErrBuyNowUnavailable:
+			return utils.BadRequestResponse(c, err.Error())
+		case service.ErrAuctionNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrInvalidAuction, service.ErrBuyNowExceeded:
+			return utils.ConflictResponse(c, err.Error())
+		default:
+			return utils.InternalServerErrorResponse(c, "failed buying item")
+		}
+	}
+
+	return utils.SuccessResponse(c, "item bought successfully", resp)
+}
+
 // GetHighestBid godoc
 // @Summary Get highest bid for auction item
-// @Description Retrieve the current highest bid for a specific auction item
+// @Description Retrieve the current highest bid and the effective end time (including soft close extensions) for a specific auction item
 // @Tags Your Donate Rise API - Bidding
 // @Accept json
 // @Produce json
@@ -166,12 +296,244 @@ func (h *BidController) GetHighestBid(c echo.Context) error {
 		}
 	}
 
+	// end time includes soft close extensions from late bids
+	endTime, err := h.svc.GetItemEndTime(sessionID, itemID)
+	if err != nil {
+		switch err {
+		case service.ErrSessionNotFoundID:
+			return utils.NotFoundResponse(c, "auction session not found")
+		default:
+			return utils.InternalServerErrorResponse(c, "failed retrieving end time")
+		}
+	}
+
 	resp := map[string]interface{}{
 		"session_id":  sessionID,
 		"item_id":     itemID,
 		"highest_bid": highest,
 		"bidder_id":   bidder,
+		"end_time":    endTime,
 	}
 
 	return utils.SuccessResponse(c, "highest bid retrieved successfully", resp)
 }
+
+// GetBidHistory godoc
+// @Summary Get bid history for auction item
+// @Description Page through every accepted bid on an auction item, highest first
+// @Tags Your Donate Rise API - Bidding
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param sessionID path int true "Auction Session ID"
+// @Param itemID path int true "Auction Item ID"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} utils.SuccessResponseData "bid history fetched"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid session or item ID"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 404 {object} utils.ErrorResponse "Auction not found"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Item is not part of the session"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auction/sessions/{sessionID}/items/{itemID}/bids [get]
+func (h *BidController) GetBidHistory(c echo.Context) error {
+	sessionIDStr := c.Param("sessionID")
+	itemIDStr := c.Param("itemID")
+
+	sessionID, err := strconv.ParseInt(sessionIDStr, 10, 64)
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid sessionID")
+	}
+
+	itemID, err := strconv.ParseInt(itemIDStr, 10, 64)
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid itemID")
+	}
+
+	page, limit := parsePagination(c)
+
+	bids, total, err := h.svc.GetBidHistory(sessionID, itemID, page, limit)
+	if err != nil {
+		switch err {
+		case service.ErrAuctionNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrInvalidAuction:
+			return utils.ConflictResponse(c, err.Error())
+		default:
+			return utils.InternalServerErrorResponse(c, "failed fetching bid history")
+		}
+	}
+
+	response := map[string]interface{}{
+		"bids":  bids,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	}
+	return utils.SuccessResponse(c, "bid history fetched", response)
+}
+
+// GetMyBids godoc
+// @Summary Get my bids
+// @Description Page through every accepted bid of the logged-in user, newest first
+// @Tags Your Donate Rise API - Bidding
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} utils.SuccessResponseData "bids fetched"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auction/bids/me [get]
+func (h *BidController) GetMyBids(c echo.Context) error {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	page, limit := parsePagination(c)
+
+	bids, total, err := h.svc.GetMyBids(userID, page, limit)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "failed fetching bids")
+	}
+
+	response := map[string]interface{}{
+		"bids":  bids,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	}
+	return utils.SuccessResponse(c, "bids fetched", response)
+}
+
+func parsePagination(c echo.Context) (int, int) {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit
+}
+
+// keep idle stream connections alive through proxies and load balancers
+const streamHeartbeatInterval = 15 * time.Second
+
+// StreamBids godoc
+// @Summary Stream bid events for auction item
+// @Description Server-Sent Events stream of accepted bids, outbid notices, end time extensions and the session close event for an auction item. The first event is a snapshot of the current highest bid.
+// @Tags Your Donate Rise API - Bidding
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param sessionID path int true "Auction Session ID"
+// @Param itemID path int true "Auction Item ID"
+// @Success 200 {object} dto.BidEventDTO "stream of bid events"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid session or item ID"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 404 {object} utils.ErrorResponse "Auction not found"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Item is not part of the session"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auction/sessions/{sessionID}/items/{itemID}/stream [get]
+func (h *BidController) StreamBids(c echo.Context) error {
+	sessionIDStr := c.Param("sessionID")
+	itemIDStr := c.Param("itemID")
+
+	sessionID, err := strconv.ParseInt(sessionIDStr, 10, 64)
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid sessionID")
+	}
+
+	itemID, err := strconv.ParseInt(itemIDStr, 10, 64)
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid itemID")
+	}
+
+	ctx := c.Request().Context()
+	events, err := h.svc.SubscribeBidEvents(ctx, sessionID, itemID)
+	if err != nil {
+		switch err {
+		case service.ErrAuctionNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrInvalidAuction:
+			return utils.ConflictResponse(c, err.Error())
+		default:
+			return utils.InternalServerErrorResponse(c, "failed subscribing to bid events")
+		}
+	}
+
+	highest, bidder, err := h.svc.GetHighestBid(sessionID, itemID)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "failed retrieving highest bid")
+	}
+
+	endTime, err := h.svc.GetItemEndTime(sessionID, itemID)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "failed retrieving end time")
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	snapshot := dto.BidEventDTO{
+		Type:       dto.BidEventSnapshot,
+		SessionID:  sessionID,
+		ItemID:     itemID,
+		Amount:     highest,
+		BidderID:   bidder,
+		EndTime:    &endTime,
+		OccurredAt: time.Now(),
+	}
+	if err := writeBidEvent(res, snapshot); err != nil {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := writeBidEvent(res, event); err != nil {
+				return nil
+			}
+			// nothing else can happen to the item once its session is closed
+			if event.Type == dto.BidEventSessionClosed {
+				return nil
+			}
+		}
+	}
+}
+
+func writeBidEvent(res *echo.Response, event dto.BidEventDTO) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
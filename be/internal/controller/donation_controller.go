@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"milestone3/be/internal/dto"
-	"milestone3/be/internal/entity"
 	"milestone3/be/internal/repository"
 	"milestone3/be/internal/service"
 	"milestone3/be/internal/utils"
@@ -34,7 +33,7 @@ func NewDonationController(s service.DonationService, privateStore repository.GC
 
 // CreateDonation godoc
 // @Summary Create new donation
-// @Description Submit a new donation with photos and details
+// @Description Submit a new donation with photos and details, it always starts pending review
 // @Tags Your Donate Rise API - Donations
 // @Accept multipart/form-data
 // @Accept json
@@ -80,10 +79,6 @@ func (h *DonationController) CreateDonation(c echo.Context) error {
 		payload.Category = form.Value["category"][0]
 		payload.Condition = form.Value["condition"][0]
 
-		if v, ok := form.Value["status"]; ok {
-			payload.Status = entity.StatusDonation(v[0])
-		}
-
 		// FILE HANDLING PRIVATE ONLY
 		if fhs, ok := form.File["photos"]; ok {
 			for _, fh := range fhs {
@@ -133,11 +128,6 @@ func (h *DonationController) CreateDonation(c echo.Context) error {
 	}
 	payload.UserID = userID
 
-	// Set default status if not provided
-	if payload.Status == "" {
-		payload.Status = entity.StatusPending
-	}
-
 	if err := h.validator.Struct(payload); err != nil {
 		return utils.BadRequestResponse(c, err.Error())
 	}
@@ -156,7 +146,7 @@ func (h *DonationController) CreateDonation(c echo.Context) error {
 
 // GetAllDonations godoc
 // @Summary Get all donations
-// @Description Get all donations (admin sees all, users see only their own) with pagination
+// @Description Get all donations (admin sees all, users see only their own) with pagination, photo_urls are signed URLs valid for 15 minutes
 // @Tags Your Donate Rise API - Donations
 // @Accept json
 // @Produce json
@@ -169,7 +159,7 @@ func (h *DonationController) CreateDonation(c echo.Context) error {
 // @Router /donations [get]
 func (h *DonationController) GetAllDonations(c echo.Context) error {
 	userID, _ := utils.GetUserID(c)
-	isAdm := utils.IsAdmin(c)
+	isAdm := utils.HasPermission(c, utils.PermReviewDonations)
 
 	if !isAdm {
 		if userID == 0 {
@@ -206,7 +196,7 @@ func (h *DonationController) GetAllDonations(c echo.Context) error {
 
 // GetDonationByID godoc
 // @Summary Get donation by ID
-// @Description Retrieve a specific donation by ID (owner or admin only)
+// @Description Retrieve a specific donation by ID (owner or admin only), photo_urls are signed URLs valid for 15 minutes
 // @Tags Your Donate Rise API - Donations
 // @Accept json
 // @Produce json
@@ -226,23 +216,21 @@ func (h *DonationController) GetDonationByID(c echo.Context) error {
 		return utils.BadRequestResponse(c, "invalid id")
 	}
 
-	d, err := h.svc.GetDonationByID(uint(id64))
+	userID, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	// owner or reviewer, checked before the photos are signed
+	d, err := h.svc.GetDonationByID(uint(id64), userID, utils.HasPermission(c, utils.PermReviewDonations))
 	if err != nil {
 		if errors.Is(err, service.ErrDonationNotFound) {
 			return utils.NotFoundResponse(c, "donation not found")
 		}
-		return utils.InternalServerErrorResponse(c, "failed fetching donation")
-	}
-
-	// permission check: owner or admin
-	if !utils.IsAdmin(c) {
-		userID, ok := utils.GetUserID(c)
-		if !ok {
-			return utils.UnauthorizedResponse(c, "unauthenticated")
-		}
-		if d.UserID != userID {
+		if errors.Is(err, service.ErrForbidden) {
 			return utils.ForbiddenResponse(c, "forbidden")
 		}
+		return utils.InternalServerErrorResponse(c, "failed fetching donation")
 	}
 
 	return utils.SuccessResponse(c, "donation fetched", d)
@@ -250,7 +238,7 @@ func (h *DonationController) GetDonationByID(c echo.Context) error {
 
 // UpdateDonation godoc
 // @Summary Update donation
-// @Description Update an existing donation (owner or admin only)
+// @Description Update an existing donation (owner or admin only). Details can be edited while it is pending or needs_info, the donor editing a needs_info donation sends it back to review. Photos sent replace the old ones. The status is only changed through PATCH
 // @Tags Your Donate Rise API - Donations
 // @Accept json
 // @Produce json
@@ -262,6 +250,7 @@ func (h *DonationController) GetDonationByID(c echo.Context) error {
 // @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
 // @Failure 403 {object} utils.ErrorResponse "Forbidden - Access denied"
 // @Failure 404 {object} utils.ErrorResponse "Donation not found"
+// @Failure 409 {object} utils.ErrorResponse "Donation can no longer be edited"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /donations/{id} [put]
 func (h *DonationController) UpdateDonation(c echo.Context) error {
@@ -281,7 +270,7 @@ func (h *DonationController) UpdateDonation(c echo.Context) error {
 	if !ok {
 		return utils.UnauthorizedResponse(c, "unauthenticated")
 	}
-	isAdm := utils.IsAdmin(c)
+	isAdm := utils.HasPermission(c, utils.PermReviewDonations)
 
 	if err := h.svc.UpdateDonation(payload, userID, isAdm); err != nil {
 		if errors.Is(err, service.ErrDonationNotFound) {
@@ -290,6 +279,9 @@ func (h *DonationController) UpdateDonation(c echo.Context) error {
 		if errors.Is(err, service.ErrForbidden) {
 			return utils.ForbiddenResponse(c, "forbidden")
 		}
+		if errors.Is(err, service.ErrDonationLocked) {
+			return utils.ConflictResponse(c, err.Error())
+		}
 		return utils.InternalServerErrorResponse(c, "failed updating donation")
 	}
 	return utils.SuccessResponse(c, "donation updated", nil)
@@ -321,7 +313,7 @@ func (h *DonationController) DeleteDonation(c echo.Context) error {
 	if !ok {
 		return utils.UnauthorizedResponse(c, "unauthenticated")
 	}
-	isAdm := utils.IsAdmin(c)
+	isAdm := utils.HasPermission(c, utils.PermReviewDonations)
 
 	if err := h.svc.DeleteDonation(uint(id64), userID, isAdm); err != nil {
 		if errors.Is(err, service.ErrDonationNotFound) {
@@ -336,26 +328,23 @@ func (h *DonationController) DeleteDonation(c echo.Context) error {
 }
 
 // PatchDonation godoc
-// @Summary Approve/update donation status
-// @Description Update donation status for approval (admin only)
+// @Summary Review donation
+// @Description Move a donation through the review (donations.review). pending and needs_info go to needs_info, rejected, verified_for_auction or verified_for_donation, a needs_info donation can also go back to pending. Verified donations go to picked_up, picked_up and rejected go to archived. A comment is required for rejected and needs_info and is shown to the donor
 // @Tags Your Donate Rise API - Donations
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Donation ID"
-// @Param approval body dto.DonationApprovalDTO true "Approval status"
+// @Param approval body dto.DonationApprovalDTO true "New status and reviewer comment"
 // @Success 200 {object} utils.SuccessResponseData "donation patched"
-// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid ID or payload"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid ID or payload, or missing comment"
 // @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
 // @Failure 403 {object} utils.ErrorResponse "Forbidden - Admin only"
 // @Failure 404 {object} utils.ErrorResponse "Donation not found"
+// @Failure 409 {object} utils.ErrorResponse "Status transition not allowed or changed by someone else"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /donations/{id} [patch]
 func (h *DonationController) PatchDonation(c echo.Context) error {
-	if !utils.IsAdmin(c) {
-		return utils.ForbiddenResponse(c, "admin only")
-	}
-
 	idParam := c.Param("id")
 	id64, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
@@ -371,18 +360,68 @@ func (h *DonationController) PatchDonation(c echo.Context) error {
 		return utils.BadRequestResponse(c, err.Error())
 	}
 
+	userID, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
 	var payload dto.DonationDTO
 	payload.ID = uint(id64)
 	payload.Status = approvalPayload.Status
+	payload.ReviewComment = approvalPayload.Comment
 
-	if err := h.svc.PatchDonation(payload, 0, true); err != nil {
+	if err := h.svc.PatchDonation(payload, userID, utils.HasPermission(c, utils.PermReviewDonations)); err != nil {
 		if errors.Is(err, service.ErrDonationNotFound) {
 			return utils.NotFoundResponse(c, "donation not found")
 		}
 		if errors.Is(err, service.ErrForbidden) {
 			return utils.ForbiddenResponse(c, "forbidden")
 		}
+		if errors.Is(err, service.ErrReviewCommentRequired) {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		if errors.Is(err, service.ErrInvalidStatusTransition) || errors.Is(err, service.ErrDonationStatusChanged) {
+			return utils.ConflictResponse(c, err.Error())
+		}
 		return utils.InternalServerErrorResponse(c, "failed patching donation")
 	}
 	return utils.SuccessResponse(c, "donation patched", nil)
 }
+
+// GetDonationStatusHistory godoc
+// @Summary Get donation status history
+// @Description Every status the donation went through, who changed it and the reviewer comments (owner or donations.review)
+// @Tags Your Donate Rise API - Donations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Donation ID"
+// @Success 200 {object} utils.SuccessResponseData{data=[]dto.DonationStatusHistoryDTO} "donation history fetched"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid donation ID"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Access denied"
+// @Failure 404 {object} utils.ErrorResponse "Donation not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /donations/{id}/history [get]
+func (h *DonationController) GetDonationStatusHistory(c echo.Context) error {
+	id64, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid id")
+	}
+
+	userID, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	history, err := h.svc.GetStatusHistory(uint(id64), userID, utils.HasPermission(c, utils.PermReviewDonations))
+	if err != nil {
+		if errors.Is(err, service.ErrDonationNotFound) {
+			return utils.NotFoundResponse(c, "donation not found")
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			return utils.ForbiddenResponse(c, "forbidden")
+		}
+		return utils.InternalServerErrorResponse(c, "failed fetching donation history")
+	}
+	return utils.SuccessResponse(c, "donation history fetched", history)
+}
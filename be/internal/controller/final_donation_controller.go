@@ -24,11 +24,12 @@ func NewFinalDonationController(finalDonationService service.FinalDonationServic
 
 // GetAllFinalDonations godoc
 // @Summary Get all final donations
-// @Description Retrieve all items that were directly donated to institutions with pagination (admin sees all, user sees own)
+// @Description Retrieve all items that were directly donated to institutions with pagination (admin and API keys with final_donations.read see all, user sees own)
 // @Tags Your Donate Rise API - Final Donations
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Items per page (default: 10, max: 100)"
 // @Success 200 {object} utils.SuccessResponseData "Final donations fetched successfully"
@@ -41,9 +42,9 @@ func (h *FinalDonationController) GetAllFinalDonations(c echo.Context) error {
 		return utils.UnauthorizedResponse(c, "unauthenticated")
 	}
 
-	isAdmin := utils.IsAdmin(c)
+	isAdmin := utils.HasPermission(c, utils.PermReviewDonations) || utils.HasPermission(c, utils.ScopeReadFinalDonations)
 
-	// Admin sees all with pagination, user sees own
+	// Admin and partner API keys see all with pagination, user sees own
 	if isAdmin {
 		page, _ := strconv.Atoi(c.QueryParam("page"))
 		if page < 1 {
@@ -117,10 +118,6 @@ func (h *FinalDonationController) GetMyFinalDonations(c echo.Context) error {
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /donations/final/user/{user_id} [get]
 func (h *FinalDonationController) GetAllFinalDonationsByUserID(c echo.Context) error {
-	if !utils.IsAdmin(c) {
-		return utils.ForbiddenResponse(c, "admin only")
-	}
-
 	userIDStr := c.Param("user_id")
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/service"
+	"milestone3/be/internal/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type OIDCService interface {
+	LoginURL(provider, loginHint string) (string, error)
+	Login(provider, code, state string) (res dto.TokenResponse, err error)
+	ConfirmLink(req dto.OIDCLinkRequest) (res dto.TokenResponse, err error)
+}
+
+type OIDCController struct {
+	validate    *validator.Validate
+	oidcService OIDCService
+}
+
+func NewOIDCController(validate *validator.Validate, os OIDCService) *OIDCController {
+	return &OIDCController{validate: validate, oidcService: os}
+}
+
+// OIDCLogin godoc
+// @Summary Sign in with a provider
+// @Description Redirect to the OpenID Connect provider's sign in page (e.g. google)
+// @Tags Your Donate Rise API - Authentication
+// @Param provider path string true "Provider name"
+// @Param login_hint query string false "Email to sign in as"
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} utils.ErrorResponse "Not found - Provider is not configured"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/oidc/{provider} [get]
+func (oc *OIDCController) OIDCLogin(c echo.Context) error {
+	url, err := oc.oidcService.LoginURL(c.Param("provider"), c.QueryParam("login_hint"))
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCProviderNotFound) {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return c.Redirect(http.StatusFound, url)
+}
+
+// OIDCCallback godoc
+// @Summary Finish signing in with a provider
+// @Description Trade the code the provider sent back for an access token and a refresh token. The first sign in creates the account, or links it to the account with the same verified email. An unverified or staff account answers with link_required and a link_token for /auth/oidc/link instead
+// @Tags Your Donate Rise API - Authentication
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code from the provider"
+// @Param state query string true "State from the provider redirect"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.TokenResponse} "success login"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Missing code, unknown or expired state"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Sign in was cancelled or the provider's token was rejected"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Email not verified by the provider or account deactivated"
+// @Failure 404 {object} utils.ErrorResponse "Not found - Provider is not configured"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/oidc/{provider}/callback [get]
+func (oc *OIDCController) OIDCCallback(c echo.Context) error {
+	// the user cancelled or the provider refused
+	if providerErr := c.QueryParam("error"); providerErr != "" {
+		return utils.UnauthorizedResponse(c, "sign in was not completed: "+providerErr)
+	}
+
+	code, state := c.QueryParam("code"), c.QueryParam("state")
+	if code == "" || state == "" {
+		return utils.BadRequestResponse(c, "code and state are required")
+	}
+
+	resp, err := oc.oidcService.Login(c.Param("provider"), code, state)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOIDCProviderNotFound):
+			return utils.NotFoundResponse(c, err.Error())
+		case errors.Is(err, service.ErrInvalidOIDCState):
+			return utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, service.ErrOIDCLoginFailed):
+			return utils.UnauthorizedResponse(c, err.Error())
+		case errors.Is(err, service.ErrOIDCEmailNotVerified), errors.Is(err, service.ErrAccountDeactivated):
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	if resp.LinkRequired {
+		return utils.SuccessResponse(c, "enter the password of your account to link it", resp)
+	}
+	if resp.MFARequired {
+		return utils.SuccessResponse(c, "enter the code from your authenticator app", resp)
+	}
+	return utils.SuccessResponse(c, "success login", resp)
+}
+
+// OIDCLink godoc
+// @Summary Link a provider to an existing account
+// @Description Confirm with the account's password that the provider sign in that answered link_required may be linked to the account, then sign in
+// @Tags Your Donate Rise API - Authentication
+// @Accept json
+// @Produce json
+// @Param link body dto.OIDCLinkRequest true "Link token from the callback and the account's password"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.TokenResponse} "success login"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or expired link token"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid password"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Account deactivated"
+// @Failure 404 {object} utils.ErrorResponse "Not found - Provider is not configured"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests - Too many wrong passwords, see Retry-After"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/oidc/link [post]
+func (oc *OIDCController) OIDCLink(c echo.Context) error {
+	req := new(dto.OIDCLinkRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := oc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	resp, err := oc.oidcService.ConfirmLink(*req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidOIDCLink):
+			return utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, service.ErrOIDCProviderNotFound):
+			return utils.NotFoundResponse(c, err.Error())
+		case errors.Is(err, service.ErrInvalidCredential):
+			return utils.UnauthorizedResponse(c, err.Error())
+		case errors.Is(err, service.ErrAccountDeactivated):
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		var lockout *service.LockoutError
+		if errors.As(err, &lockout) {
+			return utils.TooManyRequestsResponse(c, lockout.Error(), lockout.RetryAfter)
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	if resp.MFARequired {
+		return utils.SuccessResponse(c, "enter the code from your authenticator app", resp)
+	}
+	return utils.SuccessResponse(c, "success login", resp)
+}
@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"fmt"
 	"milestone3/be/internal/dto"
+	"milestone3/be/internal/service"
 	"milestone3/be/internal/utils"
 	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v5"
@@ -12,9 +15,14 @@ import (
 
 type PaymentService interface {
 	CreatePayment(req dto.PaymentRequest, userId int, auctionItemId int) (res dto.PaymentResponse, err error)
-	CheckPaymentStatusMidtrans(orderId string) (res dto.CheckPaymentStatusResponse, err error)
-	GetPaymentById(id int) (res dto.PaymentInfoResponse, err error)
-	GetAllPayment() (res []dto.PaymentInfoResponse, err error)
+	CheckPaymentStatusMidtrans(orderId string, userId int, isAdmin bool) (res dto.CheckPaymentStatusResponse, err error)
+	HandleNotification(req dto.MidtransNotification) error
+	CancelPayment(id int, req dto.CancelPaymentRequest) error
+	RefundPayment(id int, req dto.RefundRequest, adminId int) (res dto.PaymentRefundResponse, err error)
+	ReconcilePayments() (res dto.ReconciliationReport, err error)
+	GetPaymentById(id int, userId int, isAdmin bool) (res dto.PaymentInfoResponse, err error)
+	GetAllPayment(filter dto.PaymentFilter) (res []dto.PaymentInfoResponse, total int64, err error)
+	GetMyPayments(userId int, page int, limit int) (res []dto.PaymentInfoResponse, total int64, err error)
 }
 
 type PaymentController struct {
@@ -28,16 +36,21 @@ func NewPaymentController(validate *validator.Validate, ps PaymentService) *Paym
 
 // CreatePayment godoc
 // @Summary Create payment for auction item
-// @Description Create a payment transaction for a won auction item
+// @Description Create a payment transaction for a won auction item, the amount is the winning bid and must be paid before the deadline
 // @Tags Your Donate Rise API - Payments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param auctionId path int true "Auction Item ID"
-// @Param payment body dto.PaymentRequest true "Payment details"
+// @Param payment body dto.PaymentRequest false "Payment type (qris, bank_transfer, gopay, credit_card)"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
 // @Success 201 {object} utils.SuccessResponseData "create"
 // @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or auction ID"
 // @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Not the winning bidder"
+// @Failure 404 {object} utils.ErrorResponse "Auction item not found"
+// @Failure 409 {object} utils.ErrorResponse "No winner awaiting payment, deadline passed, already paid or a request with the same Idempotency-Key in progress"
+// @Failure 422 {object} utils.ErrorResponse "Idempotency-Key reused with a different request"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /payments/auction/{auctionId} [post]
 func (pc *PaymentController) CreatePayment(c echo.Context) error {
@@ -62,7 +75,16 @@ func (pc *PaymentController) CreatePayment(c echo.Context) error {
 
 	resp, err := pc.paymentService.CreatePayment(*req, userId, auctionId)
 	if err != nil {
-		return utils.InternalServerErrorResponse(c, "internal server error")
+		switch err {
+		case service.ErrAuctionNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrForbidden:
+			return utils.ForbiddenResponse(c, "only the winning bidder can pay")
+		case service.ErrNoWinningBid, service.ErrPaymentExpired, service.ErrAlreadyPaid:
+			return utils.ConflictResponse(c, err.Error())
+		default:
+			return utils.InternalServerErrorResponse(c, "internal server error")
+		}
 	}
 
 	return utils.CreatedResponse(c, "create", resp)
@@ -70,45 +92,109 @@ func (pc *PaymentController) CreatePayment(c echo.Context) error {
 
 // CheckPaymentStatusMidtrans godoc
 // @Summary Check payment status via Midtrans
-// @Description Check the payment status of an order through Midtrans payment gateway
+// @Description Check the payment status of an order through Midtrans payment gateway (owner or admin only)
 // @Tags Your Donate Rise API - Payments
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path string true "Order ID"
 // @Success 200 {object} utils.SuccessResponseData "ok"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Not your payment"
+// @Failure 404 {object} utils.ErrorResponse "Payment not found"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /payments/status/{id} [get]
 func (pc *PaymentController) CheckPaymentStatusMidtrans(c echo.Context) error {
+	userId, _ := utils.GetUserID(c)
+
 	orderId := c.Param("id")
-	resp, err := pc.paymentService.CheckPaymentStatusMidtrans(orderId)
+	resp, err := pc.paymentService.CheckPaymentStatusMidtrans(orderId, int(userId), utils.HasPermission(c, utils.PermManagePayments))
 	if err != nil {
-		return utils.InternalServerErrorResponse(c, "internal server error")
+		switch err {
+		case service.ErrPaymentNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrForbidden:
+			return utils.ForbiddenResponse(c, "not your payment")
+		default:
+			return utils.InternalServerErrorResponse(c, "internal server error")
+		}
 	}
 
 	return utils.SuccessResponse(c, "ok", resp)
 }
 
+// HandleNotification godoc
+// @Summary Midtrans payment notification
+// @Description Webhook called by Midtrans on transaction changes, verified by its SHA512 signature_key
+// @Tags Your Donate Rise API - Payments
+// @Accept json
+// @Produce json
+// @Param notification body dto.MidtransNotification true "Midtrans notification"
+// @Success 200 {object} utils.SuccessResponseData "ok"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or amount"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid signature"
+// @Failure 404 {object} utils.ErrorResponse "Payment not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /payments/notifications [post]
+func (pc *PaymentController) HandleNotification(c echo.Context) error {
+	req := new(dto.MidtransNotification)
+
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := pc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := pc.paymentService.HandleNotification(*req); err != nil {
+		switch err {
+		case service.ErrInvalidSignature:
+			return utils.UnauthorizedResponse(c, err.Error())
+		case service.ErrPaymentNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrInvalidPayment:
+			return utils.BadRequestResponse(c, err.Error())
+		default:
+			return utils.InternalServerErrorResponse(c, "internal server error")
+		}
+	}
+
+	return utils.SuccessResponse(c, "ok", nil)
+}
+
 // GetPaymentById godoc
 // @Summary Get payment by ID
-// @Description Retrieve payment information by payment ID
+// @Description Retrieve payment information by payment ID (owner or admin only)
 // @Tags Your Donate Rise API - Payments
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Payment ID"
 // @Success 200 {object} utils.SuccessResponseData "ok"
 // @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payment ID"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Not your payment"
+// @Failure 404 {object} utils.ErrorResponse "Payment not found"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /payments/{id} [get]
 func (pc *PaymentController) GetPaymentById(c echo.Context) error {
+	userId, _ := utils.GetUserID(c)
+
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		return utils.BadRequestResponse(c, err.Error())
 	}
 
-	resp, err := pc.paymentService.GetPaymentById(id)
+	resp, err := pc.paymentService.GetPaymentById(id, int(userId), utils.HasPermission(c, utils.PermManagePayments))
 	if err != nil {
-		return utils.InternalServerErrorResponse(c, "internal server error")
+		switch err {
+		case service.ErrPaymentNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrForbidden:
+			return utils.ForbiddenResponse(c, "not your payment")
+		default:
+			return utils.InternalServerErrorResponse(c, "internal server error")
+		}
 	}
 
 	return utils.SuccessResponse(c, "ok", resp)
@@ -116,18 +202,254 @@ func (pc *PaymentController) GetPaymentById(c echo.Context) error {
 
 // GetAllPayment godoc
 // @Summary Get all payments
-// @Description Retrieve all payment transactions in the system
+// @Description Retrieve every user's payments (admin only), newest first, with filters and pagination
 // @Tags Your Donate Rise API - Payments
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Param status query string false "Payment status"
+// @Param user_id query int false "Paying user ID"
+// @Param auction_item_id query int false "Auction item ID"
+// @Param from query string false "Created at or after (YYYY-MM-DD or RFC3339)"
+// @Param to query string false "Created before, a date includes the whole day (YYYY-MM-DD or RFC3339)"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10, max: 100)"
 // @Success 200 {object} utils.SuccessResponseData "ok"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid filter"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Admin only"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /payments [get]
 func (pc *PaymentController) GetAllPayment(c echo.Context) error {
-	resp, err := pc.paymentService.GetAllPayment()
+	filter, err := paymentFilter(c)
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := pc.validate.Struct(filter); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	payments, total, err := pc.paymentService.GetAllPayment(filter)
 	if err != nil {
 		return utils.InternalServerErrorResponse(c, "internal server error")
 	}
 
-	return utils.SuccessResponse(c, "ok", resp)
-}
\ No newline at end of file
+	response := map[string]interface{}{
+		"payments": payments,
+		"page":     filter.Page,
+		"limit":    filter.Limit,
+		"total":    total,
+	}
+	return utils.SuccessResponse(c, "ok", response)
+}
+
+// GetMyPayments godoc
+// @Summary Get my payments
+// @Description Retrieve the logged in user's own payments, newest first, with pagination
+// @Tags Your Donate Rise API - Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} utils.SuccessResponseData "ok"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /payments/me [get]
+func (pc *PaymentController) GetMyPayments(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok || userId == 0 {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	page, limit := paymentPage(c)
+	payments, total, err := pc.paymentService.GetMyPayments(int(userId), page, limit)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	response := map[string]interface{}{
+		"payments": payments,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+	}
+	return utils.SuccessResponse(c, "ok", response)
+}
+
+// paymentPage reads page and limit the way the other list endpoints do
+func paymentPage(c echo.Context) (page int, limit int) {
+	page, _ = strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit
+}
+
+// paymentFilter reads the admin payment list filters from the query string
+func paymentFilter(c echo.Context) (filter dto.PaymentFilter, err error) {
+	filter.Page, filter.Limit = paymentPage(c)
+	filter.Status = c.QueryParam("status")
+
+	if v := c.QueryParam("user_id"); v != "" {
+		if filter.UserId, err = strconv.Atoi(v); err != nil {
+			return dto.PaymentFilter{}, fmt.Errorf("invalid user_id %q", v)
+		}
+	}
+	if v := c.QueryParam("auction_item_id"); v != "" {
+		if filter.AuctionItemId, err = strconv.Atoi(v); err != nil {
+			return dto.PaymentFilter{}, fmt.Errorf("invalid auction_item_id %q", v)
+		}
+	}
+	if filter.From, err = queryTime(c, "from", false); err != nil {
+		return dto.PaymentFilter{}, err
+	}
+	if filter.To, err = queryTime(c, "to", true); err != nil {
+		return dto.PaymentFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// queryTime parses a date or RFC3339 query param, a date used as an exclusive
+// upper bound moves to the next day so the whole day is included
+func queryTime(c echo.Context, name string, upper bool) (*time.Time, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q, use YYYY-MM-DD or RFC3339", name, v)
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// CancelPayment godoc
+// @Summary Cancel a pending payment
+// @Description Cancel a pending charge at the payment gateway (admin only), optionally reopening the auction item
+// @Tags Your Donate Rise API - Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Payment ID"
+// @Param cancel body dto.CancelPaymentRequest true "Cancellation reason"
+// @Success 200 {object} utils.SuccessResponseData "cancelled"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or payment ID"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Admin only"
+// @Failure 404 {object} utils.ErrorResponse "Payment not found"
+// @Failure 409 {object} utils.ErrorResponse "Payment is not pending"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /payments/{id}/cancel [post]
+func (pc *PaymentController) CancelPayment(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	req := new(dto.CancelPaymentRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := pc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := pc.paymentService.CancelPayment(id, *req); err != nil {
+		switch err {
+		case service.ErrPaymentNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrPaymentNotCancellable:
+			return utils.ConflictResponse(c, err.Error())
+		default:
+			return utils.InternalServerErrorResponse(c, "internal server error")
+		}
+	}
+
+	return utils.SuccessResponse(c, "cancelled", nil)
+}
+
+// RefundPayment godoc
+// @Summary Refund a payment
+// @Description Refund all or part of a paid payment through the payment gateway (admin only), optionally reopening the auction item
+// @Tags Your Donate Rise API - Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Payment ID"
+// @Param refund body dto.RefundRequest true "Refund amount (empty for the rest) and reason"
+// @Success 201 {object} utils.SuccessResponseData "refunded"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or payment ID"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Admin only"
+// @Failure 404 {object} utils.ErrorResponse "Payment not found"
+// @Failure 409 {object} utils.ErrorResponse "Payment not paid or refund exceeds the amount paid"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /payments/{id}/refunds [post]
+func (pc *PaymentController) RefundPayment(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claim := user.Claims.(jwt.MapClaims)
+	adminId := int(claim["id"].(float64))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	req := new(dto.RefundRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := pc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	resp, err := pc.paymentService.RefundPayment(id, *req, adminId)
+	if err != nil {
+		switch err {
+		case service.ErrPaymentNotFound:
+			return utils.NotFoundResponse(c, err.Error())
+		case service.ErrPaymentNotRefundable, service.ErrRefundExceeded:
+			return utils.ConflictResponse(c, err.Error())
+		default:
+			return utils.InternalServerErrorResponse(c, "internal server error")
+		}
+	}
+
+	return utils.CreatedResponse(c, "refunded", resp)
+}
+
+// ReconcilePayments godoc
+// @Summary Reconcile payments with the gateway
+// @Description Run payment reconciliation now (admin only), stale pending payments take the gateway's status and mismatches that need a person are reported
+// @Tags Your Donate Rise API - Payments
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponseData "reconciliation report"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Admin only"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /payments/reconciliation [post]
+func (pc *PaymentController) ReconcilePayments(c echo.Context) error {
+	resp, err := pc.paymentService.ReconcilePayments()
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "reconciliation report", resp)
+}
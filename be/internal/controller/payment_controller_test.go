@@ -1,14 +1,16 @@
 package controller
 
 import (
-	"bytes"
-	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/mocks"
+	"milestone3/be/internal/service"
+	"milestone3/be/internal/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v5"
@@ -28,7 +30,7 @@ func TestPaymentController_CreatePayment(t *testing.T) {
 	tests := []struct {
 		name           string
 		auctionId      string
-		requestBody    dto.PaymentRequest
+		body           string
 		setupAuth      func(c echo.Context)
 		setupMock      func()
 		expectedStatus int
@@ -36,9 +38,6 @@ func TestPaymentController_CreatePayment(t *testing.T) {
 		{
 			name:      "successful payment creation",
 			auctionId: "1",
-			requestBody: dto.PaymentRequest{
-				Amount: 100000,
-			},
 			setupAuth: func(c echo.Context) {
 				token := &jwt.Token{
 					Claims: jwt.MapClaims{
@@ -59,9 +58,21 @@ func TestPaymentController_CreatePayment(t *testing.T) {
 		{
 			name:      "invalid auction id",
 			auctionId: "invalid",
-			requestBody: dto.PaymentRequest{
-				Amount: 100000,
+			setupAuth: func(c echo.Context) {
+				token := &jwt.Token{
+					Claims: jwt.MapClaims{
+						"id": float64(1),
+					},
+				}
+				c.Set("user", token)
 			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "bank transfer without bank",
+			auctionId: "1",
+			body:      `{"payment_type":"bank_transfer"}`,
 			setupAuth: func(c echo.Context) {
 				token := &jwt.Token{
 					Claims: jwt.MapClaims{
@@ -73,14 +84,66 @@ func TestPaymentController_CreatePayment(t *testing.T) {
 			setupMock:      func() {},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:      "virtual account payment",
+			auctionId: "1",
+			body:      `{"payment_type":"bank_transfer","bank":"bca"}`,
+			setupAuth: func(c echo.Context) {
+				token := &jwt.Token{
+					Claims: jwt.MapClaims{
+						"id": float64(1),
+					},
+				}
+				c.Set("user", token)
+			},
+			setupMock: func() {
+				mockService.EXPECT().CreatePayment(dto.PaymentRequest{PaymentType: "bank_transfer", Bank: "bca"}, 1, 1).Return(dto.PaymentResponse{
+					OrderId:     "YDR-123",
+					PaymentType: "bank_transfer",
+					VaNumber:    "12345678901",
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:      "not the winning bidder",
+			auctionId: "1",
+			setupAuth: func(c echo.Context) {
+				token := &jwt.Token{
+					Claims: jwt.MapClaims{
+						"id": float64(2),
+					},
+				}
+				c.Set("user", token)
+			},
+			setupMock: func() {
+				mockService.EXPECT().CreatePayment(gomock.Any(), 2, 1).Return(dto.PaymentResponse{}, service.ErrForbidden)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:      "payment deadline passed",
+			auctionId: "1",
+			setupAuth: func(c echo.Context) {
+				token := &jwt.Token{
+					Claims: jwt.MapClaims{
+						"id": float64(1),
+					},
+				}
+				c.Set("user", token)
+			},
+			setupMock: func() {
+				mockService.EXPECT().CreatePayment(gomock.Any(), 1, 1).Return(dto.PaymentResponse{}, service.ErrPaymentExpired)
+			},
+			expectedStatus: http.StatusConflict,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup
 			e := echo.New()
-			body, _ := json.Marshal(tt.requestBody)
-			req := httptest.NewRequest(http.MethodPost, "/payments/auction/"+tt.auctionId, bytes.NewReader(body))
+			req := httptest.NewRequest(http.MethodPost, "/payments/auction/"+tt.auctionId, strings.NewReader(tt.body))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
@@ -118,6 +181,7 @@ func TestPaymentController_GetAllPayment(t *testing.T) {
 
 	tests := []struct {
 		name           string
+		query          string
 		setupMock      func()
 		expectedStatus int
 		expectError    bool
@@ -125,23 +189,54 @@ func TestPaymentController_GetAllPayment(t *testing.T) {
 		{
 			name: "successful get all payments",
 			setupMock: func() {
-				mockService.EXPECT().GetAllPayment().Return([]dto.PaymentInfoResponse{
+				mockService.EXPECT().GetAllPayment(dto.PaymentFilter{Page: 1, Limit: 10}).Return([]dto.PaymentInfoResponse{
 					{Id: 1, Amount: 100000},
 					{Id: 2, Amount: 200000},
-				}, nil)
+				}, int64(2), nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectError:    false,
 		},
+		{
+			name:  "filters and pagination",
+			query: "?status=paid&user_id=4&auction_item_id=3&from=2026-01-01&to=2026-01-31&page=2&limit=5",
+			setupMock: func() {
+				from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+				to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local)
+				mockService.EXPECT().GetAllPayment(dto.PaymentFilter{
+					Status:        "paid",
+					UserId:        4,
+					AuctionItemId: 3,
+					From:          &from,
+					To:            &to,
+					Page:          2,
+					Limit:         5,
+				}).Return([]dto.PaymentInfoResponse{}, int64(0), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown status",
+			query:          "?status=lost",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid date",
+			query:          "?from=yesterday",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup
 			e := echo.New()
-			req := httptest.NewRequest(http.MethodGet, "/payments", nil)
+			req := httptest.NewRequest(http.MethodGet, "/payments"+tt.query, nil)
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
+			c.Set("permissions", []string{utils.PermManagePayments})
 
 			tt.setupMock()
 
@@ -157,4 +252,300 @@ func TestPaymentController_GetAllPayment(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestPaymentController_GetMyPayments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockPaymentService(ctrl)
+	controller := NewPaymentController(validator.New(), mockService)
+
+	mockService.EXPECT().GetMyPayments(4, 1, 10).Return([]dto.PaymentInfoResponse{
+		{Id: 1, UserId: 4, User: dto.PaymentUserResponse{Id: 4, Name: "Budi"}},
+	}, int64(1), nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/payments/me", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_id", uint(4))
+
+	err := controller.GetMyPayments(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"user":{"id":4,"name":"Budi"}`)
+	assert.NotContains(t, rec.Body.String(), "email")
+}
+
+func TestPaymentController_GetPaymentById(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockPaymentService(ctrl)
+	controller := NewPaymentController(validator.New(), mockService)
+
+	tests := []struct {
+		name           string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "owner",
+			setupMock: func() {
+				mockService.EXPECT().GetPaymentById(1, 4, false).Return(dto.PaymentInfoResponse{Id: 1, UserId: 4}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "not the owner",
+			setupMock: func() {
+				mockService.EXPECT().GetPaymentById(1, 4, false).Return(dto.PaymentInfoResponse{}, service.ErrForbidden)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "not found",
+			setupMock: func() {
+				mockService.EXPECT().GetPaymentById(1, 4, false).Return(dto.PaymentInfoResponse{}, service.ErrPaymentNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/payments/1", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+			c.Set("user_id", uint(4))
+			c.Set("permissions", []string{utils.PermPlaceBids})
+
+			tt.setupMock()
+
+			err := controller.GetPaymentById(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestPaymentController_HandleNotification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockPaymentService(ctrl)
+	validate := validator.New()
+	controller := NewPaymentController(validate, mockService)
+
+	notification := `{"order_id":"YDR-123","status_code":"200","gross_amount":"250000.00","signature_key":"abc","transaction_status":"settlement"}`
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "valid notification",
+			body: notification,
+			setupMock: func() {
+				mockService.EXPECT().HandleNotification(gomock.Any()).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid signature",
+			body: notification,
+			setupMock: func() {
+				mockService.EXPECT().HandleNotification(gomock.Any()).Return(service.ErrInvalidSignature)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing signature",
+			body:           `{"order_id":"YDR-123","status_code":"200","gross_amount":"250000.00","transaction_status":"settlement"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/payments/notifications", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			tt.setupMock()
+
+			err := controller.HandleNotification(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestPaymentController_CancelPayment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockPaymentService(ctrl)
+	validate := validator.New()
+	controller := NewPaymentController(validate, mockService)
+
+	tests := []struct {
+		name           string
+		id             string
+		body           string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "cancel pending payment",
+			id:   "1",
+			body: `{"reason":"duplicate charge","reopen_item":true}`,
+			setupMock: func() {
+				mockService.EXPECT().CancelPayment(1, dto.CancelPaymentRequest{Reason: "duplicate charge", ReopenItem: true}).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing reason",
+			id:             "1",
+			body:           `{}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "payment already settled",
+			id:   "1",
+			body: `{"reason":"too late"}`,
+			setupMock: func() {
+				mockService.EXPECT().CancelPayment(1, gomock.Any()).Return(service.ErrPaymentNotCancellable)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "payment not found",
+			id:   "99",
+			body: `{"reason":"missing"}`,
+			setupMock: func() {
+				mockService.EXPECT().CancelPayment(99, gomock.Any()).Return(service.ErrPaymentNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/payments/"+tt.id+"/cancel", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			tt.setupMock()
+
+			err := controller.CancelPayment(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestPaymentController_RefundPayment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockPaymentService(ctrl)
+	validate := validator.New()
+	controller := NewPaymentController(validate, mockService)
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "partial refund",
+			body: `{"amount":50000,"reason":"shipping covered"}`,
+			setupMock: func() {
+				mockService.EXPECT().RefundPayment(1, dto.RefundRequest{Amount: 50000, Reason: "shipping covered"}, 9).Return(dto.PaymentRefundResponse{Id: 1, PaymentId: 1, Amount: 50000}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "negative amount",
+			body:           `{"amount":-1,"reason":"typo"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "refund exceeds amount paid",
+			body: `{"amount":300000,"reason":"typo"}`,
+			setupMock: func() {
+				mockService.EXPECT().RefundPayment(1, gomock.Any(), 9).Return(dto.PaymentRefundResponse{}, service.ErrRefundExceeded)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/payments/1/refunds", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+			c.Set("user", &jwt.Token{Claims: jwt.MapClaims{"id": float64(9)}})
+
+			tt.setupMock()
+
+			err := controller.RefundPayment(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestPaymentController_ReconcilePayments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockPaymentService(ctrl)
+	controller := NewPaymentController(validator.New(), mockService)
+
+	mockService.EXPECT().ReconcilePayments().Return(dto.ReconciliationReport{
+		Checked:  1,
+		Resolved: 1,
+		Mismatches: []dto.ReconciliationMismatch{
+			{PaymentId: 1, OrderId: "YDR-123", LocalStatus: "pending", GatewayStatus: "settlement", Resolved: true, Note: "marked paid"},
+		},
+	}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/payments/reconciliation", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := controller.ReconcilePayments(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"resolved":1`)
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/service"
+	"milestone3/be/internal/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type RoleService interface {
+	GetRoles() (res []dto.RoleResponse, err error)
+	GetUserRoles(userId int) (res dto.UserRolesResponse, err error)
+	SetUserRoles(userId, actorId int, req dto.SetUserRolesRequest) (res dto.UserRolesResponse, err error)
+}
+
+type RoleController struct {
+	roleService RoleService
+	validate    *validator.Validate
+}
+
+func NewRoleController(validate *validator.Validate, rs RoleService) *RoleController {
+	return &RoleController{validate: validate, roleService: rs}
+}
+
+// GetRoles godoc
+// @Summary List roles
+// @Description List every role with the permissions it grants
+// @Tags Your Donate Rise API - Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponseData{data=[]dto.RoleResponse} "ok"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - users.manage permission required"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/roles [get]
+func (rc *RoleController) GetRoles(c echo.Context) error {
+	resp, err := rc.roleService.GetRoles()
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "ok", resp)
+}
+
+// GetUserRoles godoc
+// @Summary Get user roles
+// @Description Get the roles of a user and the permissions they grant
+// @Tags Your Donate Rise API - Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.UserRolesResponse} "ok"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid user ID"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - users.manage permission required"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/roles [get]
+func (rc *RoleController) GetUserRoles(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid user id")
+	}
+
+	resp, err := rc.roleService.GetUserRoles(id)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "ok", resp)
+}
+
+// SetUserRoles godoc
+// @Summary Assign user roles
+// @Description Replace the roles of a user, the user's access tokens are revoked so the change applies on their next refresh
+// @Tags Your Donate Rise API - Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param roles body dto.SetUserRolesRequest true "Role names"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.UserRolesResponse} "roles updated"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or unknown role"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - users.manage permission required"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Admins cannot remove their own admin role"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/roles [put]
+func (rc *RoleController) SetUserRoles(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return utils.BadRequestResponse(c, "invalid user id")
+	}
+
+	actorId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.SetUserRolesRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := rc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	resp, err := rc.roleService.SetUserRoles(id, int(actorId), *req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			return utils.NotFoundResponse(c, err.Error())
+		case errors.Is(err, service.ErrRoleNotFound):
+			return utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, service.ErrAdminSelfDemotion):
+			return utils.ConflictResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "roles updated", resp)
+}
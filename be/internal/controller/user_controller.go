@@ -2,18 +2,36 @@ package controller
 
 import (
 	"errors"
+	"time"
 
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/service"
 	"milestone3/be/internal/utils"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
 type UserService interface {
 	CreateUser(req dto.UserRequest) (res dto.UserResponse, err error)
-	GetUserByEmail(email, password string) (accessToken string, err error)
+	GetUserByEmail(email, password string) (res dto.TokenResponse, err error)
+	RefreshToken(refreshToken string) (res dto.TokenResponse, err error)
+	Logout(userId int, jti string, expiresAt time.Time, req dto.LogoutRequest) error
+	VerifyEmail(token string) error
+	ForgotPassword(email string) error
+	ResetPassword(req dto.ResetPasswordRequest) error
+	GetProfile(userId int) (res dto.ProfileResponse, err error)
+	UpdateProfile(userId int, req dto.UpdateProfileRequest) (res dto.ProfileResponse, err error)
+	ChangePassword(userId int, req dto.ChangePasswordRequest) error
+	DeactivateAccount(userId int, req dto.ConfirmPasswordRequest) error
+	DeleteAccount(userId int, req dto.ConfirmPasswordRequest) error
+	LoginMFA(req dto.MFALoginRequest) (res dto.TokenResponse, err error)
+	GetTwoFactorStatus(userId int) (res dto.TwoFactorStatusResponse, err error)
+	SetupTOTP(userId int, req dto.ConfirmPasswordRequest) (res dto.TOTPSetupResponse, err error)
+	EnableTOTP(userId int, code string) (res dto.RecoveryCodesResponse, err error)
+	DisableTOTP(userId int, req dto.SecondFactorRequest) error
+	RegenerateRecoveryCodes(userId int, req dto.SecondFactorRequest) (res dto.RecoveryCodesResponse, err error)
 }
 
 type UserController struct {
@@ -57,14 +75,16 @@ func (uc *UserController) CreateUser(c echo.Context) error {
 
 // LoginUser godoc
 // @Summary User login
-// @Description Authenticate user and return access token
+// @Description Authenticate user and return a short-lived access token with a refresh token. Accounts with two-factor authentication get mfa_required and an mfa_token for /auth/login/mfa instead
 // @Tags Your Donate Rise API - Authentication
 // @Accept json
 // @Produce json
 // @Param credentials body dto.UserLoginRequest true "User login credentials"
-// @Success 200 {object} utils.SuccessResponseData{data=string} "success login"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.TokenResponse} "success login"
 // @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid credentials format"
 // @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid email or password"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Email not verified (a new verification link is sent) or account deactivated"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests - Account locked after failed logins or rate limit hit, see Retry-After"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /auth/login [post]
 func (uc *UserController) LoginUser(c echo.Context) error {
@@ -81,8 +101,605 @@ func (uc *UserController) LoginUser(c echo.Context) error {
 		if errors.Is(err, service.ErrInvalidCredential) {
 			return utils.UnauthorizedResponse(c, "invalid email or password")
 		}
+		var lockout *service.LockoutError
+		if errors.As(err, &lockout) {
+			return utils.TooManyRequestsResponse(c, lockout.Error(), lockout.RetryAfter)
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			return utils.ForbiddenResponse(c, "email is not verified, check your inbox for the verification link")
+		}
+		if errors.Is(err, service.ErrAccountDeactivated) {
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	if resp.MFARequired {
+		return utils.SuccessResponse(c, "enter the code from your authenticator app", resp)
+	}
+	return utils.SuccessResponse(c, "success login", resp)
+}
+
+// LoginMFA godoc
+// @Summary Second login step
+// @Description Finish a login of an account with two-factor authentication, code is from the authenticator app or a recovery code
+// @Tags Your Donate Rise API - Authentication
+// @Accept json
+// @Produce json
+// @Param mfa body dto.MFALoginRequest true "Token from /auth/login and the code"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.TokenResponse} "success login"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid code or expired login"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Account deactivated"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests - Too many wrong codes, see Retry-After"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/login/mfa [post]
+func (uc *UserController) LoginMFA(c echo.Context) error {
+	req := new(dto.MFALoginRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	resp, err := uc.userService.LoginMFA(*req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMFAChallenge), errors.Is(err, service.ErrInvalidTOTPCode), errors.Is(err, service.ErrTOTPNotEnabled):
+			return utils.UnauthorizedResponse(c, err.Error())
+		case errors.Is(err, service.ErrAccountDeactivated):
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		var lockout *service.LockoutError
+		if errors.As(err, &lockout) {
+			return utils.TooManyRequestsResponse(c, lockout.Error(), lockout.RetryAfter)
+		}
 		return utils.InternalServerErrorResponse(c, "internal server error")
 	}
 
 	return utils.SuccessResponse(c, "success login", resp)
 }
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Trade a refresh token for a new access token and refresh token, the old refresh token stops working
+// @Tags Your Donate Rise API - Authentication
+// @Accept json
+// @Produce json
+// @Param token body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.TokenResponse} "token refreshed"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Missing refresh token"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid, expired or reused refresh token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/refresh [post]
+func (uc *UserController) RefreshToken(c echo.Context) error {
+	req := new(dto.RefreshTokenRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	resp, err := uc.userService.RefreshToken(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			return utils.UnauthorizedResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "token refreshed", resp)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current access token and the refresh token's session, or every session with all_sessions
+// @Tags Your Donate Rise API - Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param logout body dto.LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} utils.SuccessResponseData "logged out"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/logout [post]
+func (uc *UserController) Logout(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claim := user.Claims.(jwt.MapClaims)
+	userId := int(claim["id"].(float64))
+	jti, _ := claim["jti"].(string)
+	exp, err := claim.GetExpirationTime()
+	if err != nil || exp == nil {
+		return utils.UnauthorizedResponse(c, "you are unauthorized")
+	}
+
+	req := new(dto.LogoutRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := uc.userService.Logout(userId, jti, exp.Time, *req); err != nil {
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "logged out", nil)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email
+// @Description Confirm the account email, or a changed email, with the token from the emailed link
+// @Tags Your Donate Rise API - Authentication
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} utils.SuccessResponseData "email verified"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid or expired link"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - The new email was registered by another account"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/verify [get]
+func (uc *UserController) VerifyEmail(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return utils.BadRequestResponse(c, "token is required")
+	}
+
+	if err := uc.userService.VerifyEmail(token); err != nil {
+		if errors.Is(err, service.ErrInvalidActionToken) {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		if errors.Is(err, service.ErrEmailTaken) {
+			return utils.ConflictResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "email verified", nil)
+}
+
+// ForgotPassword godoc
+// @Summary Forgot password
+// @Description Email a password reset link, the response is the same whether the email is registered or not
+// @Tags Your Donate Rise API - Authentication
+// @Accept json
+// @Produce json
+// @Param request body dto.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} utils.SuccessResponseData "reset link sent"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid email"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Failure 503 {object} utils.ErrorResponse "Service unavailable - Password reset is not configured"
+// @Router /auth/forgot-password [post]
+func (uc *UserController) ForgotPassword(c echo.Context) error {
+	req := new(dto.ForgotPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := uc.userService.ForgotPassword(req.Email); err != nil {
+		if errors.Is(err, service.ErrResetUnavailable) {
+			return utils.ServiceUnavailableResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "if the email is registered a reset link has been sent", nil)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the token from the reset link, every session of the user is logged out
+// @Tags Your Donate Rise API - Authentication
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} utils.SuccessResponseData "password reset"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or invalid, expired or used link"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Account deactivated"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/reset-password [post]
+func (uc *UserController) ResetPassword(c echo.Context) error {
+	req := new(dto.ResetPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := uc.userService.ResetPassword(*req); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidActionToken):
+			return utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, service.ErrAccountDeactivated):
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "password reset", nil)
+}
+
+// GetProfile godoc
+// @Summary Get my profile
+// @Description Get the signed in user's account and shipping details
+// @Tags Your Donate Rise API - Users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponseData{data=dto.ProfileResponse} "ok"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me [get]
+func (uc *UserController) GetProfile(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	resp, err := uc.userService.GetProfile(int(userId))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "ok", resp)
+}
+
+// UpdateProfile godoc
+// @Summary Update my profile
+// @Description Update name, shipping details or email, only the fields sent are changed. A new email is applied once the link sent to it is opened
+// @Tags Your Donate Rise API - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param profile body dto.UpdateProfileRequest true "Fields to change"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.ProfileResponse} "profile updated"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Email already registered"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me [patch]
+func (uc *UserController) UpdateProfile(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.UpdateProfileRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	resp, err := uc.userService.UpdateProfile(int(userId), *req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			return utils.NotFoundResponse(c, err.Error())
+		case errors.Is(err, service.ErrEmailTaken):
+			return utils.ConflictResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "internal server error")
+	}
+
+	return utils.SuccessResponse(c, "profile updated", resp)
+}
+
+// ChangePassword godoc
+// @Summary Change my password
+// @Description Change the password after checking the current one, every session is logged out
+// @Tags Your Donate Rise API - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} utils.SuccessResponseData "password changed"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or wrong current password"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me/password [put]
+func (uc *UserController) ChangePassword(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.ChangePasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	if err := uc.userService.ChangePassword(int(userId), *req); err != nil {
+		return accountErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, "password changed, please log in again", nil)
+}
+
+// DeactivateAccount godoc
+// @Summary Deactivate my account
+// @Description Block logging in to the account and log out every session, the data is kept
+// @Tags Your Donate Rise API - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param confirm body dto.ConfirmPasswordRequest true "Current password, accounts without one log in again first"
+// @Success 200 {object} utils.SuccessResponseData "account deactivated"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or wrong password"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Account without a password, log in again first"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me/deactivate [post]
+func (uc *UserController) DeactivateAccount(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.ConfirmPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	req.AuthTime = utils.GetAuthTime(c)
+	if err := uc.userService.DeactivateAccount(int(userId), *req); err != nil {
+		return accountErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, "account deactivated", nil)
+}
+
+// DeleteAccount godoc
+// @Summary Delete my account
+// @Description Anonymise the account, donations and bids are kept without anything identifying the user. Not allowed while a won item is unpaid
+// @Tags Your Donate Rise API - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param confirm body dto.ConfirmPasswordRequest true "Current password, accounts without one log in again first"
+// @Success 200 {object} utils.SuccessResponseData "account deleted"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or wrong password"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Account without a password, log in again first"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Unpaid won items"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me [delete]
+func (uc *UserController) DeleteAccount(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.ConfirmPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	req.AuthTime = utils.GetAuthTime(c)
+	if err := uc.userService.DeleteAccount(int(userId), *req); err != nil {
+		return accountErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, "account deleted", nil)
+}
+
+// GetTwoFactorStatus godoc
+// @Summary Two-factor authentication status
+// @Description Whether two-factor authentication is on and how many recovery codes are left
+// @Tags Your Donate Rise API - Users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponseData{data=dto.TwoFactorStatusResponse} "ok"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me/2fa [get]
+func (uc *UserController) GetTwoFactorStatus(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	resp, err := uc.userService.GetTwoFactorStatus(int(userId))
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, "ok", resp)
+}
+
+// SetupTOTP godoc
+// @Summary Start two-factor setup
+// @Description Create a secret for an authenticator app, provisioning_uri is shown as a QR code. Two-factor authentication is on once a code is confirmed at /users/me/2fa/enable
+// @Tags Your Donate Rise API - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param confirm body dto.ConfirmPasswordRequest true "Current password, accounts without one log in again first"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.TOTPSetupResponse} "scan the code with your authenticator app"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload or wrong password"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Account without a password, log in again first"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Two-factor authentication already on"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me/2fa/setup [post]
+func (uc *UserController) SetupTOTP(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.ConfirmPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	req.AuthTime = utils.GetAuthTime(c)
+	resp, err := uc.userService.SetupTOTP(int(userId), *req)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, "scan the code with your authenticator app", resp)
+}
+
+// EnableTOTP godoc
+// @Summary Turn on two-factor authentication
+// @Description Confirm the first code from the authenticator app. The recovery codes are only shown here
+// @Tags Your Donate Rise API - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body dto.TOTPCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.RecoveryCodesResponse} "two-factor authentication on"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload, invalid code or setup not started"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 409 {object} utils.ErrorResponse "Conflict - Two-factor authentication already on"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests - Too many wrong codes, see Retry-After"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me/2fa/enable [post]
+func (uc *UserController) EnableTOTP(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.TOTPCodeRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	resp, err := uc.userService.EnableTOTP(int(userId), req.Code)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, "two-factor authentication on, keep the recovery codes somewhere safe and log in again", resp)
+}
+
+// DisableTOTP godoc
+// @Summary Turn off two-factor authentication
+// @Description Needs the password and a code from the authenticator app or a recovery code
+// @Tags Your Donate Rise API - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param confirm body dto.SecondFactorRequest true "Current password (accounts without one log in again first) and code"
+// @Success 200 {object} utils.SuccessResponseData "two-factor authentication off"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload, wrong password or invalid code"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Account without a password, log in again first"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests - Too many wrong codes, see Retry-After"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me/2fa/disable [post]
+func (uc *UserController) DisableTOTP(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.SecondFactorRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	req.AuthTime = utils.GetAuthTime(c)
+	if err := uc.userService.DisableTOTP(int(userId), *req); err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, "two-factor authentication off", nil)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary New recovery codes
+// @Description Replace every recovery code, needs the password and a code from the authenticator app or a recovery code
+// @Tags Your Donate Rise API - Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param confirm body dto.SecondFactorRequest true "Current password (accounts without one log in again first) and code"
+// @Success 200 {object} utils.SuccessResponseData{data=dto.RecoveryCodesResponse} "new recovery codes"
+// @Failure 400 {object} utils.ErrorResponse "Bad request - Invalid payload, wrong password or invalid code"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized - Invalid or missing token"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden - Account without a password, log in again first"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests - Too many wrong codes, see Retry-After"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /users/me/2fa/recovery-codes [post]
+func (uc *UserController) RegenerateRecoveryCodes(c echo.Context) error {
+	userId, ok := utils.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "unauthenticated")
+	}
+
+	req := new(dto.SecondFactorRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if err := uc.validate.Struct(req); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	req.AuthTime = utils.GetAuthTime(c)
+	resp, err := uc.userService.RegenerateRecoveryCodes(int(userId), *req)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, "new recovery codes, the old ones stopped working", resp)
+}
+
+func twoFactorErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidTOTPCode), errors.Is(err, service.ErrTOTPNotSetUp), errors.Is(err, service.ErrTOTPNotEnabled):
+		return utils.BadRequestResponse(c, err.Error())
+	case errors.Is(err, service.ErrTOTPAlreadyEnabled):
+		return utils.ConflictResponse(c, err.Error())
+	}
+	var lockout *service.LockoutError
+	if errors.As(err, &lockout) {
+		return utils.TooManyRequestsResponse(c, lockout.Error(), lockout.RetryAfter)
+	}
+	return accountErrorResponse(c, err)
+}
+
+func accountErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrWrongPassword):
+		return utils.BadRequestResponse(c, err.Error())
+	case errors.Is(err, service.ErrReauthRequired):
+		return utils.ForbiddenResponse(c, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		return utils.NotFoundResponse(c, err.Error())
+	case errors.Is(err, service.ErrUnpaidWins):
+		return utils.ConflictResponse(c, err.Error())
+	}
+	return utils.InternalServerErrorResponse(c, "internal server error")
+}
@@ -2,22 +2,32 @@ package scheduler
 
 import (
 	"log/slog"
+	"milestone3/be/internal/dto"
 	"milestone3/be/internal/service"
 	"time"
 
 	"github.com/go-co-op/gocron"
 )
 
+// PaymentJobs hands unpaid wins on once their payment deadline passes and
+// reconciles payments with the gateway
+type PaymentJobs interface {
+	ExpireUnpaidWins() error
+	ReconcilePayments() (dto.ReconciliationReport, error)
+}
+
 type BidScheduler struct {
 	bidSvc     service.BidService
 	auctionSvc service.AuctionItemService
+	paymentSvc PaymentJobs
 	logger     *slog.Logger
 }
 
-func NewBidScheduler(bidService service.BidService, auctionService service.AuctionItemService, logger *slog.Logger) *BidScheduler {
+func NewBidScheduler(bidService service.BidService, auctionService service.AuctionItemService, paymentService PaymentJobs, logger *slog.Logger) *BidScheduler {
 	return &BidScheduler{
 		bidSvc:     bidService,
 		auctionSvc: auctionService,
+		paymentSvc: paymentService,
 		logger:     logger,
 	}
 }
@@ -55,6 +65,40 @@ func (s *BidScheduler) Start() {
 		return
 	}
 
+	// pass unpaid wins to the runner-up every 1 minute
+	_, err = scheduler.Every(1).Minute().Do(func() {
+		s.logger.Info("Checking for unpaid wins past deadline...")
+		if expireErr := s.paymentSvc.ExpireUnpaidWins(); expireErr != nil {
+			s.logger.Error("Failed to expire unpaid wins", "error", expireErr)
+		}
+	})
+
+	if err != nil {
+		s.logger.Error("Failed to schedule payment deadline check", "error", err)
+		return
+	}
+
+	// reconcile stale payments with the gateway every 15 minutes
+	_, err = scheduler.Every(15).Minutes().Do(func() {
+		s.logger.Info("Reconciling payments with the gateway...")
+		report, reconcileErr := s.paymentSvc.ReconcilePayments()
+		if reconcileErr != nil {
+			s.logger.Error("Failed to reconcile payments", "error", reconcileErr)
+			return
+		}
+		s.logger.Info("Payment reconciliation done", "checked", report.Checked, "mismatches", len(report.Mismatches), "resolved", report.Resolved)
+		for _, m := range report.Mismatches {
+			if !m.Resolved {
+				s.logger.Warn("Payment needs attention", "payment_id", m.PaymentId, "order_id", m.OrderId, "local_status", m.LocalStatus, "gateway_status", m.GatewayStatus, "note", m.Note)
+			}
+		}
+	})
+
+	if err != nil {
+		s.logger.Error("Failed to schedule payment reconciliation", "error", err)
+		return
+	}
+
 	// delete key value at 12 AM daily
 	_, err = scheduler.Every(1).Day().At("00:00").Do(func() {
 		s.logger.Info("Running midnight Redis cleanup...")
@@ -72,5 +116,7 @@ func (s *BidScheduler) Start() {
 	s.logger.Info("Bid scheduler started")
 	s.logger.Info("- Auto-start auctions: every 1 minute")
 	s.logger.Info("- Sync to DB: every 1 minute")
+	s.logger.Info("- Payment deadlines: every 1 minute")
+	s.logger.Info("- Payment reconciliation: every 15 minutes")
 	s.logger.Info("- Redis cleanup: daily at 00:00")
 }
@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+// CreateAPIKeyRequest issues a key for the owner, rate_limit_per_minute falls
+// back to API_KEY_RATE_LIMIT_PER_MINUTE and keys without expires_in_days never expire
+type CreateAPIKeyRequest struct {
+	OwnerId            int      `json:"owner_id" validate:"required,gt=0"`
+	Name               string   `json:"name" validate:"required,max=100"`
+	Scopes             []string `json:"scopes" validate:"required,min=1,dive,required"`
+	RateLimitPerMinute int      `json:"rate_limit_per_minute" validate:"omitempty,min=1,max=10000"`
+	ExpiresInDays      int      `json:"expires_in_days" validate:"omitempty,min=1,max=730"`
+}
+
+type APIKeyResponse struct {
+	Id                 int        `json:"id"`
+	OwnerId            int        `json:"owner_id"`
+	Name               string     `json:"name"`
+	Prefix             string     `json:"prefix"`
+	Scopes             []string   `json:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	CreatedBy          int        `json:"created_by"`
+	CreatedAt          time.Time  `json:"created_at"`
+	ExpiresAt          *time.Time `json:"expires_at"`
+	LastUsedAt         *time.Time `json:"last_used_at"`
+	RevokedAt          *time.Time `json:"revoked_at"`
+	RequestsToday      int64      `json:"requests_today"`
+	RequestsLast30Days int64      `json:"requests_last_30_days"`
+}
+
+// CreatedAPIKeyResponse carries the key itself, it is only shown once
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type APIKeyUsageDay struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+}
+
+type APIKeyUsageResponse struct {
+	Id         int              `json:"id"`
+	LastUsedAt *time.Time       `json:"last_used_at"`
+	Days       []APIKeyUsageDay `json:"days"`
+}
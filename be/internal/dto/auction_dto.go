@@ -27,8 +27,13 @@ type AuctionItemDTO struct {
 	DonationID    int64   `json:"donation_id,omitempty" validate:"required"`
 	SessionID     *int64  `json:"session_id,omitempty"`
 	StartingPrice float64 `json:"starting_price,omitempty"`
-	// Photos        []string  `json:"photos,omitempty" validate:"dive,url"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
+	// ReservePrice is only accepted from and returned to admins
+	ReservePrice *float64 `json:"reserve_price,omitempty" validate:"omitempty,gt=0"`
+	BuyNowPrice  *float64 `json:"buy_now_price,omitempty" validate:"omitempty,gt=0"`
+	// Photos are object names in the private bucket, only returned to admins
+	Photos    []string   `json:"photos,omitempty"`
+	PhotoURLs []PhotoURL `json:"photo_urls,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
 }
 
 // AuctionItemUpdateDTO for partial updates (all fields optional)
@@ -36,8 +41,10 @@ type AuctionItemUpdateDTO struct {
 	Title         *string  `json:"title,omitempty"`
 	Description   *string  `json:"description,omitempty"`
 	Category      *string  `json:"category,omitempty"`
-	Status        *string  `json:"status,omitempty" validate:"omitempty,oneof=scheduled ongoing finished"`
+	Status        *string  `json:"status,omitempty" validate:"omitempty,oneof=scheduled ongoing finished unsold"`
 	StartingPrice *float64 `json:"starting_price,omitempty" validate:"omitempty,min=0"`
+	ReservePrice  *float64 `json:"reserve_price,omitempty" validate:"omitempty,gt=0"`
+	BuyNowPrice   *float64 `json:"buy_now_price,omitempty" validate:"omitempty,gt=0"`
 	SessionID     *int64   `json:"session_id,omitempty"`
 	DonationID    *int64   `json:"donation_id,omitempty"`
 }
@@ -57,6 +64,8 @@ func AuctionItemRequest(d AuctionItemDTO) (entity.AuctionItem, error) {
 		Category:      d.Category,
 		Status:        status,
 		StartingPrice: d.StartingPrice,
+		ReservePrice:  d.ReservePrice,
+		BuyNowPrice:   d.BuyNowPrice,
 		CreatedAt:     d.CreatedAt,
 	}, nil
 }
@@ -71,6 +80,7 @@ func AuctionItemResponse(m entity.AuctionItem) AuctionItemDTO {
 		Category:      m.Category,
 		Status:        m.Status,
 		StartingPrice: m.StartingPrice,
+		BuyNowPrice:   m.BuyNowPrice,
 		CreatedAt:     m.CreatedAt.In(wibLocation),
 	}
 }
@@ -83,6 +93,24 @@ func AuctionItemResponses(ms []entity.AuctionItem) []AuctionItemDTO {
 	return res
 }
 
+// AuctionItemAdminResponse also carries the reserve price and photo object
+// names, never use it for bidders
+func AuctionItemAdminResponse(m entity.AuctionItem) AuctionItemDTO {
+	res := AuctionItemResponse(m)
+	res.ReservePrice = m.ReservePrice
+	res.Photos = AuctionItemPhotos(m)
+	return res
+}
+
+// AuctionItemPhotos are the object names of the item's photos
+func AuctionItemPhotos(m entity.AuctionItem) []string {
+	var photos []string
+	for _, p := range m.Photos {
+		photos = append(photos, p.URL)
+	}
+	return photos
+}
+
 type AuctionSessionDTO struct {
 	Name      string    `json:"name,omitempty" validate:"required"`
 	ID        int64     `json:"id,omitempty"`
@@ -1,13 +1,71 @@
 package dto
 
 import (
+	"time"
+
 	"milestone3/be/internal/entity"
 )
 
+// bid stream event types
+const (
+	BidEventSnapshot      = "snapshot"
+	BidEventPlaced        = "bid_placed"
+	BidEventOutbid        = "outbid"
+	BidEventSessionClosed = "session_closed"
+
+	// sent when a late bid pushes the item's end time back
+	BidEventEndTimeExtended = "end_time_extended"
+)
+
 type BidDTO struct {
 	Amount float64 `json:"amount" validate:"required,gt=0"`
 }
 
+// MaxBidDTO registers the ceiling the system may bid up to for the user
+type MaxBidDTO struct {
+	MaxAmount float64 `json:"max_amount" validate:"required,gt=0"`
+}
+
+type MaxBidResultDTO struct {
+	SessionID       int64     `json:"session_id"`
+	ItemID          int64     `json:"item_id"`
+	MaxAmount       float64   `json:"max_amount"`
+	HighestBid      float64   `json:"highest_bid"`
+	IsHighestBidder bool      `json:"is_highest_bidder"`
+	EndTime         time.Time `json:"end_time"`
+}
+
+type BuyNowResultDTO struct {
+	SessionID int64            `json:"session_id"`
+	ItemID    int64            `json:"item_id"`
+	Amount    float64          `json:"amount"`
+	Payment   *PaymentResponse `json:"payment,omitempty"` // nil when payment creation has to be retried
+}
+
+// BidEventDTO is the payload pushed to clients streaming a session item
+type BidEventDTO struct {
+	Type         string     `json:"type"`
+	SessionID    int64      `json:"session_id"`
+	ItemID       int64      `json:"item_id"`
+	Amount       float64    `json:"amount"`
+	BidderID     int64      `json:"bidder_id,omitempty"`
+	OutbidUserID int64      `json:"outbid_user_id,omitempty"`
+	Source       string     `json:"source,omitempty"`
+	EndTime      *time.Time `json:"end_time,omitempty"`
+	Unsold       bool       `json:"unsold,omitempty"` // session closed under the reserve price
+	OccurredAt   time.Time  `json:"occurred_at"`
+}
+
+type BidHistoryResponse struct {
+	ID        int64     `json:"id"`
+	SessionID int64     `json:"session_id"`
+	ItemID    int64     `json:"item_id"`
+	BidderID  int64     `json:"bidder_id"`
+	Amount    float64   `json:"amount"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 func BidRequest(d BidDTO) entity.Bid {
 	return entity.Bid{
 		Amount: d.Amount,
@@ -27,3 +85,19 @@ func BidResponses(ms []entity.Bid) []BidDTO {
 	}
 	return res
 }
+
+func BidHistoryResponses(ms []entity.BidHistory) []BidHistoryResponse {
+	res := make([]BidHistoryResponse, 0, len(ms))
+	for _, m := range ms {
+		res = append(res, BidHistoryResponse{
+			ID:        m.ID,
+			SessionID: m.SessionID,
+			ItemID:    m.ItemID,
+			BidderID:  m.UserID,
+			Amount:    m.Amount,
+			Source:    m.Source,
+			CreatedAt: m.CreatedAt.In(wibLocation),
+		})
+	}
+	return res
+}
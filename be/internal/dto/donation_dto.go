@@ -14,12 +14,34 @@ type DonationDTO struct {
 	Category    string                `json:"category,omitempty" validate:"required"`
 	Condition   string                `json:"condition,omitempty" validate:"required"`
 	Status      entity.StatusDonation `json:"status,omitempty" validate:"omitempty"`
-	Photos      []string              `json:"photos,omitempty" validate:"omitempty"`
-	CreatedAt   time.Time             `json:"created_at,omitempty"`
+	// ReviewComment is the reviewer's latest comment, set through the review only
+	ReviewComment string   `json:"review_comment,omitempty" validate:"omitempty"`
+	Photos        []string `json:"photos,omitempty" validate:"omitempty"`
+	// PhotoURLs are signed URLs of Photos for responses, a photo that could not
+	// be signed is left out
+	PhotoURLs []PhotoURL `json:"photo_urls,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
 }
 
+// PhotoURL is a short-lived signed URL of a photo in the private bucket
+type PhotoURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DonationApprovalDTO moves a donation through the review, a comment is
+// required when rejecting or asking the donor for more information
 type DonationApprovalDTO struct {
-	Status entity.StatusDonation `json:"status" validate:"required,oneof=pending verified_for_auction verified_for_donation"`
+	Status  entity.StatusDonation `json:"status" validate:"required,oneof=pending needs_info rejected verified_for_auction verified_for_donation picked_up archived"`
+	Comment string                `json:"comment" validate:"max=2000"`
+}
+
+type DonationStatusHistoryDTO struct {
+	FromStatus *entity.StatusDonation `json:"from_status"`
+	ToStatus   entity.StatusDonation  `json:"to_status"`
+	ChangedBy  uint                   `json:"changed_by"`
+	Comment    string                 `json:"comment,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
 }
 
 // DonationRequest converts DTO to entity.Donation
@@ -29,15 +51,16 @@ func DonationRequest(d DonationDTO) (entity.Donation, error) {
 		photos = append(photos, entity.DonationPhoto{URL: u})
 	}
 	return entity.Donation{
-		ID:          d.ID,
-		UserID:      d.UserID,
-		Title:       d.Title,
-		Description: d.Description,
-		Category:    d.Category,
-		Condition:   d.Condition,
-		Status:      d.Status,
-		CreatedAt:   d.CreatedAt,
-		Photos:      photos,
+		ID:            d.ID,
+		UserID:        d.UserID,
+		Title:         d.Title,
+		Description:   d.Description,
+		Category:      d.Category,
+		Condition:     d.Condition,
+		Status:        d.Status,
+		ReviewComment: d.ReviewComment,
+		CreatedAt:     d.CreatedAt,
+		Photos:        photos,
 	}, nil
 }
 
@@ -48,15 +71,16 @@ func DonationResponse(m entity.Donation) DonationDTO {
 		photos = append(photos, p.URL)
 	}
 	return DonationDTO{
-		ID:          m.ID,
-		UserID:      m.UserID,
-		Title:       m.Title,
-		Description: m.Description,
-		Category:    m.Category,
-		Condition:   m.Condition,
-		Status:      m.Status,
-		Photos:      photos,
-		CreatedAt:   m.CreatedAt,
+		ID:            m.ID,
+		UserID:        m.UserID,
+		Title:         m.Title,
+		Description:   m.Description,
+		Category:      m.Category,
+		Condition:     m.Condition,
+		Status:        m.Status,
+		ReviewComment: m.ReviewComment,
+		Photos:        photos,
+		CreatedAt:     m.CreatedAt,
 	}
 }
 
@@ -68,3 +92,18 @@ func DonationResponses(ms []entity.Donation) []DonationDTO {
 	}
 	return res
 }
+
+// DonationStatusHistoryResponses converts the status history to DTOs
+func DonationStatusHistoryResponses(hs []entity.DonationStatusHistory) []DonationStatusHistoryDTO {
+	res := make([]DonationStatusHistoryDTO, 0, len(hs))
+	for _, h := range hs {
+		res = append(res, DonationStatusHistoryDTO{
+			FromStatus: h.FromStatus,
+			ToStatus:   h.ToStatus,
+			ChangedBy:  h.ChangedBy,
+			Comment:    h.Comment,
+			CreatedAt:  h.CreatedAt,
+		})
+	}
+	return res
+}
@@ -1,11 +1,15 @@
 package dto
 
-import "milestone3/be/internal/entity"
+import (
+	"milestone3/be/internal/entity"
+	"time"
+)
 
+// PaymentRequest picks how the winner pays, QRIS when empty
 type PaymentRequest struct {
-	UserId int `json:"user_id"`
-	AuctionItemId float64 `json:"auction_item_id"`
-	Amount float64 `json:"amount" validate:"required"`
+	PaymentType string `json:"payment_type" validate:"omitempty,oneof=qris bank_transfer gopay credit_card"`
+	Bank string `json:"bank" validate:"required_if=PaymentType bank_transfer,omitempty,oneof=bca bni bri permata"`
+	CardToken string `json:"card_token" validate:"required_if=PaymentType credit_card"`
 }
 
 type PaymentResponse struct {
@@ -13,20 +17,152 @@ type PaymentResponse struct {
 	TransactionId string `json:"transaction_id"`
 	ExpiryTime string `json:"expiry_time"`
 	OrderId string `json:"order_id"`
+	PaymentType string `json:"payment_type"`
+	VaNumber string `json:"va_number,omitempty"`
+	Amount float64 `json:"amount"`
+	PaymentDeadline *time.Time `json:"payment_deadline,omitempty"`
 }
 
 type CheckPaymentStatusResponse struct {
 	OrderId string `json:"order_id"`
 	TransactionId string `json:"transaction_id"`
 	PaymentStatus string `json:"payment_status"`
+	FraudStatus string `json:"fraud_status,omitempty"`
+	GrossAmount string `json:"gross_amount,omitempty"`
+}
+
+// MidtransNotification is the HTTP notification Midtrans posts on every transaction change
+type MidtransNotification struct {
+	OrderId string `json:"order_id" validate:"required"`
+	StatusCode string `json:"status_code" validate:"required"`
+	GrossAmount string `json:"gross_amount" validate:"required"`
+	SignatureKey string `json:"signature_key" validate:"required"`
+	TransactionId string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status" validate:"required"`
+	FraudStatus string `json:"fraud_status"`
+	PaymentType string `json:"payment_type"`
 }
 
 type PaymentInfoResponse struct {
 	Id int `json:"id"`
 	UserId int `json:"user_id"`
-	User entity.Users `json:"user"`
+	User PaymentUserResponse `json:"user"`
 	AuctionItemId int `json:"auction_item_id"`
 	Status string `json:"payment_status"`
+	PaymentType string `json:"payment_type"`
 	// PaymentStatus entity.PaymentStatus `json:"payment_status"`
 	Amount float64 `json:"amount"`
-}
\ No newline at end of file
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Refunds []PaymentRefundResponse `json:"refunds,omitempty"`
+	StatusHistory []PaymentStatusHistoryResponse `json:"status_history,omitempty"`
+}
+
+// PaymentUserResponse is who owes the payment, without the rest of the account
+type PaymentUserResponse struct {
+	Id int `json:"id"`
+	Name string `json:"name"`
+}
+
+// PaymentFilter narrows the admin payment list, zero values match everything.
+// From is inclusive and To exclusive, both on the payment's creation time
+type PaymentFilter struct {
+	Status string `validate:"omitempty,oneof=pending paid failed expired cancelled refunded partially_refunded"`
+	UserId int
+	AuctionItemId int
+	From *time.Time
+	To *time.Time
+	Page int
+	Limit int
+}
+
+type CancelPaymentRequest struct {
+	Reason string `json:"reason" validate:"required"`
+	ReopenItem bool `json:"reopen_item"`
+}
+
+// RefundRequest refunds what is left of the payment when Amount is empty
+type RefundRequest struct {
+	Amount float64 `json:"amount" validate:"omitempty,gt=0"`
+	Reason string `json:"reason" validate:"required"`
+	ReopenItem bool `json:"reopen_item"`
+}
+
+type PaymentRefundResponse struct {
+	Id int `json:"id"`
+	PaymentId int `json:"payment_id"`
+	Amount float64 `json:"amount"`
+	Reason string `json:"reason"`
+	CreatedBy int `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentStatusHistoryResponse struct {
+	FromStatus *string `json:"from_status"`
+	ToStatus string `json:"to_status"`
+	Reason string `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func PaymentRefundResponses(refunds []entity.PaymentRefund) []PaymentRefundResponse {
+	res := make([]PaymentRefundResponse, 0, len(refunds))
+	for _, r := range refunds {
+		res = append(res, PaymentRefundResponse{
+			Id: r.Id,
+			PaymentId: r.PaymentId,
+			Amount: r.Amount,
+			Reason: r.Reason,
+			CreatedBy: r.CreatedBy,
+			CreatedAt: r.CreatedAt,
+		})
+	}
+	return res
+}
+
+func PaymentStatusHistoryResponses(history []entity.PaymentStatusHistory) []PaymentStatusHistoryResponse {
+	res := make([]PaymentStatusHistoryResponse, 0, len(history))
+	for _, h := range history {
+		res = append(res, PaymentStatusHistoryResponse{
+			FromStatus: h.FromStatus,
+			ToStatus: h.ToStatus,
+			Reason: h.Reason,
+			CreatedAt: h.CreatedAt,
+		})
+	}
+	return res
+}
+
+// PaymentInfo converts a payment with its preloaded user, refunds and history
+func PaymentInfo(payment entity.Payment) PaymentInfoResponse {
+	return PaymentInfoResponse{
+		Id: payment.Id,
+		UserId: payment.UserId,
+		User: PaymentUserResponse{Id: payment.User.Id, Name: payment.User.Name},
+		AuctionItemId: payment.AuctionItemId,
+		Status: payment.Status,
+		PaymentType: payment.PaymentType,
+		Amount: payment.Amount,
+		ExpiresAt: payment.ExpiresAt,
+		CreatedAt: payment.CreatedAt,
+		Refunds: PaymentRefundResponses(payment.Refunds),
+		StatusHistory: PaymentStatusHistoryResponses(payment.StatusHistory),
+	}
+}
+
+// ReconciliationReport lists every payment whose status differed from the gateway's
+type ReconciliationReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Checked int `json:"checked"`
+	Resolved int `json:"resolved"`
+	Mismatches []ReconciliationMismatch `json:"mismatches"`
+}
+
+// ReconciliationMismatch is resolved when the payment was moved to the gateway's status
+type ReconciliationMismatch struct {
+	PaymentId int `json:"payment_id"`
+	OrderId string `json:"order_id"`
+	LocalStatus string `json:"local_status"`
+	GatewayStatus string `json:"gateway_status,omitempty"`
+	Resolved bool `json:"resolved"`
+	Note string `json:"note"`
+}
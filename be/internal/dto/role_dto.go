@@ -0,0 +1,19 @@
+package dto
+
+type RoleResponse struct {
+	Id          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UserRolesResponse struct {
+	UserId      int      `json:"user_id"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// SetUserRolesRequest replaces every role of the user, an empty list removes all of them
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles" validate:"required,dive,required"`
+}
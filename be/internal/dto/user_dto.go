@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type UserRequest struct {
 	Name string `json:"name" validate:"required,gte=3"` 
 	Email string `json:"email" validate:"required,email"` 
@@ -11,9 +13,126 @@ type UserResponse struct {
 	Name string `json:"name"`
 	Email string `json:"email"`
 	Role string `json:"role"`
+	Roles []string `json:"roles"`
+	EmailVerified bool `json:"email_verified"`
 }
 
 type UserLoginRequest struct {
 	Email string `json:"email" validate:"required,email"` 
 	Password string `json:"password" validate:"required,gte=8"` 	
-}
\ No newline at end of file
+}
+
+// TokenResponse is returned on login and refresh, expires_in is the access token lifetime in seconds.
+// Accounts with two-factor authentication get mfa_required and an mfa_token for /auth/login/mfa
+// instead of tokens, mfa_setup_required means the account's role needs two-factor authentication first.
+// A provider sign in that matches an existing account gets link_required and a link_token for
+// /auth/oidc/link when the account's password has to confirm the link first
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType string `json:"token_type"`
+	ExpiresIn int `json:"expires_in"`
+	MFARequired bool `json:"mfa_required,omitempty"`
+	MFAToken string `json:"mfa_token,omitempty"`
+	MFASetupRequired bool `json:"mfa_setup_required,omitempty"`
+	LinkRequired bool `json:"link_required,omitempty"`
+	LinkToken string `json:"link_token,omitempty"`
+}
+
+// OIDCLinkRequest links a provider to the existing account with the account's password
+type OIDCLinkRequest struct {
+	LinkToken string `json:"link_token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// MFALoginRequest is the second login step, code is from the authenticator app or a recovery code
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code string `json:"code" validate:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutRequest revokes the session of refresh_token, or every session of the user with all_sessions
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	AllSessions bool `json:"all_sessions"`
+}
+
+// ProfileResponse is the signed in user's own account, pending_email waits for confirmation
+type ProfileResponse struct {
+	Id int `json:"id"`
+	Name string `json:"name"`
+	Email string `json:"email"`
+	PendingEmail *string `json:"pending_email,omitempty"`
+	EmailVerified bool `json:"email_verified"`
+	Phone string `json:"phone"`
+	Address string `json:"address"`
+	City string `json:"city"`
+	PostalCode string `json:"postal_code"`
+	Roles []string `json:"roles"`
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+}
+
+// UpdateProfileRequest only changes the fields that are sent, a new email is
+// applied once the link sent to it is opened
+type UpdateProfileRequest struct {
+	Name *string `json:"name" validate:"omitempty,gte=3,lte=255"`
+	Email *string `json:"email" validate:"omitempty,email"`
+	Phone *string `json:"phone" validate:"omitempty,min=8,max=20,numeric"`
+	Address *string `json:"address" validate:"omitempty,max=500"`
+	City *string `json:"city" validate:"omitempty,max=100"`
+	PostalCode *string `json:"postal_code" validate:"omitempty,max=10,numeric"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,gte=8,nefield=CurrentPassword"`
+}
+
+// ConfirmPasswordRequest guards deactivating and deleting the account. Accounts
+// created through a login provider have no password and send none, AuthTime
+// comes from the access token
+type ConfirmPasswordRequest struct {
+	Password string `json:"password"`
+	AuthTime time.Time `json:"-"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest carries the token from the reset link and the new password
+type ResetPasswordRequest struct {
+	Token string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,gte=8"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled bool `json:"enabled"`
+	RecoveryCodesLeft int `json:"recovery_codes_left"`
+}
+
+// TOTPSetupResponse is shown once, provisioning_uri is rendered as a QR code for the authenticator app
+type TOTPSetupResponse struct {
+	Secret string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// SecondFactorRequest guards turning two-factor authentication off and new recovery codes
+type SecondFactorRequest struct {
+	Password string `json:"password"`
+	Code string `json:"code" validate:"required"`
+	AuthTime time.Time `json:"-"`
+}
+
+// RecoveryCodesResponse is shown once, every code can replace the authenticator app one time
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
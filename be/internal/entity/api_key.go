@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey lets a partner's scripts call the API for its owner without a
+// password. Only the hash of the key is stored, Prefix identifies it in lists
+type APIKey struct {
+	Id                 int
+	UserId             int
+	Name               string
+	Prefix             string
+	KeyHash            string `json:"-"`
+	Scopes             string
+	RateLimitPerMinute int
+	CreatedBy          int
+	CreatedAt          time.Time `gorm:"autoCreateTime"`
+	ExpiresAt          *time.Time
+	LastUsedAt         *time.Time
+	RevokedAt          *time.Time
+}
+
+// ScopeList splits the comma separated scopes
+func (k APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -19,8 +19,21 @@ type AuctionItem struct {
 	ID            int64     `gorm:"primaryKey;autoIncrement" json:"id"`
 	DonationID    int64     `gorm:"not null" json:"donation_id"`
 	StartingPrice float64   `gorm:"not null" json:"starting_price"`
+	ReservePrice  *float64  `gorm:"null" json:"-"` // hidden from bidders, see dto.AuctionItemAdminResponse
+	BuyNowPrice   *float64  `gorm:"null" json:"buy_now_price"`
 	SessionID     *int64    `gorm:"null" json:"session_id"`
 	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
 
-	Session *AuctionSession `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	Session *AuctionSession    `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	Photos  []AuctionItemPhoto `gorm:"foreignKey:AuctionItemID;constraint:OnDelete:CASCADE" json:"photos,omitempty"`
+}
+
+// AuctionItemStatusDraft is an item created from a verified donation that no
+// bidder sees until an admin schedules it
+const AuctionItemStatusDraft = "draft"
+
+type AuctionItemPhoto struct {
+	ID            int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	AuctionItemID int64  `gorm:"not null" json:"auction_item_id"`
+	URL           string `gorm:"size:255" json:"url"`
 }
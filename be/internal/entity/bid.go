@@ -2,14 +2,40 @@ package entity
 
 import "time"
 
+// bid history sources
+const (
+	BidSourceManual = "manual"
+	BidSourceProxy  = "proxy"
+	BidSourceBuyNow = "buy_now"
+)
+
+// Bid is a winner of an item, it lapses when PaymentDeadline passes unpaid
 type Bid struct {
+	ID              int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	SessionID       int64      `gorm:"column:auction_session_id;index" json:"auction_session_id"`
+	ItemID          int64      `gorm:"column:auction_item_id;not null;index" json:"auction_item_id"`
+	Amount          float64    `gorm:"not null" json:"amount"`
+	UserID          int64      `gorm:"not null;index" json:"user_id"`
+	PaymentDeadline *time.Time `gorm:"null" json:"payment_deadline"`
+	Lapsed          bool       `gorm:"not null;default:false" json:"lapsed"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Bid) TableName() string {
+	return "bids"
+}
+
+// BidHistory is every accepted bid on an item, Bid only keeps the winner
+type BidHistory struct {
 	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	SessionID int64     `gorm:"column:auction_session_id;not null;index" json:"auction_session_id"`
 	ItemID    int64     `gorm:"column:auction_item_id;not null;index" json:"auction_item_id"`
-	Amount    float64   `gorm:"not null" json:"amount"`
 	UserID    int64     `gorm:"not null;index" json:"user_id"`
+	Amount    float64   `gorm:"not null" json:"amount"`
+	Source    string    `gorm:"type:bid_source;default:'manual';not null" json:"source"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 }
 
-func (Bid) TableName() string {
-	return "bids"
+func (BidHistory) TableName() string {
+	return "bid_history"
 }
@@ -10,18 +10,54 @@ var (
 	StatusPending             StatusDonation = "pending"
 	StatusVerifiedForAuction  StatusDonation = "verified_for_auction"
 	StatusVerifiedForDonation StatusDonation = "verified_for_donation"
+	StatusRejected            StatusDonation = "rejected"
+	StatusNeedsInfo           StatusDonation = "needs_info"
+	StatusPickedUp            StatusDonation = "picked_up"
+	StatusArchived            StatusDonation = "archived"
 )
 
+// donationTransitions are the statuses a donation may move to from each
+// status, verified donations only go forward since they already have a final
+// donation or auction behind them
+var donationTransitions = map[StatusDonation][]StatusDonation{
+	StatusPending:             {StatusNeedsInfo, StatusRejected, StatusVerifiedForAuction, StatusVerifiedForDonation},
+	StatusNeedsInfo:           {StatusPending, StatusRejected, StatusVerifiedForAuction, StatusVerifiedForDonation},
+	StatusVerifiedForAuction:  {StatusPickedUp},
+	StatusVerifiedForDonation: {StatusPickedUp},
+	StatusPickedUp:            {StatusArchived},
+	StatusRejected:            {StatusArchived},
+}
+
+// CanMoveTo reports whether the review workflow allows going from s to next
+func (s StatusDonation) CanMoveTo(next StatusDonation) bool {
+	for _, allowed := range donationTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// FinalDonationStatuses are the statuses a donation with a final donation can
+// be in, it stays one after being picked up and archived
+var FinalDonationStatuses = []StatusDonation{StatusVerifiedForDonation, StatusPickedUp, StatusArchived}
+
+// Editable is whether the donor may still change the donation's details
+func (s StatusDonation) Editable() bool {
+	return s == StatusPending || s == StatusNeedsInfo
+}
+
 type Donation struct {
-	ID          uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID      uint           `gorm:"not null" json:"user_id"`
-	User        Users          `gorm:"foreignKey:UserID" json:"user,omitempty"` // Assuming User entity exists elsewhere
-	Title       string         `gorm:"size:255;not null" json:"title"`
-	Description string         `gorm:"type:text" json:"description"`
-	Category    string         `gorm:"size:255" json:"category"`
-	Condition   string         `gorm:"size:255" json:"condition"`
-	Status      StatusDonation `gorm:"type:donation_status;default:'pending';not null" json:"status"` // enum: pending, verified_for_auction, verified_for_donation
-	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        uint           `gorm:"not null" json:"user_id"`
+	User          Users          `gorm:"foreignKey:UserID" json:"user,omitempty"` // Assuming User entity exists elsewhere
+	Title         string         `gorm:"size:255;not null" json:"title"`
+	Description   string         `gorm:"type:text" json:"description"`
+	Category      string         `gorm:"size:255" json:"category"`
+	Condition     string         `gorm:"size:255" json:"condition"`
+	Status        StatusDonation `gorm:"type:donation_status;default:'pending';not null" json:"status"` // enum: pending, needs_info, rejected, verified_for_auction, verified_for_donation, picked_up, archived
+	ReviewComment string         `gorm:"type:text" json:"review_comment"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
 
 	Photos []DonationPhoto `gorm:"foreignKey:DonationID;constraint:OnDelete:CASCADE" json:"photos,omitempty"`
 }
@@ -31,3 +67,19 @@ type DonationPhoto struct {
 	DonationID uint   `gorm:"not null" json:"donation_id"`
 	URL        string `gorm:"size:255" json:"url"`
 }
+
+// DonationStatusHistory records every status a donation went through, FromStatus
+// is nil for the submission
+type DonationStatusHistory struct {
+	ID         uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	DonationID uint            `gorm:"not null" json:"donation_id"`
+	FromStatus *StatusDonation `gorm:"type:donation_status" json:"from_status"`
+	ToStatus   StatusDonation  `gorm:"type:donation_status;not null" json:"to_status"`
+	ChangedBy  uint            `json:"changed_by"`
+	Comment    string          `gorm:"type:text" json:"comment"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (DonationStatusHistory) TableName() string {
+	return "donation_status_history"
+}
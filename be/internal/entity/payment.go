@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type Payment struct {
 	Id int
 	UserId int
@@ -7,8 +9,37 @@ type Payment struct {
 	AuctionItemId int
 	Status string
 	OrderId string
+	PaymentType string
 	// PaymentStatus PaymentStatus `gorm:"foreignKey:StatusId;references:Id"`
 	Amount float64
+	ExpiresAt *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	Refunds []PaymentRefund `gorm:"foreignKey:PaymentId;references:Id"`
+	StatusHistory []PaymentStatusHistory `gorm:"foreignKey:PaymentId;references:Id"`
+}
+
+// PaymentRefund is one refund issued through the gateway
+type PaymentRefund struct {
+	Id int
+	PaymentId int
+	Amount float64
+	Reason string
+	CreatedBy int
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// PaymentStatusHistory records every status a payment went through
+type PaymentStatusHistory struct {
+	Id int
+	PaymentId int
+	FromStatus *string
+	ToStatus string
+	Reason string
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (PaymentStatusHistory) TableName() string {
+	return "payment_status_history"
 }
 
 // type PaymentStatus struct {
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// RefreshToken is one issued refresh token, tokens rotated from the same login
+// share a family so a reused token can revoke all of them
+type RefreshToken struct {
+	Id        int
+	UserId    int
+	TokenHash string
+	FamilyId  string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	// MFA is whether the login passed a second factor, rotated tokens keep it
+	MFA       bool
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,19 @@
+package entity
+
+// Role groups permissions, users can hold several roles
+type Role struct {
+	Id          int
+	Name        string
+	Description string
+	Permissions []Permission `gorm:"many2many:role_permissions"`
+}
+
+// Permission is a named action checked per route, e.g. "auctions.manage"
+type Permission struct {
+	Id          int
+	Name        string
+	Description string
+}
+
+// DefaultRoles are given to every new account
+var DefaultRoles = []string{"donor", "bidder"}
@@ -1,15 +1,23 @@
 package entity
 
+import "time"
+
 type Users struct {
 	Id int
 	Name string
 	Email string
 	Password string `json:"-"`
 	Role string
-	// Role Role `gorm:"foreignKey:RoleId;references:Id"`
-}
-
-// type Role struct {
-// 	Id int
-// 	Name string
-// }
\ No newline at end of file
+	EmailVerifiedAt *time.Time
+	PendingEmail *string
+	Phone string
+	Address string
+	City string
+	PostalCode string
+	DeactivatedAt *time.Time
+	DeletedAt *time.Time
+	TotpSecret *string `json:"-"`
+	TotpEnabledAt *time.Time
+	TotpLastStep int64
+	Roles []Role `gorm:"many2many:user_roles;joinForeignKey:UserId;joinReferences:RoleId"`
+}
\ No newline at end of file
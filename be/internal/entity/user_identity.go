@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// UserIdentity links an account at an OpenID Connect provider to a user, the
+// provider's subject is stable while the email there may change
+type UserIdentity struct {
+	Id          int
+	UserId      int
+	Provider    string
+	Subject     string
+	Email       string
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	LastLoginAt *time.Time
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// UserRecoveryCode is a single-use code that replaces the authenticator app, only
+// its hash is stored
+type UserRecoveryCode struct {
+	Id        int
+	UserId    int
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/api_key_usage_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAPIKeyUsageRepository is a mock of APIKeyUsageRepository interface.
+type MockAPIKeyUsageRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyUsageRepositoryMockRecorder
+}
+
+// MockAPIKeyUsageRepositoryMockRecorder is the mock recorder for MockAPIKeyUsageRepository.
+type MockAPIKeyUsageRepositoryMockRecorder struct {
+	mock *MockAPIKeyUsageRepository
+}
+
+// NewMockAPIKeyUsageRepository creates a new mock instance.
+func NewMockAPIKeyUsageRepository(ctrl *gomock.Controller) *MockAPIKeyUsageRepository {
+	mock := &MockAPIKeyUsageRepository{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyUsageRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyUsageRepository) EXPECT() *MockAPIKeyUsageRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Daily mocks base method.
+func (m *MockAPIKeyUsageRepository) Daily(keyId int, to time.Time, days int) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Daily", keyId, to, days)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Daily indicates an expected call of Daily.
+func (mr *MockAPIKeyUsageRepositoryMockRecorder) Daily(keyId, to, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Daily", reflect.TypeOf((*MockAPIKeyUsageRepository)(nil).Daily), keyId, to, days)
+}
+
+// Record mocks base method.
+func (m *MockAPIKeyUsageRepository) Record(keyId int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", keyId, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAPIKeyUsageRepositoryMockRecorder) Record(keyId, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAPIKeyUsageRepository)(nil).Record), keyId, at)
+}
@@ -62,6 +62,21 @@ func (mr *MockAuctionItemRepositoryMockRecorder) Delete(id interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAuctionItemRepository)(nil).Delete), id)
 }
 
+// ExistsForDonation mocks base method.
+func (m *MockAuctionItemRepository) ExistsForDonation(donationID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistsForDonation", donationID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistsForDonation indicates an expected call of ExistsForDonation.
+func (mr *MockAuctionItemRepositoryMockRecorder) ExistsForDonation(donationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsForDonation", reflect.TypeOf((*MockAuctionItemRepository)(nil).ExistsForDonation), donationID)
+}
+
 // GetAll mocks base method.
 func (m *MockAuctionItemRepository) GetAll() ([]entity.AuctionItem, error) {
 	m.ctrl.T.Helper()
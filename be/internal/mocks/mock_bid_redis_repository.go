@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	entity "milestone3/be/internal/entity"
 	repository "milestone3/be/internal/repository"
 	reflect "reflect"
 	time "time"
@@ -35,18 +36,63 @@ func (m *MockBidRedisRepository) EXPECT() *MockBidRedisRepositoryMockRecorder {
 	return m.recorder
 }
 
-// CheckDuplicateBid mocks base method.
-func (m *MockBidRedisRepository) CheckDuplicateBid(userID, itemID int64, amount float64, ttl time.Duration) error {
+// BuyNow mocks base method.
+func (m *MockBidRedisRepository) BuyNow(sessionID, itemID int64, attempt repository.BidAttempt) (repository.BidResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckDuplicateBid", userID, itemID, amount, ttl)
+	ret := m.ctrl.Call(m, "BuyNow", sessionID, itemID, attempt)
+	ret0, _ := ret[0].(repository.BidResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuyNow indicates an expected call of BuyNow.
+func (mr *MockBidRedisRepositoryMockRecorder) BuyNow(sessionID, itemID, attempt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyNow", reflect.TypeOf((*MockBidRedisRepository)(nil).BuyNow), sessionID, itemID, attempt)
+}
+
+// ClaimFinalisation mocks base method.
+func (m *MockBidRedisRepository) ClaimFinalisation(key string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimFinalisation", key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimFinalisation indicates an expected call of ClaimFinalisation.
+func (mr *MockBidRedisRepositoryMockRecorder) ClaimFinalisation(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimFinalisation", reflect.TypeOf((*MockBidRedisRepository)(nil).ClaimFinalisation), key)
+}
+
+// CompareAndSetHighestBid mocks base method.
+func (m *MockBidRedisRepository) CompareAndSetHighestBid(sessionID, itemID int64, attempt repository.BidAttempt) (repository.BidResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndSetHighestBid", sessionID, itemID, attempt)
+	ret0, _ := ret[0].(repository.BidResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareAndSetHighestBid indicates an expected call of CompareAndSetHighestBid.
+func (mr *MockBidRedisRepositoryMockRecorder) CompareAndSetHighestBid(sessionID, itemID, attempt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndSetHighestBid", reflect.TypeOf((*MockBidRedisRepository)(nil).CompareAndSetHighestBid), sessionID, itemID, attempt)
+}
+
+// DeleteBidHistory mocks base method.
+func (m *MockBidRedisRepository) DeleteBidHistory(sessionID, itemID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBidHistory", sessionID, itemID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// CheckDuplicateBid indicates an expected call of CheckDuplicateBid.
-func (mr *MockBidRedisRepositoryMockRecorder) CheckDuplicateBid(userID, itemID, amount, ttl interface{}) *gomock.Call {
+// DeleteBidHistory indicates an expected call of DeleteBidHistory.
+func (mr *MockBidRedisRepositoryMockRecorder) DeleteBidHistory(sessionID, itemID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckDuplicateBid", reflect.TypeOf((*MockBidRedisRepository)(nil).CheckDuplicateBid), userID, itemID, amount, ttl)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBidHistory", reflect.TypeOf((*MockBidRedisRepository)(nil).DeleteBidHistory), sessionID, itemID)
 }
 
 // DeleteKey mocks base method.
@@ -78,6 +124,21 @@ func (mr *MockBidRedisRepositoryMockRecorder) GetBidByKey(key interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidByKey", reflect.TypeOf((*MockBidRedisRepository)(nil).GetBidByKey), key)
 }
 
+// GetBidHistory mocks base method.
+func (m *MockBidRedisRepository) GetBidHistory(sessionID, itemID int64) ([]entity.BidHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidHistory", sessionID, itemID)
+	ret0, _ := ret[0].([]entity.BidHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBidHistory indicates an expected call of GetBidHistory.
+func (mr *MockBidRedisRepositoryMockRecorder) GetBidHistory(sessionID, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidHistory", reflect.TypeOf((*MockBidRedisRepository)(nil).GetBidHistory), sessionID, itemID)
+}
+
 // GetEndTime mocks base method.
 func (m *MockBidRedisRepository) GetEndTime(key string) (time.Time, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +170,20 @@ func (mr *MockBidRedisRepositoryMockRecorder) GetHighestBid(sessionID, itemID in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHighestBid", reflect.TypeOf((*MockBidRedisRepository)(nil).GetHighestBid), sessionID, itemID)
 }
 
+// ReleaseFinalisation mocks base method.
+func (m *MockBidRedisRepository) ReleaseFinalisation(key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseFinalisation", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseFinalisation indicates an expected call of ReleaseFinalisation.
+func (mr *MockBidRedisRepositoryMockRecorder) ReleaseFinalisation(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseFinalisation", reflect.TypeOf((*MockBidRedisRepository)(nil).ReleaseFinalisation), key)
+}
+
 // ScanKeys mocks base method.
 func (m *MockBidRedisRepository) ScanKeys(pattern string) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -124,16 +199,17 @@ func (mr *MockBidRedisRepositoryMockRecorder) ScanKeys(pattern interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanKeys", reflect.TypeOf((*MockBidRedisRepository)(nil).ScanKeys), pattern)
 }
 
-// SetHighestBid mocks base method.
-func (m *MockBidRedisRepository) SetHighestBid(sessionID, itemID int64, amount float64, userID int64, sessionEndTime time.Time) error {
+// SetMaxBid mocks base method.
+func (m *MockBidRedisRepository) SetMaxBid(sessionID, itemID int64, attempt repository.BidAttempt) (repository.BidResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetHighestBid", sessionID, itemID, amount, userID, sessionEndTime)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "SetMaxBid", sessionID, itemID, attempt)
+	ret0, _ := ret[0].(repository.BidResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// SetHighestBid indicates an expected call of SetHighestBid.
-func (mr *MockBidRedisRepositoryMockRecorder) SetHighestBid(sessionID, itemID, amount, userID, sessionEndTime interface{}) *gomock.Call {
+// SetMaxBid indicates an expected call of SetMaxBid.
+func (mr *MockBidRedisRepositoryMockRecorder) SetMaxBid(sessionID, itemID, attempt interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHighestBid", reflect.TypeOf((*MockBidRedisRepository)(nil).SetHighestBid), sessionID, itemID, amount, userID, sessionEndTime)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxBid", reflect.TypeOf((*MockBidRedisRepository)(nil).SetMaxBid), sessionID, itemID, attempt)
 }
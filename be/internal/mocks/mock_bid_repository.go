@@ -7,6 +7,7 @@ package mocks
 import (
 	entity "milestone3/be/internal/entity"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,6 +35,126 @@ func (m *MockBidRepository) EXPECT() *MockBidRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CountWinningBids mocks base method.
+func (m *MockBidRepository) CountWinningBids(sessionID, itemID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountWinningBids", sessionID, itemID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountWinningBids indicates an expected call of CountWinningBids.
+func (mr *MockBidRepositoryMockRecorder) CountWinningBids(sessionID, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountWinningBids", reflect.TypeOf((*MockBidRepository)(nil).CountWinningBids), sessionID, itemID)
+}
+
+// GetBidHistoryByItem mocks base method.
+func (m *MockBidRepository) GetBidHistoryByItem(sessionID, itemID int64, page, limit int) ([]entity.BidHistory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidHistoryByItem", sessionID, itemID, page, limit)
+	ret0, _ := ret[0].([]entity.BidHistory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBidHistoryByItem indicates an expected call of GetBidHistoryByItem.
+func (mr *MockBidRepositoryMockRecorder) GetBidHistoryByItem(sessionID, itemID, page, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidHistoryByItem", reflect.TypeOf((*MockBidRepository)(nil).GetBidHistoryByItem), sessionID, itemID, page, limit)
+}
+
+// GetBidHistoryByUser mocks base method.
+func (m *MockBidRepository) GetBidHistoryByUser(userID int64, page, limit int) ([]entity.BidHistory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidHistoryByUser", userID, page, limit)
+	ret0, _ := ret[0].([]entity.BidHistory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBidHistoryByUser indicates an expected call of GetBidHistoryByUser.
+func (mr *MockBidRepositoryMockRecorder) GetBidHistoryByUser(userID, page, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidHistoryByUser", reflect.TypeOf((*MockBidRepository)(nil).GetBidHistoryByUser), userID, page, limit)
+}
+
+// GetRunnerUpBid mocks base method.
+func (m *MockBidRepository) GetRunnerUpBid(sessionID, itemID int64) (entity.BidHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRunnerUpBid", sessionID, itemID)
+	ret0, _ := ret[0].(entity.BidHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRunnerUpBid indicates an expected call of GetRunnerUpBid.
+func (mr *MockBidRepositoryMockRecorder) GetRunnerUpBid(sessionID, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRunnerUpBid", reflect.TypeOf((*MockBidRepository)(nil).GetRunnerUpBid), sessionID, itemID)
+}
+
+// GetUnpaidExpiredBids mocks base method.
+func (m *MockBidRepository) GetUnpaidExpiredBids(now time.Time) ([]entity.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnpaidExpiredBids", now)
+	ret0, _ := ret[0].([]entity.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnpaidExpiredBids indicates an expected call of GetUnpaidExpiredBids.
+func (mr *MockBidRepositoryMockRecorder) GetUnpaidExpiredBids(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnpaidExpiredBids", reflect.TypeOf((*MockBidRepository)(nil).GetUnpaidExpiredBids), now)
+}
+
+// GetWinningBid mocks base method.
+func (m *MockBidRepository) GetWinningBid(itemID int64) (entity.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWinningBid", itemID)
+	ret0, _ := ret[0].(entity.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWinningBid indicates an expected call of GetWinningBid.
+func (mr *MockBidRepositoryMockRecorder) GetWinningBid(itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWinningBid", reflect.TypeOf((*MockBidRepository)(nil).GetWinningBid), itemID)
+}
+
+// MarkBidLapsed mocks base method.
+func (m *MockBidRepository) MarkBidLapsed(id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkBidLapsed", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkBidLapsed indicates an expected call of MarkBidLapsed.
+func (mr *MockBidRepositoryMockRecorder) MarkBidLapsed(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkBidLapsed", reflect.TypeOf((*MockBidRepository)(nil).MarkBidLapsed), id)
+}
+
+// SaveBidHistory mocks base method.
+func (m *MockBidRepository) SaveBidHistory(entries []entity.BidHistory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBidHistory", entries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveBidHistory indicates an expected call of SaveBidHistory.
+func (mr *MockBidRepositoryMockRecorder) SaveBidHistory(entries interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBidHistory", reflect.TypeOf((*MockBidRepository)(nil).SaveBidHistory), entries)
+}
+
 // SaveFinalBid mocks base method.
 func (m *MockBidRepository) SaveFinalBid(bid *entity.Bid) error {
 	m.ctrl.T.Helper()
@@ -47,3 +168,17 @@ func (mr *MockBidRepositoryMockRecorder) SaveFinalBid(bid interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFinalBid", reflect.TypeOf((*MockBidRepository)(nil).SaveFinalBid), bid)
 }
+
+// SaveWinningBid mocks base method.
+func (m *MockBidRepository) SaveWinningBid(bid *entity.Bid) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWinningBid", bid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveWinningBid indicates an expected call of SaveWinningBid.
+func (mr *MockBidRepositoryMockRecorder) SaveWinningBid(bid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWinningBid", reflect.TypeOf((*MockBidRepository)(nil).SaveWinningBid), bid)
+}
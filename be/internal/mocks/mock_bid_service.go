@@ -0,0 +1,239 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/bid_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	dto "milestone3/be/internal/dto"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPaymentCreator is a mock of PaymentCreator interface.
+type MockPaymentCreator struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentCreatorMockRecorder
+}
+
+// MockPaymentCreatorMockRecorder is the mock recorder for MockPaymentCreator.
+type MockPaymentCreatorMockRecorder struct {
+	mock *MockPaymentCreator
+}
+
+// NewMockPaymentCreator creates a new mock instance.
+func NewMockPaymentCreator(ctrl *gomock.Controller) *MockPaymentCreator {
+	mock := &MockPaymentCreator{ctrl: ctrl}
+	mock.recorder = &MockPaymentCreatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentCreator) EXPECT() *MockPaymentCreatorMockRecorder {
+	return m.recorder
+}
+
+// CreatePayment mocks base method.
+func (m *MockPaymentCreator) CreatePayment(req dto.PaymentRequest, userId, auctionItemId int) (dto.PaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayment", req, userId, auctionItemId)
+	ret0, _ := ret[0].(dto.PaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayment indicates an expected call of CreatePayment.
+func (mr *MockPaymentCreatorMockRecorder) CreatePayment(req, userId, auctionItemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockPaymentCreator)(nil).CreatePayment), req, userId, auctionItemId)
+}
+
+// MockBidService is a mock of BidService interface.
+type MockBidService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBidServiceMockRecorder
+}
+
+// MockBidServiceMockRecorder is the mock recorder for MockBidService.
+type MockBidServiceMockRecorder struct {
+	mock *MockBidService
+}
+
+// NewMockBidService creates a new mock instance.
+func NewMockBidService(ctrl *gomock.Controller) *MockBidService {
+	mock := &MockBidService{ctrl: ctrl}
+	mock.recorder = &MockBidServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBidService) EXPECT() *MockBidServiceMockRecorder {
+	return m.recorder
+}
+
+// BuyNow mocks base method.
+func (m *MockBidService) BuyNow(sessionID, itemID, userID int64, sessionEndTime time.Time) (dto.BuyNowResultDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuyNow", sessionID, itemID, userID, sessionEndTime)
+	ret0, _ := ret[0].(dto.BuyNowResultDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuyNow indicates an expected call of BuyNow.
+func (mr *MockBidServiceMockRecorder) BuyNow(sessionID, itemID, userID, sessionEndTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyNow", reflect.TypeOf((*MockBidService)(nil).BuyNow), sessionID, itemID, userID, sessionEndTime)
+}
+
+// CloseExpiredItemsWithoutBids mocks base method.
+func (m *MockBidService) CloseExpiredItemsWithoutBids() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseExpiredItemsWithoutBids")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseExpiredItemsWithoutBids indicates an expected call of CloseExpiredItemsWithoutBids.
+func (mr *MockBidServiceMockRecorder) CloseExpiredItemsWithoutBids() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseExpiredItemsWithoutBids", reflect.TypeOf((*MockBidService)(nil).CloseExpiredItemsWithoutBids))
+}
+
+// DeleteKeyValue mocks base method.
+func (m *MockBidService) DeleteKeyValue() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteKeyValue")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteKeyValue indicates an expected call of DeleteKeyValue.
+func (mr *MockBidServiceMockRecorder) DeleteKeyValue() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKeyValue", reflect.TypeOf((*MockBidService)(nil).DeleteKeyValue))
+}
+
+// GetBidHistory mocks base method.
+func (m *MockBidService) GetBidHistory(sessionID, itemID int64, page, limit int) ([]dto.BidHistoryResponse, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidHistory", sessionID, itemID, page, limit)
+	ret0, _ := ret[0].([]dto.BidHistoryResponse)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBidHistory indicates an expected call of GetBidHistory.
+func (mr *MockBidServiceMockRecorder) GetBidHistory(sessionID, itemID, page, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidHistory", reflect.TypeOf((*MockBidService)(nil).GetBidHistory), sessionID, itemID, page, limit)
+}
+
+// GetHighestBid mocks base method.
+func (m *MockBidService) GetHighestBid(sessionID, itemID int64) (float64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHighestBid", sessionID, itemID)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetHighestBid indicates an expected call of GetHighestBid.
+func (mr *MockBidServiceMockRecorder) GetHighestBid(sessionID, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHighestBid", reflect.TypeOf((*MockBidService)(nil).GetHighestBid), sessionID, itemID)
+}
+
+// GetItemEndTime mocks base method.
+func (m *MockBidService) GetItemEndTime(sessionID, itemID int64) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItemEndTime", sessionID, itemID)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItemEndTime indicates an expected call of GetItemEndTime.
+func (mr *MockBidServiceMockRecorder) GetItemEndTime(sessionID, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemEndTime", reflect.TypeOf((*MockBidService)(nil).GetItemEndTime), sessionID, itemID)
+}
+
+// GetMyBids mocks base method.
+func (m *MockBidService) GetMyBids(userID int64, page, limit int) ([]dto.BidHistoryResponse, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMyBids", userID, page, limit)
+	ret0, _ := ret[0].([]dto.BidHistoryResponse)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetMyBids indicates an expected call of GetMyBids.
+func (mr *MockBidServiceMockRecorder) GetMyBids(userID, page, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMyBids", reflect.TypeOf((*MockBidService)(nil).GetMyBids), userID, page, limit)
+}
+
+// PlaceBid mocks base method.
+func (m *MockBidService) PlaceBid(sessionID, itemID, userID int64, amount float64, sessionEndTime time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlaceBid", sessionID, itemID, userID, amount, sessionEndTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PlaceBid indicates an expected call of PlaceBid.
+func (mr *MockBidServiceMockRecorder) PlaceBid(sessionID, itemID, userID, amount, sessionEndTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlaceBid", reflect.TypeOf((*MockBidService)(nil).PlaceBid), sessionID, itemID, userID, amount, sessionEndTime)
+}
+
+// SaveKeyToDB mocks base method.
+func (m *MockBidService) SaveKeyToDB() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveKeyToDB")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveKeyToDB indicates an expected call of SaveKeyToDB.
+func (mr *MockBidServiceMockRecorder) SaveKeyToDB() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveKeyToDB", reflect.TypeOf((*MockBidService)(nil).SaveKeyToDB))
+}
+
+// SetMaxBid mocks base method.
+func (m *MockBidService) SetMaxBid(sessionID, itemID, userID int64, maxAmount float64, sessionEndTime time.Time) (dto.MaxBidResultDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMaxBid", sessionID, itemID, userID, maxAmount, sessionEndTime)
+	ret0, _ := ret[0].(dto.MaxBidResultDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetMaxBid indicates an expected call of SetMaxBid.
+func (mr *MockBidServiceMockRecorder) SetMaxBid(sessionID, itemID, userID, maxAmount, sessionEndTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxBid", reflect.TypeOf((*MockBidService)(nil).SetMaxBid), sessionID, itemID, userID, maxAmount, sessionEndTime)
+}
+
+// SubscribeBidEvents mocks base method.
+func (m *MockBidService) SubscribeBidEvents(ctx context.Context, sessionID, itemID int64) (<-chan dto.BidEventDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeBidEvents", ctx, sessionID, itemID)
+	ret0, _ := ret[0].(<-chan dto.BidEventDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubscribeBidEvents indicates an expected call of SubscribeBidEvents.
+func (mr *MockBidServiceMockRecorder) SubscribeBidEvents(ctx, sessionID, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeBidEvents", reflect.TypeOf((*MockBidService)(nil).SubscribeBidEvents), ctx, sessionID, itemID)
+}
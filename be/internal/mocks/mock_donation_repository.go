@@ -114,31 +114,48 @@ func (mr *MockDonationRepoMockRecorder) GetDonationsByUserID(userID, page, limit
 }
 
 // PatchDonation mocks base method.
-func (m *MockDonationRepo) PatchDonation(donation entity.Donation) error {
+func (m *MockDonationRepo) PatchDonation(donation entity.Donation, from entity.StatusDonation, changedBy uint, auctionItem *entity.AuctionItem) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PatchDonation", donation)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "PatchDonation", donation, from, changedBy, auctionItem)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // PatchDonation indicates an expected call of PatchDonation.
-func (mr *MockDonationRepoMockRecorder) PatchDonation(donation interface{}) *gomock.Call {
+func (mr *MockDonationRepoMockRecorder) PatchDonation(donation, from, changedBy, auctionItem interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchDonation", reflect.TypeOf((*MockDonationRepo)(nil).PatchDonation), donation, from, changedBy, auctionItem)
+}
+
+// GetStatusHistory mocks base method.
+func (m *MockDonationRepo) GetStatusHistory(donationID uint) ([]entity.DonationStatusHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusHistory", donationID)
+	ret0, _ := ret[0].([]entity.DonationStatusHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusHistory indicates an expected call of GetStatusHistory.
+func (mr *MockDonationRepoMockRecorder) GetStatusHistory(donationID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchDonation", reflect.TypeOf((*MockDonationRepo)(nil).PatchDonation), donation)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusHistory", reflect.TypeOf((*MockDonationRepo)(nil).GetStatusHistory), donationID)
 }
 
 // UpdateDonation mocks base method.
-func (m *MockDonationRepo) UpdateDonation(donation entity.Donation) error {
+func (m *MockDonationRepo) UpdateDonation(donation entity.Donation, resubmit bool, changedBy uint) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateDonation", donation)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "UpdateDonation", donation, resubmit, changedBy)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // UpdateDonation indicates an expected call of UpdateDonation.
-func (mr *MockDonationRepoMockRecorder) UpdateDonation(donation interface{}) *gomock.Call {
+func (mr *MockDonationRepoMockRecorder) UpdateDonation(donation, resubmit, changedBy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDonation", reflect.TypeOf((*MockDonationRepo)(nil).UpdateDonation), donation)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDonation", reflect.TypeOf((*MockDonationRepo)(nil).UpdateDonation), donation, resubmit, changedBy)
 }
 
 // MockGCPStorageRepo is a mock of GCPStorageRepo interface.
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/controller/oidc_controller.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	dto "milestone3/be/internal/dto"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOIDCService is a mock of OIDCService interface.
+type MockOIDCService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCServiceMockRecorder
+}
+
+// MockOIDCServiceMockRecorder is the mock recorder for MockOIDCService.
+type MockOIDCServiceMockRecorder struct {
+	mock *MockOIDCService
+}
+
+// NewMockOIDCService creates a new mock instance.
+func NewMockOIDCService(ctrl *gomock.Controller) *MockOIDCService {
+	mock := &MockOIDCService{ctrl: ctrl}
+	mock.recorder = &MockOIDCServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCService) EXPECT() *MockOIDCServiceMockRecorder {
+	return m.recorder
+}
+
+// ConfirmLink mocks base method.
+func (m *MockOIDCService) ConfirmLink(req dto.OIDCLinkRequest) (dto.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmLink", req)
+	ret0, _ := ret[0].(dto.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmLink indicates an expected call of ConfirmLink.
+func (mr *MockOIDCServiceMockRecorder) ConfirmLink(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmLink", reflect.TypeOf((*MockOIDCService)(nil).ConfirmLink), req)
+}
+
+// Login mocks base method.
+func (m *MockOIDCService) Login(provider, code, state string) (dto.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", provider, code, state)
+	ret0, _ := ret[0].(dto.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockOIDCServiceMockRecorder) Login(provider, code, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockOIDCService)(nil).Login), provider, code, state)
+}
+
+// LoginURL mocks base method.
+func (m *MockOIDCService) LoginURL(provider, loginHint string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginURL", provider, loginHint)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginURL indicates an expected call of LoginURL.
+func (mr *MockOIDCServiceMockRecorder) LoginURL(provider, loginHint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginURL", reflect.TypeOf((*MockOIDCService)(nil).LoginURL), provider, loginHint)
+}
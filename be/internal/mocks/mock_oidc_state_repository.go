@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/oidc_state_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	repository "milestone3/be/internal/repository"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOIDCStateRepository is a mock of OIDCStateRepository interface.
+type MockOIDCStateRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCStateRepositoryMockRecorder
+}
+
+// MockOIDCStateRepositoryMockRecorder is the mock recorder for MockOIDCStateRepository.
+type MockOIDCStateRepositoryMockRecorder struct {
+	mock *MockOIDCStateRepository
+}
+
+// NewMockOIDCStateRepository creates a new mock instance.
+func NewMockOIDCStateRepository(ctrl *gomock.Controller) *MockOIDCStateRepository {
+	mock := &MockOIDCStateRepository{ctrl: ctrl}
+	mock.recorder = &MockOIDCStateRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCStateRepository) EXPECT() *MockOIDCStateRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Save mocks base method.
+func (m *MockOIDCStateRepository) Save(state string, login repository.OIDCLoginState, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", state, login, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockOIDCStateRepositoryMockRecorder) Save(state, login, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOIDCStateRepository)(nil).Save), state, login, ttl)
+}
+
+// Take mocks base method.
+func (m *MockOIDCStateRepository) Take(state string) (repository.OIDCLoginState, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", state)
+	ret0, _ := ret[0].(repository.OIDCLoginState)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockOIDCStateRepositoryMockRecorder) Take(state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockOIDCStateRepository)(nil).Take), state)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/payment_gateway.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	dto "milestone3/be/internal/dto"
+	repository "milestone3/be/internal/repository"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPaymentGateway is a mock of PaymentGateway interface.
+type MockPaymentGateway struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentGatewayMockRecorder
+}
+
+// MockPaymentGatewayMockRecorder is the mock recorder for MockPaymentGateway.
+type MockPaymentGatewayMockRecorder struct {
+	mock *MockPaymentGateway
+}
+
+// NewMockPaymentGateway creates a new mock instance.
+func NewMockPaymentGateway(ctrl *gomock.Controller) *MockPaymentGateway {
+	mock := &MockPaymentGateway{ctrl: ctrl}
+	mock.recorder = &MockPaymentGatewayMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentGateway) EXPECT() *MockPaymentGatewayMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockPaymentGateway) Cancel(orderId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", orderId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockPaymentGatewayMockRecorder) Cancel(orderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockPaymentGateway)(nil).Cancel), orderId)
+}
+
+// Charge mocks base method.
+func (m *MockPaymentGateway) Charge(req repository.ChargeRequest) (dto.PaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Charge", req)
+	ret0, _ := ret[0].(dto.PaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Charge indicates an expected call of Charge.
+func (mr *MockPaymentGatewayMockRecorder) Charge(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Charge", reflect.TypeOf((*MockPaymentGateway)(nil).Charge), req)
+}
+
+// Refund mocks base method.
+func (m *MockPaymentGateway) Refund(orderId string, amount float64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", orderId, amount, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockPaymentGatewayMockRecorder) Refund(orderId, amount, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockPaymentGateway)(nil).Refund), orderId, amount, reason)
+}
+
+// Status mocks base method.
+func (m *MockPaymentGateway) Status(orderId string) (dto.CheckPaymentStatusResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status", orderId)
+	ret0, _ := ret[0].(dto.CheckPaymentStatusResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockPaymentGatewayMockRecorder) Status(orderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockPaymentGateway)(nil).Status), orderId)
+}
@@ -8,6 +8,7 @@ import (
 	dto "milestone3/be/internal/dto"
 	entity "milestone3/be/internal/entity"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,19 +36,19 @@ func (m *MockPaymentRepository) EXPECT() *MockPaymentRepositoryMockRecorder {
 	return m.recorder
 }
 
-// CheckPaymentStatusMidtrans mocks base method.
-func (m *MockPaymentRepository) CheckPaymentStatusMidtrans(orderId string) (dto.CheckPaymentStatusResponse, error) {
+// CountPaid mocks base method.
+func (m *MockPaymentRepository) CountPaid(auctionItemId, userId int) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckPaymentStatusMidtrans", orderId)
-	ret0, _ := ret[0].(dto.CheckPaymentStatusResponse)
+	ret := m.ctrl.Call(m, "CountPaid", auctionItemId, userId)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CheckPaymentStatusMidtrans indicates an expected call of CheckPaymentStatusMidtrans.
-func (mr *MockPaymentRepositoryMockRecorder) CheckPaymentStatusMidtrans(orderId interface{}) *gomock.Call {
+// CountPaid indicates an expected call of CountPaid.
+func (mr *MockPaymentRepositoryMockRecorder) CountPaid(auctionItemId, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPaymentStatusMidtrans", reflect.TypeOf((*MockPaymentRepository)(nil).CheckPaymentStatusMidtrans), orderId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPaid", reflect.TypeOf((*MockPaymentRepository)(nil).CountPaid), auctionItemId, userId)
 }
 
 // Create mocks base method.
@@ -64,34 +65,48 @@ func (mr *MockPaymentRepositoryMockRecorder) Create(payment, orderId interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPaymentRepository)(nil).Create), payment, orderId)
 }
 
-// CreateMidtrans mocks base method.
-func (m *MockPaymentRepository) CreateMidtrans(payment entity.Payment, orderId string) (dto.PaymentResponse, error) {
+// CreateRefund mocks base method.
+func (m *MockPaymentRepository) CreateRefund(refund *entity.PaymentRefund, orderId string, issue func(entity.Payment, float64) (string, error)) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateMidtrans", payment, orderId)
-	ret0, _ := ret[0].(dto.PaymentResponse)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "CreateRefund", refund, orderId, issue)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// CreateMidtrans indicates an expected call of CreateMidtrans.
-func (mr *MockPaymentRepositoryMockRecorder) CreateMidtrans(payment, orderId interface{}) *gomock.Call {
+// CreateRefund indicates an expected call of CreateRefund.
+func (mr *MockPaymentRepositoryMockRecorder) CreateRefund(refund, orderId, issue interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMidtrans", reflect.TypeOf((*MockPaymentRepository)(nil).CreateMidtrans), payment, orderId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefund", reflect.TypeOf((*MockPaymentRepository)(nil).CreateRefund), refund, orderId, issue)
+}
+
+// ExpirePending mocks base method.
+func (m *MockPaymentRepository) ExpirePending(auctionItemId, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpirePending", auctionItemId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExpirePending indicates an expected call of ExpirePending.
+func (mr *MockPaymentRepositoryMockRecorder) ExpirePending(auctionItemId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpirePending", reflect.TypeOf((*MockPaymentRepository)(nil).ExpirePending), auctionItemId, userId)
 }
 
 // GetAll mocks base method.
-func (m *MockPaymentRepository) GetAll() ([]entity.Payment, error) {
+func (m *MockPaymentRepository) GetAll(filter dto.PaymentFilter) ([]entity.Payment, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll")
+	ret := m.ctrl.Call(m, "GetAll", filter)
 	ret0, _ := ret[0].([]entity.Payment)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockPaymentRepositoryMockRecorder) GetAll() *gomock.Call {
+func (mr *MockPaymentRepositoryMockRecorder) GetAll(filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockPaymentRepository)(nil).GetAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockPaymentRepository)(nil).GetAll), filter)
 }
 
 // GetById mocks base method.
@@ -108,3 +123,93 @@ func (mr *MockPaymentRepositoryMockRecorder) GetById(id interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockPaymentRepository)(nil).GetById), id)
 }
+
+// GetByOrderId mocks base method.
+func (m *MockPaymentRepository) GetByOrderId(orderId string) (entity.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByOrderId", orderId)
+	ret0, _ := ret[0].(entity.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByOrderId indicates an expected call of GetByOrderId.
+func (mr *MockPaymentRepositoryMockRecorder) GetByOrderId(orderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOrderId", reflect.TypeOf((*MockPaymentRepository)(nil).GetByOrderId), orderId)
+}
+
+// GetClosedUnpaidSince mocks base method.
+func (m *MockPaymentRepository) GetClosedUnpaidSince(since time.Time) ([]entity.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClosedUnpaidSince", since)
+	ret0, _ := ret[0].([]entity.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClosedUnpaidSince indicates an expected call of GetClosedUnpaidSince.
+func (mr *MockPaymentRepositoryMockRecorder) GetClosedUnpaidSince(since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClosedUnpaidSince", reflect.TypeOf((*MockPaymentRepository)(nil).GetClosedUnpaidSince), since)
+}
+
+// GetPayer mocks base method.
+func (m *MockPaymentRepository) GetPayer(userId int) (entity.Users, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayer", userId)
+	ret0, _ := ret[0].(entity.Users)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayer indicates an expected call of GetPayer.
+func (mr *MockPaymentRepositoryMockRecorder) GetPayer(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayer", reflect.TypeOf((*MockPaymentRepository)(nil).GetPayer), userId)
+}
+
+// GetPending mocks base method.
+func (m *MockPaymentRepository) GetPending(auctionItemId, userId int) ([]entity.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPending", auctionItemId, userId)
+	ret0, _ := ret[0].([]entity.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPending indicates an expected call of GetPending.
+func (mr *MockPaymentRepositoryMockRecorder) GetPending(auctionItemId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPending", reflect.TypeOf((*MockPaymentRepository)(nil).GetPending), auctionItemId, userId)
+}
+
+// GetStalePending mocks base method.
+func (m *MockPaymentRepository) GetStalePending(now time.Time) ([]entity.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStalePending", now)
+	ret0, _ := ret[0].([]entity.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStalePending indicates an expected call of GetStalePending.
+func (mr *MockPaymentRepositoryMockRecorder) GetStalePending(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStalePending", reflect.TypeOf((*MockPaymentRepository)(nil).GetStalePending), now)
+}
+
+// TransitionStatus mocks base method.
+func (m *MockPaymentRepository) TransitionStatus(orderId, from, to, reason string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionStatus", orderId, from, to, reason)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransitionStatus indicates an expected call of TransitionStatus.
+func (mr *MockPaymentRepositoryMockRecorder) TransitionStatus(orderId, from, to, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionStatus", reflect.TypeOf((*MockPaymentRepository)(nil).TransitionStatus), orderId, from, to, reason)
+}
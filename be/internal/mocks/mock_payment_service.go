@@ -34,19 +34,33 @@ func (m *MockPaymentService) EXPECT() *MockPaymentServiceMockRecorder {
 	return m.recorder
 }
 
+// CancelPayment mocks base method.
+func (m *MockPaymentService) CancelPayment(id int, req dto.CancelPaymentRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPayment", id, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelPayment indicates an expected call of CancelPayment.
+func (mr *MockPaymentServiceMockRecorder) CancelPayment(id, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPayment", reflect.TypeOf((*MockPaymentService)(nil).CancelPayment), id, req)
+}
+
 // CheckPaymentStatusMidtrans mocks base method.
-func (m *MockPaymentService) CheckPaymentStatusMidtrans(orderId string) (dto.CheckPaymentStatusResponse, error) {
+func (m *MockPaymentService) CheckPaymentStatusMidtrans(orderId string, userId int, isAdmin bool) (dto.CheckPaymentStatusResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckPaymentStatusMidtrans", orderId)
+	ret := m.ctrl.Call(m, "CheckPaymentStatusMidtrans", orderId, userId, isAdmin)
 	ret0, _ := ret[0].(dto.CheckPaymentStatusResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CheckPaymentStatusMidtrans indicates an expected call of CheckPaymentStatusMidtrans.
-func (mr *MockPaymentServiceMockRecorder) CheckPaymentStatusMidtrans(orderId interface{}) *gomock.Call {
+func (mr *MockPaymentServiceMockRecorder) CheckPaymentStatusMidtrans(orderId, userId, isAdmin interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPaymentStatusMidtrans", reflect.TypeOf((*MockPaymentService)(nil).CheckPaymentStatusMidtrans), orderId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPaymentStatusMidtrans", reflect.TypeOf((*MockPaymentService)(nil).CheckPaymentStatusMidtrans), orderId, userId, isAdmin)
 }
 
 // CreatePayment mocks base method.
@@ -65,31 +79,92 @@ func (mr *MockPaymentServiceMockRecorder) CreatePayment(req, userId, auctionItem
 }
 
 // GetAllPayment mocks base method.
-func (m *MockPaymentService) GetAllPayment() ([]dto.PaymentInfoResponse, error) {
+func (m *MockPaymentService) GetAllPayment(filter dto.PaymentFilter) ([]dto.PaymentInfoResponse, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllPayment")
+	ret := m.ctrl.Call(m, "GetAllPayment", filter)
 	ret0, _ := ret[0].([]dto.PaymentInfoResponse)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetAllPayment indicates an expected call of GetAllPayment.
-func (mr *MockPaymentServiceMockRecorder) GetAllPayment() *gomock.Call {
+func (mr *MockPaymentServiceMockRecorder) GetAllPayment(filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPayment", reflect.TypeOf((*MockPaymentService)(nil).GetAllPayment))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPayment", reflect.TypeOf((*MockPaymentService)(nil).GetAllPayment), filter)
+}
+
+// GetMyPayments mocks base method.
+func (m *MockPaymentService) GetMyPayments(userId, page, limit int) ([]dto.PaymentInfoResponse, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMyPayments", userId, page, limit)
+	ret0, _ := ret[0].([]dto.PaymentInfoResponse)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetMyPayments indicates an expected call of GetMyPayments.
+func (mr *MockPaymentServiceMockRecorder) GetMyPayments(userId, page, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMyPayments", reflect.TypeOf((*MockPaymentService)(nil).GetMyPayments), userId, page, limit)
 }
 
 // GetPaymentById mocks base method.
-func (m *MockPaymentService) GetPaymentById(id int) (dto.PaymentInfoResponse, error) {
+func (m *MockPaymentService) GetPaymentById(id, userId int, isAdmin bool) (dto.PaymentInfoResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPaymentById", id)
+	ret := m.ctrl.Call(m, "GetPaymentById", id, userId, isAdmin)
 	ret0, _ := ret[0].(dto.PaymentInfoResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPaymentById indicates an expected call of GetPaymentById.
-func (mr *MockPaymentServiceMockRecorder) GetPaymentById(id interface{}) *gomock.Call {
+func (mr *MockPaymentServiceMockRecorder) GetPaymentById(id, userId, isAdmin interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentById", reflect.TypeOf((*MockPaymentService)(nil).GetPaymentById), id, userId, isAdmin)
+}
+
+// HandleNotification mocks base method.
+func (m *MockPaymentService) HandleNotification(req dto.MidtransNotification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleNotification", req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleNotification indicates an expected call of HandleNotification.
+func (mr *MockPaymentServiceMockRecorder) HandleNotification(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleNotification", reflect.TypeOf((*MockPaymentService)(nil).HandleNotification), req)
+}
+
+// ReconcilePayments mocks base method.
+func (m *MockPaymentService) ReconcilePayments() (dto.ReconciliationReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcilePayments")
+	ret0, _ := ret[0].(dto.ReconciliationReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcilePayments indicates an expected call of ReconcilePayments.
+func (mr *MockPaymentServiceMockRecorder) ReconcilePayments() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcilePayments", reflect.TypeOf((*MockPaymentService)(nil).ReconcilePayments))
+}
+
+// RefundPayment mocks base method.
+func (m *MockPaymentService) RefundPayment(id int, req dto.RefundRequest, adminId int) (dto.PaymentRefundResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundPayment", id, req, adminId)
+	ret0, _ := ret[0].(dto.PaymentRefundResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundPayment indicates an expected call of RefundPayment.
+func (mr *MockPaymentServiceMockRecorder) RefundPayment(id, req, adminId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentById", reflect.TypeOf((*MockPaymentService)(nil).GetPaymentById), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundPayment", reflect.TypeOf((*MockPaymentService)(nil).RefundPayment), id, req, adminId)
 }
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/photo_url_cache_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	dto "milestone3/be/internal/dto"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPhotoURLCache is a mock of PhotoURLCache interface.
+type MockPhotoURLCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockPhotoURLCacheMockRecorder
+}
+
+// MockPhotoURLCacheMockRecorder is the mock recorder for MockPhotoURLCache.
+type MockPhotoURLCacheMockRecorder struct {
+	mock *MockPhotoURLCache
+}
+
+// NewMockPhotoURLCache creates a new mock instance.
+func NewMockPhotoURLCache(ctrl *gomock.Controller) *MockPhotoURLCache {
+	mock := &MockPhotoURLCache{ctrl: ctrl}
+	mock.recorder = &MockPhotoURLCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPhotoURLCache) EXPECT() *MockPhotoURLCacheMockRecorder {
+	return m.recorder
+}
+
+// GetMany mocks base method.
+func (m *MockPhotoURLCache) GetMany(objectNames []string) (map[string]dto.PhotoURL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMany", objectNames)
+	ret0, _ := ret[0].(map[string]dto.PhotoURL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMany indicates an expected call of GetMany.
+func (mr *MockPhotoURLCacheMockRecorder) GetMany(objectNames interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMany", reflect.TypeOf((*MockPhotoURLCache)(nil).GetMany), objectNames)
+}
+
+// SetMany mocks base method.
+func (m *MockPhotoURLCache) SetMany(urls map[string]dto.PhotoURL, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMany", urls, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMany indicates an expected call of SetMany.
+func (mr *MockPhotoURLCacheMockRecorder) SetMany(urls, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMany", reflect.TypeOf((*MockPhotoURLCache)(nil).SetMany), urls, ttl)
+}
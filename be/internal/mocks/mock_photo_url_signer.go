@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/photo_url_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	dto "milestone3/be/internal/dto"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPhotoURLSigner is a mock of PhotoURLSigner interface.
+type MockPhotoURLSigner struct {
+	ctrl     *gomock.Controller
+	recorder *MockPhotoURLSignerMockRecorder
+}
+
+// MockPhotoURLSignerMockRecorder is the mock recorder for MockPhotoURLSigner.
+type MockPhotoURLSignerMockRecorder struct {
+	mock *MockPhotoURLSigner
+}
+
+// NewMockPhotoURLSigner creates a new mock instance.
+func NewMockPhotoURLSigner(ctrl *gomock.Controller) *MockPhotoURLSigner {
+	mock := &MockPhotoURLSigner{ctrl: ctrl}
+	mock.recorder = &MockPhotoURLSignerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPhotoURLSigner) EXPECT() *MockPhotoURLSignerMockRecorder {
+	return m.recorder
+}
+
+// Sign mocks base method.
+func (m *MockPhotoURLSigner) Sign(objectNames []string) map[string]dto.PhotoURL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sign", objectNames)
+	ret0, _ := ret[0].(map[string]dto.PhotoURL)
+	return ret0
+}
+
+// Sign indicates an expected call of Sign.
+func (mr *MockPhotoURLSignerMockRecorder) Sign(objectNames interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sign", reflect.TypeOf((*MockPhotoURLSigner)(nil).Sign), objectNames)
+}
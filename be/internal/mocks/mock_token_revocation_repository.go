@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/token_revocation_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTokenRevocationRepository is a mock of TokenRevocationRepository interface.
+type MockTokenRevocationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenRevocationRepositoryMockRecorder
+}
+
+// MockTokenRevocationRepositoryMockRecorder is the mock recorder for MockTokenRevocationRepository.
+type MockTokenRevocationRepositoryMockRecorder struct {
+	mock *MockTokenRevocationRepository
+}
+
+// NewMockTokenRevocationRepository creates a new mock instance.
+func NewMockTokenRevocationRepository(ctrl *gomock.Controller) *MockTokenRevocationRepository {
+	mock := &MockTokenRevocationRepository{ctrl: ctrl}
+	mock.recorder = &MockTokenRevocationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenRevocationRepository) EXPECT() *MockTokenRevocationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// IsRevoked mocks base method.
+func (m *MockTokenRevocationRepository) IsRevoked(jti string, userID int, issuedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", jti, userID, issuedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockTokenRevocationRepositoryMockRecorder) IsRevoked(jti, userID, issuedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockTokenRevocationRepository)(nil).IsRevoked), jti, userID, issuedAt)
+}
+
+// RevokeToken mocks base method.
+func (m *MockTokenRevocationRepository) RevokeToken(jti string, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", jti, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockTokenRevocationRepositoryMockRecorder) RevokeToken(jti, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockTokenRevocationRepository)(nil).RevokeToken), jti, ttl)
+}
+
+// RevokeUser mocks base method.
+func (m *MockTokenRevocationRepository) RevokeUser(userID int, at time.Time, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUser", userID, at, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUser indicates an expected call of RevokeUser.
+func (mr *MockTokenRevocationRepositoryMockRecorder) RevokeUser(userID, at, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUser", reflect.TypeOf((*MockTokenRevocationRepository)(nil).RevokeUser), userID, at, ttl)
+}
@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/oidc_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	entity "milestone3/be/internal/entity"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUserIdentityRepository is a mock of UserIdentityRepository interface.
+type MockUserIdentityRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserIdentityRepositoryMockRecorder
+}
+
+// MockUserIdentityRepositoryMockRecorder is the mock recorder for MockUserIdentityRepository.
+type MockUserIdentityRepositoryMockRecorder struct {
+	mock *MockUserIdentityRepository
+}
+
+// NewMockUserIdentityRepository creates a new mock instance.
+func NewMockUserIdentityRepository(ctrl *gomock.Controller) *MockUserIdentityRepository {
+	mock := &MockUserIdentityRepository{ctrl: ctrl}
+	mock.recorder = &MockUserIdentityRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserIdentityRepository) EXPECT() *MockUserIdentityRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockUserIdentityRepository) Create(identity *entity.UserIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserIdentityRepositoryMockRecorder) Create(identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserIdentityRepository)(nil).Create), identity)
+}
+
+// GetByProviderSubject mocks base method.
+func (m *MockUserIdentityRepository) GetByProviderSubject(provider, subject string) (entity.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByProviderSubject", provider, subject)
+	ret0, _ := ret[0].(entity.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByProviderSubject indicates an expected call of GetByProviderSubject.
+func (mr *MockUserIdentityRepositoryMockRecorder) GetByProviderSubject(provider, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByProviderSubject", reflect.TypeOf((*MockUserIdentityRepository)(nil).GetByProviderSubject), provider, subject)
+}
+
+// TouchLogin mocks base method.
+func (m *MockUserIdentityRepository) TouchLogin(id int, email string, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchLogin", id, email, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchLogin indicates an expected call of TouchLogin.
+func (mr *MockUserIdentityRepositoryMockRecorder) TouchLogin(id, email, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchLogin", reflect.TypeOf((*MockUserIdentityRepository)(nil).TouchLogin), id, email, at)
+}
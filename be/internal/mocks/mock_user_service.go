@@ -7,6 +7,7 @@ package mocks
 import (
 	dto "milestone3/be/internal/dto"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,6 +35,20 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockUserService) ChangePassword(userId int, req dto.ChangePasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", userId, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserServiceMockRecorder) ChangePassword(userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserService)(nil).ChangePassword), userId, req)
+}
+
 // CreateUser mocks base method.
 func (m *MockUserService) CreateUser(req dto.UserRequest) (dto.UserResponse, error) {
 	m.ctrl.T.Helper()
@@ -49,11 +64,112 @@ func (mr *MockUserServiceMockRecorder) CreateUser(req interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserService)(nil).CreateUser), req)
 }
 
+// DeactivateAccount mocks base method.
+func (m *MockUserService) DeactivateAccount(userId int, req dto.ConfirmPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateAccount", userId, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeactivateAccount indicates an expected call of DeactivateAccount.
+func (mr *MockUserServiceMockRecorder) DeactivateAccount(userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateAccount", reflect.TypeOf((*MockUserService)(nil).DeactivateAccount), userId, req)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockUserService) DeleteAccount(userId int, req dto.ConfirmPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", userId, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockUserServiceMockRecorder) DeleteAccount(userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockUserService)(nil).DeleteAccount), userId, req)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockUserService) DisableTOTP(userId int, req dto.SecondFactorRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", userId, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockUserServiceMockRecorder) DisableTOTP(userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockUserService)(nil).DisableTOTP), userId, req)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockUserService) EnableTOTP(userId int, code string) (dto.RecoveryCodesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", userId, code)
+	ret0, _ := ret[0].(dto.RecoveryCodesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockUserServiceMockRecorder) EnableTOTP(userId, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockUserService)(nil).EnableTOTP), userId, code)
+}
+
+// ForgotPassword mocks base method.
+func (m *MockUserService) ForgotPassword(email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockUserServiceMockRecorder) ForgotPassword(email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockUserService)(nil).ForgotPassword), email)
+}
+
+// GetProfile mocks base method.
+func (m *MockUserService) GetProfile(userId int) (dto.ProfileResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", userId)
+	ret0, _ := ret[0].(dto.ProfileResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockUserServiceMockRecorder) GetProfile(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockUserService)(nil).GetProfile), userId)
+}
+
+// GetTwoFactorStatus mocks base method.
+func (m *MockUserService) GetTwoFactorStatus(userId int) (dto.TwoFactorStatusResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactorStatus", userId)
+	ret0, _ := ret[0].(dto.TwoFactorStatusResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactorStatus indicates an expected call of GetTwoFactorStatus.
+func (mr *MockUserServiceMockRecorder) GetTwoFactorStatus(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactorStatus", reflect.TypeOf((*MockUserService)(nil).GetTwoFactorStatus), userId)
+}
+
 // GetUserByEmail mocks base method.
-func (m *MockUserService) GetUserByEmail(email, password string) (string, error) {
+func (m *MockUserService) GetUserByEmail(email, password string) (dto.TokenResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetUserByEmail", email, password)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(dto.TokenResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -63,3 +179,120 @@ func (mr *MockUserServiceMockRecorder) GetUserByEmail(email, password interface{
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserService)(nil).GetUserByEmail), email, password)
 }
+
+// LoginMFA mocks base method.
+func (m *MockUserService) LoginMFA(req dto.MFALoginRequest) (dto.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginMFA", req)
+	ret0, _ := ret[0].(dto.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginMFA indicates an expected call of LoginMFA.
+func (mr *MockUserServiceMockRecorder) LoginMFA(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginMFA", reflect.TypeOf((*MockUserService)(nil).LoginMFA), req)
+}
+
+// Logout mocks base method.
+func (m *MockUserService) Logout(userId int, jti string, expiresAt time.Time, req dto.LogoutRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", userId, jti, expiresAt, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockUserServiceMockRecorder) Logout(userId, jti, expiresAt, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUserService)(nil).Logout), userId, jti, expiresAt, req)
+}
+
+// RefreshToken mocks base method.
+func (m *MockUserService) RefreshToken(refreshToken string) (dto.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", refreshToken)
+	ret0, _ := ret[0].(dto.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockUserServiceMockRecorder) RefreshToken(refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockUserService)(nil).RefreshToken), refreshToken)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockUserService) RegenerateRecoveryCodes(userId int, req dto.SecondFactorRequest) (dto.RecoveryCodesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", userId, req)
+	ret0, _ := ret[0].(dto.RecoveryCodesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockUserServiceMockRecorder) RegenerateRecoveryCodes(userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockUserService)(nil).RegenerateRecoveryCodes), userId, req)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUserService) ResetPassword(req dto.ResetPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserServiceMockRecorder) ResetPassword(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserService)(nil).ResetPassword), req)
+}
+
+// SetupTOTP mocks base method.
+func (m *MockUserService) SetupTOTP(userId int, req dto.ConfirmPasswordRequest) (dto.TOTPSetupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetupTOTP", userId, req)
+	ret0, _ := ret[0].(dto.TOTPSetupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetupTOTP indicates an expected call of SetupTOTP.
+func (mr *MockUserServiceMockRecorder) SetupTOTP(userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetupTOTP", reflect.TypeOf((*MockUserService)(nil).SetupTOTP), userId, req)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUserService) UpdateProfile(userId int, req dto.UpdateProfileRequest) (dto.ProfileResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", userId, req)
+	ret0, _ := ret[0].(dto.ProfileResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserServiceMockRecorder) UpdateProfile(userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserService)(nil).UpdateProfile), userId, req)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUserService) VerifyEmail(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserServiceMockRecorder) VerifyEmail(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUserService)(nil).VerifyEmail), token)
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"milestone3/be/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lastUsedPrecision limits the last_used_at writes to one a minute per key
+const lastUsedPrecision = time.Minute
+
+type APIKeyRepo struct {
+	ctx context.Context
+	db  *gorm.DB
+}
+
+func NewAPIKeyRepo(db *gorm.DB, ctx context.Context) *APIKeyRepo {
+	return &APIKeyRepo{db: db, ctx: ctx}
+}
+
+func (kr *APIKeyRepo) Create(key *entity.APIKey) error {
+	return kr.db.WithContext(kr.ctx).Create(key).Error
+}
+
+func (kr *APIKeyRepo) GetById(id int) (key entity.APIKey, err error) {
+	if err := kr.db.WithContext(kr.ctx).First(&key, "id = ?", id).Error; err != nil {
+		return entity.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// GetActiveByHash finds a key that is not revoked or expired and whose owner
+// can still log in
+func (kr *APIKeyRepo) GetActiveByHash(hash string, at time.Time) (key entity.APIKey, err error) {
+	err = kr.db.WithContext(kr.ctx).
+		Joins("JOIN users ON users.id = api_keys.user_id AND users.deactivated_at IS NULL AND users.deleted_at IS NULL").
+		Where("api_keys.key_hash = ? AND api_keys.revoked_at IS NULL", hash).
+		Where("api_keys.expires_at IS NULL OR api_keys.expires_at > ?", at).
+		First(&key).Error
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// GetAll lists the keys of an owner, every key when ownerId is 0
+func (kr *APIKeyRepo) GetAll(ownerId int) (keys []entity.APIKey, err error) {
+	query := kr.db.WithContext(kr.ctx).Order("id DESC")
+	if ownerId != 0 {
+		query = query.Where("user_id = ?", ownerId)
+	}
+	if err := query.Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Revoke reports false when the key was already revoked
+func (kr *APIKeyRepo) Revoke(id int, at time.Time) (bool, error) {
+	res := kr.db.WithContext(kr.ctx).Model(&entity.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (kr *APIKeyRepo) TouchLastUsed(id int, at time.Time) error {
+	return kr.db.WithContext(kr.ctx).Model(&entity.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-lastUsedPrecision)).
+		Update("last_used_at", at).Error
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// APIKeyUsageRetention is how long the daily request counts are kept
+const APIKeyUsageRetention = 90 * 24 * time.Hour
+
+// APIKeyUsageRepository counts the requests made with each API key per day (UTC)
+type APIKeyUsageRepository interface {
+	Record(keyId int, at time.Time) error
+	// Daily returns the counts of the days up to and including to, oldest first
+	Daily(keyId int, to time.Time, days int) ([]int64, error)
+}
+
+type apiKeyUsageRepository struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewAPIKeyUsageRepository(client *redis.Client, ctx context.Context) APIKeyUsageRepository {
+	return &apiKeyUsageRepository{client: client, ctx: ctx}
+}
+
+func apiKeyUsageKey(keyId int, day time.Time) string {
+	return fmt.Sprintf("apikey:usage:%d:%s", keyId, day.UTC().Format("2006-01-02"))
+}
+
+func (r *apiKeyUsageRepository) Record(keyId int, at time.Time) error {
+	key := apiKeyUsageKey(keyId, at)
+	pipe := r.client.TxPipeline()
+	pipe.Incr(r.ctx, key)
+	pipe.Expire(r.ctx, key, APIKeyUsageRetention)
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+func (r *apiKeyUsageRepository) Daily(keyId int, to time.Time, days int) ([]int64, error) {
+	if days <= 0 {
+		return []int64{}, nil
+	}
+
+	to = to.UTC()
+	keys := make([]string, days)
+	for i := range keys {
+		keys[i] = apiKeyUsageKey(keyId, to.AddDate(0, 0, i-days+1))
+	}
+
+	values, err := r.client.MGet(r.ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, days)
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			counts[i], _ = strconv.ParseInt(s, 10, 64)
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyUsageRepository(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := NewAPIKeyUsageRepository(client, context.Background())
+
+	today := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	yesterday := today.Add(-24 * time.Hour)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repo.Record(1, today))
+	}
+	require.NoError(t, repo.Record(1, yesterday))
+	require.NoError(t, repo.Record(2, today))
+
+	daily, err := repo.Daily(1, today, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 1, 3}, daily)
+
+	// days are counted in UTC whatever the caller's zone
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	daily, err = repo.Daily(1, time.Date(2024, 3, 11, 2, 0, 0, 0, jakarta), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3}, daily)
+
+	assert.Equal(t, APIKeyUsageRetention, mr.TTL("apikey:usage:1:2024-03-10"))
+
+	daily, err = repo.Daily(1, today, 0)
+	require.NoError(t, err)
+	assert.Empty(t, daily)
+}
@@ -10,6 +10,7 @@ type AuctionItemRepository interface {
 	Create(item *entity.AuctionItem) error
 	GetAll() ([]entity.AuctionItem, error)
 	GetByID(id int64) (*entity.AuctionItem, error)
+	ExistsForDonation(donationID int64) (bool, error)
 	ReadBySession(sessionID int64) ([]entity.AuctionItem, error)
 	GetScheduledItems() ([]entity.AuctionItem, error)
 	Update(item *entity.AuctionItem) error
@@ -30,19 +31,25 @@ func (r *auctionItemRepository) Create(item *entity.AuctionItem) error {
 
 func (r *auctionItemRepository) GetAll() ([]entity.AuctionItem, error) {
 	var items []entity.AuctionItem
-	err := r.db.Preload("Session").Find(&items).Error
+	err := r.db.Preload("Session").Preload("Photos").Find(&items).Error
 	return items, err
 }
 
 func (r *auctionItemRepository) GetByID(id int64) (*entity.AuctionItem, error) {
 	var item entity.AuctionItem
-	err := r.db.Preload("Session").First(&item, id).Error
+	err := r.db.Preload("Session").Preload("Photos").First(&item, id).Error
 	return &item, err
 }
 
+func (r *auctionItemRepository) ExistsForDonation(donationID int64) (bool, error) {
+	var count int64
+	err := r.db.Model(&entity.AuctionItem{}).Where("donation_id = ?", donationID).Count(&count).Error
+	return count > 0, err
+}
+
 func (r *auctionItemRepository) ReadBySession(sessionID int64) ([]entity.AuctionItem, error) {
 	var items []entity.AuctionItem
-	err := r.db.Preload("Session").Where("session_id = ?", sessionID).Find(&items).Error
+	err := r.db.Preload("Session").Preload("Photos").Where("session_id = ?", sessionID).Find(&items).Error
 	return items, err
 }
 
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"milestone3/be/internal/dto"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BidEventRepository fans bid events out through redis pub/sub so every
+// instance can push them to its own stream subscribers
+type BidEventRepository interface {
+	Publish(event dto.BidEventDTO) error
+	Subscribe(ctx context.Context, sessionID, itemID int64) (<-chan dto.BidEventDTO, error)
+}
+
+type bidEventRepository struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewBidEventRepository(client *redis.Client, ctx context.Context) BidEventRepository {
+	return &bidEventRepository{client: client, ctx: ctx}
+}
+
+func bidEventChannel(sessionID, itemID int64) string {
+	return fmt.Sprintf("auction:%d:item:%d:events", sessionID, itemID)
+}
+
+func (r *bidEventRepository) Publish(event dto.BidEventDTO) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Publish(r.ctx, bidEventChannel(event.SessionID, event.ItemID), payload).Err()
+}
+
+// Subscribe returns a channel that is closed once ctx is done
+func (r *bidEventRepository) Subscribe(ctx context.Context, sessionID, itemID int64) (<-chan dto.BidEventDTO, error) {
+	sub := r.client.Subscribe(ctx, bidEventChannel(sessionID, itemID))
+
+	// wait for subscription confirmation so no event published after this call is missed
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+
+	events := make(chan dto.BidEventDTO, 16)
+	go func() {
+		defer close(events)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event dto.BidEventDTO
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -5,21 +5,29 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"milestone3/be/internal/entity"
+
 	"github.com/redis/go-redis/v9"
 )
 
 type BidRedisRepository interface {
-	SetHighestBid(sessionID, itemID int64, amount float64, userID int64, sessionEndTime time.Time) error
+	CompareAndSetHighestBid(sessionID, itemID int64, attempt BidAttempt) (BidResult, error)
+	SetMaxBid(sessionID, itemID int64, attempt BidAttempt) (BidResult, error)
+	BuyNow(sessionID, itemID int64, attempt BidAttempt) (BidResult, error)
 	GetHighestBid(sessionID, itemID int64) (float64, int64, error)
 	GetEndTime(key string) (time.Time, error)
 
 	ScanKeys(pattern string) ([]string, error)
 	GetBidByKey(key string) (BidEntry, error)
 	DeleteKey(key string) error
+	ClaimFinalisation(key string) (bool, error)
+	ReleaseFinalisation(key string) error
 
-	CheckDuplicateBid(userID, itemID int64, amount float64, ttl time.Duration) error
+	GetBidHistory(sessionID, itemID int64) ([]entity.BidHistory, error)
+	DeleteBidHistory(sessionID, itemID int64) error
 }
 
 type bidRedisRepository struct {
@@ -31,41 +39,359 @@ type BidEntry struct {
 	UserID int64
 	ItemID int64
 	Amount float64
+	Closed bool // bought now, no further bids accepted
+}
+
+// BidAttempt is everything the compare-and-set needs to validate a bid,
+// for a max bid the amount is the bidder's ceiling. A bid placed within
+// SoftCloseWindow of the item's end pushes the end back to now + window.
+type BidAttempt struct {
+	UserID          int64
+	Amount          float64
+	StartingPrice   float64
+	MinIncrement    float64
+	SessionEndTime  time.Time
+	SoftCloseWindow time.Duration
+}
+
+type BidOutcome string
+
+const (
+	BidAccepted          BidOutcome = "accepted"
+	BidRejectedTooLow    BidOutcome = "too_low"
+	BidRejectedSameOwner BidOutcome = "same_bidder"
+	BidRejectedClosed    BidOutcome = "closed"
+	BidRejectedExceeded  BidOutcome = "exceeded"
+)
+
+// PlacedBid is a bid written by the script, either the manual one or one
+// placed on behalf of a proxy ceiling
+type PlacedBid struct {
+	UserID int64
+	Amount float64
+	Source string
 }
 
+// BidResult holds the outcome, the highest bid before and after the attempt,
+// the item's effective end time and every bid placed in between, in order
+type BidResult struct {
+	Outcome        BidOutcome
+	PreviousAmount float64
+	PreviousBidder int64
+	HighestAmount  float64
+	HighestBidder  int64
+	EndTime        time.Time
+	Extended       bool
+	Placed         []PlacedBid
+}
+
+// placeBidScript validates and writes the highest bid in one step so
+// concurrent instances can never overwrite a higher bid with a lower one.
+// Proxy ceilings live in the same hash as proxy:{user} = "max:seq" and are
+// resolved right after the manual bid or max bid is applied. The effective
+// end is the later of the session end and the hash end_time, which late bids
+// push back by the soft close window.
+//
+// KEYS[1] active item hash, KEYS[2] history sorted set of "user:amount:source:unix"
+// ARGV mode (bid|proxy), amount, user id, starting price, min increment,
+// session end, now, ttl buffer seconds, soft close seconds
+var placeBidScript = redis.NewScript(`
+local mode = ARGV[1]
+local amount = tonumber(ARGV[2])
+local user = ARGV[3]
+local starting = tonumber(ARGV[4])
+local inc = tonumber(ARGV[5])
+local now = tonumber(ARGV[7])
+
+local endTime = tonumber(ARGV[6])
+local storedEnd = tonumber(redis.call('HGET', KEYS[1], 'end_time') or '0') or 0
+if storedEnd > endTime then
+	endTime = storedEnd
+end
+
+local current = tonumber(redis.call('HGET', KEYS[1], 'highest_amount') or '0') or 0
+local leader = redis.call('HGET', KEYS[1], 'highest_bidder') or ''
+local prevAmount, prevBidder = current, leader
+local placed = {}
+
+local function minNext()
+	if current == 0 then
+		return starting
+	end
+	return current + inc
+end
+
+local function place(bidder, value, source)
+	current = value
+	leader = bidder
+	table.insert(placed, bidder)
+	table.insert(placed, tostring(value))
+	table.insert(placed, source)
+	redis.call('ZADD', KEYS[2], value, bidder .. ':' .. tostring(value) .. ':' .. source .. ':' .. ARGV[7])
+end
+
+local function reject(outcome)
+	return {outcome, tostring(prevAmount), prevBidder, tostring(prevAmount), prevBidder, string.format('%d', endTime), '0'}
+end
+
+if now > endTime or redis.call('HEXISTS', KEYS[1], 'closed') == 1 then
+	return reject('closed')
+end
+
+if mode == 'bid' then
+	if current == 0 then
+		if amount < starting then
+			return reject('too_low')
+		end
+	else
+		if amount <= current then
+			return reject('too_low')
+		end
+		if leader == user then
+			return reject('same_bidder')
+		end
+		if amount < current + inc then
+			return reject('too_low')
+		end
+	end
+	place(user, amount, 'manual')
+else
+	if leader == user then
+		if amount <= current then
+			return reject('too_low')
+		end
+	elseif amount < minNext() then
+		return reject('too_low')
+	end
+	local seq = redis.call('HINCRBY', KEYS[1], 'proxy_seq', 1)
+	redis.call('HSET', KEYS[1], 'proxy:' .. user, ARGV[2] .. ':' .. seq)
+end
+
+-- the leader defends up to its own ceiling, challengers are the other
+-- ceilings still able to outbid, ties go to the earliest registration
+local leaderMax = current
+local best, second = nil, nil
+local fields = redis.call('HGETALL', KEYS[1])
+for i = 1, #fields, 2 do
+	local bidder = string.match(fields[i], '^proxy:(.+)$')
+	if bidder then
+		local max, seq = string.match(fields[i + 1], '^([^:]+):(%d+)$')
+		local p = {user = bidder, max = tonumber(max), seq = tonumber(seq)}
+		if p.user == leader then
+			if p.max > leaderMax then
+				leaderMax = p.max
+			end
+		elseif p.max >= minNext() then
+			if best == nil or p.max > best.max or (p.max == best.max and p.seq < best.seq) then
+				second = best
+				best = p
+			elseif second == nil or p.max > second.max then
+				second = p
+			end
+		end
+	end
+end
+
+if best ~= nil then
+	if leader ~= '' and best.max <= leaderMax then
+		-- leader holds, the price moves just above the challenger's ceiling
+		local holder = leader
+		local price = math.min(leaderMax, best.max + inc)
+		if price > best.max then
+			place(best.user, best.max, 'proxy')
+		end
+		place(holder, price, 'proxy')
+	else
+		-- challenger takes over, paying one increment above the runner-up
+		local price = minNext()
+		if leader ~= '' then
+			price = math.max(price, leaderMax + inc)
+			if leaderMax > current then
+				place(leader, leaderMax, 'proxy')
+			end
+		end
+		if second ~= nil then
+			price = math.max(price, second.max + inc)
+			if second.max > leaderMax and second.max < best.max then
+				place(second.user, second.max, 'proxy')
+			end
+		end
+		place(best.user, math.min(price, best.max), 'proxy')
+	end
+end
+
+local extended = '0'
+if #placed > 0 then
+	local window = tonumber(ARGV[9])
+	if window > 0 and endTime - now < window then
+		endTime = now + window
+		extended = '1'
+	end
+	redis.call('HSET', KEYS[1],
+		'highest_amount', tostring(current),
+		'highest_bidder', leader,
+		'updated_at', ARGV[7])
+end
+redis.call('HSET', KEYS[1], 'end_time', string.format('%d', endTime))
+
+local ttl = endTime - now + tonumber(ARGV[8])
+if ttl > 0 then
+	redis.call('EXPIRE', KEYS[1], string.format('%d', ttl))
+end
+
+local result = {'accepted', tostring(prevAmount), prevBidder, tostring(current), leader, string.format('%d', endTime), extended}
+for _, v in ipairs(placed) do
+	table.insert(result, v)
+end
+return result
+`)
+
+// buyNowScript closes the item for the buyer unless bids already reached the
+// price. It also claims finalisation so SaveKeyToDB leaves the key to the
+// buy now flow.
+//
+// KEYS[1] active item hash, KEYS[2] history sorted set
+// ARGV user id, price, session end, now, ttl buffer seconds
+var buyNowScript = redis.NewScript(`
+local price = tonumber(ARGV[2])
+local now = tonumber(ARGV[4])
+
+local endTime = tonumber(ARGV[3])
+local storedEnd = tonumber(redis.call('HGET', KEYS[1], 'end_time') or '0') or 0
+if storedEnd > endTime then
+	endTime = storedEnd
+end
+
+local current = tonumber(redis.call('HGET', KEYS[1], 'highest_amount') or '0') or 0
+local leader = redis.call('HGET', KEYS[1], 'highest_bidder') or ''
+
+if now > endTime or redis.call('HEXISTS', KEYS[1], 'closed') == 1 then
+	return {'closed', tostring(current), leader, string.format('%d', endTime)}
+end
+
+if current >= price then
+	return {'exceeded', tostring(current), leader, string.format('%d', endTime)}
+end
+
+redis.call('HSET', KEYS[1],
+	'highest_amount', tostring(price),
+	'highest_bidder', ARGV[1],
+	'updated_at', ARGV[4],
+	'end_time', string.format('%d', endTime),
+	'closed', '1',
+	'finalising', '1')
+redis.call('ZADD', KEYS[2], price, ARGV[1] .. ':' .. tostring(price) .. ':buy_now:' .. ARGV[4])
+
+local ttl = endTime - now + tonumber(ARGV[5])
+if ttl > 0 then
+	redis.call('EXPIRE', KEYS[1], string.format('%d', ttl))
+end
+
+return {'accepted', tostring(current), leader, string.format('%d', endTime)}
+`)
+
+// claimScript marks an existing key as being finalised, never creating it
+var claimScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+return redis.call('HSETNX', KEYS[1], 'finalising', '1')
+`)
+
 func NewBidRedisRepository(client *redis.Client, ctx context.Context) BidRedisRepository {
 	return &bidRedisRepository{client: client, ctx: ctx}
 }
 
-func (r *bidRedisRepository) SetHighestBid(sessionID, itemID int64, amount float64, userID int64, sessionEndTime time.Time) error {
+func (r *bidRedisRepository) CompareAndSetHighestBid(sessionID, itemID int64, attempt BidAttempt) (BidResult, error) {
+	return r.runPlaceBid("bid", sessionID, itemID, attempt)
+}
+
+// SetMaxBid registers or raises the user's proxy ceiling and lets the
+// script bid on their behalf right away when they are not leading
+func (r *bidRedisRepository) SetMaxBid(sessionID, itemID int64, attempt BidAttempt) (BidResult, error) {
+	return r.runPlaceBid("proxy", sessionID, itemID, attempt)
+}
+
+func (r *bidRedisRepository) runPlaceBid(mode string, sessionID, itemID int64, attempt BidAttempt) (BidResult, error) {
 	key := fmt.Sprintf("active:auction:%d:item:%d", sessionID, itemID)
+	historyKey := fmt.Sprintf("auction:%d:item:%d:history", sessionID, itemID)
+
+	// buffer on top of the effective end before the key expires on its own
+	ttlBuffer := int64((5 * time.Minute).Seconds())
 
-	if err := r.client.HSet(r.ctx, key, map[string]interface{}{
-		"highest_amount": amount,
-		"highest_bidder": userID,
-		"updated_at":     time.Now().Unix(),
-		"end_time":       sessionEndTime.Unix(),
-	}).Err(); err != nil {
-		return err
+	res, err := placeBidScript.Run(r.ctx, r.client, []string{key, historyKey},
+		mode,
+		strconv.FormatFloat(attempt.Amount, 'f', -1, 64),
+		attempt.UserID,
+		strconv.FormatFloat(attempt.StartingPrice, 'f', -1, 64),
+		strconv.FormatFloat(attempt.MinIncrement, 'f', -1, 64),
+		attempt.SessionEndTime.Unix(),
+		time.Now().Unix(),
+		ttlBuffer,
+		int64(attempt.SoftCloseWindow.Seconds()),
+	).StringSlice()
+	if err != nil {
+		return BidResult{}, err
 	}
 
-	// buffer with ttl to delete key after session end
-	ttl := time.Until(sessionEndTime) + (5 * time.Minute)
-	if ttl > 0 {
-		if err := r.client.Expire(r.ctx, key, ttl).Err(); err != nil {
-			return err
-		}
+	if len(res) < 7 || (len(res)-7)%3 != 0 {
+		return BidResult{}, fmt.Errorf("unexpected place bid script result: %v", res)
+	}
+
+	result := BidResult{Outcome: BidOutcome(res[0])}
+	result.PreviousAmount, _ = strconv.ParseFloat(res[1], 64)
+	result.PreviousBidder, _ = strconv.ParseInt(res[2], 10, 64)
+	result.HighestAmount, _ = strconv.ParseFloat(res[3], 64)
+	result.HighestBidder, _ = strconv.ParseInt(res[4], 10, 64)
+	endUnix, _ := strconv.ParseInt(res[5], 10, 64)
+	result.EndTime = time.Unix(endUnix, 0)
+	result.Extended = res[6] == "1"
+
+	for i := 7; i < len(res); i += 3 {
+		userID, _ := strconv.ParseInt(res[i], 10, 64)
+		amount, _ := strconv.ParseFloat(res[i+1], 64)
+		result.Placed = append(result.Placed, PlacedBid{UserID: userID, Amount: amount, Source: res[i+2]})
 	}
 
+	return result, nil
+}
+
+// BuyNow ends bidding for the user at the attempt amount
+func (r *bidRedisRepository) BuyNow(sessionID, itemID int64, attempt BidAttempt) (BidResult, error) {
+	key := fmt.Sprintf("active:auction:%d:item:%d", sessionID, itemID)
 	historyKey := fmt.Sprintf("auction:%d:item:%d:history", sessionID, itemID)
-	if err := r.client.ZAdd(r.ctx, historyKey, redis.Z{
-		Score:  amount,
-		Member: userID,
-	}).Err(); err != nil {
-		return err
+
+	res, err := buyNowScript.Run(r.ctx, r.client, []string{key, historyKey},
+		attempt.UserID,
+		strconv.FormatFloat(attempt.Amount, 'f', -1, 64),
+		attempt.SessionEndTime.Unix(),
+		time.Now().Unix(),
+		int64((5 * time.Minute).Seconds()),
+	).StringSlice()
+	if err != nil {
+		return BidResult{}, err
 	}
 
-	return nil
+	if len(res) != 4 {
+		return BidResult{}, fmt.Errorf("unexpected buy now script result: %v", res)
+	}
+
+	result := BidResult{Outcome: BidOutcome(res[0])}
+	result.PreviousAmount, _ = strconv.ParseFloat(res[1], 64)
+	result.PreviousBidder, _ = strconv.ParseInt(res[2], 10, 64)
+	endUnix, _ := strconv.ParseInt(res[3], 10, 64)
+	result.EndTime = time.Unix(endUnix, 0)
+
+	if result.Outcome == BidAccepted {
+		result.HighestAmount = attempt.Amount
+		result.HighestBidder = attempt.UserID
+		result.Placed = []PlacedBid{{UserID: attempt.UserID, Amount: attempt.Amount, Source: entity.BidSourceBuyNow}}
+	} else {
+		result.HighestAmount = result.PreviousAmount
+		result.HighestBidder = result.PreviousBidder
+	}
+
+	return result, nil
 }
 
 func (r *bidRedisRepository) GetHighestBid(sessionID, itemID int64) (float64, int64, error) {
@@ -152,6 +478,7 @@ func (r *bidRedisRepository) GetBidByKey(key string) (BidEntry, error) {
 		UserID: userID,
 		ItemID: itemID,
 		Amount: amount,
+		Closed: data["closed"] == "1",
 	}, nil
 }
 
@@ -159,14 +486,62 @@ func (r *bidRedisRepository) DeleteKey(key string) error {
 	return r.client.Del(r.ctx, key).Err()
 }
 
-func (r *bidRedisRepository) CheckDuplicateBid(userID, itemID int64, amount float64, ttl time.Duration) error {
-	key := fmt.Sprintf("bidder:%d:item:%d:amount:%.2f", userID, itemID, amount)
-	result, err := r.client.SetNX(r.ctx, key, "exists", ttl).Result()
+// ClaimFinalisation lets exactly one of the cron and the buy now flow save the item
+func (r *bidRedisRepository) ClaimFinalisation(key string) (bool, error) {
+	claimed, err := claimScript.Run(r.ctx, r.client, []string{key}).Int()
 	if err != nil {
-		return err
+		return false, err
 	}
-	if !result {
-		return errors.New("duplicate bid detected")
+	return claimed == 1, nil
+}
+
+// ReleaseFinalisation hands the key back to the cron after a failed attempt
+func (r *bidRedisRepository) ReleaseFinalisation(key string) error {
+	return r.client.HDel(r.ctx, key, "finalising").Err()
+}
+
+// GetBidHistory reads every accepted bid kept for the item, lowest first
+func (r *bidRedisRepository) GetBidHistory(sessionID, itemID int64) ([]entity.BidHistory, error) {
+	historyKey := fmt.Sprintf("auction:%d:item:%d:history", sessionID, itemID)
+
+	members, err := r.client.ZRangeWithScores(r.ctx, historyKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]entity.BidHistory, 0, len(members))
+	for _, m := range members {
+		member, ok := m.Member.(string)
+		if !ok {
+			continue
+		}
+
+		entry := entity.BidHistory{
+			SessionID: sessionID,
+			ItemID:    itemID,
+			Amount:    m.Score,
+			Source:    entity.BidSourceManual,
+		}
+
+		// older members only hold the user id
+		parts := strings.Split(member, ":")
+		entry.UserID, err = strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(parts) == 4 {
+			entry.Source = parts[2]
+			if placedAt, err := strconv.ParseInt(parts[3], 10, 64); err == nil {
+				entry.CreatedAt = time.Unix(placedAt, 0)
+			}
+		}
+
+		history = append(history, entry)
 	}
-	return nil
+
+	return history, nil
+}
+
+func (r *bidRedisRepository) DeleteBidHistory(sessionID, itemID int64) error {
+	return r.client.Del(r.ctx, fmt.Sprintf("auction:%d:item:%d:history", sessionID, itemID)).Err()
 }
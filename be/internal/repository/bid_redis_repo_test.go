@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/entity"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBidRedisRepository(t *testing.T) (BidRedisRepository, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewBidRedisRepository(client, context.Background()), mr
+}
+
+func TestBidRedisRepository_CompareAndSetHighestBid(t *testing.T) {
+	repo, mr := newTestBidRedisRepository(t)
+	endTime := time.Now().Add(time.Hour)
+
+	attempt := func(userID int64, amount float64) BidResult {
+		res, err := repo.CompareAndSetHighestBid(1, 1, BidAttempt{
+			UserID:         userID,
+			Amount:         amount,
+			StartingPrice:  50000,
+			MinIncrement:   10000,
+			SessionEndTime: endTime,
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	// below starting price
+	assert.Equal(t, BidRejectedTooLow, attempt(1, 40000).Outcome)
+
+	first := attempt(1, 50000)
+	assert.Equal(t, BidAccepted, first.Outcome)
+	assert.Equal(t, float64(0), first.PreviousAmount)
+	assert.Equal(t, int64(0), first.PreviousBidder)
+
+	// same bidder cannot raise own bid
+	assert.Equal(t, BidRejectedSameOwner, attempt(1, 70000).Outcome)
+
+	// increment smaller than minimum
+	assert.Equal(t, BidRejectedTooLow, attempt(2, 55000).Outcome)
+
+	second := attempt(2, 60000)
+	assert.Equal(t, BidAccepted, second.Outcome)
+	assert.Equal(t, float64(50000), second.PreviousAmount)
+	assert.Equal(t, int64(1), second.PreviousBidder)
+
+	amount, bidder, err := repo.GetHighestBid(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, float64(60000), amount)
+	assert.Equal(t, int64(2), bidder)
+
+	assert.True(t, mr.TTL("active:auction:1:item:1") > time.Hour)
+
+	end, err := repo.GetEndTime("active:auction:1:item:1")
+	require.NoError(t, err)
+	assert.Equal(t, endTime.Unix(), end.Unix())
+}
+
+func TestBidRedisRepository_CompareAndSetHighestBid_Concurrent(t *testing.T) {
+	repo, _ := newTestBidRedisRepository(t)
+	endTime := time.Now().Add(time.Hour)
+
+	const bidders = 50
+
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		maxAccepted float64
+	)
+
+	for i := 1; i <= bidders; i++ {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+
+			// spread amounts so that lower and higher bids race each other
+			amount := float64(100000 + (userID%7)*20000 + userID*1000)
+			res, err := repo.CompareAndSetHighestBid(1, 1, BidAttempt{
+				UserID:         userID,
+				Amount:         amount,
+				StartingPrice:  100000,
+				MinIncrement:   10000,
+				SessionEndTime: endTime,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if res.Outcome == BidAccepted {
+				mu.Lock()
+				if amount > maxAccepted {
+					maxAccepted = amount
+				}
+				mu.Unlock()
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	amount, _, err := repo.GetHighestBid(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, maxAccepted, amount, "a lower bid must never overwrite a higher accepted bid")
+}
+
+func TestBidRedisRepository_SetMaxBid(t *testing.T) {
+	repo, _ := newTestBidRedisRepository(t)
+	endTime := time.Now().Add(time.Hour)
+
+	attempt := func(userID int64, amount float64) BidAttempt {
+		return BidAttempt{
+			UserID:         userID,
+			Amount:         amount,
+			StartingPrice:  50000,
+			MinIncrement:   10000,
+			SessionEndTime: endTime,
+		}
+	}
+
+	// ceiling below starting price
+	res, err := repo.SetMaxBid(1, 1, attempt(1, 40000))
+	require.NoError(t, err)
+	assert.Equal(t, BidRejectedTooLow, res.Outcome)
+
+	// first ceiling on an empty item opens at the starting price
+	res, err = repo.SetMaxBid(1, 1, attempt(1, 200000))
+	require.NoError(t, err)
+	assert.Equal(t, BidAccepted, res.Outcome)
+	assert.Equal(t, []PlacedBid{{UserID: 1, Amount: 50000, Source: entity.BidSourceProxy}}, res.Placed)
+
+	// manual bid below the ceiling is answered by the proxy
+	res, err = repo.CompareAndSetHighestBid(1, 1, attempt(2, 100000))
+	require.NoError(t, err)
+	assert.Equal(t, BidAccepted, res.Outcome)
+	assert.Equal(t, []PlacedBid{
+		{UserID: 2, Amount: 100000, Source: entity.BidSourceManual},
+		{UserID: 1, Amount: 110000, Source: entity.BidSourceProxy},
+	}, res.Placed)
+	assert.Equal(t, float64(110000), res.HighestAmount)
+	assert.Equal(t, int64(1), res.HighestBidder)
+
+	// a higher ceiling takes over one increment above the old one
+	res, err = repo.SetMaxBid(1, 1, attempt(3, 300000))
+	require.NoError(t, err)
+	assert.Equal(t, BidAccepted, res.Outcome)
+	assert.Equal(t, []PlacedBid{
+		{UserID: 1, Amount: 200000, Source: entity.BidSourceProxy},
+		{UserID: 3, Amount: 210000, Source: entity.BidSourceProxy},
+	}, res.Placed)
+
+	amount, bidder, err := repo.GetHighestBid(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, float64(210000), amount)
+	assert.Equal(t, int64(3), bidder)
+
+	// leader raising its own ceiling does not bid against itself
+	res, err = repo.SetMaxBid(1, 1, attempt(3, 400000))
+	require.NoError(t, err)
+	assert.Equal(t, BidAccepted, res.Outcome)
+	assert.Empty(t, res.Placed)
+	assert.Equal(t, float64(210000), res.HighestAmount)
+}
+
+func TestBidRedisRepository_SetMaxBid_TieGoesToEarliest(t *testing.T) {
+	repo, _ := newTestBidRedisRepository(t)
+	endTime := time.Now().Add(time.Hour)
+
+	attempt := func(userID int64, amount float64) BidAttempt {
+		return BidAttempt{
+			UserID:         userID,
+			Amount:         amount,
+			StartingPrice:  50000,
+			MinIncrement:   10000,
+			SessionEndTime: endTime,
+		}
+	}
+
+	_, err := repo.SetMaxBid(1, 1, attempt(1, 150000))
+	require.NoError(t, err)
+
+	res, err := repo.SetMaxBid(1, 1, attempt(2, 150000))
+	require.NoError(t, err)
+	assert.Equal(t, []PlacedBid{{UserID: 1, Amount: 150000, Source: entity.BidSourceProxy}}, res.Placed)
+	assert.Equal(t, int64(1), res.HighestBidder)
+}
+
+func TestBidRedisRepository_SoftClose(t *testing.T) {
+	repo, mr := newTestBidRedisRepository(t)
+	sessionEnd := time.Now().Add(30 * time.Second)
+
+	attempt := func(userID int64, amount float64) BidAttempt {
+		return BidAttempt{
+			UserID:          userID,
+			Amount:          amount,
+			StartingPrice:   50000,
+			MinIncrement:    10000,
+			SessionEndTime:  sessionEnd,
+			SoftCloseWindow: 2 * time.Minute,
+		}
+	}
+
+	// late bid pushes the end back to now + window
+	res, err := repo.CompareAndSetHighestBid(1, 1, attempt(1, 50000))
+	require.NoError(t, err)
+	assert.Equal(t, BidAccepted, res.Outcome)
+	assert.True(t, res.Extended)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), res.EndTime, 2*time.Second)
+
+	end, err := repo.GetEndTime("active:auction:1:item:1")
+	require.NoError(t, err)
+	assert.Equal(t, res.EndTime.Unix(), end.Unix())
+	assert.True(t, mr.TTL("active:auction:1:item:1") > 2*time.Minute)
+
+	// rejected bids never extend
+	res, err = repo.CompareAndSetHighestBid(1, 1, attempt(2, 55000))
+	require.NoError(t, err)
+	assert.Equal(t, BidRejectedTooLow, res.Outcome)
+	assert.False(t, res.Extended)
+}
+
+func TestBidRedisRepository_RejectsAfterEnd(t *testing.T) {
+	repo, _ := newTestBidRedisRepository(t)
+
+	res, err := repo.CompareAndSetHighestBid(1, 1, BidAttempt{
+		UserID:         1,
+		Amount:         50000,
+		StartingPrice:  50000,
+		MinIncrement:   10000,
+		SessionEndTime: time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, BidRejectedClosed, res.Outcome)
+}
+
+func TestBidRedisRepository_SetMaxBid_TakeoverSkipsLowerRunnerUp(t *testing.T) {
+	endTime := time.Now().Add(time.Hour)
+
+	attempt := func(userID int64, amount float64) BidAttempt {
+		return BidAttempt{
+			UserID:         userID,
+			Amount:         amount,
+			StartingPrice:  50000,
+			MinIncrement:   10000,
+			SessionEndTime: endTime,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		challenger float64
+		wantPlaced []PlacedBid
+		wantLeader int64
+	}{
+		{
+			name:       "challenger equals the leader's ceiling",
+			challenger: 200000,
+			wantPlaced: []PlacedBid{{UserID: 1, Amount: 200000, Source: entity.BidSourceProxy}},
+			wantLeader: 1,
+		},
+		{
+			name:       "challenger beats the leader's ceiling",
+			challenger: 250000,
+			wantPlaced: []PlacedBid{
+				{UserID: 1, Amount: 200000, Source: entity.BidSourceProxy},
+				{UserID: 3, Amount: 210000, Source: entity.BidSourceProxy},
+			},
+			wantLeader: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestBidRedisRepository(t)
+
+			_, err := repo.SetMaxBid(1, 1, attempt(1, 200000))
+			require.NoError(t, err)
+			// runner-up ceiling stays registered below the leader's
+			_, err = repo.SetMaxBid(1, 1, attempt(2, 150000))
+			require.NoError(t, err)
+
+			res, err := repo.SetMaxBid(1, 1, attempt(3, tt.challenger))
+			require.NoError(t, err)
+			assert.Equal(t, BidAccepted, res.Outcome)
+			assert.Equal(t, tt.wantPlaced, res.Placed)
+			assert.Equal(t, tt.wantLeader, res.HighestBidder)
+
+			history, err := repo.GetBidHistory(1, 1)
+			require.NoError(t, err)
+			for i := 1; i < len(history); i++ {
+				assert.Greater(t, history[i].Amount, history[i-1].Amount)
+			}
+		})
+	}
+}
+
+func TestBidRedisRepository_GetBidHistory(t *testing.T) {
+	repo, _ := newTestBidRedisRepository(t)
+	endTime := time.Now().Add(time.Hour)
+
+	attempt := func(userID int64, amount float64) BidAttempt {
+		return BidAttempt{
+			UserID:         userID,
+			Amount:         amount,
+			StartingPrice:  50000,
+			MinIncrement:   10000,
+			SessionEndTime: endTime,
+		}
+	}
+
+	// the same user bidding twice keeps both entries
+	_, err := repo.CompareAndSetHighestBid(1, 1, attempt(1, 50000))
+	require.NoError(t, err)
+	_, err = repo.CompareAndSetHighestBid(1, 1, attempt(2, 60000))
+	require.NoError(t, err)
+	_, err = repo.CompareAndSetHighestBid(1, 1, attempt(1, 70000))
+	require.NoError(t, err)
+	_, err = repo.SetMaxBid(1, 1, attempt(2, 100000))
+	require.NoError(t, err)
+
+	history, err := repo.GetBidHistory(1, 1)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+
+	assert.Equal(t, int64(1), history[0].UserID)
+	assert.Equal(t, float64(50000), history[0].Amount)
+	assert.Equal(t, entity.BidSourceManual, history[0].Source)
+	assert.Equal(t, int64(1), history[2].UserID)
+	assert.Equal(t, float64(70000), history[2].Amount)
+	assert.Equal(t, int64(2), history[3].UserID)
+	assert.Equal(t, float64(80000), history[3].Amount)
+	assert.Equal(t, entity.BidSourceProxy, history[3].Source)
+	assert.WithinDuration(t, time.Now(), history[3].CreatedAt, 2*time.Second)
+
+	require.NoError(t, repo.DeleteBidHistory(1, 1))
+	history, err = repo.GetBidHistory(1, 1)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestBidRedisRepository_BuyNow(t *testing.T) {
+	repo, _ := newTestBidRedisRepository(t)
+	endTime := time.Now().Add(time.Hour)
+	key := "active:auction:1:item:1"
+
+	attempt := func(userID int64, amount float64) BidAttempt {
+		return BidAttempt{
+			UserID:         userID,
+			Amount:         amount,
+			StartingPrice:  50000,
+			MinIncrement:   10000,
+			SessionEndTime: endTime,
+		}
+	}
+
+	_, err := repo.CompareAndSetHighestBid(1, 1, attempt(1, 60000))
+	require.NoError(t, err)
+
+	res, err := repo.BuyNow(1, 1, attempt(2, 200000))
+	require.NoError(t, err)
+	assert.Equal(t, BidAccepted, res.Outcome)
+	assert.Equal(t, int64(1), res.PreviousBidder)
+	assert.Equal(t, []PlacedBid{{UserID: 2, Amount: 200000, Source: entity.BidSourceBuyNow}}, res.Placed)
+
+	entry, err := repo.GetBidByKey(key)
+	require.NoError(t, err)
+	assert.True(t, entry.Closed)
+	assert.Equal(t, int64(2), entry.UserID)
+
+	// closed items take no more bids, ceilings or purchases
+	bid, err := repo.CompareAndSetHighestBid(1, 1, attempt(3, 300000))
+	require.NoError(t, err)
+	assert.Equal(t, BidRejectedClosed, bid.Outcome)
+
+	bid, err = repo.SetMaxBid(1, 1, attempt(3, 300000))
+	require.NoError(t, err)
+	assert.Equal(t, BidRejectedClosed, bid.Outcome)
+
+	bid, err = repo.BuyNow(1, 1, attempt(3, 200000))
+	require.NoError(t, err)
+	assert.Equal(t, BidRejectedClosed, bid.Outcome)
+
+	// buy now already holds the claim until it is released
+	claimed, err := repo.ClaimFinalisation(key)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	require.NoError(t, repo.ReleaseFinalisation(key))
+	claimed, err = repo.ClaimFinalisation(key)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+func TestBidRedisRepository_BuyNow_Exceeded(t *testing.T) {
+	repo, _ := newTestBidRedisRepository(t)
+	endTime := time.Now().Add(time.Hour)
+
+	_, err := repo.CompareAndSetHighestBid(1, 1, BidAttempt{
+		UserID:         1,
+		Amount:         200000,
+		StartingPrice:  50000,
+		MinIncrement:   10000,
+		SessionEndTime: endTime,
+	})
+	require.NoError(t, err)
+
+	res, err := repo.BuyNow(1, 1, BidAttempt{UserID: 2, Amount: 200000, SessionEndTime: endTime})
+	require.NoError(t, err)
+	assert.Equal(t, BidRejectedExceeded, res.Outcome)
+	assert.Empty(t, res.Placed)
+
+	// claiming a missing key must not create it
+	claimed, err := repo.ClaimFinalisation("active:auction:9:item:9")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
@@ -1,13 +1,29 @@
 package repository
 
 import (
+	"time"
+
 	"milestone3/be/internal/entity"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BidRepository interface {
 	SaveFinalBid(bid *entity.Bid) error
+	// SaveWinningBid saves the final bid and marks its item finished in one
+	// transaction
+	SaveWinningBid(bid *entity.Bid) error
+
+	SaveBidHistory(entries []entity.BidHistory) error
+	GetBidHistoryByItem(sessionID, itemID int64, page, limit int) ([]entity.BidHistory, int64, error)
+	GetBidHistoryByUser(userID int64, page, limit int) ([]entity.BidHistory, int64, error)
+
+	GetWinningBid(itemID int64) (entity.Bid, error)
+	GetUnpaidExpiredBids(now time.Time) ([]entity.Bid, error)
+	MarkBidLapsed(id int64) error
+	CountWinningBids(sessionID, itemID int64) (int64, error)
+	GetRunnerUpBid(sessionID, itemID int64) (entity.BidHistory, error)
 }
 
 type bidRepository struct {
@@ -21,3 +37,93 @@ func NewBidRepository(db *gorm.DB) BidRepository {
 func (r *bidRepository) SaveFinalBid(bid *entity.Bid) error {
 	return r.db.Create(bid).Error
 }
+
+func (r *bidRepository) SaveWinningBid(bid *entity.Bid) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(bid).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.AuctionItem{}).Where("id = ?", bid.ItemID).Update("status", "finished").Error
+	})
+}
+
+// SaveBidHistory skips bids already stored, so replaying the redis history
+// after the live writes is safe
+func (r *bidRepository) SaveBidHistory(entries []entity.BidHistory) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error
+}
+
+func (r *bidRepository) GetBidHistoryByItem(sessionID, itemID int64, page, limit int) ([]entity.BidHistory, int64, error) {
+	var history []entity.BidHistory
+	var total int64
+
+	if err := r.db.Model(&entity.BidHistory{}).Where("auction_session_id = ? AND auction_item_id = ?", sessionID, itemID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := r.db.Where("auction_session_id = ? AND auction_item_id = ?", sessionID, itemID).Offset(offset).Limit(limit).Order("amount DESC").Find(&history).Error
+	return history, total, err
+}
+
+func (r *bidRepository) GetBidHistoryByUser(userID int64, page, limit int) ([]entity.BidHistory, int64, error) {
+	var history []entity.BidHistory
+	var total int64
+
+	if err := r.db.Model(&entity.BidHistory{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := r.db.Where("user_id = ?", userID).Offset(offset).Limit(limit).Order("created_at DESC, id DESC").Find(&history).Error
+	return history, total, err
+}
+
+// GetWinningBid returns the item's current winner, the latest bid that has not lapsed
+func (r *bidRepository) GetWinningBid(itemID int64) (entity.Bid, error) {
+	var bid entity.Bid
+	err := r.db.Where("auction_item_id = ? AND lapsed = ?", itemID, false).Order("id DESC").First(&bid).Error
+	return bid, err
+}
+
+// GetUnpaidExpiredBids returns winners past their payment deadline without a paid payment
+func (r *bidRepository) GetUnpaidExpiredBids(now time.Time) ([]entity.Bid, error) {
+	var bids []entity.Bid
+	paid := r.db.Model(&entity.Payment{}).
+		Select("1").
+		Where("payments.auction_item_id = bids.auction_item_id AND payments.user_id = bids.user_id AND payments.status IN ?", SettledStatuses)
+
+	err := r.db.Where("lapsed = ? AND payment_deadline < ?", false, now).
+		Where("NOT EXISTS (?)", paid).
+		Order("payment_deadline ASC").
+		Find(&bids).Error
+	return bids, err
+}
+
+func (r *bidRepository) MarkBidLapsed(id int64) error {
+	return r.db.Model(&entity.Bid{}).Where("id = ?", id).Update("lapsed", true).Error
+}
+
+// CountWinningBids counts every winner the item had in a session, lapsed ones included
+func (r *bidRepository) CountWinningBids(sessionID, itemID int64) (int64, error) {
+	var total int64
+	err := r.db.Model(&entity.Bid{}).Where("auction_session_id = ? AND auction_item_id = ?", sessionID, itemID).Count(&total).Error
+	return total, err
+}
+
+// GetRunnerUpBid returns the highest bid in the session by someone who has not won the item yet
+func (r *bidRepository) GetRunnerUpBid(sessionID, itemID int64) (entity.BidHistory, error) {
+	var bid entity.BidHistory
+	winners := r.db.Model(&entity.Bid{}).
+		Select("user_id").
+		Where("auction_session_id = ? AND auction_item_id = ?", sessionID, itemID)
+
+	err := r.db.Where("auction_session_id = ? AND auction_item_id = ?", sessionID, itemID).
+		Where("user_id NOT IN (?)", winners).
+		Order("amount DESC, created_at ASC").
+		First(&bid).Error
+	return bid, err
+}
@@ -9,14 +9,21 @@ import (
 type DonationRepo interface {
 	CreateDonation(donation entity.Donation) error
 	GetDonationByID(id uint) (entity.Donation, error)
-	UpdateDonation(donation entity.Donation) error
+	// UpdateDonation changes the details of a donation that is still pending or
+	// needs_info, false when a review moved it on first. resubmit sends a
+	// needs_info donation back to pending in the same transaction
+	UpdateDonation(donation entity.Donation, resubmit bool, changedBy uint) (bool, error)
 	DeleteDonation(id uint) error
 
 	// Admin-only or filtered queries with pagination
 	GetAllDonations(page, limit int) ([]entity.Donation, int64, error)
 	GetDonationsByUserID(userID uint, page, limit int) ([]entity.Donation, int64, error)
 
-	PatchDonation(donation entity.Donation) error
+	// PatchDonation moves the donation to donation.Status when it is still in
+	// from, false when another review changed it first. auctionItem is created
+	// with it when the donation has none yet, nil to skip
+	PatchDonation(donation entity.Donation, from entity.StatusDonation, changedBy uint, auctionItem *entity.AuctionItem) (bool, error)
+	GetStatusHistory(donationID uint) ([]entity.DonationStatusHistory, error)
 	CreateFinalDonation(donationID uint) error
 }
 
@@ -46,7 +53,12 @@ func (r *donationRepo) CreateDonation(donation entity.Donation) error {
 			}
 		}
 
-		return nil
+		// The submission starts the status history
+		return tx.Create(&entity.DonationStatusHistory{
+			DonationID: donation.ID,
+			ToStatus:   donation.Status,
+			ChangedBy:  donation.UserID,
+		}).Error
 	})
 }
 
@@ -86,18 +98,96 @@ func (r *donationRepo) GetDonationByID(id uint) (entity.Donation, error) {
 	return donation, err
 }
 
-func (r *donationRepo) UpdateDonation(donation entity.Donation) error {
-	return r.db.Save(&donation).Error
+func (r *donationRepo) UpdateDonation(donation entity.Donation, resubmit bool, changedBy uint) (bool, error) {
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Only the details, status and review comment belong to the review
+		res := tx.Model(&entity.Donation{}).
+			Where("id = ? AND status IN ?", donation.ID, []entity.StatusDonation{entity.StatusPending, entity.StatusNeedsInfo}).
+			Updates(map[string]interface{}{
+				"title":       donation.Title,
+				"description": donation.Description,
+				"category":    donation.Category,
+				"condition":   donation.Condition,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		// Photos sent with the edit replace the old ones
+		if len(donation.Photos) > 0 {
+			if err := tx.Where("donation_id = ?", donation.ID).Delete(&entity.DonationPhoto{}).Error; err != nil {
+				return err
+			}
+			for i := range donation.Photos {
+				donation.Photos[i].ID = 0
+				donation.Photos[i].DonationID = donation.ID
+			}
+			if err := tx.Create(&donation.Photos).Error; err != nil {
+				return err
+			}
+		}
+
+		if resubmit {
+			res := tx.Model(&entity.Donation{}).
+				Where("id = ? AND status = ?", donation.ID, entity.StatusNeedsInfo).
+				Updates(map[string]interface{}{
+					"status":         entity.StatusPending,
+					"review_comment": "",
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected > 0 {
+				from := entity.StatusNeedsInfo
+				if err := tx.Create(&entity.DonationStatusHistory{
+					DonationID: donation.ID,
+					FromStatus: &from,
+					ToStatus:   entity.StatusPending,
+					ChangedBy:  changedBy,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		changed = true
+		return nil
+	})
+	return changed, err
 }
 
 func (r *donationRepo) DeleteDonation(id uint) error {
 	return r.db.Delete(&entity.Donation{}, id).Error
 }
 
-func (r *donationRepo) PatchDonation(donation entity.Donation) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Update donation status
-		if err := tx.Model(&entity.Donation{}).Where("id = ?", donation.ID).Updates(donation).Error; err != nil {
+func (r *donationRepo) PatchDonation(donation entity.Donation, from entity.StatusDonation, changedBy uint, auctionItem *entity.AuctionItem) (bool, error) {
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Update status only if nobody moved the donation since it was read
+		res := tx.Model(&entity.Donation{}).
+			Where("id = ? AND status = ?", donation.ID, from).
+			Updates(map[string]interface{}{
+				"status":         donation.Status,
+				"review_comment": donation.ReviewComment,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&entity.DonationStatusHistory{
+			DonationID: donation.ID,
+			FromStatus: &from,
+			ToStatus:   donation.Status,
+			ChangedBy:  changedBy,
+			Comment:    donation.ReviewComment,
+		}).Error; err != nil {
 			return err
 		}
 
@@ -116,8 +206,30 @@ func (r *donationRepo) PatchDonation(donation entity.Donation) error {
 			}
 		}
 
+		// A donation is auctioned once, the unique index backs this up
+		if auctionItem != nil {
+			var count int64
+			if err := tx.Model(&entity.AuctionItem{}).Where("donation_id = ?", donation.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				auctionItem.DonationID = int64(donation.ID)
+				if err := tx.Create(auctionItem).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		changed = true
 		return nil
 	})
+	return changed, err
+}
+
+func (r *donationRepo) GetStatusHistory(donationID uint) ([]entity.DonationStatusHistory, error) {
+	var history []entity.DonationStatusHistory
+	err := r.db.Where("donation_id = ?", donationID).Order("created_at ASC, id ASC").Find(&history).Error
+	return history, err
 }
 
 func (r *donationRepo) CreateFinalDonation(donationID uint) error {
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fakeOIDCKeyId = "fake-oidc-key"
+
+// FakeOIDCServer is an OpenID Connect provider for local development and
+// tests. It signs in the email passed as login_hint (donor@example.com when
+// missing) without asking for a password, and keeps codes in memory
+type FakeOIDCServer struct {
+	issuer   string
+	basePath string
+	key      *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]fakeOIDCCode
+}
+
+type fakeOIDCCode struct {
+	clientId    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	email       string
+	expiresAt   time.Time
+}
+
+// NewFakeOIDCServer serves the provider at issuer, which must be the URL the
+// handler is reachable at
+func NewFakeOIDCServer(issuer string) (*FakeOIDCServer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer = strings.TrimSuffix(issuer, "/")
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FakeOIDCServer{issuer: issuer, basePath: u.Path, key: key, codes: map[string]fakeOIDCCode{}}, nil
+}
+
+func (s *FakeOIDCServer) Issuer() string {
+	return s.issuer
+}
+
+func (s *FakeOIDCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, s.basePath) {
+	case "/.well-known/openid-configuration":
+		writeFakeOIDCJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                s.issuer,
+			"authorization_endpoint":                s.issuer + "/authorize",
+			"token_endpoint":                        s.issuer + "/token",
+			"jwks_uri":                              s.issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"code_challenge_methods_supported":      []string{"S256"},
+		})
+	case "/jwks":
+		writeFakeOIDCJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": fakeOIDCKeyId,
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+			}},
+		})
+	case "/authorize":
+		s.authorize(w, r)
+	case "/token":
+		s.token(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *FakeOIDCServer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" || q.Get("client_id") == "" || q.Get("response_type") != "code" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.ToLower(q.Get("login_hint"))
+	if email == "" {
+		email = "donor@example.com"
+	}
+
+	code := randomFakeOIDCValue()
+	s.mu.Lock()
+	s.codes[code] = fakeOIDCCode{
+		clientId:    q.Get("client_id"),
+		redirectURI: redirectURI.String(),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		email:       email,
+		expiresAt:   time.Now().Add(time.Minute),
+	}
+	s.mu.Unlock()
+
+	params := redirectURI.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirectURI.RawQuery = params.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *FakeOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeFakeOIDCJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	clientId, _, ok := r.BasicAuth()
+	if !ok {
+		clientId = r.PostForm.Get("client_id")
+	}
+
+	s.mu.Lock()
+	code, found := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found || time.Now().After(code.expiresAt) ||
+		code.clientId != clientId ||
+		code.redirectURI != r.PostForm.Get("redirect_uri") ||
+		code.challenge != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		writeFakeOIDCJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            s.issuer,
+		"sub":            "fake-" + code.email,
+		"aud":            code.clientId,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          code.nonce,
+		"email":          code.email,
+		"email_verified": true,
+		"name":           strings.Split(code.email, "@")[0],
+	})
+	idToken.Header["kid"] = fakeOIDCKeyId
+
+	signed, err := idToken.SignedString(s.key)
+	if err != nil {
+		writeFakeOIDCJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeFakeOIDCJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomFakeOIDCValue(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func randomFakeOIDCValue() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeFakeOIDCJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
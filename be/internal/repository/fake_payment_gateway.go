@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"milestone3/be/internal/dto"
+)
+
+var ErrFakeTransactionNotFound = errors.New("transaction not found")
+
+// FakePaymentGateway keeps transactions in memory with Midtrans transaction
+// statuses, so local development and tests run without a Midtrans account
+type FakePaymentGateway struct {
+	mu           sync.Mutex
+	transactions map[string]*fakeTransaction
+	autoSettle   bool
+	seq          int
+	notify       func(orderId string)
+}
+
+type fakeTransaction struct {
+	transactionId string
+	amount        float64
+	paymentType   string
+	status        string
+}
+
+// NewFakePaymentGateway settles every charge right away when autoSettle is set,
+// otherwise charges stay pending until Settle or Expire is called, from Go or
+// over HTTP
+func NewFakePaymentGateway(autoSettle bool) *FakePaymentGateway {
+	return &FakePaymentGateway{
+		transactions: map[string]*fakeTransaction{},
+		autoSettle:   autoSettle,
+	}
+}
+
+func (g *FakePaymentGateway) Charge(req ChargeRequest) (dto.PaymentResponse, error) {
+	paymentType := req.PaymentType
+	if paymentType == "" {
+		paymentType = PaymentTypeQris
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.seq++
+	res := dto.PaymentResponse{
+		OrderId:       req.OrderId,
+		TransactionId: fmt.Sprintf("FAKE-%d", g.seq),
+		PaymentType:   paymentType,
+	}
+
+	switch paymentType {
+	case PaymentTypeQris, PaymentTypeGopay, PaymentTypeCreditCard:
+		res.PaymentLinkUrl = fmt.Sprintf("https://fake-gateway.local/pay/%s", req.OrderId)
+	case PaymentTypeBankTransfer:
+		res.VaNumber = fmt.Sprintf("8808%08d", g.seq)
+	default:
+		return dto.PaymentResponse{}, ErrUnsupportedPaymentType
+	}
+
+	status := "pending"
+	if g.autoSettle {
+		status = "settlement"
+	}
+	g.transactions[req.OrderId] = &fakeTransaction{
+		transactionId: res.TransactionId,
+		amount:        req.Amount,
+		paymentType:   paymentType,
+		status:        status,
+	}
+
+	return res, nil
+}
+
+func (g *FakePaymentGateway) Status(orderId string) (dto.CheckPaymentStatusResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tx, ok := g.transactions[orderId]
+	if !ok {
+		return dto.CheckPaymentStatusResponse{}, ErrFakeTransactionNotFound
+	}
+
+	return dto.CheckPaymentStatusResponse{
+		OrderId:       orderId,
+		TransactionId: tx.transactionId,
+		PaymentStatus: tx.status,
+		GrossAmount:   fmt.Sprintf("%.2f", tx.amount),
+	}, nil
+}
+
+func (g *FakePaymentGateway) Cancel(orderId string) error {
+	return g.setStatus(orderId, "cancel", "pending")
+}
+
+func (g *FakePaymentGateway) Refund(orderId string, amount float64, reason string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tx, ok := g.transactions[orderId]
+	if !ok {
+		return ErrFakeTransactionNotFound
+	}
+	if (tx.status != "settlement" && tx.status != "partial_refund") || amount > tx.amount {
+		return fmt.Errorf("cannot refund %.0f of %s transaction", amount, tx.status)
+	}
+
+	if amount == tx.amount {
+		tx.status = "refund"
+	} else {
+		tx.status = "partial_refund"
+	}
+	tx.amount -= amount
+	return nil
+}
+
+// Settle marks a pending charge paid, as if the customer completed it
+func (g *FakePaymentGateway) Settle(orderId string) error {
+	return g.setStatus(orderId, "settlement", "pending")
+}
+
+// Expire marks a pending charge expired, as if the customer never paid
+func (g *FakePaymentGateway) Expire(orderId string) error {
+	return g.setStatus(orderId, "expire", "pending")
+}
+
+func (g *FakePaymentGateway) setStatus(orderId, status, from string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tx, ok := g.transactions[orderId]
+	if !ok {
+		return ErrFakeTransactionNotFound
+	}
+	if tx.status != from {
+		return fmt.Errorf("cannot %s a %s transaction", status, tx.status)
+	}
+
+	tx.status = status
+	return nil
+}
+
+// OnStatusChange is called with the order id after a charge is settled or
+// expired over HTTP, standing in for the Midtrans notification
+func (g *FakePaymentGateway) OnStatusChange(notify func(orderId string)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.notify = notify
+}
+
+// ServeHTTP lets a developer finish a pending charge the way a customer would,
+// POST .../{order id}/settle or .../{order id}/expire under wherever it is mounted
+func (g *FakePaymentGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if r.Method != http.MethodPost || len(parts) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+	orderId, action := parts[len(parts)-2], parts[len(parts)-1]
+
+	var err error
+	switch action {
+	case "settle":
+		err = g.Settle(orderId)
+	case "expire":
+		err = g.Expire(orderId)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, ErrFakeTransactionNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	case err != nil:
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+
+	g.mu.Lock()
+	notify := g.notify
+	g.mu.Unlock()
+	if notify != nil {
+		notify(orderId)
+	}
+
+	status, _ := g.Status(orderId)
+	json.NewEncoder(w).Encode(status)
+}
@@ -21,7 +21,7 @@ func NewFinalDonationRepository(db *gorm.DB) FinalDonationRepository {
 	return &finalDonationRepository{db: db}
 }
 
-// Return final_donations where the related donation has status = entity.StatusVerifiedForDonation
+// Return final_donations where the related donation was verified for donation, it may have been picked up or archived since
 func (r *finalDonationRepository) GetAllFinalDonations(page, limit int) ([]entity.FinalDonation, int64, error) {
 	var finalDonations []entity.FinalDonation
 	var total int64
@@ -29,7 +29,7 @@ func (r *finalDonationRepository) GetAllFinalDonations(page, limit int) ([]entit
 	// Count total records
 	if err := r.db.Model(&entity.FinalDonation{}).
 		Joins("JOIN donations d ON d.id = final_donations.donation_id").
-		Where("d.status = ?", entity.StatusVerifiedForDonation).
+		Where("d.status IN ?", entity.FinalDonationStatuses).
 		Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
@@ -38,7 +38,7 @@ func (r *finalDonationRepository) GetAllFinalDonations(page, limit int) ([]entit
 	offset := (page - 1) * limit
 	err := r.db.
 		Joins("JOIN donations d ON d.id = final_donations.donation_id").
-		Where("d.status = ?", entity.StatusVerifiedForDonation).
+		Where("d.status IN ?", entity.FinalDonationStatuses).
 		Preload("Donation").
 		Offset(offset).Limit(limit).
 		Order("final_donations.created_at DESC").
@@ -51,7 +51,7 @@ func (r *finalDonationRepository) GetAllFinalDonationsByUserID(userID int) ([]en
 	var finalDonations []entity.FinalDonation
 	err := r.db.
 		Joins("JOIN donations d ON d.id = final_donations.donation_id").
-		Where("d.user_id = ? AND d.status = ?", userID, entity.StatusVerifiedForDonation).
+		Where("d.user_id = ? AND d.status IN ?", userID, entity.FinalDonationStatuses).
 		Preload("Donation").
 		Find(&finalDonations).Error
 	return finalDonations, err
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotentResponse is the first response to a request made with an
+// Idempotency-Key, replayed for every retry with the same key
+type IdempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyRepository keeps idempotent responses in redis
+type IdempotencyRepository interface {
+	// Reserve claims key for a request with fingerprint, when the key is
+	// already taken the stored response (possibly not completed yet) is returned
+	Reserve(key, fingerprint string, lockTTL time.Duration) (existing *IdempotentResponse, reserved bool, err error)
+	// Extend keeps a reservation made with fingerprint for another lockTTL, a
+	// saved response or someone else's reservation is left alone
+	Extend(key, fingerprint string, lockTTL time.Duration) error
+	Save(key string, res IdempotentResponse, ttl time.Duration) error
+	Release(key string) error
+}
+
+type idempotencyRepository struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewIdempotencyRepository(client *redis.Client, ctx context.Context) IdempotencyRepository {
+	return &idempotencyRepository{client: client, ctx: ctx}
+}
+
+// ErrIdempotencyKeyBusy is returned when the key kept changing hands while it
+// was being reserved, the caller treats it like a request still in progress
+var ErrIdempotencyKeyBusy = errors.New("idempotency key is busy")
+
+// reserveAttempts bounds how often Reserve goes back to SETNX when the key
+// expires between SETNX and GET
+const reserveAttempts = 3
+
+func (r *idempotencyRepository) Reserve(key, fingerprint string, lockTTL time.Duration) (*IdempotentResponse, bool, error) {
+	pending, err := json.Marshal(IdempotentResponse{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, false, err
+	}
+
+	for i := 0; i < reserveAttempts; i++ {
+		reserved, err := r.client.SetNX(r.ctx, key, pending, lockTTL).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if reserved {
+			return nil, true, nil
+		}
+
+		raw, err := r.client.Get(r.ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			// expired between SETNX and GET, someone else may take it before
+			// the next SETNX so read it again then
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		var existing IdempotentResponse
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return nil, false, err
+		}
+		return &existing, false, nil
+	}
+
+	return nil, false, ErrIdempotencyKeyBusy
+}
+
+// extendScript renews the lock only while the key still holds the reservation
+var extendScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+func (r *idempotencyRepository) Extend(key, fingerprint string, lockTTL time.Duration) error {
+	pending, err := json.Marshal(IdempotentResponse{Fingerprint: fingerprint})
+	if err != nil {
+		return err
+	}
+
+	return extendScript.Run(r.ctx, r.client, []string{key}, pending, lockTTL.Milliseconds()).Err()
+}
+
+func (r *idempotencyRepository) Save(key string, res IdempotentResponse, ttl time.Duration) error {
+	res.Completed = true
+	payload, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(r.ctx, key, payload, ttl).Err()
+}
+
+func (r *idempotencyRepository) Release(key string) error {
+	return r.client.Del(r.ctx, key).Err()
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := NewIdempotencyRepository(client, context.Background())
+
+	existing, reserved, err := repo.Reserve("idempotency:1:key", "abc", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Nil(t, existing)
+
+	// a retry while the first request runs sees it in progress
+	existing, reserved, err = repo.Reserve("idempotency:1:key", "abc", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	require.NotNil(t, existing)
+	assert.False(t, existing.Completed)
+	assert.Equal(t, "abc", existing.Fingerprint)
+
+	// a slow request keeps its reservation past the lock TTL
+	mr.FastForward(50 * time.Second)
+	require.NoError(t, repo.Extend("idempotency:1:key", "abc", time.Minute))
+	mr.FastForward(50 * time.Second)
+	existing, reserved, err = repo.Reserve("idempotency:1:key", "abc", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+
+	require.NoError(t, repo.Save("idempotency:1:key", IdempotentResponse{
+		Fingerprint: "abc",
+		StatusCode:  201,
+		ContentType: "application/json",
+		Body:        []byte(`{"order_id":"YDR-1"}`),
+	}, time.Hour))
+
+	existing, reserved, err = repo.Reserve("idempotency:1:key", "abc", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	require.NotNil(t, existing)
+	assert.True(t, existing.Completed)
+	assert.Equal(t, 201, existing.StatusCode)
+	assert.JSONEq(t, `{"order_id":"YDR-1"}`, string(existing.Body))
+
+	// a late extend does not cut the saved response short
+	require.NoError(t, repo.Extend("idempotency:1:key", "abc", time.Minute))
+	assert.Equal(t, time.Hour, mr.TTL("idempotency:1:key"))
+
+	mr.FastForward(2 * time.Hour)
+	_, reserved, err = repo.Reserve("idempotency:1:key", "abc", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved, "key is free again after the TTL")
+
+	require.NoError(t, repo.Release("idempotency:1:key"))
+	_, reserved, err = repo.Reserve("idempotency:1:key", "def", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved, "released keys can be reserved again")
+}
+
+// expiringHook runs after every command, the tests use it to move the clock
+// or take the key between Reserve's SETNX and GET
+type expiringHook struct {
+	after func(cmd redis.Cmder, err error)
+}
+
+func (h expiringHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h expiringHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		h.after(cmd, err)
+		return err
+	}
+}
+
+func (h expiringHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestIdempotencyRepository_ReserveKeyExpiresBeforeGet(t *testing.T) {
+	const key = "idempotency:1:key"
+	other := `{"fingerprint":"other","completed":false}`
+
+	t.Run("another request takes the key before the retry", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		repo := NewIdempotencyRepository(client, context.Background())
+		require.NoError(t, mr.Set(key, other))
+		mr.SetTTL(key, time.Minute)
+
+		sets := 0
+		client.AddHook(expiringHook{after: func(cmd redis.Cmder, err error) {
+			switch cmd.Name() {
+			case "set":
+				sets++
+				if sets == 1 {
+					mr.FastForward(time.Minute)
+				}
+			case "get":
+				if errors.Is(err, redis.Nil) {
+					_ = mr.Set(key, other)
+					mr.SetTTL(key, time.Minute)
+				}
+			}
+		}})
+
+		existing, reserved, err := repo.Reserve(key, "abc", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, reserved)
+		require.NotNil(t, existing, "the key taken in between is returned, not nil")
+		assert.Equal(t, "other", existing.Fingerprint)
+	})
+
+	t.Run("key keeps changing hands", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		repo := NewIdempotencyRepository(client, context.Background())
+		require.NoError(t, mr.Set(key, other))
+		mr.SetTTL(key, time.Minute)
+
+		client.AddHook(expiringHook{after: func(cmd redis.Cmder, err error) {
+			switch cmd.Name() {
+			case "set":
+				mr.FastForward(time.Minute)
+			case "get":
+				_ = mr.Set(key, other)
+				mr.SetTTL(key, time.Minute)
+			}
+		}})
+
+		existing, reserved, err := repo.Reserve(key, "abc", time.Minute)
+		assert.ErrorIs(t, err, ErrIdempotencyKeyBusy)
+		assert.False(t, reserved)
+		assert.Nil(t, existing)
+	})
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer_AppendsMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	mailer := NewFileMailer(path, "no-reply@example.com")
+
+	require.NoError(t, mailer.Send("a@example.com", "Verify your email", "first\nlink"))
+	require.NoError(t, mailer.Send("b@example.com", "Reset your password", "second"))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(raw)
+
+	assert.Contains(t, content, "From: no-reply@example.com\r\n")
+	assert.Contains(t, content, "To: a@example.com\r\nSubject: Verify your email\r\n")
+	assert.Contains(t, content, "first\r\nlink")
+	assert.Contains(t, content, "To: b@example.com\r\nSubject: Reset your password\r\n")
+}
+
+func TestFileMailer_LogsWithoutPath(t *testing.T) {
+	assert.NoError(t, NewFileMailer("", "no-reply@example.com").Send("a@example.com", "subject", "body"))
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// OIDCIdentity is what a verified ID token says about the user
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Nonce         string
+}
+
+// OIDCProvider is an OpenID Connect provider users can sign in with, Google
+// in production and FakeOIDCServer for local development and tests
+type OIDCProvider interface {
+	Name() string
+	// AuthCodeURL is the provider's sign in page, the code comes back with PKCE.
+	// loginHint is an optional email to sign in as
+	AuthCodeURL(state, nonce, verifier, loginHint string) (string, error)
+	// Exchange trades the code for tokens and returns the verified ID token claims
+	Exchange(code, verifier string) (OIDCIdentity, error)
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type oidcProvider struct {
+	name   string
+	issuer string
+	config oauth2.Config
+	client *http.Client
+
+	mu          sync.Mutex
+	meta        *oidcMetadata
+	keys        map[string]*rsa.PublicKey
+	keysFetched time.Time
+}
+
+// NewOIDCProvider reads the provider's discovery document on first use, so
+// the API starts even when the provider is unreachable
+func NewOIDCProvider(name, issuer, clientId, clientSecret, redirectURL string) OIDCProvider {
+	return &oidcProvider{
+		name:   name,
+		issuer: strings.TrimSuffix(issuer, "/"),
+		config: oauth2.Config{
+			ClientID:     clientId,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{"openid", "email", "profile"},
+		},
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthCodeURL(state, nonce, verifier, loginHint string) (string, error) {
+	config, err := p.oauthConfig()
+	if err != nil {
+		return "", err
+	}
+
+	opts := []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("nonce", nonce), oauth2.S256ChallengeOption(verifier)}
+	if loginHint != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("login_hint", loginHint))
+	}
+	return config.AuthCodeURL(state, opts...), nil
+}
+
+func (p *oidcProvider) Exchange(code, verifier string) (OIDCIdentity, error) {
+	config, err := p.oauthConfig()
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), oauth2.HTTPClient, p.client), 10*time.Second)
+	defer cancel()
+
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return OIDCIdentity{}, fmt.Errorf("%w: missing from token response", ErrInvalidIDToken)
+	}
+
+	return p.verify(rawIDToken)
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+	Nonce         string      `json:"nonce"`
+	AuthorizedBy  string      `json:"azp"`
+}
+
+// verify checks the signature against the provider's keys and that the token
+// was issued by the provider for this client
+func (p *oidcProvider) verify(rawIDToken string) (OIDCIdentity, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims, p.signingKey,
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.AuthorizedBy != "" && claims.AuthorizedBy != p.config.ClientID {
+		return OIDCIdentity{}, fmt.Errorf("%w: issued to another client", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return OIDCIdentity{}, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	// some providers send email_verified as a string
+	verified := claims.EmailVerified == true || claims.EmailVerified == "true"
+
+	return OIDCIdentity{
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: verified,
+		Name:          claims.Name,
+		Nonce:         claims.Nonce,
+	}, nil
+}
+
+func (p *oidcProvider) oauthConfig() (oauth2.Config, error) {
+	meta, err := p.metadata()
+	if err != nil {
+		return oauth2.Config{}, err
+	}
+
+	config := p.config
+	config.Endpoint = oauth2.Endpoint{AuthURL: meta.AuthorizationEndpoint, TokenURL: meta.TokenEndpoint}
+	return config, nil
+}
+
+func (p *oidcProvider) metadata() (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta oidcMetadata
+	if err := p.getJSON(p.issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery %s: %w", p.name, err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("oidc discovery %s: issuer %q does not match %q", p.name, meta.Issuer, p.issuer)
+	}
+
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// signingKey finds the key of the token's kid, unknown kids refetch the key
+// set at most once a minute since providers rotate their keys
+func (p *oidcProvider) signingKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	meta, err := p.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < time.Minute {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := p.fetchKeys(meta.JwksURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys, p.keysFetched = keys, time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (p *oidcProvider) fetchKeys(jwksURI string) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc keys %s: %w", p.name, err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	return keys, nil
+}
+
+func (p *oidcProvider) getJSON(url string, out interface{}) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// fakeOIDC serves a FakeOIDCServer under /oidc like the API does locally
+func fakeOIDC(t *testing.T) (*FakeOIDCServer, *httptest.Server) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	fake, err := NewFakeOIDCServer(srv.URL + "/oidc")
+	require.NoError(t, err)
+	mux.Handle("/oidc/", fake)
+	return fake, srv
+}
+
+// signIn follows the provider's sign in page and returns the query of the redirect
+func signIn(t *testing.T, authURL string) url.Values {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://app.example.com/auth/oidc/fake/callback", location.Scheme+"://"+location.Host+location.Path)
+	return location.Query()
+}
+
+func TestOIDCProvider_FakeServer(t *testing.T) {
+	fake, _ := fakeOIDC(t)
+	provider := NewOIDCProvider("fake", fake.Issuer(), "client-1", "secret", "https://app.example.com/auth/oidc/fake/callback")
+	assert.Equal(t, "fake", provider.Name())
+
+	verifier := oauth2.GenerateVerifier()
+	authURL, err := provider.AuthCodeURL("state-1", "nonce-1", verifier, "")
+	require.NoError(t, err)
+
+	t.Run("sign in returns the verified identity", func(t *testing.T) {
+		hinted, err := provider.AuthCodeURL("state-1", "nonce-1", verifier, "Donor@Example.com")
+		require.NoError(t, err)
+		callback := signIn(t, hinted)
+		assert.Equal(t, "state-1", callback.Get("state"))
+
+		identity, err := provider.Exchange(callback.Get("code"), verifier)
+		require.NoError(t, err)
+		assert.Equal(t, "fake-donor@example.com", identity.Subject)
+		assert.Equal(t, "donor@example.com", identity.Email)
+		assert.True(t, identity.EmailVerified)
+		assert.Equal(t, "nonce-1", identity.Nonce)
+		assert.Equal(t, "donor", identity.Name)
+	})
+
+	t.Run("codes work once", func(t *testing.T) {
+		code := signIn(t, authURL).Get("code")
+		_, err := provider.Exchange(code, verifier)
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(code, verifier)
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong PKCE verifier is refused", func(t *testing.T) {
+		code := signIn(t, authURL).Get("code")
+		_, err := provider.Exchange(code, oauth2.GenerateVerifier())
+		assert.Error(t, err)
+	})
+}
+
+func TestOIDCProvider_Verify(t *testing.T) {
+	fake, _ := fakeOIDC(t)
+	provider := NewOIDCProvider("fake", fake.Issuer(), "client-1", "secret", "https://app.example.com/cb").(*oidcProvider)
+	forger, err := NewFakeOIDCServer(fake.Issuer())
+	require.NoError(t, err)
+
+	sign := func(key *rsa.PrivateKey, change func(jwt.MapClaims)) string {
+		claims := jwt.MapClaims{
+			"iss":            fake.Issuer(),
+			"sub":            "subject-1",
+			"aud":            "client-1",
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"email":          "donor@example.com",
+			"email_verified": "true",
+		}
+		change(claims)
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = fakeOIDCKeyId
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	identity, err := provider.verify(sign(fake.key, func(jwt.MapClaims) {}))
+	require.NoError(t, err)
+	assert.True(t, identity.EmailVerified, "email_verified may be a string")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not a jwt", "not-a-jwt"},
+		{"signed with another key", sign(forger.key, func(jwt.MapClaims) {})},
+		{"expired", sign(fake.key, func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() })},
+		{"another issuer", sign(fake.key, func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" })},
+		{"another audience", sign(fake.key, func(c jwt.MapClaims) { c["aud"] = "client-2" })},
+		{"authorized party is another client", sign(fake.key, func(c jwt.MapClaims) { c["aud"] = []string{"client-1", "client-2"}; c["azp"] = "client-2" })},
+		{"no subject", sign(fake.key, func(c jwt.MapClaims) { delete(c, "sub") })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.verify(tt.token)
+			assert.ErrorIs(t, err, ErrInvalidIDToken)
+		})
+	}
+}
+
+func TestOIDCProvider_Discovery(t *testing.T) {
+	_, srv := fakeOIDC(t)
+
+	provider := NewOIDCProvider("fake", srv.URL+"/oidc/", "client-1", "secret", "https://app.example.com/cb")
+	_, err := provider.AuthCodeURL("s", "n", oauth2.GenerateVerifier(), "")
+	assert.NoError(t, err, "a trailing slash is the same issuer")
+
+	missing := NewOIDCProvider("fake", srv.URL+"/other", "client-1", "secret", "https://app.example.com/cb")
+	_, err = missing.AuthCodeURL("s", "n", oauth2.GenerateVerifier(), "")
+	assert.Error(t, err)
+
+	// the document served here names another issuer
+	mux := http.NewServeMux()
+	other := httptest.NewServer(mux)
+	t.Cleanup(other.Close)
+	impostor, err := NewFakeOIDCServer("https://accounts.example.com/oidc")
+	require.NoError(t, err)
+	mux.Handle("/oidc/", impostor)
+
+	mismatch := NewOIDCProvider("fake", other.URL+"/oidc", "client-1", "secret", "https://app.example.com/cb")
+	_, err = mismatch.AuthCodeURL("s", "n", oauth2.GenerateVerifier(), "")
+	assert.ErrorContains(t, err, "does not match")
+}
+
+func TestOIDCStateRepository(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := NewOIDCStateRepository(client, context.Background())
+
+	login := OIDCLoginState{Provider: "google", Nonce: "nonce", Verifier: "verifier"}
+	require.NoError(t, repo.Save("state-1", login, 10*time.Minute))
+
+	got, found, err := repo.Take("state-1")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, login, got)
+
+	_, found, err = repo.Take("state-1")
+	require.NoError(t, err)
+	assert.False(t, found, "a state is used once")
+
+	require.NoError(t, repo.Save("state-2", login, 10*time.Minute))
+	mr.FastForward(10 * time.Minute)
+	_, found, err = repo.Take("state-2")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
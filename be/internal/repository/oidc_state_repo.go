@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// OIDCLoginState is kept between sending the user to the provider and the
+// callback, the state parameter is its key
+type OIDCLoginState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// OIDCStateRepository keeps social logins that are waiting for their callback
+type OIDCStateRepository interface {
+	Save(state string, login OIDCLoginState, ttl time.Duration) error
+	// Take returns the login and forgets it so a callback works only once,
+	// found is false for unknown or expired states
+	Take(state string) (login OIDCLoginState, found bool, err error)
+}
+
+type oidcStateRepository struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewOIDCStateRepository(client *redis.Client, ctx context.Context) OIDCStateRepository {
+	return &oidcStateRepository{client: client, ctx: ctx}
+}
+
+func oidcStateKey(state string) string {
+	return fmt.Sprintf("oidc:state:%s", state)
+}
+
+func (r *oidcStateRepository) Save(state string, login OIDCLoginState, ttl time.Duration) error {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(r.ctx, oidcStateKey(state), data, ttl).Err()
+}
+
+func (r *oidcStateRepository) Take(state string) (login OIDCLoginState, found bool, err error) {
+	data, err := r.client.GetDel(r.ctx, oidcStateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return OIDCLoginState{}, false, nil
+	}
+	if err != nil {
+		return OIDCLoginState{}, false, err
+	}
+
+	if err := json.Unmarshal(data, &login); err != nil {
+		return OIDCLoginState{}, false, err
+	}
+	return login, true, nil
+}
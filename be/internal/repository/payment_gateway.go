@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"milestone3/be/internal/dto"
+
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
+)
+
+// payment types a gateway can charge
+const (
+	PaymentTypeQris         = "qris"
+	PaymentTypeBankTransfer = "bank_transfer"
+	PaymentTypeGopay        = "gopay"
+	PaymentTypeCreditCard   = "credit_card"
+)
+
+var ErrUnsupportedPaymentType = errors.New("unsupported payment type")
+
+// PaymentGateway is the payment provider, Midtrans in production and a fake
+// for local development and tests
+type PaymentGateway interface {
+	Charge(req ChargeRequest) (dto.PaymentResponse, error)
+	Status(orderId string) (dto.CheckPaymentStatusResponse, error)
+	Cancel(orderId string) error
+	Refund(orderId string, amount float64, reason string) error
+}
+
+type ChargeRequest struct {
+	OrderId     string
+	Amount      float64
+	PaymentType string
+	Bank        string // bank_transfer only
+	CardToken   string // credit_card only, tokenised by the Midtrans JS on the client
+	ExpiresAt   *time.Time
+	Customer    ChargeCustomer
+}
+
+// ChargeCustomer is who pays, shown in the gateway dashboard and its emails
+type ChargeCustomer struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+type midtransGateway struct {
+	client coreapi.Client
+}
+
+// NewMidtransGateway builds a Core API client once, baseURL overrides the
+// environment's API URL when set (e.g. a fake server in tests)
+func NewMidtransGateway(serverKey string, env midtrans.EnvironmentType, baseURL string) PaymentGateway {
+	c := coreapi.Client{}
+	c.New(serverKey, env)
+
+	if baseURL != "" {
+		c.HttpClient = &baseURLClient{HttpClient: c.HttpClient, envURL: env.BaseUrl(), baseURL: baseURL}
+	}
+
+	return &midtransGateway{client: c}
+}
+
+func (g *midtransGateway) Charge(req ChargeRequest) (dto.PaymentResponse, error) {
+	chargeReq := &coreapi.ChargeReq{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  req.OrderId,
+			GrossAmt: int64(req.Amount),
+		},
+		CustomerDetails: &midtrans.CustomerDetails{
+			FName: req.Customer.Name,
+			Email: req.Customer.Email,
+			Phone: req.Customer.Phone,
+		},
+	}
+
+	switch req.PaymentType {
+	case PaymentTypeQris, "":
+		chargeReq.PaymentType = coreapi.PaymentTypeQris
+	case PaymentTypeGopay:
+		chargeReq.PaymentType = coreapi.PaymentTypeGopay
+	case PaymentTypeBankTransfer:
+		chargeReq.PaymentType = coreapi.PaymentTypeBankTransfer
+		chargeReq.BankTransfer = &coreapi.BankTransferDetails{Bank: midtrans.Bank(req.Bank)}
+	case PaymentTypeCreditCard:
+		chargeReq.PaymentType = coreapi.PaymentTypeCreditCard
+		chargeReq.CreditCard = &coreapi.CreditCardDetails{TokenID: req.CardToken, Authentication: true}
+	default:
+		return dto.PaymentResponse{}, ErrUnsupportedPaymentType
+	}
+
+	// the charge should not outlive the winner's payment deadline
+	if req.ExpiresAt != nil {
+		if minutes := int(time.Until(*req.ExpiresAt).Minutes()); minutes > 0 {
+			chargeReq.CustomExpiry = &coreapi.CustomExpiry{
+				ExpiryDuration: minutes,
+				Unit:           "minute",
+			}
+		}
+	}
+
+	// the client turns api error status codes into errors
+	resp, mErr := g.client.ChargeTransaction(chargeReq)
+	if mErr != nil {
+		return dto.PaymentResponse{}, mErr
+	}
+
+	res := dto.PaymentResponse{
+		TransactionId: resp.TransactionID,
+		ExpiryTime:    resp.ExpiryTime,
+		OrderId:       resp.OrderID,
+		PaymentType:   string(chargeReq.PaymentType),
+	}
+
+	switch chargeReq.PaymentType {
+	case coreapi.PaymentTypeQris:
+		res.PaymentLinkUrl = actionURL(resp.Actions, "generate-qr-code")
+	case coreapi.PaymentTypeGopay:
+		res.PaymentLinkUrl = actionURL(resp.Actions, "deeplink-redirect", "generate-qr-code")
+	case coreapi.PaymentTypeBankTransfer:
+		res.VaNumber = resp.PermataVaNumber
+		if len(resp.VaNumbers) > 0 {
+			res.VaNumber = resp.VaNumbers[0].VANumber
+		}
+	case coreapi.PaymentTypeCreditCard:
+		// 3DS page, the card is charged once the customer completes it
+		res.PaymentLinkUrl = resp.RedirectURL
+	}
+
+	return res, nil
+}
+
+func (g *midtransGateway) Status(orderId string) (dto.CheckPaymentStatusResponse, error) {
+	resp, mErr := g.client.CheckTransaction(orderId)
+	if mErr != nil {
+		return dto.CheckPaymentStatusResponse{}, mErr
+	}
+
+	return dto.CheckPaymentStatusResponse{
+		OrderId:       resp.OrderID,
+		TransactionId: resp.TransactionID,
+		PaymentStatus: resp.TransactionStatus,
+		FraudStatus:   resp.FraudStatus,
+		GrossAmount:   resp.GrossAmount,
+	}, nil
+}
+
+func (g *midtransGateway) Cancel(orderId string) error {
+	if _, mErr := g.client.CancelTransaction(orderId); mErr != nil {
+		return mErr
+	}
+	return nil
+}
+
+func (g *midtransGateway) Refund(orderId string, amount float64, reason string) error {
+	_, mErr := g.client.RefundTransaction(orderId, &coreapi.RefundReq{
+		RefundKey: fmt.Sprintf("%s-refund-%d", orderId, time.Now().Unix()),
+		Amount:    int64(amount),
+		Reason:    reason,
+	})
+	if mErr != nil {
+		return mErr
+	}
+	return nil
+}
+
+// actionURL picks the first matching action, the order of actions differs per payment type
+func actionURL(actions []coreapi.Action, names ...string) string {
+	for _, name := range names {
+		for _, action := range actions {
+			if action.Name == name {
+				return action.URL
+			}
+		}
+	}
+	return ""
+}
+
+type baseURLClient struct {
+	midtrans.HttpClient
+	envURL  string
+	baseURL string
+}
+
+func (b *baseURLClient) Call(method string, url string, apiKey *string, options *midtrans.ConfigOptions, body io.Reader, result interface{}) *midtrans.Error {
+	url = strings.Replace(url, b.envURL, b.baseURL, 1)
+	return b.HttpClient.Call(method, url, apiKey, options, body, result)
+}
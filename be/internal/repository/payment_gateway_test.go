@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/midtrans/midtrans-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMidtrans answers the Core API like the sandbox does
+func fakeMidtrans(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/YDR-123/status":
+			w.Write([]byte(`{"status_code":"200","order_id":"YDR-123","transaction_id":"TXN-123","gross_amount":"250000.00","transaction_status":"settlement","fraud_status":"accept"}`))
+
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/charge":
+			var req map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+			switch req["payment_type"] {
+			case "qris":
+				w.Write([]byte(`{"status_code":"201","order_id":"YDR-123","transaction_id":"TXN-123","payment_type":"qris","actions":[{"name":"generate-qr-code","method":"GET","url":"https://sandbox/qr"}]}`))
+			case "bank_transfer":
+				bank := req["bank_transfer"].(map[string]interface{})["bank"]
+				assert.Equal(t, "bca", bank)
+				customer := req["customer_details"].(map[string]interface{})
+				assert.Equal(t, "Budi", customer["first_name"])
+				assert.Equal(t, "budi@example.com", customer["email"])
+				w.Write([]byte(`{"status_code":"201","order_id":"YDR-123","transaction_id":"TXN-123","payment_type":"bank_transfer","va_numbers":[{"bank":"bca","va_number":"12345678901"}]}`))
+			default:
+				w.Write([]byte(`{"status_code":"402","status_message":"Payment channel is not activated."}`))
+			}
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status_code":"404","status_message":"Transaction doesn't exist."}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMidtransGateway_Status(t *testing.T) {
+	srv := fakeMidtrans(t)
+	gateway := NewMidtransGateway("server-key", midtrans.Sandbox, srv.URL)
+
+	res, err := gateway.Status("YDR-123")
+	require.NoError(t, err)
+	assert.Equal(t, "YDR-123", res.OrderId)
+	assert.Equal(t, "TXN-123", res.TransactionId)
+	assert.Equal(t, "settlement", res.PaymentStatus)
+	assert.Equal(t, "accept", res.FraudStatus)
+
+	_, err = gateway.Status("YDR-404")
+	assert.Error(t, err)
+}
+
+func TestMidtransGateway_Charge(t *testing.T) {
+	srv := fakeMidtrans(t)
+	gateway := NewMidtransGateway("server-key", midtrans.Sandbox, srv.URL)
+
+	res, err := gateway.Charge(ChargeRequest{OrderId: "YDR-123", Amount: 250000})
+	require.NoError(t, err)
+	assert.Equal(t, PaymentTypeQris, res.PaymentType)
+	assert.Equal(t, "https://sandbox/qr", res.PaymentLinkUrl)
+
+	res, err = gateway.Charge(ChargeRequest{
+		OrderId:     "YDR-123",
+		Amount:      250000,
+		PaymentType: PaymentTypeBankTransfer,
+		Bank:        "bca",
+		Customer:    ChargeCustomer{Name: "Budi", Email: "budi@example.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PaymentTypeBankTransfer, res.PaymentType)
+	assert.Equal(t, "12345678901", res.VaNumber)
+
+	_, err = gateway.Charge(ChargeRequest{OrderId: "YDR-123", Amount: 250000, PaymentType: PaymentTypeGopay})
+	assert.Error(t, err)
+
+	_, err = gateway.Charge(ChargeRequest{OrderId: "YDR-123", Amount: 250000, PaymentType: "cash"})
+	assert.ErrorIs(t, err, ErrUnsupportedPaymentType)
+}
+
+func TestFakePaymentGateway(t *testing.T) {
+	gateway := NewFakePaymentGateway(false)
+
+	res, err := gateway.Charge(ChargeRequest{OrderId: "YDR-1", Amount: 250000, PaymentType: PaymentTypeBankTransfer, Bank: "bni"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, res.VaNumber)
+
+	status, err := gateway.Status("YDR-1")
+	require.NoError(t, err)
+	assert.Equal(t, "pending", status.PaymentStatus)
+
+	// nothing to refund before the customer pays
+	assert.Error(t, gateway.Refund("YDR-1", 250000, "cancelled"))
+
+	require.NoError(t, gateway.Settle("YDR-1"))
+	require.NoError(t, gateway.Refund("YDR-1", 100000, "partial"))
+	status, _ = gateway.Status("YDR-1")
+	assert.Equal(t, "partial_refund", status.PaymentStatus)
+
+	_, err = gateway.Charge(ChargeRequest{OrderId: "YDR-2", Amount: 250000})
+	require.NoError(t, err)
+	require.NoError(t, gateway.Cancel("YDR-2"))
+	status, _ = gateway.Status("YDR-2")
+	assert.Equal(t, "cancel", status.PaymentStatus)
+
+	_, err = gateway.Status("YDR-404")
+	assert.ErrorIs(t, err, ErrFakeTransactionNotFound)
+}
+
+func TestFakePaymentGateway_AutoSettle(t *testing.T) {
+	gateway := NewFakePaymentGateway(true)
+
+	_, err := gateway.Charge(ChargeRequest{OrderId: "YDR-1", Amount: 250000})
+	require.NoError(t, err)
+
+	status, err := gateway.Status("YDR-1")
+	require.NoError(t, err)
+	assert.Equal(t, "settlement", status.PaymentStatus)
+}
+
+func TestFakePaymentGateway_ServeHTTP(t *testing.T) {
+	gateway := NewFakePaymentGateway(false)
+	var notified []string
+	gateway.OnStatusChange(func(orderId string) {
+		notified = append(notified, orderId)
+	})
+
+	_, err := gateway.Charge(ChargeRequest{OrderId: "YDR-1", Amount: 250000})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/payment-fake/YDR-1/settle", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"settlement"`)
+	assert.Equal(t, []string{"YDR-1"}, notified)
+
+	// settled charges cannot expire
+	rec = httptest.NewRecorder()
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/payment-fake/YDR-1/expire", nil))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = httptest.NewRecorder()
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/payment-fake/YDR-404/settle", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/payment-fake/YDR-1/refund", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, []string{"YDR-1"}, notified)
+}
@@ -2,13 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/entity"
-	"os"
+	"time"
 
-	"github.com/midtrans/midtrans-go"
-	"github.com/midtrans/midtrans-go/coreapi"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepo struct {
@@ -20,104 +20,203 @@ func NewPaymentRepository(db *gorm.DB, ctx context.Context) *PaymentRepo {
 	return &PaymentRepo{db: db, ctx: ctx}
 }
 
+// SettledStatuses are payments the winner completed, refunds included
+var SettledStatuses = []string{"paid", "partially_refunded", "refunded"}
+
 func (pr *PaymentRepo) Create(payment *entity.Payment, orderId string) (error) {
 	payment.OrderId = orderId
-	if err := pr.db.WithContext(pr.ctx).Omit("Status").Preload("User").Create(payment).Error; err != nil {
-		return err
-	}
+	return pr.db.WithContext(pr.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Status").Preload("User").Create(payment).Error; err != nil {
+			return err
+		}
 
-	return nil
+		return recordStatus(tx, payment.Id, nil, "pending", "charge created")
+	})
 }
 
 func (pr *PaymentRepo) GetById(id int) (payment entity.Payment, err error) {
-	if err := pr.db.WithContext(pr.ctx).Preload("User").First(&payment, id).Error; err != nil {
+	err = pr.db.WithContext(pr.ctx).
+		Preload("User").
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC, id ASC") }).
+		First(&payment, id).Error
+	if err != nil {
 		return entity.Payment{}, err
 	}
 
 	return payment, nil
 }
 
-func (pr *PaymentRepo) GetAll() (payment []entity.Payment, err error) {
-	if err := pr.db.WithContext(pr.ctx).Preload("User").Find(&payment).Error; err != nil {
-		return []entity.Payment{}, err
+// GetAll returns one page of payments matching the filter, newest first, with the total count
+func (pr *PaymentRepo) GetAll(filter dto.PaymentFilter) (payment []entity.Payment, total int64, err error) {
+	query := pr.db.WithContext(pr.ctx).Model(&entity.Payment{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	if filter.AuctionItemId != 0 {
+		query = query.Where("auction_item_id = ?", filter.AuctionItemId)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
 	}
 
-	return payment, err
-}
-
-func (pr *PaymentRepo) CreateMidtrans(payment entity.Payment, orderId string) (res dto.PaymentResponse, err error) {
-	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
-	c := coreapi.Client{}
-	c.New(serverKey, midtrans.Sandbox)
-	chargeReq := &coreapi.ChargeReq{
-		PaymentType: coreapi.PaymentTypeQris,
-		TransactionDetails: midtrans.TransactionDetails{
-			OrderID: orderId,
-			GrossAmt: int64(payment.Amount),
-		},
-		CustomerDetails: &midtrans.CustomerDetails{
-			FName: payment.User.Name,
-			Email: payment.User.Email,
-		},
+	if err := query.Count(&total).Error; err != nil {
+		return []entity.Payment{}, 0, err
 	}
-	coreApiResp, _ := c.ChargeTransaction(chargeReq)
-
-	var paymentURL string
-    if len(coreApiResp.Actions) > 0 {
-        paymentURL = coreApiResp.Actions[1].URL
-    }
-
-	resp := dto.PaymentResponse{
-		PaymentLinkUrl: paymentURL,
-		TransactionId: coreApiResp.TransactionID,
-		ExpiryTime: coreApiResp.ExpiryTime,
-		OrderId: coreApiResp.OrderID,
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Preload("User").Order("created_at DESC, id DESC").Offset(offset).Limit(filter.Limit).Find(&payment).Error; err != nil {
+		return []entity.Payment{}, 0, err
 	}
-	return resp, nil
+
+	return payment, total, nil
 }
 
-func (pr *PaymentRepo) CheckPaymentStatusMidtrans(orderId string) (res dto.CheckPaymentStatusResponse, err error) {
-	var payment entity.Payment
-	var auction entity.AuctionItem
-
-	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
-	c := coreapi.Client{}
-	c.New(serverKey, midtrans.Sandbox)
-
-	resp, _ := c.CheckTransaction(orderId)
-	// if err != nil {
-	// 	return res, err
-	// }
-
-	// assign into named variable res
-	res = dto.CheckPaymentStatusResponse{
-		OrderId:        resp.OrderID,
-		TransactionId:  resp.TransactionID,
-		PaymentStatus:  resp.TransactionStatus,
+func (pr *PaymentRepo) CountPaid(auctionItemId int, userId int) (total int64, err error) {
+	err = pr.db.WithContext(pr.ctx).Model(&entity.Payment{}).
+		Where("auction_item_id = ? AND user_id = ? AND status IN ?", auctionItemId, userId, SettledStatuses).
+		Count(&total).Error
+
+	return total, err
+}
+
+func (pr *PaymentRepo) GetPending(auctionItemId int, userId int) (payments []entity.Payment, err error) {
+	err = pr.db.WithContext(pr.ctx).
+		Where("auction_item_id = ? AND user_id = ? AND status = ?", auctionItemId, userId, "pending").
+		Find(&payments).Error
+
+	return payments, err
+}
+
+func (pr *PaymentRepo) ExpirePending(auctionItemId int, userId int) error {
+	return pr.db.WithContext(pr.ctx).Transaction(func(tx *gorm.DB) error {
+		var pending []entity.Payment
+		if err := tx.Where("auction_item_id = ? AND user_id = ? AND status = ?", auctionItemId, userId, "pending").Find(&pending).Error; err != nil {
+			return err
+		}
+
+		for _, payment := range pending {
+			if _, err := transition(tx, payment.OrderId, "pending", "expired", "payment deadline passed"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetPayer returns the user a charge is made for
+func (pr *PaymentRepo) GetPayer(userId int) (user entity.Users, err error) {
+	if err := pr.db.WithContext(pr.ctx).First(&user, userId).Error; err != nil {
+		return entity.Users{}, err
 	}
 
-	switch resp.TransactionStatus {
-	case "settlement":
-		pr.db.Model(&payment).
-			WithContext(pr.ctx).
-			Where("order_id = ?", orderId).
-			Update("status", "paid")
-
-	case "cancel", "expire":
-		// update auction to scheduled
-		pr.db.Model(&auction).
-			Where("id = (?)",
-				pr.db.Model(&payment).
-					Select("auction_item_id").
-					Where("order_id = ?", orderId),
-			).Update("status", "scheduled")
-
-		// update payment to failed
-		pr.db.Model(&payment).
-			WithContext(pr.ctx).
-			Where("order_id = ?", orderId).
-			Update("status", "failed")
+	return user, nil
+}
+
+func (pr *PaymentRepo) GetByOrderId(orderId string) (payment entity.Payment, err error) {
+	if err := pr.db.WithContext(pr.ctx).Where("order_id = ?", orderId).First(&payment).Error; err != nil {
+		return entity.Payment{}, err
 	}
 
-	return res, nil
+	return payment, nil
+}
+
+// GetStalePending returns payments still pending after their expiry, the
+// gateway should have settled or expired them by now
+func (pr *PaymentRepo) GetStalePending(now time.Time) (payments []entity.Payment, err error) {
+	err = pr.db.WithContext(pr.ctx).
+		Where("status = ? AND expires_at < ?", "pending", now).
+		Order("expires_at ASC").
+		Find(&payments).Error
+
+	return payments, err
+}
+
+// GetClosedUnpaidSince returns payments we closed without payment (expired,
+// failed or cancelled) whose expiry is after since
+func (pr *PaymentRepo) GetClosedUnpaidSince(since time.Time) (payments []entity.Payment, err error) {
+	err = pr.db.WithContext(pr.ctx).
+		Where("status IN ? AND expires_at >= ?", []string{"expired", "failed", "cancelled"}, since).
+		Order("expires_at ASC").
+		Find(&payments).Error
+
+	return payments, err
+}
+
+// TransitionStatus moves the payment from one status to another and records it,
+// changed is false when the payment had already left from, e.g. a replayed notification
+func (pr *PaymentRepo) TransitionStatus(orderId string, from string, to string, reason string) (changed bool, err error) {
+	err = pr.db.WithContext(pr.ctx).Transaction(func(tx *gorm.DB) error {
+		changed, err = transition(tx, orderId, from, to, reason)
+		return err
+	})
+
+	return changed, err
+}
+
+// CreateRefund holds a lock on the payment for the whole refund, so concurrent
+// refunds see each other's amounts. issue gets the locked payment and what is
+// left to refund, refunds refund.Amount at the gateway and returns the payment's
+// next status, the refund and the status change are stored together after it
+func (pr *PaymentRepo) CreateRefund(refund *entity.PaymentRefund, orderId string, issue func(payment entity.Payment, remaining float64) (to string, err error)) error {
+	return pr.db.WithContext(pr.ctx).Transaction(func(tx *gorm.DB) error {
+		var payment entity.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderId).First(&payment).Error; err != nil {
+			return err
+		}
+
+		var refunded float64
+		err := tx.Model(&entity.PaymentRefund{}).
+			Where("payment_id = ?", payment.Id).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&refunded).Error
+		if err != nil {
+			return err
+		}
+
+		to, err := issue(payment, payment.Amount-refunded)
+		if err != nil {
+			return err
+		}
+
+		if _, err := transition(tx, orderId, payment.Status, to, refund.Reason); err != nil {
+			return err
+		}
+
+		refund.PaymentId = payment.Id
+		return tx.Create(refund).Error
+	})
+}
+
+var ErrPaymentStatusChanged = errors.New("payment status changed concurrently")
+
+func transition(tx *gorm.DB, orderId string, from string, to string, reason string) (bool, error) {
+	var payment entity.Payment
+	res := tx.Model(&payment).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("order_id = ? AND status = ?", orderId, from).
+		Update("status", to)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+
+	return true, recordStatus(tx, payment.Id, &from, to, reason)
+}
+
+func recordStatus(tx *gorm.DB, paymentId int, from *string, to string, reason string) error {
+	return tx.Create(&entity.PaymentStatusHistory{
+		PaymentId:  paymentId,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+	}).Error
 }
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"milestone3/be/internal/dto"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PhotoURLCache keeps signed URLs of private photos so listing donations does
+// not sign every photo again on each request
+type PhotoURLCache interface {
+	// GetMany returns the cached URLs by object name, misses are left out
+	GetMany(objectNames []string) (map[string]dto.PhotoURL, error)
+	SetMany(urls map[string]dto.PhotoURL, ttl time.Duration) error
+}
+
+type photoURLCache struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewPhotoURLCache(client *redis.Client, ctx context.Context) PhotoURLCache {
+	return &photoURLCache{client: client, ctx: ctx}
+}
+
+func photoURLKey(objectName string) string {
+	return fmt.Sprintf("photo:url:%s", objectName)
+}
+
+func (r *photoURLCache) GetMany(objectNames []string) (map[string]dto.PhotoURL, error) {
+	urls := map[string]dto.PhotoURL{}
+	if len(objectNames) == 0 {
+		return urls, nil
+	}
+
+	keys := make([]string, len(objectNames))
+	for i, name := range objectNames {
+		keys[i] = photoURLKey(name)
+	}
+
+	values, err := r.client.MGet(r.ctx, keys...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var url dto.PhotoURL
+		if err := json.Unmarshal([]byte(s), &url); err != nil {
+			continue
+		}
+		urls[objectNames[i]] = url
+	}
+	return urls, nil
+}
+
+func (r *photoURLCache) SetMany(urls map[string]dto.PhotoURL, ttl time.Duration) error {
+	if len(urls) == 0 || ttl <= 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	for name, url := range urls {
+		data, err := json.Marshal(url)
+		if err != nil {
+			return err
+		}
+		pipe.Set(r.ctx, photoURLKey(name), data, ttl)
+	}
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/dto"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhotoURLCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	cache := NewPhotoURLCache(client, context.Background())
+
+	urls, err := cache.GetMany([]string{"donations/private/1_a.jpg"})
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+
+	expires := time.Now().Add(15 * time.Minute).UTC().Truncate(time.Second)
+	require.NoError(t, cache.SetMany(map[string]dto.PhotoURL{
+		"donations/private/1_a.jpg": {URL: "https://storage.example/a?sig=1", ExpiresAt: expires},
+		"donations/private/2_b.jpg": {URL: "https://storage.example/b?sig=2", ExpiresAt: expires},
+	}, 12*time.Minute))
+
+	urls, err = cache.GetMany([]string{"donations/private/1_a.jpg", "donations/private/3_c.jpg", "donations/private/2_b.jpg"})
+	require.NoError(t, err)
+	assert.Len(t, urls, 2, "misses are left out")
+	assert.Equal(t, "https://storage.example/a?sig=1", urls["donations/private/1_a.jpg"].URL)
+	assert.True(t, expires.Equal(urls["donations/private/2_b.jpg"].ExpiresAt))
+
+	mr.FastForward(13 * time.Minute)
+	urls, err = cache.GetMany([]string{"donations/private/1_a.jpg"})
+	require.NoError(t, err)
+	assert.Empty(t, urls, "entries are dropped before the URLs expire")
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitResult is the state of a bucket after counting one request
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	ResetIn   time.Duration
+}
+
+// RateLimitRepository counts requests in fixed windows
+type RateLimitRepository interface {
+	Allow(key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// LoginAttemptRepository counts failed logins and locks the account once
+// there are too many of them
+type LoginAttemptRepository interface {
+	// LockedFor is how long the account is still locked, zero when it is not
+	LockedFor(account string) (time.Duration, error)
+	// RecordFailure returns the lockout started by this failure, zero when it did not lock
+	RecordFailure(account string, maxAttempts int, window, lockout time.Duration) (time.Duration, error)
+	Reset(account string) error
+}
+
+type rateLimitRepository struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewRateLimitRepository(client *redis.Client, ctx context.Context) RateLimitRepository {
+	return &rateLimitRepository{client: client, ctx: ctx}
+}
+
+// the window starts with the first request, so the count and its expiry are set together
+var rateLimitScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {count, redis.call('PTTL', KEYS[1])}
+`)
+
+func (r *rateLimitRepository) Allow(key string, limit int, window time.Duration) (RateLimitResult, error) {
+	res, err := rateLimitScript.Run(r.ctx, r.client, []string{key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	count, ttl := int(res[0]), time.Duration(res[1])*time.Millisecond
+	if ttl < 0 {
+		ttl = window
+	}
+
+	remaining := limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return RateLimitResult{Allowed: count <= limit, Limit: limit, Remaining: remaining, ResetIn: ttl}, nil
+}
+
+type loginAttemptRepository struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewLoginAttemptRepository(client *redis.Client, ctx context.Context) LoginAttemptRepository {
+	return &loginAttemptRepository{client: client, ctx: ctx}
+}
+
+func loginFailuresKey(account string) string {
+	return fmt.Sprintf("login:failures:%s", account)
+}
+
+func loginLockKey(account string) string {
+	return fmt.Sprintf("login:locked:%s", account)
+}
+
+// reaching the limit swaps the failure count for a lock
+var loginFailureScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if count >= tonumber(ARGV[1]) then
+	redis.call('DEL', KEYS[1])
+	redis.call('SET', KEYS[2], '1', 'PX', ARGV[3])
+	return tonumber(ARGV[3])
+end
+return 0
+`)
+
+func (r *loginAttemptRepository) LockedFor(account string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(r.ctx, loginLockKey(account)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		// -2 missing key, -1 no expiry which the lock always has
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (r *loginAttemptRepository) RecordFailure(account string, maxAttempts int, window, lockout time.Duration) (time.Duration, error) {
+	ms, err := loginFailureScript.Run(r.ctx, r.client,
+		[]string{loginFailuresKey(account), loginLockKey(account)},
+		maxAttempts, window.Milliseconds(), lockout.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (r *loginAttemptRepository) Reset(account string) error {
+	return r.client.Del(r.ctx, loginFailuresKey(account)).Err()
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitRepository(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := NewRateLimitRepository(client, context.Background())
+
+	for i := 1; i <= 3; i++ {
+		res, err := repo.Allow("ratelimit:login:ip:1.2.3.4", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 3, res.Limit)
+		assert.Equal(t, 3-i, res.Remaining)
+		assert.Equal(t, time.Minute, res.ResetIn)
+	}
+
+	res, err := repo.Allow("ratelimit:login:ip:1.2.3.4", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// other buckets are counted on their own
+	res, err = repo.Allow("ratelimit:login:ip:5.6.7.8", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// the window does not slide with later requests
+	mr.FastForward(40 * time.Second)
+	res, err = repo.Allow("ratelimit:login:ip:1.2.3.4", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 20*time.Second, res.ResetIn)
+
+	mr.FastForward(20 * time.Second)
+	res, err = repo.Allow("ratelimit:login:ip:1.2.3.4", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Remaining)
+}
+
+func TestLoginAttemptRepository(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := NewLoginAttemptRepository(client, context.Background())
+
+	locked, err := repo.LockedFor("test@example.com")
+	require.NoError(t, err)
+	assert.Zero(t, locked)
+
+	for i := 0; i < 2; i++ {
+		lockout, err := repo.RecordFailure("test@example.com", 3, 15*time.Minute, 10*time.Minute)
+		require.NoError(t, err)
+		assert.Zero(t, lockout)
+	}
+
+	lockout, err := repo.RecordFailure("test@example.com", 3, 15*time.Minute, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, lockout)
+
+	locked, err = repo.LockedFor("test@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, locked)
+	assert.False(t, mr.Exists(loginFailuresKey("test@example.com")), "the count starts over after the lock")
+
+	mr.FastForward(10 * time.Minute)
+	locked, err = repo.LockedFor("test@example.com")
+	require.NoError(t, err)
+	assert.Zero(t, locked)
+
+	// a successful login forgets earlier failures
+	_, err = repo.RecordFailure("test@example.com", 3, 15*time.Minute, 10*time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, repo.Reset("test@example.com"))
+	assert.False(t, mr.Exists(loginFailuresKey("test@example.com")))
+
+	// failures outside the window are forgotten
+	_, err = repo.RecordFailure("test@example.com", 3, 15*time.Minute, 10*time.Minute)
+	require.NoError(t, err)
+	mr.FastForward(15 * time.Minute)
+	assert.False(t, mr.Exists(loginFailuresKey("test@example.com")))
+}
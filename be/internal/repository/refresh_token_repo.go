@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"milestone3/be/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepo struct {
+	ctx context.Context
+	db  *gorm.DB
+}
+
+func NewRefreshTokenRepo(db *gorm.DB, ctx context.Context) *RefreshTokenRepo {
+	return &RefreshTokenRepo{db: db, ctx: ctx}
+}
+
+func (rr *RefreshTokenRepo) Create(token *entity.RefreshToken) error {
+	return rr.db.WithContext(rr.ctx).Create(token).Error
+}
+
+func (rr *RefreshTokenRepo) GetByHash(hash string) (token entity.RefreshToken, err error) {
+	if err := rr.db.WithContext(rr.ctx).First(&token, "token_hash = ?", hash).Error; err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	return token, nil
+}
+
+// Rotate revokes the old token and stores its replacement together, rotated is
+// false when the old token was already revoked, e.g. by a concurrent refresh
+func (rr *RefreshTokenRepo) Rotate(oldId int, next *entity.RefreshToken) (rotated bool, err error) {
+	err = rr.db.WithContext(rr.ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&entity.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", oldId).
+			Update("revoked_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		rotated = true
+		return tx.Create(next).Error
+	})
+
+	return rotated, err
+}
+
+func (rr *RefreshTokenRepo) RevokeFamily(familyId string) error {
+	return rr.db.WithContext(rr.ctx).Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (rr *RefreshTokenRepo) RevokeAllForUser(userId int) error {
+	return rr.db.WithContext(rr.ctx).Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", time.Now()).Error
+}
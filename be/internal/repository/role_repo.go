@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"milestone3/be/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepo struct {
+	ctx context.Context
+	db  *gorm.DB
+}
+
+func NewRoleRepo(db *gorm.DB, ctx context.Context) *RoleRepo {
+	return &RoleRepo{db: db, ctx: ctx}
+}
+
+func (rr *RoleRepo) GetAll() (roles []entity.Role, err error) {
+	if err := rr.db.WithContext(rr.ctx).Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (rr *RoleRepo) GetByNames(names []string) (roles []entity.Role, err error) {
+	if len(names) == 0 {
+		return []entity.Role{}, nil
+	}
+
+	if err := rr.db.WithContext(rr.ctx).Where("name IN ?", names).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// SetUserRoles replaces every role of the user
+func (rr *RoleRepo) SetUserRoles(userId int, roleIds []int) error {
+	return rr.db.WithContext(rr.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", userId).Error; err != nil {
+			return err
+		}
+
+		for _, roleId := range roleIds {
+			if err := tx.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)", userId, roleId).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
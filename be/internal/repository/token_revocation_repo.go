@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenRevocationRepository is the access token revocation list. Entries only
+// live as long as the tokens they revoke could still be used
+type TokenRevocationRepository interface {
+	RevokeToken(jti string, ttl time.Duration) error
+	// RevokeUser revokes every access token of the user issued before at
+	RevokeUser(userID int, at time.Time, ttl time.Duration) error
+	IsRevoked(jti string, userID int, issuedAt time.Time) (bool, error)
+}
+
+type tokenRevocationRepository struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewTokenRevocationRepository(client *redis.Client, ctx context.Context) TokenRevocationRepository {
+	return &tokenRevocationRepository{client: client, ctx: ctx}
+}
+
+// a revoke-all time below this is in seconds, milliseconds passed it in 1973
+const secondsBefore = 100_000_000_000
+
+func revokedTokenKey(jti string) string {
+	return fmt.Sprintf("revoked:token:%s", jti)
+}
+
+func revokedUserKey(userID int) string {
+	return fmt.Sprintf("revoked:user:%d", userID)
+}
+
+func (r *tokenRevocationRepository) RevokeToken(jti string, ttl time.Duration) error {
+	if ttl <= 0 || jti == "" {
+		return nil
+	}
+	return r.client.Set(r.ctx, revokedTokenKey(jti), 1, ttl).Err()
+}
+
+// RevokeUser keeps the time in milliseconds, a token minted in the same second
+// as a password or role change must not outlive it
+func (r *tokenRevocationRepository) RevokeUser(userID int, at time.Time, ttl time.Duration) error {
+	return r.client.Set(r.ctx, revokedUserKey(userID), at.UnixMilli(), ttl).Err()
+}
+
+func (r *tokenRevocationRepository) IsRevoked(jti string, userID int, issuedAt time.Time) (bool, error) {
+	values, err := r.client.MGet(r.ctx, revokedTokenKey(jti), revokedUserKey(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if values[0] != nil {
+		return true, nil
+	}
+
+	if s, ok := values[1].(string); ok {
+		revokedAt, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		// entries written before milliseconds were kept are in seconds, they
+		// cover their whole second
+		if revokedAt < secondsBefore {
+			revokedAt = revokedAt*1000 + 999
+		}
+		return issuedAt.UnixMilli() < revokedAt, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenRevocationRepository(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := NewTokenRevocationRepository(client, context.Background())
+
+	issued := time.Now().Add(-time.Minute)
+
+	revoked, err := repo.IsRevoked("jti-1", 1, issued)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, repo.RevokeToken("jti-1", 15*time.Minute))
+	revoked, err = repo.IsRevoked("jti-1", 1, issued)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = repo.IsRevoked("jti-2", 1, issued)
+	require.NoError(t, err)
+	assert.False(t, revoked, "other tokens of the user still work")
+
+	revokedAt := time.Now().Truncate(time.Second).Add(500 * time.Millisecond)
+	require.NoError(t, repo.RevokeUser(1, revokedAt, 15*time.Minute))
+	revoked, err = repo.IsRevoked("jti-2", 1, issued)
+	require.NoError(t, err)
+	assert.True(t, revoked, "tokens issued before revoke-all are revoked")
+
+	revoked, err = repo.IsRevoked("jti-3", 1, revokedAt.Add(time.Millisecond))
+	require.NoError(t, err)
+	assert.False(t, revoked, "tokens issued after revoke-all work")
+
+	revoked, err = repo.IsRevoked("jti-4", 1, revokedAt.Add(-time.Millisecond))
+	require.NoError(t, err)
+	assert.True(t, revoked, "tokens issued earlier in the revoke-all's second are revoked")
+
+	// an entry written in seconds covers its whole second
+	mr.Set("revoked:user:2", strconv.FormatInt(revokedAt.Unix(), 10))
+	revoked, err = repo.IsRevoked("jti-5", 2, revokedAt.Add(400*time.Millisecond))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsRevoked("jti-5", 2, revokedAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// an empty jti never becomes a key every jti-less token would match
+	require.NoError(t, repo.RevokeToken("", 15*time.Minute))
+	assert.False(t, mr.Exists("revoked:token:"))
+
+	mr.FastForward(16 * time.Minute)
+	revoked, err = repo.IsRevoked("jti-1", 1, issued)
+	require.NoError(t, err)
+	assert.False(t, revoked, "entries expire with the tokens")
+}
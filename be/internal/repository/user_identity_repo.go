@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"milestone3/be/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepo struct {
+	ctx context.Context
+	db  *gorm.DB
+}
+
+func NewUserIdentityRepo(db *gorm.DB, ctx context.Context) *UserIdentityRepo {
+	return &UserIdentityRepo{db: db, ctx: ctx}
+}
+
+func (ir *UserIdentityRepo) GetByProviderSubject(provider, subject string) (identity entity.UserIdentity, err error) {
+	if err := ir.db.WithContext(ir.ctx).First(&identity, "provider = ? AND subject = ?", provider, subject).Error; err != nil {
+		return entity.UserIdentity{}, err
+	}
+
+	return identity, nil
+}
+
+func (ir *UserIdentityRepo) Create(identity *entity.UserIdentity) error {
+	return ir.db.WithContext(ir.ctx).Create(identity).Error
+}
+
+// TouchLogin records the sign in and keeps the email the provider knows
+func (ir *UserIdentityRepo) TouchLogin(id int, email string, at time.Time) error {
+	return ir.db.WithContext(ir.ctx).Model(&entity.UserIdentity{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"email":         email,
+		"last_login_at": at,
+	}).Error
+}
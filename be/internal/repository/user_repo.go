@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"milestone3/be/internal/entity"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -16,27 +18,192 @@ func NewUserRepo(db *gorm.DB, ctx context.Context) *UserRepo {
 	return &UserRepo{db: db, ctx: ctx}
 }
 
+// Create saves the user with the default roles
 func (ur *UserRepo) Create(user *entity.Users) error {
-	if err := ur.db.WithContext(ur.ctx).Omit("Role").Create(user).Error; err != nil {
-		return err
-	}
-	
-	return nil
+	return ur.db.WithContext(ur.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Role", "Roles").Create(user).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			"INSERT INTO user_roles (user_id, role_id) SELECT ?, id FROM roles WHERE name IN ?",
+			user.Id, entity.DefaultRoles,
+		).Error
+	})
 }
 
 func (ur *UserRepo) GetByEmail(email string) (user entity.Users, err error) {
-	if err := ur.db.WithContext(ur.ctx).First(&user, "email = ?", email).Error; err != nil {
+	if err := ur.db.WithContext(ur.ctx).Preload("Roles.Permissions").First(&user, "email = ?", email).Error; err != nil {
 		return entity.Users{}, err
 	}
 
 	return user, nil
 }
 func (ur *UserRepo) GetById(id int) (user entity.Users, err error) {
-	if err := ur.db.WithContext(ur.ctx).First(&user, "id = ?", id).Error; err != nil {
+	if err := ur.db.WithContext(ur.ctx).Preload("Roles.Permissions").First(&user, "id = ?", id).Error; err != nil {
 		return entity.Users{}, err
 	}
 
 	return user, nil
 }
-// reset password?
-// validation?
\ No newline at end of file
+
+func (ur *UserRepo) UpdatePassword(id int, passwordHash string) error {
+	return ur.db.WithContext(ur.ctx).Model(&entity.Users{}).Where("id = ?", id).Update("password", passwordHash).Error
+}
+
+// MarkEmailVerified keeps the first verification time when called again
+func (ur *UserRepo) MarkEmailVerified(id int, at time.Time) error {
+	return ur.db.WithContext(ur.ctx).Model(&entity.Users{}).
+		Where("id = ? AND email_verified_at IS NULL", id).
+		Update("email_verified_at", at).Error
+}
+
+// UpdateProfile saves the fields a user can edit themselves
+func (ur *UserRepo) UpdateProfile(user *entity.Users) error {
+	return ur.db.WithContext(ur.ctx).Model(user).
+		Select("name", "phone", "address", "city", "postal_code", "pending_email").
+		Updates(user).Error
+}
+
+// ConfirmEmailChange moves the confirmed pending email into place
+func (ur *UserRepo) ConfirmEmailChange(id int, email string, at time.Time) error {
+	return ur.db.WithContext(ur.ctx).Model(&entity.Users{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"email":             email,
+		"pending_email":     nil,
+		"email_verified_at": at,
+	}).Error
+}
+
+func (ur *UserRepo) Deactivate(id int, at time.Time) error {
+	return ur.db.WithContext(ur.ctx).Model(&entity.Users{}).Where("id = ?", id).Update("deactivated_at", at).Error
+}
+
+// Anonymize scrubs everything that identifies the user. Donations, bids and
+// payments keep pointing at the row so totals and history stay intact
+func (ur *UserRepo) Anonymize(id int, at time.Time) error {
+	return ur.db.WithContext(ur.ctx).Transaction(func(tx *gorm.DB) error {
+		// linked social logins would still identify the user
+		if err := tx.Where("user_id = ?", id).Delete(&entity.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&entity.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", id).Update("revoked_at", at).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&entity.Users{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"name":            "Deleted user",
+			"email":           fmt.Sprintf("deleted-user-%d@deleted.invalid", id),
+			"password":        "!",
+			"phone":           "",
+			"address":         "",
+			"city":            "",
+			"postal_code":     "",
+			"pending_email":   nil,
+			"totp_secret":     nil,
+			"totp_enabled_at": nil,
+			"deactivated_at":  gorm.Expr("COALESCE(deactivated_at, ?)", at),
+			"deleted_at":      at,
+		}).Error
+	})
+}
+
+// HasUnpaidWins reports whether the user still owes payment for a won item
+func (ur *UserRepo) HasUnpaidWins(id int) (bool, error) {
+	paid := ur.db.Model(&entity.Payment{}).
+		Select("1").
+		Where("payments.auction_item_id = bids.auction_item_id AND payments.user_id = bids.user_id AND payments.status IN ?", SettledStatuses)
+
+	var count int64
+	err := ur.db.WithContext(ur.ctx).Model(&entity.Bid{}).
+		Where("user_id = ? AND lapsed = ? AND payment_deadline IS NOT NULL", id, false).
+		Where("NOT EXISTS (?)", paid).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SetTOTPSecret keeps a new secret until the first code confirms it, an
+// enabled secret is never replaced
+func (ur *UserRepo) SetTOTPSecret(id int, encryptedSecret string) error {
+	return ur.db.WithContext(ur.ctx).Model(&entity.Users{}).
+		Where("id = ? AND totp_enabled_at IS NULL", id).
+		Update("totp_secret", encryptedSecret).Error
+}
+
+// EnableTOTP turns on the second factor with the step of the confirming code
+// and the first recovery codes
+func (ur *UserRepo) EnableTOTP(id int, at time.Time, step int64, codeHashes []string) error {
+	return ur.db.WithContext(ur.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Users{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"totp_enabled_at": at,
+			"totp_last_step":  step,
+		}).Error; err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(tx, id, codeHashes)
+	})
+}
+
+func (ur *UserRepo) DisableTOTP(id int) error {
+	return ur.db.WithContext(ur.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Users{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"totp_secret":     nil,
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", id).Delete(&entity.UserRecoveryCode{}).Error
+	})
+}
+
+// ReplaceRecoveryCodes drops every code of the user, used or not
+func (ur *UserRepo) ReplaceRecoveryCodes(id int, codeHashes []string) error {
+	return ur.db.WithContext(ur.ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, id, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, id int, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", id).Delete(&entity.UserRecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	codes := make([]entity.UserRecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, entity.UserRecoveryCode{UserId: id, CodeHash: hash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseTOTPStep records the step of an accepted code, false when that step or a
+// later one was already used so the same code cannot log in twice
+func (ur *UserRepo) UseTOTPStep(id int, step int64) (bool, error) {
+	res := ur.db.WithContext(ur.ctx).Model(&entity.Users{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+// UseRecoveryCode spends an unused code, false when there is none with that hash
+func (ur *UserRepo) UseRecoveryCode(id int, codeHash string, at time.Time) (bool, error) {
+	res := ur.db.WithContext(ur.ctx).Model(&entity.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", id, codeHash).
+		Update("used_at", at)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (ur *UserRepo) CountRecoveryCodes(id int) (int, error) {
+	var count int64
+	err := ur.db.WithContext(ur.ctx).Model(&entity.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", id).
+		Count(&count).Error
+	return int(count), err
+}
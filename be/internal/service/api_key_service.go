@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyUsageDays is the longest usage history an admin can ask for
+const apiKeyUsageDays = 90
+
+type APIKeyRepository interface {
+	Create(key *entity.APIKey) error
+	GetById(id int) (key entity.APIKey, err error)
+	GetActiveByHash(hash string, at time.Time) (key entity.APIKey, err error)
+	GetAll(ownerId int) (keys []entity.APIKey, err error)
+	Revoke(id int, at time.Time) (bool, error)
+	TouchLastUsed(id int, at time.Time) error
+}
+
+type APIKeyServ struct {
+	keyRepo  APIKeyRepository
+	userRepo UserRepository
+	usage    repository.APIKeyUsageRepository
+}
+
+func NewAPIKeyService(kr APIKeyRepository, ur UserRepository, usage repository.APIKeyUsageRepository) *APIKeyServ {
+	return &APIKeyServ{keyRepo: kr, userRepo: ur, usage: usage}
+}
+
+// CreateAPIKey issues a key acting for the owner, the key is only returned here
+func (ks *APIKeyServ) CreateAPIKey(actorId int, req dto.CreateAPIKeyRequest) (res dto.CreatedAPIKeyResponse, err error) {
+	scopes := uniqueNames(req.Scopes)
+	for _, s := range scopes {
+		if !contains(utils.APIKeyScopes, s) {
+			return dto.CreatedAPIKeyResponse{}, ErrUnknownScope
+		}
+	}
+
+	owner, err := ks.userRepo.GetById(req.OwnerId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.CreatedAPIKeyResponse{}, ErrUserNotFound
+		}
+		return dto.CreatedAPIKeyResponse{}, err
+	}
+	if owner.DeactivatedAt != nil {
+		return dto.CreatedAPIKeyResponse{}, ErrAPIKeyOwnerInactive
+	}
+
+	raw, prefix, hash, err := utils.GenerateAPIKey()
+	if err != nil {
+		return dto.CreatedAPIKeyResponse{}, err
+	}
+
+	key := entity.APIKey{
+		UserId:             owner.Id,
+		Name:               strings.TrimSpace(req.Name),
+		Prefix:             prefix,
+		KeyHash:            hash,
+		Scopes:             strings.Join(scopes, ","),
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		CreatedBy:          actorId,
+	}
+	if key.RateLimitPerMinute == 0 {
+		key.RateLimitPerMinute = utils.APIKeyRateLimit()
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := ks.keyRepo.Create(&key); err != nil {
+		log.Printf("failed create api key for user %d %s", owner.Id, err)
+		return dto.CreatedAPIKeyResponse{}, err
+	}
+
+	log.Printf("user %d issued api key %d (%s) for user %d with scopes %v", actorId, key.Id, key.Prefix, owner.Id, scopes)
+	return dto.CreatedAPIKeyResponse{APIKeyResponse: apiKeyResponse(key, 0, 0), Key: raw}, nil
+}
+
+// GetAPIKeys lists the keys of an owner, or every key when ownerId is 0, with
+// their request counts
+func (ks *APIKeyServ) GetAPIKeys(ownerId int) (res []dto.APIKeyResponse, err error) {
+	keys, err := ks.keyRepo.GetAll(ownerId)
+	if err != nil {
+		log.Printf("failed get api keys %s", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	res = make([]dto.APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		var today, month int64
+		daily, err := ks.usage.Daily(k.Id, now, 30)
+		if err != nil {
+			// the keys are still worth listing without their counts
+			log.Printf("failed get usage of api key %d %s", k.Id, err)
+		}
+		for i, n := range daily {
+			month += n
+			if i == len(daily)-1 {
+				today = n
+			}
+		}
+		res = append(res, apiKeyResponse(k, today, month))
+	}
+
+	return res, nil
+}
+
+// GetAPIKeyUsage returns the requests made with the key per day (UTC), oldest first
+func (ks *APIKeyServ) GetAPIKeyUsage(id, days int) (res dto.APIKeyUsageResponse, err error) {
+	key, err := ks.keyRepo.GetById(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.APIKeyUsageResponse{}, ErrAPIKeyNotFound
+		}
+		return dto.APIKeyUsageResponse{}, err
+	}
+
+	if days <= 0 || days > apiKeyUsageDays {
+		days = 30
+	}
+
+	now := time.Now().UTC()
+	daily, err := ks.usage.Daily(id, now, days)
+	if err != nil {
+		log.Printf("failed get usage of api key %d %s", id, err)
+		return dto.APIKeyUsageResponse{}, err
+	}
+
+	res = dto.APIKeyUsageResponse{Id: key.Id, LastUsedAt: key.LastUsedAt, Days: make([]dto.APIKeyUsageDay, 0, days)}
+	for i, n := range daily {
+		day := now.AddDate(0, 0, i-days+1)
+		res.Days = append(res.Days, dto.APIKeyUsageDay{Date: day.Format("2006-01-02"), Requests: n})
+	}
+
+	return res, nil
+}
+
+// RevokeAPIKey stops the key immediately, revoked keys are kept for their history
+func (ks *APIKeyServ) RevokeAPIKey(id, actorId int) error {
+	if _, err := ks.keyRepo.GetById(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+
+	revoked, err := ks.keyRepo.Revoke(id, time.Now())
+	if err != nil {
+		log.Printf("failed revoke api key %d %s", id, err)
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyRevoked
+	}
+
+	log.Printf("user %d revoked api key %d", actorId, id)
+	return nil
+}
+
+// Authenticate resolves the key of a request and counts the request
+func (ks *APIKeyServ) Authenticate(rawKey string) (key entity.APIKey, err error) {
+	if !strings.HasPrefix(rawKey, utils.APIKeyPrefix) {
+		return entity.APIKey{}, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	key, err = ks.keyRepo.GetActiveByHash(utils.HashToken(rawKey), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.APIKey{}, ErrInvalidAPIKey
+		}
+		return entity.APIKey{}, err
+	}
+
+	// usage is best effort, a failed count does not fail the request
+	if err := ks.usage.Record(key.Id, now); err != nil {
+		log.Printf("failed record usage of api key %d %s", key.Id, err)
+	}
+	if err := ks.keyRepo.TouchLastUsed(key.Id, now); err != nil {
+		log.Printf("failed update last use of api key %d %s", key.Id, err)
+	}
+
+	return key, nil
+}
+
+func apiKeyResponse(k entity.APIKey, today, month int64) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		Id:                 k.Id,
+		OwnerId:            k.UserId,
+		Name:               k.Name,
+		Prefix:             k.Prefix,
+		Scopes:             k.ScopeList(),
+		RateLimitPerMinute: k.RateLimitPerMinute,
+		CreatedBy:          k.CreatedBy,
+		CreatedAt:          k.CreatedAt,
+		ExpiresAt:          k.ExpiresAt,
+		LastUsedAt:         k.LastUsedAt,
+		RevokedAt:          k.RevokedAt,
+		RequestsToday:      today,
+		RequestsLast30Days: month,
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/mocks"
+	"milestone3/be/internal/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	req := dto.CreateAPIKeyRequest{OwnerId: 7, Name: " Charity sync ", Scopes: []string{utils.ScopeReadFinalDonations, utils.ScopeReadFinalDonations}}
+	deactivatedAt := time.Now()
+
+	tests := []struct {
+		name    string
+		req     dto.CreateAPIKeyRequest
+		setup   func(keys *mocks.MockAPIKeyRepository, users *mocks.MockUserRepository)
+		wantErr error
+	}{
+		{
+			name: "issued with the default limit",
+			req:  req,
+			setup: func(keys *mocks.MockAPIKeyRepository, users *mocks.MockUserRepository) {
+				users.EXPECT().GetById(7).Return(entity.Users{Id: 7}, nil)
+				keys.EXPECT().Create(gomock.Any()).DoAndReturn(func(key *entity.APIKey) error {
+					assert.Equal(t, 7, key.UserId)
+					assert.Equal(t, 1, key.CreatedBy)
+					assert.Equal(t, "Charity sync", key.Name)
+					assert.Equal(t, utils.ScopeReadFinalDonations, key.Scopes)
+					assert.Equal(t, 60, key.RateLimitPerMinute)
+					assert.Nil(t, key.ExpiresAt)
+					key.Id = 3
+					return nil
+				})
+			},
+		},
+		{
+			name:    "unknown scope",
+			req:     dto.CreateAPIKeyRequest{OwnerId: 7, Name: "x", Scopes: []string{"users.manage"}},
+			setup:   func(keys *mocks.MockAPIKeyRepository, users *mocks.MockUserRepository) {},
+			wantErr: ErrUnknownScope,
+		},
+		{
+			name: "unknown owner",
+			req:  req,
+			setup: func(keys *mocks.MockAPIKeyRepository, users *mocks.MockUserRepository) {
+				users.EXPECT().GetById(7).Return(entity.Users{}, gorm.ErrRecordNotFound)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "deactivated owner",
+			req:  req,
+			setup: func(keys *mocks.MockAPIKeyRepository, users *mocks.MockUserRepository) {
+				users.EXPECT().GetById(7).Return(entity.Users{Id: 7, DeactivatedAt: &deactivatedAt}, nil)
+			},
+			wantErr: ErrAPIKeyOwnerInactive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+			mockUsers := mocks.NewMockUserRepository(ctrl)
+			tt.setup(mockKeys, mockUsers)
+			keyService := NewAPIKeyService(mockKeys, mockUsers, mocks.NewMockAPIKeyUsageRepository(ctrl))
+
+			res, err := keyService.CreateAPIKey(1, tt.req)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 3, res.Id)
+			assert.True(t, strings.HasPrefix(res.Key, utils.APIKeyPrefix))
+			assert.True(t, strings.HasPrefix(res.Key, res.Prefix))
+			assert.Equal(t, []string{utils.ScopeReadFinalDonations}, res.Scopes)
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	rawKey := utils.APIKeyPrefix + "secret"
+
+	tests := []struct {
+		name    string
+		rawKey  string
+		setup   func(keys *mocks.MockAPIKeyRepository, usage *mocks.MockAPIKeyUsageRepository)
+		wantErr error
+	}{
+		{
+			name:   "active key counts the request",
+			rawKey: rawKey,
+			setup: func(keys *mocks.MockAPIKeyRepository, usage *mocks.MockAPIKeyUsageRepository) {
+				keys.EXPECT().GetActiveByHash(utils.HashToken(rawKey), gomock.Any()).Return(entity.APIKey{Id: 3, UserId: 7}, nil)
+				usage.EXPECT().Record(3, gomock.Any()).Return(nil)
+				keys.EXPECT().TouchLastUsed(3, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:   "usage failures do not fail the request",
+			rawKey: rawKey,
+			setup: func(keys *mocks.MockAPIKeyRepository, usage *mocks.MockAPIKeyUsageRepository) {
+				keys.EXPECT().GetActiveByHash(gomock.Any(), gomock.Any()).Return(entity.APIKey{Id: 3, UserId: 7}, nil)
+				usage.EXPECT().Record(3, gomock.Any()).Return(errors.New("redis down"))
+				keys.EXPECT().TouchLastUsed(3, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:   "unknown, revoked or expired key",
+			rawKey: rawKey,
+			setup: func(keys *mocks.MockAPIKeyRepository, usage *mocks.MockAPIKeyUsageRepository) {
+				keys.EXPECT().GetActiveByHash(gomock.Any(), gomock.Any()).Return(entity.APIKey{}, gorm.ErrRecordNotFound)
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name:    "not an api key",
+			rawKey:  "Bearer abc",
+			setup:   func(keys *mocks.MockAPIKeyRepository, usage *mocks.MockAPIKeyUsageRepository) {},
+			wantErr: ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+			mockUsage := mocks.NewMockAPIKeyUsageRepository(ctrl)
+			tt.setup(mockKeys, mockUsage)
+			keyService := NewAPIKeyService(mockKeys, mocks.NewMockUserRepository(ctrl), mockUsage)
+
+			key, err := keyService.Authenticate(tt.rawKey)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 7, key.UserId)
+		})
+	}
+}
+
+func TestAPIKeyService_GetAPIKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+	mockUsage := mocks.NewMockAPIKeyUsageRepository(ctrl)
+	keyService := NewAPIKeyService(mockKeys, mocks.NewMockUserRepository(ctrl), mockUsage)
+
+	mockKeys.EXPECT().GetAll(7).Return([]entity.APIKey{
+		{Id: 3, UserId: 7, Scopes: "final_donations.read,auctions.read"},
+		{Id: 2, UserId: 7},
+	}, nil)
+	mockUsage.EXPECT().Daily(3, gomock.Any(), 30).Return([]int64{4, 0, 5}, nil)
+	mockUsage.EXPECT().Daily(2, gomock.Any(), 30).Return(nil, errors.New("redis down"))
+
+	res, err := keyService.GetAPIKeys(7)
+
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, []string{"final_donations.read", "auctions.read"}, res[0].Scopes)
+	assert.Equal(t, int64(5), res[0].RequestsToday)
+	assert.Equal(t, int64(9), res[0].RequestsLast30Days)
+	assert.Equal(t, []string{}, res[1].Scopes)
+	assert.Zero(t, res[1].RequestsLast30Days)
+}
+
+func TestAPIKeyService_RevokeAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(keys *mocks.MockAPIKeyRepository)
+		wantErr error
+	}{
+		{
+			name: "revoked",
+			setup: func(keys *mocks.MockAPIKeyRepository) {
+				keys.EXPECT().GetById(3).Return(entity.APIKey{Id: 3}, nil)
+				keys.EXPECT().Revoke(3, gomock.Any()).Return(true, nil)
+			},
+		},
+		{
+			name: "already revoked",
+			setup: func(keys *mocks.MockAPIKeyRepository) {
+				keys.EXPECT().GetById(3).Return(entity.APIKey{Id: 3}, nil)
+				keys.EXPECT().Revoke(3, gomock.Any()).Return(false, nil)
+			},
+			wantErr: ErrAPIKeyRevoked,
+		},
+		{
+			name: "not found",
+			setup: func(keys *mocks.MockAPIKeyRepository) {
+				keys.EXPECT().GetById(3).Return(entity.APIKey{}, gorm.ErrRecordNotFound)
+			},
+			wantErr: ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+			tt.setup(mockKeys)
+			keyService := NewAPIKeyService(mockKeys, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyUsageRepository(ctrl))
+
+			err := keyService.RevokeAPIKey(3, 1)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -1,29 +1,34 @@
 package service
 
 import (
+	"errors"
 	"log/slog"
 	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
 	"milestone3/be/internal/repository"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type itemsService struct {
 	repo   repository.AuctionItemRepository
 	logger *slog.Logger
 	ai     repository.AIRepository
+	photos PhotoURLSigner
 }
 
 type AuctionItemService interface {
 	Create(item *dto.AuctionItemDTO) (dto.AuctionItemDTO, error)
-	GetAll() ([]dto.AuctionItemDTO, error)
-	GetByID(id int64) (dto.AuctionItemDTO, error)
+	GetAll(isAdmin bool) ([]dto.AuctionItemDTO, error)
+	GetByID(id int64, isAdmin bool) (dto.AuctionItemDTO, error)
 	Update(id int64, item *dto.AuctionItemUpdateDTO) (dto.AuctionItemDTO, error)
 	Delete(id int64) error
 	CheckAndStartScheduledItems() error
 }
 
-func NewAuctionItemService(r repository.AuctionItemRepository, aiRepo repository.AIRepository, logger *slog.Logger) AuctionItemService {
-	return &itemsService{repo: r, logger: logger, ai: aiRepo}
+func NewAuctionItemService(r repository.AuctionItemRepository, aiRepo repository.AIRepository, photos PhotoURLSigner, logger *slog.Logger) AuctionItemService {
+	return &itemsService{repo: r, logger: logger, ai: aiRepo, photos: photos}
 }
 
 const DefaultStartingPrice = 10000
@@ -34,6 +39,15 @@ func (s *itemsService) Create(itemDTO *dto.AuctionItemDTO) (dto.AuctionItemDTO,
 		return dto.AuctionItemDTO{}, ErrInvalidAuction
 	}
 
+	exists, err := s.repo.ExistsForDonation(item.DonationID)
+	if err != nil {
+		s.logger.Error("Failed to check auction items of donation", "donationID", item.DonationID, "error", err)
+		return dto.AuctionItemDTO{}, ErrInvalidAuction
+	}
+	if exists {
+		return dto.AuctionItemDTO{}, ErrDonationAuctioned
+	}
+
 	estimationReq := repository.PriceEstimationRequest{
 		Name:        itemDTO.Title,
 		Category:    itemDTO.Category,
@@ -52,40 +66,107 @@ func (s *itemsService) Create(itemDTO *dto.AuctionItemDTO) (dto.AuctionItemDTO,
 
 	item.StartingPrice = estimatedPrice
 
+	if err := validatePrices(&item); err != nil {
+		s.logger.Warn("Invalid buy now price", "buyNowPrice", *item.BuyNowPrice, "startingPrice", item.StartingPrice)
+		return dto.AuctionItemDTO{}, err
+	}
+
 	if item.Status == "" {
 		item.Status = "scheduled"
 	}
 
 	err = s.repo.Create(&item)
+	if donationAuctioned(err) {
+		return dto.AuctionItemDTO{}, ErrDonationAuctioned
+	}
 	if err != nil {
 		s.logger.Error("Failed to create auction item", "error", err)
 		return dto.AuctionItemDTO{}, ErrInvalidAuction
 	}
 
-	return dto.AuctionItemResponse(item), nil
+	return dto.AuctionItemAdminResponse(item), nil
+}
+
+// donationAuctioned reports whether err is the unique index refusing a second
+// auction item for a donation, a concurrent request can pass ExistsForDonation
+// before the other one inserts
+func donationAuctioned(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_auction_items_donation"
 }
 
-func (s *itemsService) GetAll() ([]dto.AuctionItemDTO, error) {
+// validatePrices keeps the buy now price above the starting and reserve price
+func validatePrices(item *entity.AuctionItem) error {
+	if item.BuyNowPrice == nil {
+		return nil
+	}
+	if *item.BuyNowPrice <= item.StartingPrice {
+		return ErrInvalidBuyNowPrice
+	}
+	if item.ReservePrice != nil && *item.BuyNowPrice < *item.ReservePrice {
+		return ErrInvalidBuyNowPrice
+	}
+	return nil
+}
+
+// itemResponse only exposes the reserve price to admins
+func itemResponse(item entity.AuctionItem, isAdmin bool) dto.AuctionItemDTO {
+	if isAdmin {
+		return dto.AuctionItemAdminResponse(item)
+	}
+	return dto.AuctionItemResponse(item)
+}
+
+func (s *itemsService) GetAll(isAdmin bool) ([]dto.AuctionItemDTO, error) {
 	items, err := s.repo.GetAll()
 	if err != nil {
 		s.logger.Error("Failed to get all auction items", "error", err)
 		return nil, ErrAuctionNotFound
 	}
 
-	var itemDTOs []dto.AuctionItemDTO
+	var visible []entity.AuctionItem
+	for _, item := range items {
+		// drafts wait for an admin to schedule them
+		if !isAdmin && item.Status == entity.AuctionItemStatusDraft {
+			continue
+		}
+		visible = append(visible, item)
+	}
+
+	return s.itemResponses(visible, isAdmin), nil
+}
+
+// itemResponses signs the photos of all the items in one batch, they are
+// public like the items themselves
+func (s *itemsService) itemResponses(items []entity.AuctionItem, isAdmin bool) []dto.AuctionItemDTO {
+	var names []string
 	for _, item := range items {
-		itemDTOs = append(itemDTOs, dto.AuctionItemResponse(item))
+		names = append(names, dto.AuctionItemPhotos(item)...)
+	}
+
+	var urls map[string]dto.PhotoURL
+	if len(names) > 0 {
+		urls = s.photos.Sign(names)
 	}
 
-	return itemDTOs, nil
+	var itemDTOs []dto.AuctionItemDTO
+	for _, item := range items {
+		res := itemResponse(item, isAdmin)
+		res.PhotoURLs = photoURLsOf(dto.AuctionItemPhotos(item), urls)
+		itemDTOs = append(itemDTOs, res)
+	}
+	return itemDTOs
 }
 
-func (s *itemsService) GetByID(id int64) (dto.AuctionItemDTO, error) {
+func (s *itemsService) GetByID(id int64, isAdmin bool) (dto.AuctionItemDTO, error) {
 	item, err := s.repo.GetByID(id)
 	if err != nil {
 		return dto.AuctionItemDTO{}, ErrAuctionNotFoundID
 	}
-	return dto.AuctionItemResponse(*item), nil
+	if !isAdmin && item.Status == entity.AuctionItemStatusDraft {
+		return dto.AuctionItemDTO{}, ErrAuctionNotFoundID
+	}
+	return s.itemResponses([]entity.AuctionItem{*item}, isAdmin)[0], nil
 }
 
 func (s *itemsService) Update(id int64, updateDTO *dto.AuctionItemUpdateDTO) (dto.AuctionItemDTO, error) {
@@ -117,10 +198,35 @@ func (s *itemsService) Update(id int64, updateDTO *dto.AuctionItemUpdateDTO) (dt
 		}
 		existingItem.StartingPrice = *updateDTO.StartingPrice
 	}
+	if updateDTO.ReservePrice != nil {
+		// moving the reserve while people are bidding is not allowed
+		if existingItem.Status == "ongoing" {
+			s.logger.Warn("Cannot change reserve price for ongoing auction", "itemID", id)
+			return dto.AuctionItemDTO{}, ErrActiveSession
+		}
+		existingItem.ReservePrice = updateDTO.ReservePrice
+	}
+	if updateDTO.BuyNowPrice != nil {
+		if existingItem.Status == "ongoing" {
+			s.logger.Warn("Cannot change buy now price for ongoing auction", "itemID", id)
+			return dto.AuctionItemDTO{}, ErrActiveSession
+		}
+		existingItem.BuyNowPrice = updateDTO.BuyNowPrice
+	}
+	if err := validatePrices(existingItem); err != nil {
+		s.logger.Warn("Invalid buy now price", "itemID", id)
+		return dto.AuctionItemDTO{}, err
+	}
 	if updateDTO.Status != nil {
 		newStatus := *updateDTO.Status
 		// status transition rules
 		switch existingItem.Status {
+		case entity.AuctionItemStatusDraft:
+			// to scheduled once an admin reviewed it
+			if newStatus != "scheduled" && newStatus != entity.AuctionItemStatusDraft {
+				s.logger.Warn("Invalid status transition", "from", existingItem.Status, "to", newStatus)
+				return dto.AuctionItemDTO{}, ErrInvalidAuction
+			}
 		case "scheduled":
 			// to ongoing
 			if newStatus != "ongoing" && newStatus != "scheduled" {
@@ -137,6 +243,12 @@ func (s *itemsService) Update(id int64, updateDTO *dto.AuctionItemUpdateDTO) (dt
 			// cannot change from finished
 			s.logger.Warn("Cannot change status from finished", "itemID", id)
 			return dto.AuctionItemDTO{}, ErrAuctionFinished
+		case "unsold":
+			// relist only
+			if newStatus != "scheduled" && newStatus != "unsold" {
+				s.logger.Warn("Invalid status transition", "from", existingItem.Status, "to", newStatus)
+				return dto.AuctionItemDTO{}, ErrInvalidAuction
+			}
 		}
 		existingItem.Status = newStatus
 	}
@@ -148,17 +260,28 @@ func (s *itemsService) Update(id int64, updateDTO *dto.AuctionItemUpdateDTO) (dt
 		}
 		existingItem.SessionID = updateDTO.SessionID
 	}
-	if updateDTO.DonationID != nil {
+	if updateDTO.DonationID != nil && *updateDTO.DonationID != existingItem.DonationID {
+		exists, err := s.repo.ExistsForDonation(*updateDTO.DonationID)
+		if err != nil {
+			s.logger.Error("Failed to check auction items of donation", "donationID", *updateDTO.DonationID, "error", err)
+			return dto.AuctionItemDTO{}, ErrInvalidAuction
+		}
+		if exists {
+			return dto.AuctionItemDTO{}, ErrDonationAuctioned
+		}
 		existingItem.DonationID = *updateDTO.DonationID
 	}
 
 	err = s.repo.Update(existingItem)
+	if donationAuctioned(err) {
+		return dto.AuctionItemDTO{}, ErrDonationAuctioned
+	}
 	if err != nil {
 		s.logger.Error("Failed to update auction item", "error", err)
 		return dto.AuctionItemDTO{}, ErrInvalidAuction
 	}
 
-	return dto.AuctionItemResponse(*existingItem), nil
+	return dto.AuctionItemAdminResponse(*existingItem), nil
 }
 
 func (s *itemsService) Delete(id int64) error {
@@ -11,6 +11,7 @@ import (
 	"milestone3/be/internal/mocks"
 
 	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,9 +21,10 @@ func TestAuctionItemService_Create(t *testing.T) {
 
 	mockRepo := mocks.NewMockAuctionItemRepository(ctrl)
 	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	auctionService := NewAuctionItemService(mockRepo, mockAI, logger)
+	auctionService := NewAuctionItemService(mockRepo, mockAI, mockPhotos, logger)
 
 	tests := []struct {
 		name    string
@@ -38,6 +40,7 @@ func TestAuctionItemService_Create(t *testing.T) {
 				Description: "Test description",
 			},
 			setup: func() {
+				mockRepo.EXPECT().ExistsForDonation(gomock.Any()).Return(false, nil)
 				mockAI.EXPECT().EstimateStartingPrice(gomock.Any()).Return(float64(100), nil)
 				mockRepo.EXPECT().Create(gomock.Any()).Return(nil)
 			},
@@ -51,6 +54,7 @@ func TestAuctionItemService_Create(t *testing.T) {
 				Description: "Test description",
 			},
 			setup: func() {
+				mockRepo.EXPECT().ExistsForDonation(gomock.Any()).Return(false, nil)
 				mockAI.EXPECT().EstimateStartingPrice(gomock.Any()).Return(float64(0), errors.New("AI error"))
 				mockRepo.EXPECT().Create(gomock.Any()).Return(nil)
 			},
@@ -64,11 +68,25 @@ func TestAuctionItemService_Create(t *testing.T) {
 				Description: "Test description",
 			},
 			setup: func() {
+				mockRepo.EXPECT().ExistsForDonation(gomock.Any()).Return(false, nil)
 				mockAI.EXPECT().EstimateStartingPrice(gomock.Any()).Return(float64(100), nil)
 				mockRepo.EXPECT().Create(gomock.Any()).Return(errors.New("db error"))
 			},
 			wantErr: true,
 		},
+		{
+			name: "donation already has an auction item",
+			req: dto.AuctionItemDTO{
+				Title:       "Test Item",
+				Category:    "Electronics",
+				Description: "Test description",
+				DonationID:  3,
+			},
+			setup: func() {
+				mockRepo.EXPECT().ExistsForDonation(int64(3)).Return(true, nil)
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -94,9 +112,10 @@ func TestAuctionItemService_GetAll(t *testing.T) {
 
 	mockRepo := mocks.NewMockAuctionItemRepository(ctrl)
 	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	auctionService := NewAuctionItemService(mockRepo, mockAI, logger)
+	auctionService := NewAuctionItemService(mockRepo, mockAI, mockPhotos, logger)
 
 	tests := []struct {
 		name    string
@@ -109,6 +128,7 @@ func TestAuctionItemService_GetAll(t *testing.T) {
 				items := []entity.AuctionItem{
 					{ID: 1, Title: "Item 1", StartingPrice: 100},
 					{ID: 2, Title: "Item 2", StartingPrice: 200},
+					{ID: 3, Title: "Draft", StartingPrice: 300, Status: entity.AuctionItemStatusDraft},
 				}
 				mockRepo.EXPECT().GetAll().Return(items, nil)
 			},
@@ -127,7 +147,7 @@ func TestAuctionItemService_GetAll(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
 
-			result, err := auctionService.GetAll()
+			result, err := auctionService.GetAll(false)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -146,15 +166,21 @@ func TestAuctionItemService_GetByID(t *testing.T) {
 
 	mockRepo := mocks.NewMockAuctionItemRepository(ctrl)
 	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	auctionService := NewAuctionItemService(mockRepo, mockAI, logger)
+	auctionService := NewAuctionItemService(mockRepo, mockAI, mockPhotos, logger)
+
+	reserve := 500.0
 
 	tests := []struct {
-		name    string
-		id      int64
-		setup   func()
-		wantErr bool
+		name        string
+		id          int64
+		isAdmin     bool
+		setup       func()
+		wantErr     bool
+		wantReserve *float64
+		wantPhotos  []dto.PhotoURL
 	}{
 		{
 			name: "successful get by id",
@@ -165,6 +191,48 @@ func TestAuctionItemService_GetByID(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "reserve hidden from non admin",
+			id:   1,
+			setup: func() {
+				item := &entity.AuctionItem{ID: 1, Title: "Test Item", StartingPrice: 100, ReservePrice: &reserve}
+				mockRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:    "reserve visible to admin",
+			id:      1,
+			isAdmin: true,
+			setup: func() {
+				item := &entity.AuctionItem{ID: 1, Title: "Test Item", StartingPrice: 100, ReservePrice: &reserve}
+				mockRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+			},
+			wantErr:     false,
+			wantReserve: &reserve,
+		},
+		{
+			name: "photos signed for bidders",
+			id:   1,
+			setup: func() {
+				item := &entity.AuctionItem{ID: 1, Title: "Test Item", StartingPrice: 100, Status: "scheduled", Photos: []entity.AuctionItemPhoto{{URL: "donations/private/1_a.jpg"}}}
+				mockRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+				mockPhotos.EXPECT().Sign([]string{"donations/private/1_a.jpg"}).Return(map[string]dto.PhotoURL{
+					"donations/private/1_a.jpg": {URL: "https://signed/1_a"},
+				})
+			},
+			wantErr:    false,
+			wantPhotos: []dto.PhotoURL{{URL: "https://signed/1_a"}},
+		},
+		{
+			name: "draft hidden from non admin",
+			id:   1,
+			setup: func() {
+				item := &entity.AuctionItem{ID: 1, Title: "Test Item", StartingPrice: 100, Status: entity.AuctionItemStatusDraft}
+				mockRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+			},
+			wantErr: true,
+		},
 		{
 			name: "item not found",
 			id:   999,
@@ -179,7 +247,7 @@ func TestAuctionItemService_GetByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
 
-			result, err := auctionService.GetByID(tt.id)
+			result, err := auctionService.GetByID(tt.id, tt.isAdmin)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -187,6 +255,11 @@ func TestAuctionItemService_GetByID(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, int64(1), result.ID)
+				assert.Equal(t, tt.wantReserve, result.ReservePrice)
+				assert.Equal(t, tt.wantPhotos, result.PhotoURLs)
+				if !tt.isAdmin {
+					assert.Empty(t, result.Photos, "object names are only for admins")
+				}
 			}
 		})
 	}
@@ -198,8 +271,9 @@ func TestAuctionItemService_Update(t *testing.T) {
 
 	mockRepo := mocks.NewMockAuctionItemRepository(ctrl)
 	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	auctionService := NewAuctionItemService(mockRepo, mockAI, logger)
+	auctionService := NewAuctionItemService(mockRepo, mockAI, mockPhotos, logger)
 
 	tests := []struct {
 		name    string
@@ -222,6 +296,33 @@ func TestAuctionItemService_Update(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "reserve locked while ongoing",
+			id:   1,
+			req: func() *dto.AuctionItemUpdateDTO {
+				reserve := 500.0
+				return &dto.AuctionItemUpdateDTO{ReservePrice: &reserve}
+			}(),
+			setup: func() {
+				item := &entity.AuctionItem{ID: 1, Status: "ongoing"}
+				mockRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "relist unsold item",
+			id:   1,
+			req: func() *dto.AuctionItemUpdateDTO {
+				status := "scheduled"
+				return &dto.AuctionItemUpdateDTO{Status: &status}
+			}(),
+			setup: func() {
+				item := &entity.AuctionItem{ID: 1, Status: "unsold"}
+				mockRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+				mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			wantErr: false,
+		},
 		{
 			name: "item not found",
 			id:   999,
@@ -246,14 +347,57 @@ func TestAuctionItemService_Update(t *testing.T) {
 	}
 }
 
+func TestAuctionItemService_DonationAuctionedConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuctionItemRepository(ctrl)
+	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	auctionService := NewAuctionItemService(mockRepo, mockAI, mockPhotos, logger)
+
+	// the other request inserted between the check and the write
+	duplicate := &pgconn.PgError{Code: "23505", ConstraintName: "idx_auction_items_donation"}
+
+	t.Run("create", func(t *testing.T) {
+		mockRepo.EXPECT().ExistsForDonation(int64(3)).Return(false, nil)
+		mockAI.EXPECT().EstimateStartingPrice(gomock.Any()).Return(float64(100), nil)
+		mockRepo.EXPECT().Create(gomock.Any()).Return(duplicate)
+
+		_, err := auctionService.Create(&dto.AuctionItemDTO{Title: "Test Item", DonationID: 3})
+		assert.ErrorIs(t, err, ErrDonationAuctioned)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		donationID := int64(3)
+		mockRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, DonationID: 2}, nil)
+		mockRepo.EXPECT().ExistsForDonation(donationID).Return(false, nil)
+		mockRepo.EXPECT().Update(gomock.Any()).Return(duplicate)
+
+		_, err := auctionService.Update(1, &dto.AuctionItemUpdateDTO{DonationID: &donationID})
+		assert.ErrorIs(t, err, ErrDonationAuctioned)
+	})
+
+	t.Run("other unique violations are not mapped", func(t *testing.T) {
+		mockRepo.EXPECT().ExistsForDonation(int64(3)).Return(false, nil)
+		mockAI.EXPECT().EstimateStartingPrice(gomock.Any()).Return(float64(100), nil)
+		mockRepo.EXPECT().Create(gomock.Any()).Return(&pgconn.PgError{Code: "23505", ConstraintName: "auction_items_pkey"})
+
+		_, err := auctionService.Create(&dto.AuctionItemDTO{Title: "Test Item", DonationID: 3})
+		assert.ErrorIs(t, err, ErrInvalidAuction)
+	})
+}
+
 func TestAuctionItemService_Delete(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockAuctionItemRepository(ctrl)
 	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	auctionService := NewAuctionItemService(mockRepo, mockAI, logger)
+	auctionService := NewAuctionItemService(mockRepo, mockAI, mockPhotos, logger)
 
 	tests := []struct {
 		name    string
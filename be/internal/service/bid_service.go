@@ -1,29 +1,29 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"milestone3/be/internal/dto"
 	"milestone3/be/internal/entity"
 	"milestone3/be/internal/repository"
+	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
-
-	"github.com/redis/go-redis/v9"
 )
 
 const (
 	MinBidIncrement = 10000
 	MaxRetries      = 3
-)
 
-var (
-	mutex       sync.Map
-	wibLocation *time.Location
+	// DefaultSoftCloseMinutes is used when AUCTION_SOFT_CLOSE_MINUTES is not set
+	DefaultSoftCloseMinutes = 2
 )
 
+var wibLocation *time.Location
+
 func init() {
 	var err error
 	wibLocation, err = time.LoadLocation("Asia/Jakarta")
@@ -33,34 +33,44 @@ func init() {
 	}
 }
 
-func getMutex(itemID int64) *sync.Mutex {
-	m, _ := mutex.LoadOrStore(itemID, &sync.Mutex{})
-	return m.(*sync.Mutex)
-}
-
 type bidService struct {
 	redisRepo          repository.BidRedisRepository
 	bidRepo            repository.BidRepository
 	itemRepo           repository.AuctionItemRepository
 	auctionSessionRepo repository.AuctionSessionRepository
+	eventRepo          repository.BidEventRepository
+	payments           PaymentCreator
 	logger             *slog.Logger
 }
 
+// PaymentCreator starts the payment of a won item, PaymentServ implements it
+type PaymentCreator interface {
+	CreatePayment(req dto.PaymentRequest, userId int, auctionItemId int) (dto.PaymentResponse, error)
+}
+
 type BidService interface {
 	PlaceBid(sessionID, itemID, userID int64, amount float64, sessionEndTime time.Time) error
+	SetMaxBid(sessionID, itemID, userID int64, maxAmount float64, sessionEndTime time.Time) (dto.MaxBidResultDTO, error)
+	BuyNow(sessionID, itemID, userID int64, sessionEndTime time.Time) (dto.BuyNowResultDTO, error)
 	GetHighestBid(sessionID, itemID int64) (float64, int64, error)
+	GetItemEndTime(sessionID, itemID int64) (time.Time, error)
+	GetBidHistory(sessionID, itemID int64, page, limit int) ([]dto.BidHistoryResponse, int64, error)
+	GetMyBids(userID int64, page, limit int) ([]dto.BidHistoryResponse, int64, error)
+	SubscribeBidEvents(ctx context.Context, sessionID, itemID int64) (<-chan dto.BidEventDTO, error)
 
 	SaveKeyToDB() error
 	DeleteKeyValue() error
 	CloseExpiredItemsWithoutBids() error
 }
 
-func NewBidService(r repository.BidRedisRepository, b repository.BidRepository, itemRepo repository.AuctionItemRepository, sessionRepo repository.AuctionSessionRepository, logger *slog.Logger) BidService {
+func NewBidService(r repository.BidRedisRepository, b repository.BidRepository, itemRepo repository.AuctionItemRepository, sessionRepo repository.AuctionSessionRepository, eventRepo repository.BidEventRepository, payments PaymentCreator, logger *slog.Logger) BidService {
 	return &bidService{
 		redisRepo:          r,
 		bidRepo:            b,
 		itemRepo:           itemRepo,
 		auctionSessionRepo: sessionRepo,
+		eventRepo:          eventRepo,
+		payments:           payments,
 		logger:             logger,
 	}
 }
@@ -70,23 +80,195 @@ func (s *bidService) PlaceBid(sessionID, itemID, userID int64, amount float64, s
 		return ErrInvalidBidding
 	}
 
+	item, err := s.getBiddableItem(sessionID, itemID)
+	if err != nil {
+		return err
+	}
+
+	// starting price, increment, current highest and proxy ceilings are resolved atomically in redis
+	result, err := s.redisRepo.CompareAndSetHighestBid(sessionID, itemID, repository.BidAttempt{
+		UserID:          userID,
+		Amount:          amount,
+		StartingPrice:   item.StartingPrice,
+		MinIncrement:    MinBidIncrement,
+		SessionEndTime:  sessionEndTime,
+		SoftCloseWindow: softCloseWindow(),
+	})
+	if err != nil {
+		s.logger.Error("failed to set highest bid", "error", err)
+		return err
+	}
+
+	if result.Outcome == repository.BidRejectedSameOwner {
+		return ErrAlreadyHighestBidder
+	}
+
+	if result.Outcome == repository.BidRejectedClosed {
+		return ErrInvalidAuction
+	}
+
+	if result.Outcome != repository.BidAccepted {
+		return ErrBidTooLow
+	}
+
+	s.logger.Info("bid placed", "sessionID", sessionID, "itemID", itemID, "userID", userID, "amount", amount)
+
+	s.recordBidHistory(sessionID, itemID, result.Placed)
+	s.publishPlacedBids(sessionID, itemID, result)
+
+	return nil
+}
+
+// SetMaxBid registers the highest amount the system may bid for the user,
+// raising it by MinBidIncrement whenever someone else outbids them
+func (s *bidService) SetMaxBid(sessionID, itemID, userID int64, maxAmount float64, sessionEndTime time.Time) (dto.MaxBidResultDTO, error) {
+	if maxAmount <= 0 {
+		return dto.MaxBidResultDTO{}, ErrInvalidBidding
+	}
+
+	item, err := s.getBiddableItem(sessionID, itemID)
+	if err != nil {
+		return dto.MaxBidResultDTO{}, err
+	}
+
+	result, err := s.redisRepo.SetMaxBid(sessionID, itemID, repository.BidAttempt{
+		UserID:          userID,
+		Amount:          maxAmount,
+		StartingPrice:   item.StartingPrice,
+		MinIncrement:    MinBidIncrement,
+		SessionEndTime:  sessionEndTime,
+		SoftCloseWindow: softCloseWindow(),
+	})
+	if err != nil {
+		s.logger.Error("failed to set max bid", "error", err)
+		return dto.MaxBidResultDTO{}, err
+	}
+
+	if result.Outcome == repository.BidRejectedClosed {
+		return dto.MaxBidResultDTO{}, ErrInvalidAuction
+	}
+
+	if result.Outcome != repository.BidAccepted {
+		return dto.MaxBidResultDTO{}, ErrBidTooLow
+	}
+
+	s.logger.Info("max bid set", "sessionID", sessionID, "itemID", itemID, "userID", userID, "maxAmount", maxAmount)
+
+	s.recordBidHistory(sessionID, itemID, result.Placed)
+	s.publishPlacedBids(sessionID, itemID, result)
+
+	return dto.MaxBidResultDTO{
+		SessionID:       sessionID,
+		ItemID:          itemID,
+		MaxAmount:       maxAmount,
+		HighestBid:      result.HighestAmount,
+		IsHighestBidder: result.HighestBidder == userID,
+		EndTime:         result.EndTime.In(wibLocation),
+	}, nil
+}
+
+// BuyNow ends the item's auction for the user at its buy now price, unless
+// bids already reached it, and starts the payment right away
+func (s *bidService) BuyNow(sessionID, itemID, userID int64, sessionEndTime time.Time) (dto.BuyNowResultDTO, error) {
+	item, err := s.getBiddableItem(sessionID, itemID)
+	if err != nil {
+		return dto.BuyNowResultDTO{}, err
+	}
+
+	if item.BuyNowPrice == nil {
+		return dto.BuyNowResultDTO{}, ErrBuyNowUnavailable
+	}
+	price := *item.BuyNowPrice
+
+	// closes the redis state and claims finalisation so SaveKeyToDB skips the key
+	result, err := s.redisRepo.BuyNow(sessionID, itemID, repository.BidAttempt{
+		UserID:         userID,
+		Amount:         price,
+		SessionEndTime: sessionEndTime,
+	})
+	if err != nil {
+		s.logger.Error("failed to buy now", "error", err)
+		return dto.BuyNowResultDTO{}, err
+	}
+
+	switch result.Outcome {
+	case repository.BidAccepted:
+	case repository.BidRejectedExceeded:
+		return dto.BuyNowResultDTO{}, ErrBuyNowExceeded
+	default:
+		return dto.BuyNowResultDTO{}, ErrInvalidAuction
+	}
+
+	key := activeBidKey(sessionID, itemID)
+
+	// the bid and the finished item are saved together
+	err = s.bidRepo.SaveWinningBid(&entity.Bid{
+		SessionID:       sessionID,
+		ItemID:          itemID,
+		UserID:          userID,
+		Amount:          price,
+		PaymentDeadline: paymentDeadline(time.Now()),
+	})
+	if err != nil {
+		// the buyer stays the closed winner, the cron finalises it after the session ends
+		s.logger.Error("failed to save buy now bid", "sessionID", sessionID, "itemID", itemID, "error", err)
+		s.releaseFinalisation(key)
+		return dto.BuyNowResultDTO{}, err
+	}
+
+	s.flushBidHistory(sessionID, itemID)
+
+	if err := s.redisRepo.DeleteKey(key); err != nil {
+		s.logger.Warn("failed to delete Redis key", "key", key, "error", err)
+	}
+
+	s.logger.Info("item bought now", "sessionID", sessionID, "itemID", itemID, "userID", userID, "amount", price)
+
+	s.publishPlacedBids(sessionID, itemID, result)
+	s.publishEvent(dto.BidEventDTO{
+		Type:      dto.BidEventSessionClosed,
+		SessionID: sessionID,
+		ItemID:    itemID,
+		Amount:    price,
+		BidderID:  userID,
+	})
+
+	res := dto.BuyNowResultDTO{
+		SessionID: sessionID,
+		ItemID:    itemID,
+		Amount:    price,
+	}
+
+	// the purchase stands even if the payment has to be retried from the payments endpoint
+	payment, err := s.payments.CreatePayment(dto.PaymentRequest{}, int(userID), int(itemID))
+	if err != nil {
+		s.logger.Error("failed to create buy now payment", "itemID", itemID, "userID", userID, "error", err)
+		return res, nil
+	}
+	res.Payment = &payment
+
+	return res, nil
+}
+
+// getBiddableItem checks the item is ongoing in a session that is open right now
+func (s *bidService) getBiddableItem(sessionID, itemID int64) (*entity.AuctionItem, error) {
 	item, err := s.itemRepo.GetByID(itemID)
 	if err != nil {
-		return ErrAuctionNotFound
+		return nil, ErrAuctionNotFound
 	}
 
 	if item.SessionID == nil || *item.SessionID != sessionID {
-		return ErrInvalidAuction
+		return nil, ErrInvalidAuction
 	}
 
 	if item.Status != "ongoing" {
-		return ErrInvalidAuction
+		return nil, ErrInvalidAuction
 	}
 
 	// validate session has started
 	session, err := s.auctionSessionRepo.GetByID(sessionID)
 	if err != nil {
-		return ErrSessionNotFoundID
+		return nil, ErrSessionNotFoundID
 	}
 
 	// Convert both to same timezone for comparison
@@ -97,62 +279,207 @@ func (s *bidService) PlaceBid(sessionID, itemID, userID int64, amount float64, s
 	sessionEnd := session.EndTime.In(wibLocation)
 
 	if now.Before(sessionStart) {
-		return ErrInvalidAuction
+		return nil, ErrInvalidAuction
 	}
 
-	if now.After(sessionEnd) {
-		return ErrInvalidAuction
+	// a late bid may have kept the item open past the session end
+	if now.After(sessionEnd) && now.After(s.effectiveEndTime(sessionID, itemID, sessionEnd)) {
+		return nil, ErrInvalidAuction
 	}
 
-	if err = s.redisRepo.CheckDuplicateBid(userID, itemID, amount, 10*time.Second); err != nil {
-		return ErrDuplicateBid
+	return item, nil
+}
+
+// softCloseWindow reads AUCTION_SOFT_CLOSE_MINUTES, 0 turns extensions off
+func softCloseWindow() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("AUCTION_SOFT_CLOSE_MINUTES"))
+	if err != nil || minutes < 0 {
+		minutes = DefaultSoftCloseMinutes
 	}
+	return time.Duration(minutes) * time.Minute
+}
 
-	// lock per item
-	mu := getMutex(itemID)
-	mu.Lock()
-	defer mu.Unlock()
+func activeBidKey(sessionID, itemID int64) string {
+	return fmt.Sprintf("active:auction:%d:item:%d", sessionID, itemID)
+}
 
-	currentHighest, currentBid, err := s.redisRepo.GetHighestBid(sessionID, itemID)
-	if err != nil && !errors.Is(err, redis.Nil) {
-		return err
+// effectiveEndTime is the later of the session end and the end_time late bids
+// wrote to redis, items without bids simply end with their session
+func (s *bidService) effectiveEndTime(sessionID, itemID int64, sessionEnd time.Time) time.Time {
+	end, err := s.redisRepo.GetEndTime(activeBidKey(sessionID, itemID))
+	if err != nil || end.Before(sessionEnd) {
+		return sessionEnd.In(wibLocation)
 	}
+	return end.In(wibLocation)
+}
 
-	// validate amount > starting price
-	if currentHighest == 0 {
-		if amount < item.StartingPrice {
-			return ErrBidTooLow
-		}
+func (s *bidService) GetHighestBid(sessionID, itemID int64) (float64, int64, error) {
+	_, err := s.itemRepo.GetByID(itemID)
+	if err != nil {
+		return 0, 0, ErrAuctionNotFound
 	}
+	return s.redisRepo.GetHighestBid(sessionID, itemID)
+}
 
-	if currentHighest > 0 && amount <= currentHighest {
-		return ErrBidTooLow
+func (s *bidService) GetBidHistory(sessionID, itemID int64, page, limit int) ([]dto.BidHistoryResponse, int64, error) {
+	item, err := s.itemRepo.GetByID(itemID)
+	if err != nil {
+		return nil, 0, ErrAuctionNotFound
 	}
 
-	if currentBid == userID {
-		return ErrAlreadyHighestBidder
+	if item.SessionID == nil || *item.SessionID != sessionID {
+		return nil, 0, ErrInvalidAuction
 	}
 
-	if currentHighest > 0 && amount < currentHighest+MinBidIncrement {
-		return ErrBidTooLow
+	history, total, err := s.bidRepo.GetBidHistoryByItem(sessionID, itemID, page, limit)
+	if err != nil {
+		s.logger.Error("failed to get bid history", "sessionID", sessionID, "itemID", itemID, "error", err)
+		return nil, 0, err
 	}
 
-	if err := s.redisRepo.SetHighestBid(sessionID, itemID, amount, userID, sessionEndTime); err != nil {
-		s.logger.Error("failed to set highest bid", "error", err)
-		return err
-	}
+	return dto.BidHistoryResponses(history), total, nil
+}
 
-	s.logger.Info("bid placed", "sessionID", sessionID, "itemID", itemID, "userID", userID, "amount", amount)
+func (s *bidService) GetMyBids(userID int64, page, limit int) ([]dto.BidHistoryResponse, int64, error) {
+	history, total, err := s.bidRepo.GetBidHistoryByUser(userID, page, limit)
+	if err != nil {
+		s.logger.Error("failed to get user bids", "userID", userID, "error", err)
+		return nil, 0, err
+	}
 
-	return nil
+	return dto.BidHistoryResponses(history), total, nil
 }
 
-func (s *bidService) GetHighestBid(sessionID, itemID int64) (float64, int64, error) {
+// GetItemEndTime returns when bidding on the item closes, including soft close extensions
+func (s *bidService) GetItemEndTime(sessionID, itemID int64) (time.Time, error) {
 	_, err := s.itemRepo.GetByID(itemID)
 	if err != nil {
-		return 0, 0, ErrAuctionNotFound
+		return time.Time{}, ErrAuctionNotFound
+	}
+
+	session, err := s.auctionSessionRepo.GetByID(sessionID)
+	if err != nil {
+		return time.Time{}, ErrSessionNotFoundID
+	}
+
+	return s.effectiveEndTime(sessionID, itemID, session.EndTime), nil
+}
+
+// SubscribeBidEvents streams events of one session item until ctx is done
+func (s *bidService) SubscribeBidEvents(ctx context.Context, sessionID, itemID int64) (<-chan dto.BidEventDTO, error) {
+	item, err := s.itemRepo.GetByID(itemID)
+	if err != nil {
+		return nil, ErrAuctionNotFound
+	}
+
+	if item.SessionID == nil || *item.SessionID != sessionID {
+		return nil, ErrInvalidAuction
+	}
+
+	return s.eventRepo.Subscribe(ctx, sessionID, itemID)
+}
+
+// recordBidHistory stores the accepted bids right away, on failure they are
+// still replayed from the redis history when the item closes
+func (s *bidService) recordBidHistory(sessionID, itemID int64, placed []repository.PlacedBid) {
+	if len(placed) == 0 {
+		return
+	}
+
+	now := time.Now()
+	entries := make([]entity.BidHistory, 0, len(placed))
+	for _, bid := range placed {
+		entries = append(entries, entity.BidHistory{
+			SessionID: sessionID,
+			ItemID:    itemID,
+			UserID:    bid.UserID,
+			Amount:    bid.Amount,
+			Source:    bid.Source,
+			CreatedAt: now,
+		})
+	}
+
+	if err := s.bidRepo.SaveBidHistory(entries); err != nil {
+		s.logger.Error("failed to save bid history", "sessionID", sessionID, "itemID", itemID, "error", err)
+	}
+}
+
+// flushBidHistory replays the redis history into the database so bids missed
+// by the live writes are kept, the redis copy is dropped once saved
+func (s *bidService) flushBidHistory(sessionID, itemID int64) {
+	history, err := s.redisRepo.GetBidHistory(sessionID, itemID)
+	if err != nil {
+		s.logger.Warn("failed to read bid history", "sessionID", sessionID, "itemID", itemID, "error", err)
+		return
+	}
+
+	if err := s.bidRepo.SaveBidHistory(history); err != nil {
+		s.logger.Error("failed to save bid history", "sessionID", sessionID, "itemID", itemID, "error", err)
+		return
+	}
+
+	if err := s.redisRepo.DeleteBidHistory(sessionID, itemID); err != nil {
+		s.logger.Warn("failed to delete bid history", "sessionID", sessionID, "itemID", itemID, "error", err)
+	}
+}
+
+// publishPlacedBids emits every bid the redis step wrote, in order, and
+// tells whoever lost the lead at each step that they were outbid
+func (s *bidService) publishPlacedBids(sessionID, itemID int64, result repository.BidResult) {
+	var endTime *time.Time
+	if !result.EndTime.IsZero() {
+		end := result.EndTime.In(wibLocation)
+		endTime = &end
+	}
+
+	leader := result.PreviousBidder
+	for _, bid := range result.Placed {
+		s.publishEvent(dto.BidEventDTO{
+			Type:      dto.BidEventPlaced,
+			SessionID: sessionID,
+			ItemID:    itemID,
+			Amount:    bid.Amount,
+			BidderID:  bid.UserID,
+			Source:    bid.Source,
+			EndTime:   endTime,
+		})
+
+		if leader != 0 && leader != bid.UserID {
+			s.publishEvent(dto.BidEventDTO{
+				Type:         dto.BidEventOutbid,
+				SessionID:    sessionID,
+				ItemID:       itemID,
+				Amount:       bid.Amount,
+				BidderID:     bid.UserID,
+				OutbidUserID: leader,
+				Source:       bid.Source,
+			})
+		}
+		leader = bid.UserID
+	}
+
+	if result.Extended {
+		s.logger.Info("item end time extended", "sessionID", sessionID, "itemID", itemID, "endTime", endTime)
+		s.publishEvent(dto.BidEventDTO{
+			Type:      dto.BidEventEndTimeExtended,
+			SessionID: sessionID,
+			ItemID:    itemID,
+			Amount:    result.HighestAmount,
+			BidderID:  result.HighestBidder,
+			EndTime:   endTime,
+		})
+	}
+}
+
+// publishEvent only logs failures, a missed push must never fail the bid itself
+func (s *bidService) publishEvent(event dto.BidEventDTO) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().In(wibLocation)
+	}
+
+	if err := s.eventRepo.Publish(event); err != nil {
+		s.logger.Warn("failed to publish bid event", "type", event.Type, "sessionID", event.SessionID, "itemID", event.ItemID, "error", err)
 	}
-	return s.redisRepo.GetHighestBid(sessionID, itemID)
 }
 
 func parseKey(key string) (sessionID, itemID int64, err error) {
@@ -208,8 +535,8 @@ func (s *bidService) SaveKeyToDB() error {
 		// Convert both to same timezone for comparison
 		now := time.Now().In(wibLocation)
 
-		// DB stores UTC, convert to WIB
-		endTimeLocal := session.EndTime.In(wibLocation)
+		// honour soft close extensions on top of the session end
+		endTimeLocal := s.effectiveEndTime(parsedSessionID, itemID, session.EndTime)
 
 		if now.Before(endTimeLocal) {
 			continue
@@ -221,39 +548,86 @@ func (s *bidService) SaveKeyToDB() error {
 			continue
 		}
 
-		// save final bid to DB
-		err = s.bidRepo.SaveFinalBid(&entity.Bid{
-			ItemID: itemID,
-			UserID: bid.UserID,
-			Amount: bid.Amount,
-		})
-		if err != nil {
-			s.logger.Error("failed to save final bid", "sessionID", parsedSessionID, "itemID", itemID, "error", err)
+		// a buy now in progress holds the claim, never process the key twice
+		claimed, err := s.redisRepo.ClaimFinalisation(key)
+		if err != nil || !claimed {
 			continue
 		}
 
-		// set status to finished
+		// reserve is checked against the item, retry on the next run if it can't be read
 		item, err := s.itemRepo.GetByID(itemID)
 		if err != nil {
 			s.logger.Warn("failed to get item for status update", "itemID", itemID, "error", err)
+			s.releaseFinalisation(key)
+			continue
+		}
+
+		// below the reserve nobody wins, the item ends unsold
+		reserveMet := item.ReservePrice == nil || bid.Amount >= *item.ReservePrice
+
+		// the redis key stays until the item status is saved, a failure is
+		// retried on the next run
+		if reserveMet {
+			// save final bid to DB together with the finished item
+			err = s.bidRepo.SaveWinningBid(&entity.Bid{
+				SessionID:       parsedSessionID,
+				ItemID:          itemID,
+				UserID:          bid.UserID,
+				Amount:          bid.Amount,
+				PaymentDeadline: paymentDeadline(time.Now()),
+			})
+			if err != nil {
+				s.logger.Error("failed to save final bid", "sessionID", parsedSessionID, "itemID", itemID, "error", err)
+				s.releaseFinalisation(key)
+				continue
+			}
 		} else {
-			item.Status = "finished"
+			item.Status = "unsold"
 			if err := s.itemRepo.Update(item); err != nil {
 				s.logger.Error("failed to update item status", "itemID", itemID, "error", err)
+				s.releaseFinalisation(key)
+				continue
 			}
 		}
 
+		s.flushBidHistory(parsedSessionID, itemID)
+
 		// delete redis key after saved to table Bid
 		if err := s.redisRepo.DeleteKey(key); err != nil {
 			s.logger.Warn("failed to delete Redis key", "key", key, "error", err)
 		}
 
+		if !reserveMet {
+			s.logger.Info("reserve not met, item unsold",
+				"sessionID", parsedSessionID,
+				"itemID", itemID,
+				"amount", bid.Amount,
+			)
+
+			s.publishEvent(dto.BidEventDTO{
+				Type:      dto.BidEventSessionClosed,
+				SessionID: parsedSessionID,
+				ItemID:    itemID,
+				Amount:    bid.Amount,
+				Unsold:    true,
+			})
+			continue
+		}
+
 		s.logger.Info("final bid saved",
 			"sessionID", parsedSessionID,
 			"itemID", itemID,
 			"amount", bid.Amount,
 			"winner", bid.UserID,
 		)
+
+		s.publishEvent(dto.BidEventDTO{
+			Type:      dto.BidEventSessionClosed,
+			SessionID: parsedSessionID,
+			ItemID:    itemID,
+			Amount:    bid.Amount,
+			BidderID:  bid.UserID,
+		})
 		totalSavedAuctionItemToDB++
 	}
 
@@ -269,6 +643,12 @@ func (s *bidService) SaveKeyToDB() error {
 	return nil
 }
 
+func (s *bidService) releaseFinalisation(key string) {
+	if err := s.redisRepo.ReleaseFinalisation(key); err != nil {
+		s.logger.Warn("failed to release finalisation", "key", key, "error", err)
+	}
+}
+
 // CloseExpiredItemsWithoutBids to get the redis key with no bids
 func (s *bidService) CloseExpiredItemsWithoutBids() error {
 	items, err := s.itemRepo.GetAll()
@@ -297,8 +677,8 @@ func (s *bidService) CloseExpiredItemsWithoutBids() error {
 			continue
 		}
 
-		// DB stores UTC, convert to WIB
-		endTimeLocal := session.EndTime.In(wibLocation)
+		// honour soft close extensions on top of the session end
+		endTimeLocal := s.effectiveEndTime(*item.SessionID, item.ID, session.EndTime)
 
 		if now.After(endTimeLocal) {
 			amount, _, err := s.redisRepo.GetHighestBid(*item.SessionID, item.ID)
@@ -310,6 +690,11 @@ func (s *bidService) CloseExpiredItemsWithoutBids() error {
 					s.logger.Error("failed to revert item to scheduled", "itemID", item.ID, "error", err)
 				} else {
 					closedCount++
+					s.publishEvent(dto.BidEventDTO{
+						Type:      dto.BidEventSessionClosed,
+						SessionID: *item.SessionID,
+						ItemID:    item.ID,
+					})
 				}
 			}
 		}
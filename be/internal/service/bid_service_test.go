@@ -1,14 +1,17 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"os"
 	"testing"
 	"time"
 
+	"milestone3/be/internal/dto"
 	"milestone3/be/internal/entity"
 	"milestone3/be/internal/mocks"
+	"milestone3/be/internal/repository"
 
 	"github.com/golang/mock/gomock"
 
@@ -23,9 +26,18 @@ func TestBidService_PlaceBid(t *testing.T) {
 	mockBidRepo := mocks.NewMockBidRepository(ctrl)
 	mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
 	mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+	mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+	mockPayments := mocks.NewMockPaymentCreator(ctrl)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, logger)
+	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
+
+	sessionID := int64(1)
+	activeSession := &entity.AuctionSession{
+		ID:        1,
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(time.Hour),
+	}
 
 	tests := []struct {
 		name           string
@@ -42,19 +54,117 @@ func TestBidService_PlaceBid(t *testing.T) {
 			sessionID:      1,
 			itemID:         1,
 			userID:         1,
-			amount:         150.0,
+			amount:         150000.0,
 			sessionEndTime: time.Now().Add(time.Hour),
 			setup: func() {
 				item := &entity.AuctionItem{
-					ID:     1,
-					Status: "ongoing",
+					ID:        1,
+					Status:    "ongoing",
+					SessionID: &sessionID,
 				}
 				mockItemRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
-				mockRedisRepo.EXPECT().GetHighestBid(int64(1), int64(1)).Return(100.0, int64(2), nil)
-				mockRedisRepo.EXPECT().SetHighestBid(int64(1), int64(1), 150.0, int64(1), gomock.Any()).Return(nil)
+				mockSessionRepo.EXPECT().GetByID(int64(1)).Return(activeSession, nil)
+				mockRedisRepo.EXPECT().CompareAndSetHighestBid(int64(1), int64(1), gomock.Any()).Return(repository.BidResult{
+					Outcome:        repository.BidAccepted,
+					PreviousAmount: 100000,
+					PreviousBidder: 2,
+					HighestAmount:  150000,
+					HighestBidder:  1,
+					Placed:         []repository.PlacedBid{{UserID: 1, Amount: 150000, Source: entity.BidSourceManual}},
+				}, nil)
+				mockBidRepo.EXPECT().SaveBidHistory(gomock.Any()).DoAndReturn(func(entries []entity.BidHistory) error {
+					assert.Len(t, entries, 1)
+					assert.Equal(t, entity.BidSourceManual, entries[0].Source)
+					return nil
+				})
+				mockEventRepo.EXPECT().Publish(gomock.Any()).DoAndReturn(func(event dto.BidEventDTO) error {
+					assert.Equal(t, dto.BidEventPlaced, event.Type)
+					assert.Equal(t, int64(1), event.BidderID)
+					return nil
+				})
+				mockEventRepo.EXPECT().Publish(gomock.Any()).DoAndReturn(func(event dto.BidEventDTO) error {
+					assert.Equal(t, dto.BidEventOutbid, event.Type)
+					assert.Equal(t, int64(2), event.OutbidUserID)
+					return nil
+				})
 			},
 			wantErr: false,
 		},
+		{
+			name:           "late bid inside soft close extension",
+			sessionID:      1,
+			itemID:         1,
+			userID:         1,
+			amount:         150000.0,
+			sessionEndTime: time.Now().Add(-time.Minute),
+			setup: func() {
+				item := &entity.AuctionItem{
+					ID:        1,
+					Status:    "ongoing",
+					SessionID: &sessionID,
+				}
+				endedSession := &entity.AuctionSession{
+					ID:        1,
+					StartTime: time.Now().Add(-time.Hour),
+					EndTime:   time.Now().Add(-time.Minute),
+				}
+				extendedEnd := time.Now().Add(time.Minute)
+				mockItemRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+				mockSessionRepo.EXPECT().GetByID(int64(1)).Return(endedSession, nil)
+				mockRedisRepo.EXPECT().GetEndTime("active:auction:1:item:1").Return(extendedEnd, nil)
+				mockRedisRepo.EXPECT().CompareAndSetHighestBid(int64(1), int64(1), gomock.Any()).Return(repository.BidResult{
+					Outcome:        repository.BidAccepted,
+					PreviousAmount: 100000,
+					PreviousBidder: 0,
+					HighestAmount:  150000,
+					HighestBidder:  1,
+					EndTime:        extendedEnd.Add(time.Minute),
+					Extended:       true,
+					Placed:         []repository.PlacedBid{{UserID: 1, Amount: 150000, Source: entity.BidSourceManual}},
+				}, nil)
+				mockBidRepo.EXPECT().SaveBidHistory(gomock.Any()).DoAndReturn(func(entries []entity.BidHistory) error {
+					assert.Len(t, entries, 1)
+					assert.Equal(t, entity.BidSourceManual, entries[0].Source)
+					return nil
+				})
+				mockEventRepo.EXPECT().Publish(gomock.Any()).DoAndReturn(func(event dto.BidEventDTO) error {
+					assert.Equal(t, dto.BidEventPlaced, event.Type)
+					return nil
+				})
+				mockEventRepo.EXPECT().Publish(gomock.Any()).DoAndReturn(func(event dto.BidEventDTO) error {
+					assert.Equal(t, dto.BidEventEndTimeExtended, event.Type)
+					if assert.NotNil(t, event.EndTime) {
+						assert.Equal(t, extendedEnd.Add(time.Minute).Unix(), event.EndTime.Unix())
+					}
+					return nil
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name:           "bid after extended end",
+			sessionID:      1,
+			itemID:         1,
+			userID:         1,
+			amount:         150000.0,
+			sessionEndTime: time.Now().Add(-2 * time.Minute),
+			setup: func() {
+				item := &entity.AuctionItem{
+					ID:        1,
+					Status:    "ongoing",
+					SessionID: &sessionID,
+				}
+				endedSession := &entity.AuctionSession{
+					ID:        1,
+					StartTime: time.Now().Add(-time.Hour),
+					EndTime:   time.Now().Add(-2 * time.Minute),
+				}
+				mockItemRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+				mockSessionRepo.EXPECT().GetByID(int64(1)).Return(endedSession, nil)
+				mockRedisRepo.EXPECT().GetEndTime("active:auction:1:item:1").Return(time.Now().Add(-time.Minute), nil)
+			},
+			wantErr: true,
+		},
 		{
 			name:           "invalid bid amount - zero",
 			sessionID:      1,
@@ -102,11 +212,40 @@ func TestBidService_PlaceBid(t *testing.T) {
 			sessionEndTime: time.Now().Add(time.Hour),
 			setup: func() {
 				item := &entity.AuctionItem{
-					ID:     1,
-					Status: "ongoing",
+					ID:        1,
+					Status:    "ongoing",
+					SessionID: &sessionID,
 				}
 				mockItemRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
-				mockRedisRepo.EXPECT().GetHighestBid(int64(1), int64(1)).Return(100.0, int64(2), nil)
+				mockSessionRepo.EXPECT().GetByID(int64(1)).Return(activeSession, nil)
+				mockRedisRepo.EXPECT().CompareAndSetHighestBid(int64(1), int64(1), gomock.Any()).Return(repository.BidResult{
+					Outcome:        repository.BidRejectedTooLow,
+					PreviousAmount: 100,
+					PreviousBidder: 2,
+				}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:           "already highest bidder",
+			sessionID:      1,
+			itemID:         1,
+			userID:         2,
+			amount:         200000.0,
+			sessionEndTime: time.Now().Add(time.Hour),
+			setup: func() {
+				item := &entity.AuctionItem{
+					ID:        1,
+					Status:    "ongoing",
+					SessionID: &sessionID,
+				}
+				mockItemRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+				mockSessionRepo.EXPECT().GetByID(int64(1)).Return(activeSession, nil)
+				mockRedisRepo.EXPECT().CompareAndSetHighestBid(int64(1), int64(1), gomock.Any()).Return(repository.BidResult{
+					Outcome:        repository.BidRejectedSameOwner,
+					PreviousAmount: 100000,
+					PreviousBidder: 2,
+				}, nil)
 			},
 			wantErr: true,
 		},
@@ -127,6 +266,128 @@ func TestBidService_PlaceBid(t *testing.T) {
 	}
 }
 
+func TestBidService_SetMaxBid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedisRepo := mocks.NewMockBidRedisRepository(ctrl)
+	mockBidRepo := mocks.NewMockBidRepository(ctrl)
+	mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+	mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+	mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+	mockPayments := mocks.NewMockPaymentCreator(ctrl)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
+
+	sessionID := int64(1)
+	activeSession := &entity.AuctionSession{
+		ID:        1,
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(time.Hour),
+	}
+	item := &entity.AuctionItem{
+		ID:            1,
+		Status:        "ongoing",
+		SessionID:     &sessionID,
+		StartingPrice: 50000,
+	}
+
+	tests := []struct {
+		name       string
+		userID     int64
+		maxAmount  float64
+		setup      func()
+		wantErr    error
+		wantLeader bool
+	}{
+		{
+			name:      "proxy outbids current leader",
+			userID:    3,
+			maxAmount: 300000,
+			setup: func() {
+				mockItemRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+				mockSessionRepo.EXPECT().GetByID(int64(1)).Return(activeSession, nil)
+				mockRedisRepo.EXPECT().SetMaxBid(int64(1), int64(1), gomock.Any()).DoAndReturn(func(_, _ int64, attempt repository.BidAttempt) (repository.BidResult, error) {
+					assert.Equal(t, 300000.0, attempt.Amount)
+					assert.Equal(t, 50000.0, attempt.StartingPrice)
+					return repository.BidResult{
+						Outcome:        repository.BidAccepted,
+						PreviousAmount: 110000,
+						PreviousBidder: 1,
+						HighestAmount:  210000,
+						HighestBidder:  3,
+						Placed: []repository.PlacedBid{
+							{UserID: 1, Amount: 200000, Source: entity.BidSourceProxy},
+							{UserID: 3, Amount: 210000, Source: entity.BidSourceProxy},
+						},
+					}, nil
+				})
+				mockBidRepo.EXPECT().SaveBidHistory(gomock.Any()).DoAndReturn(func(entries []entity.BidHistory) error {
+					assert.Len(t, entries, 2)
+					return nil
+				})
+				// leader's own ceiling raise, then the takeover and its outbid notice
+				gomock.InOrder(
+					mockEventRepo.EXPECT().Publish(gomock.Any()).DoAndReturn(func(event dto.BidEventDTO) error {
+						assert.Equal(t, dto.BidEventPlaced, event.Type)
+						assert.Equal(t, int64(1), event.BidderID)
+						return nil
+					}),
+					mockEventRepo.EXPECT().Publish(gomock.Any()).DoAndReturn(func(event dto.BidEventDTO) error {
+						assert.Equal(t, dto.BidEventPlaced, event.Type)
+						assert.Equal(t, int64(3), event.BidderID)
+						assert.Equal(t, entity.BidSourceProxy, event.Source)
+						return nil
+					}),
+					mockEventRepo.EXPECT().Publish(gomock.Any()).DoAndReturn(func(event dto.BidEventDTO) error {
+						assert.Equal(t, dto.BidEventOutbid, event.Type)
+						assert.Equal(t, int64(1), event.OutbidUserID)
+						return nil
+					}),
+				)
+			},
+			wantLeader: true,
+		},
+		{
+			name:      "max below next valid bid",
+			userID:    3,
+			maxAmount: 60000,
+			setup: func() {
+				mockItemRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+				mockSessionRepo.EXPECT().GetByID(int64(1)).Return(activeSession, nil)
+				mockRedisRepo.EXPECT().SetMaxBid(int64(1), int64(1), gomock.Any()).Return(repository.BidResult{
+					Outcome: repository.BidRejectedTooLow,
+				}, nil)
+			},
+			wantErr: ErrBidTooLow,
+		},
+		{
+			name:      "invalid max amount",
+			userID:    3,
+			maxAmount: 0,
+			setup:     func() {},
+			wantErr:   ErrInvalidBidding,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+
+			res, err := bidService.SetMaxBid(1, 1, tt.userID, tt.maxAmount, activeSession.EndTime)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.maxAmount, res.MaxAmount)
+				assert.Equal(t, tt.wantLeader, res.IsHighestBidder)
+			}
+		})
+	}
+}
+
 func TestBidService_GetHighestBid(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -135,9 +396,11 @@ func TestBidService_GetHighestBid(t *testing.T) {
 	mockBidRepo := mocks.NewMockBidRepository(ctrl)
 	mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
 	mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+	mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+	mockPayments := mocks.NewMockPaymentCreator(ctrl)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, logger)
+	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
 
 	tests := []struct {
 		name      string
@@ -186,3 +449,432 @@ func TestBidService_GetHighestBid(t *testing.T) {
 		})
 	}
 }
+
+func TestBidService_SubscribeBidEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedisRepo := mocks.NewMockBidRedisRepository(ctrl)
+	mockBidRepo := mocks.NewMockBidRepository(ctrl)
+	mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+	mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+	mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+	mockPayments := mocks.NewMockPaymentCreator(ctrl)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
+
+	sessionID := int64(1)
+	otherSessionID := int64(2)
+
+	tests := []struct {
+		name    string
+		itemID  int64
+		setup   func()
+		wantErr error
+	}{
+		{
+			name:   "successful subscribe",
+			itemID: 1,
+			setup: func() {
+				mockItemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, SessionID: &sessionID}, nil)
+				mockEventRepo.EXPECT().Subscribe(gomock.Any(), int64(1), int64(1)).Return(make(chan dto.BidEventDTO), nil)
+			},
+		},
+		{
+			name:   "item not found",
+			itemID: 999,
+			setup: func() {
+				mockItemRepo.EXPECT().GetByID(int64(999)).Return(nil, errors.New("not found"))
+			},
+			wantErr: ErrAuctionNotFound,
+		},
+		{
+			name:   "item belongs to another session",
+			itemID: 2,
+			setup: func() {
+				mockItemRepo.EXPECT().GetByID(int64(2)).Return(&entity.AuctionItem{ID: 2, SessionID: &otherSessionID}, nil)
+			},
+			wantErr: ErrInvalidAuction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+
+			events, err := bidService.SubscribeBidEvents(context.Background(), sessionID, tt.itemID)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, events)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, events)
+			}
+		})
+	}
+}
+
+func TestBidService_GetBidHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedisRepo := mocks.NewMockBidRedisRepository(ctrl)
+	mockBidRepo := mocks.NewMockBidRepository(ctrl)
+	mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+	mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+	mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+	mockPayments := mocks.NewMockPaymentCreator(ctrl)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
+
+	sessionID := int64(1)
+	otherSessionID := int64(2)
+
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr error
+		want    int
+	}{
+		{
+			name: "successful get bid history",
+			setup: func() {
+				mockItemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, SessionID: &sessionID}, nil)
+				mockBidRepo.EXPECT().GetBidHistoryByItem(int64(1), int64(1), 1, 10).Return([]entity.BidHistory{
+					{ID: 2, SessionID: 1, ItemID: 1, UserID: 2, Amount: 60000, Source: entity.BidSourceProxy},
+					{ID: 1, SessionID: 1, ItemID: 1, UserID: 1, Amount: 50000, Source: entity.BidSourceManual},
+				}, int64(2), nil)
+			},
+			want: 2,
+		},
+		{
+			name: "item not in session",
+			setup: func() {
+				mockItemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, SessionID: &otherSessionID}, nil)
+			},
+			wantErr: ErrInvalidAuction,
+		},
+		{
+			name: "item not found",
+			setup: func() {
+				mockItemRepo.EXPECT().GetByID(int64(1)).Return(nil, errors.New("not found"))
+			},
+			wantErr: ErrAuctionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+
+			bids, total, err := bidService.GetBidHistory(1, 1, 1, 10)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, bids, tt.want)
+				assert.Equal(t, int64(tt.want), total)
+				assert.Equal(t, int64(2), bids[0].BidderID)
+			}
+		})
+	}
+}
+
+func TestBidService_GetMyBids(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedisRepo := mocks.NewMockBidRedisRepository(ctrl)
+	mockBidRepo := mocks.NewMockBidRepository(ctrl)
+	mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+	mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+	mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+	mockPayments := mocks.NewMockPaymentCreator(ctrl)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
+
+	mockBidRepo.EXPECT().GetBidHistoryByUser(int64(1), 1, 10).Return([]entity.BidHistory{
+		{ID: 1, SessionID: 1, ItemID: 3, UserID: 1, Amount: 50000, Source: entity.BidSourceManual},
+	}, int64(1), nil)
+
+	bids, total, err := bidService.GetMyBids(1, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, int64(3), bids[0].ItemID)
+
+	mockBidRepo.EXPECT().GetBidHistoryByUser(int64(1), 1, 10).Return(nil, int64(0), errors.New("db error"))
+
+	_, _, err = bidService.GetMyBids(1, 1, 10)
+	assert.Error(t, err)
+}
+
+func TestBidService_SaveKeyToDB_ReservePrice(t *testing.T) {
+	sessionID := int64(1)
+	reserve := 200000.0
+	endedSession := &entity.AuctionSession{
+		ID:        1,
+		StartTime: time.Now().Add(-2 * time.Hour),
+		EndTime:   time.Now().Add(-time.Minute),
+	}
+
+	tests := []struct {
+		name       string
+		amount     float64
+		wantStatus string
+		wantSaved  bool
+	}{
+		{name: "reserve met", amount: 250000, wantStatus: "finished", wantSaved: true},
+		{name: "reserve not met", amount: 150000, wantStatus: "unsold", wantSaved: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRedisRepo := mocks.NewMockBidRedisRepository(ctrl)
+			mockBidRepo := mocks.NewMockBidRepository(ctrl)
+			mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+			mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+			mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+			mockPayments := mocks.NewMockPaymentCreator(ctrl)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+			bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
+
+			key := "active:auction:1:item:1"
+			item := &entity.AuctionItem{ID: 1, Status: "ongoing", SessionID: &sessionID, ReservePrice: &reserve}
+
+			mockRedisRepo.EXPECT().ScanKeys("active:auction:*:item:*").Return([]string{key}, nil)
+			mockSessionRepo.EXPECT().GetByID(int64(1)).Return(endedSession, nil)
+			mockRedisRepo.EXPECT().GetEndTime(key).Return(time.Time{}, errors.New("key not found"))
+			mockRedisRepo.EXPECT().GetBidByKey(key).Return(repository.BidEntry{UserID: 2, ItemID: 1, Amount: tt.amount}, nil)
+			mockRedisRepo.EXPECT().ClaimFinalisation(key).Return(true, nil)
+			mockItemRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+			if tt.wantSaved {
+				// the item is finished in the same transaction as the bid
+				mockBidRepo.EXPECT().SaveWinningBid(gomock.Any()).Return(nil)
+			} else {
+				mockItemRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(updated *entity.AuctionItem) error {
+					assert.Equal(t, tt.wantStatus, updated.Status)
+					return nil
+				})
+			}
+			mockRedisRepo.EXPECT().GetBidHistory(int64(1), int64(1)).Return(nil, nil)
+			mockBidRepo.EXPECT().SaveBidHistory(gomock.Any()).Return(nil)
+			mockRedisRepo.EXPECT().DeleteBidHistory(int64(1), int64(1)).Return(nil)
+			mockRedisRepo.EXPECT().DeleteKey(key).Return(nil)
+			mockEventRepo.EXPECT().Publish(gomock.Any()).DoAndReturn(func(event dto.BidEventDTO) error {
+				assert.Equal(t, dto.BidEventSessionClosed, event.Type)
+				assert.Equal(t, !tt.wantSaved, event.Unsold)
+				return nil
+			})
+			mockItemRepo.EXPECT().GetAll().Return(nil, nil)
+
+			assert.NoError(t, bidService.SaveKeyToDB())
+		})
+	}
+}
+
+func TestBidService_SaveKeyToDB_SkipsClaimedKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedisRepo := mocks.NewMockBidRedisRepository(ctrl)
+	mockBidRepo := mocks.NewMockBidRepository(ctrl)
+	mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+	mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+	mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+	mockPayments := mocks.NewMockPaymentCreator(ctrl)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
+
+	key := "active:auction:1:item:1"
+	endedSession := &entity.AuctionSession{
+		ID:        1,
+		StartTime: time.Now().Add(-2 * time.Hour),
+		EndTime:   time.Now().Add(-time.Minute),
+	}
+
+	// a buy now holds the key, the cron must not save a second final bid
+	mockRedisRepo.EXPECT().ScanKeys("active:auction:*:item:*").Return([]string{key}, nil)
+	mockSessionRepo.EXPECT().GetByID(int64(1)).Return(endedSession, nil)
+	mockRedisRepo.EXPECT().GetEndTime(key).Return(endedSession.EndTime, nil)
+	mockRedisRepo.EXPECT().GetBidByKey(key).Return(repository.BidEntry{UserID: 2, ItemID: 1, Amount: 300000, Closed: true}, nil)
+	mockRedisRepo.EXPECT().ClaimFinalisation(key).Return(false, nil)
+	mockItemRepo.EXPECT().GetAll().Return(nil, nil)
+
+	assert.NoError(t, bidService.SaveKeyToDB())
+}
+
+func TestBidService_SaveKeyToDB_KeepsKeyWhenItemNotSaved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedisRepo := mocks.NewMockBidRedisRepository(ctrl)
+	mockBidRepo := mocks.NewMockBidRepository(ctrl)
+	mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+	mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+	mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+	mockPayments := mocks.NewMockPaymentCreator(ctrl)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
+
+	key := "active:auction:1:item:1"
+	sessionID := int64(1)
+	reserve := 200000.0
+	endedSession := &entity.AuctionSession{
+		ID:        1,
+		StartTime: time.Now().Add(-2 * time.Hour),
+		EndTime:   time.Now().Add(-time.Minute),
+	}
+
+	// the unsold status cannot be saved, the key stays for the next run
+	mockRedisRepo.EXPECT().ScanKeys("active:auction:*:item:*").Return([]string{key}, nil)
+	mockSessionRepo.EXPECT().GetByID(int64(1)).Return(endedSession, nil)
+	mockRedisRepo.EXPECT().GetEndTime(key).Return(endedSession.EndTime, nil)
+	mockRedisRepo.EXPECT().GetBidByKey(key).Return(repository.BidEntry{UserID: 2, ItemID: 1, Amount: 150000}, nil)
+	mockRedisRepo.EXPECT().ClaimFinalisation(key).Return(true, nil)
+	mockItemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "ongoing", SessionID: &sessionID, ReservePrice: &reserve}, nil)
+	mockItemRepo.EXPECT().Update(gomock.Any()).Return(errors.New("db error"))
+	mockRedisRepo.EXPECT().ReleaseFinalisation(key).Return(nil)
+	mockItemRepo.EXPECT().GetAll().Return(nil, nil)
+
+	assert.NoError(t, bidService.SaveKeyToDB())
+}
+
+func TestBidService_BuyNow(t *testing.T) {
+	sessionID := int64(1)
+	buyNowPrice := 300000.0
+	activeSession := &entity.AuctionSession{
+		ID:        1,
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(time.Hour),
+	}
+	key := "active:auction:1:item:1"
+
+	tests := []struct {
+		name        string
+		buyNowPrice *float64
+		setup       func(redisRepo *mocks.MockBidRedisRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository, eventRepo *mocks.MockBidEventRepository, payments *mocks.MockPaymentCreator)
+		wantErr     error
+		wantPayment bool
+	}{
+		{
+			name:        "successful buy now",
+			buyNowPrice: &buyNowPrice,
+			setup: func(redisRepo *mocks.MockBidRedisRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository, eventRepo *mocks.MockBidEventRepository, payments *mocks.MockPaymentCreator) {
+				redisRepo.EXPECT().BuyNow(int64(1), int64(1), gomock.Any()).Return(repository.BidResult{
+					Outcome:        repository.BidAccepted,
+					PreviousAmount: 100000,
+					PreviousBidder: 2,
+					HighestAmount:  buyNowPrice,
+					HighestBidder:  1,
+					Placed:         []repository.PlacedBid{{UserID: 1, Amount: buyNowPrice, Source: entity.BidSourceBuyNow}},
+				}, nil)
+				bidRepo.EXPECT().SaveWinningBid(gomock.Any()).DoAndReturn(func(bid *entity.Bid) error {
+					assert.Equal(t, buyNowPrice, bid.Amount)
+					assert.Equal(t, int64(1), bid.UserID)
+					assert.NotNil(t, bid.PaymentDeadline)
+					return nil
+				})
+				redisRepo.EXPECT().GetBidHistory(int64(1), int64(1)).Return(nil, nil)
+				bidRepo.EXPECT().SaveBidHistory(gomock.Any()).Return(nil)
+				redisRepo.EXPECT().DeleteBidHistory(int64(1), int64(1)).Return(nil)
+				redisRepo.EXPECT().DeleteKey(key).Return(nil)
+				// bid placed, outbid notice, session closed
+				eventRepo.EXPECT().Publish(gomock.Any()).Return(nil).Times(3)
+				payments.EXPECT().CreatePayment(dto.PaymentRequest{}, 1, 1).Return(dto.PaymentResponse{OrderId: "YDR-1"}, nil)
+			},
+			wantPayment: true,
+		},
+		{
+			name:        "bids already reached buy now price",
+			buyNowPrice: &buyNowPrice,
+			setup: func(redisRepo *mocks.MockBidRedisRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository, eventRepo *mocks.MockBidEventRepository, payments *mocks.MockPaymentCreator) {
+				redisRepo.EXPECT().BuyNow(int64(1), int64(1), gomock.Any()).Return(repository.BidResult{
+					Outcome: repository.BidRejectedExceeded,
+				}, nil)
+			},
+			wantErr: ErrBuyNowExceeded,
+		},
+		{
+			name: "item without buy now price",
+			setup: func(redisRepo *mocks.MockBidRedisRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository, eventRepo *mocks.MockBidEventRepository, payments *mocks.MockPaymentCreator) {
+			},
+			wantErr: ErrBuyNowUnavailable,
+		},
+		{
+			name:        "final bid save fails releases the claim",
+			buyNowPrice: &buyNowPrice,
+			setup: func(redisRepo *mocks.MockBidRedisRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository, eventRepo *mocks.MockBidEventRepository, payments *mocks.MockPaymentCreator) {
+				redisRepo.EXPECT().BuyNow(int64(1), int64(1), gomock.Any()).Return(repository.BidResult{
+					Outcome: repository.BidAccepted,
+				}, nil)
+				// the item update fails with the bid, the redis key is kept for the cron
+				bidRepo.EXPECT().SaveWinningBid(gomock.Any()).Return(errors.New("db error"))
+				redisRepo.EXPECT().ReleaseFinalisation(key).Return(nil)
+			},
+			wantErr: errors.New("db error"),
+		},
+		{
+			name:        "payment failure keeps the purchase",
+			buyNowPrice: &buyNowPrice,
+			setup: func(redisRepo *mocks.MockBidRedisRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository, eventRepo *mocks.MockBidEventRepository, payments *mocks.MockPaymentCreator) {
+				redisRepo.EXPECT().BuyNow(int64(1), int64(1), gomock.Any()).Return(repository.BidResult{
+					Outcome:       repository.BidAccepted,
+					HighestAmount: buyNowPrice,
+					HighestBidder: 1,
+					Placed:        []repository.PlacedBid{{UserID: 1, Amount: buyNowPrice, Source: entity.BidSourceBuyNow}},
+				}, nil)
+				bidRepo.EXPECT().SaveWinningBid(gomock.Any()).Return(nil)
+				redisRepo.EXPECT().GetBidHistory(int64(1), int64(1)).Return(nil, nil)
+				bidRepo.EXPECT().SaveBidHistory(gomock.Any()).Return(nil)
+				redisRepo.EXPECT().DeleteBidHistory(int64(1), int64(1)).Return(nil)
+				redisRepo.EXPECT().DeleteKey(key).Return(nil)
+				eventRepo.EXPECT().Publish(gomock.Any()).Return(nil).Times(2)
+				payments.EXPECT().CreatePayment(dto.PaymentRequest{}, 1, 1).Return(dto.PaymentResponse{}, errors.New("gateway down"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRedisRepo := mocks.NewMockBidRedisRepository(ctrl)
+			mockBidRepo := mocks.NewMockBidRepository(ctrl)
+			mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+			mockSessionRepo := mocks.NewMockAuctionSessionRepository(ctrl)
+			mockEventRepo := mocks.NewMockBidEventRepository(ctrl)
+			mockPayments := mocks.NewMockPaymentCreator(ctrl)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+			bidService := NewBidService(mockRedisRepo, mockBidRepo, mockItemRepo, mockSessionRepo, mockEventRepo, mockPayments, logger)
+
+			item := &entity.AuctionItem{ID: 1, Status: "ongoing", SessionID: &sessionID, BuyNowPrice: tt.buyNowPrice}
+			mockItemRepo.EXPECT().GetByID(int64(1)).Return(item, nil)
+			mockSessionRepo.EXPECT().GetByID(int64(1)).Return(activeSession, nil)
+			tt.setup(mockRedisRepo, mockBidRepo, mockItemRepo, mockEventRepo, mockPayments)
+
+			res, err := bidService.BuyNow(1, 1, 1, activeSession.EndTime)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, buyNowPrice, res.Amount)
+			assert.Equal(t, tt.wantPayment, res.Payment != nil)
+		})
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"context"
 	"errors"
 	"io"
-	"time"
+	"strings"
 
 	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
 	"milestone3/be/internal/repository"
 
 	"github.com/sirupsen/logrus"
@@ -16,22 +17,27 @@ import (
 type DonationService interface {
 	CreateDonation(donationDTO dto.DonationDTO) error
 	GetAllDonations(userID uint, isAdmin bool, page, limit int) ([]dto.DonationDTO, int64, error)
-	GetDonationByID(id uint) (dto.DonationDTO, error)
+	GetDonationByID(id uint, userID uint, isAdmin bool) (dto.DonationDTO, error)
 	UpdateDonation(donationDTO dto.DonationDTO, userID uint, isAdmin bool) error
 	DeleteDonation(id uint, userID uint, isAdmin bool) error
 	PatchDonation(donationDTO dto.DonationDTO, userID uint, isAdmin bool) error
+	GetStatusHistory(id uint, userID uint, isAdmin bool) ([]dto.DonationStatusHistoryDTO, error)
 	CanManageDonations(userID uint, ownerID uint, isAdmin bool) bool
 }
 
 type donationService struct {
 	repo         repository.DonationRepo
 	privateStore repository.GCPStorageRepo
+	ai           repository.AIRepository
+	photos       PhotoURLSigner
 }
 
-func NewDonationService(repo repository.DonationRepo, privateStore repository.GCPStorageRepo) DonationService {
+func NewDonationService(repo repository.DonationRepo, privateStore repository.GCPStorageRepo, ai repository.AIRepository, photos PhotoURLSigner) DonationService {
 	return &donationService{
 		repo:         repo,
 		privateStore: privateStore,
+		ai:           ai,
+		photos:       photos,
 	}
 }
 
@@ -41,6 +47,11 @@ func (s *donationService) CreateDonation(donationDTO dto.DonationDTO) error {
 		logrus.WithError(err).Error("Failed to convert DTO to entity")
 		return err
 	}
+
+	// Every donation starts in review, whatever the donor sent
+	donation.Status = entity.StatusPending
+	donation.ReviewComment = ""
+
 	if err := s.repo.CreateDonation(donation); err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"user_id": donation.UserID,
@@ -57,16 +68,16 @@ func (s *donationService) GetAllDonations(userID uint, isAdmin bool, page, limit
 		if err != nil {
 			return nil, 0, err
 		}
-		return dto.DonationResponses(donations), total, nil
+		return s.withPhotoURLs(dto.DonationResponses(donations)), total, nil
 	}
 	donations, total, err := s.repo.GetDonationsByUserID(userID, page, limit)
 	if err != nil {
 		return nil, 0, err
 	}
-	return dto.DonationResponses(donations), total, nil
+	return s.withPhotoURLs(dto.DonationResponses(donations)), total, nil
 }
 
-func (s *donationService) GetDonationByID(id uint) (dto.DonationDTO, error) {
+func (s *donationService) GetDonationByID(id uint, userID uint, isAdmin bool) (dto.DonationDTO, error) {
 	donation, err := s.repo.GetDonationByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -74,7 +85,29 @@ func (s *donationService) GetDonationByID(id uint) (dto.DonationDTO, error) {
 		}
 		return dto.DonationDTO{}, err
 	}
-	return dto.DonationResponse(donation), nil
+
+	// Photos are only signed for the owner and reviewers
+	if !s.CanManageDonations(userID, donation.UserID, isAdmin) {
+		return dto.DonationDTO{}, ErrForbidden
+	}
+	return s.withPhotoURLs([]dto.DonationDTO{dto.DonationResponse(donation)})[0], nil
+}
+
+// withPhotoURLs signs the photos of all the donations in one batch
+func (s *donationService) withPhotoURLs(donations []dto.DonationDTO) []dto.DonationDTO {
+	var names []string
+	for _, d := range donations {
+		names = append(names, d.Photos...)
+	}
+	if len(names) == 0 {
+		return donations
+	}
+
+	urls := s.photos.Sign(names)
+	for i := range donations {
+		donations[i].PhotoURLs = photoURLsOf(donations[i].Photos, urls)
+	}
+	return donations
 }
 
 func (s *donationService) UpdateDonation(donationDTO dto.DonationDTO, userID uint, isAdmin bool) error {
@@ -94,8 +127,23 @@ func (s *donationService) UpdateDonation(donationDTO dto.DonationDTO, userID uin
 	if !s.CanManageDonations(userID, existing.UserID, isAdmin) {
 		return ErrForbidden
 	}
+	if !existing.Status.Editable() {
+		return ErrDonationLocked
+	}
 
-	return s.repo.UpdateDonation(donation)
+	// The donor answering a request for more information sends it back to review
+	resubmit := existing.Status == entity.StatusNeedsInfo && userID == existing.UserID
+
+	changed, err := s.repo.UpdateDonation(donation, resubmit, userID)
+	if err != nil {
+		logrus.WithError(err).WithField("donation_id", existing.ID).Error("Failed to update donation")
+		return err
+	}
+	if !changed {
+		// a review got there first
+		return ErrDonationLocked
+	}
+	return nil
 }
 
 func (s *donationService) DeleteDonation(id uint, userID uint, isAdmin bool) error {
@@ -114,13 +162,14 @@ func (s *donationService) DeleteDonation(id uint, userID uint, isAdmin bool) err
 	return s.repo.DeleteDonation(id)
 }
 
+// PatchDonation moves a donation through the review, only reviewers may do it
+// and only along the transitions entity.StatusDonation allows
 func (s *donationService) PatchDonation(donationDTO dto.DonationDTO, userID uint, isAdmin bool) error {
-	donation, err := dto.DonationRequest(donationDTO)
-	if err != nil {
-		return err
+	if !isAdmin {
+		return ErrForbidden
 	}
 
-	existing, err := s.repo.GetDonationByID(donation.ID)
+	existing, err := s.repo.GetDonationByID(donationDTO.ID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrDonationNotFound
@@ -128,11 +177,85 @@ func (s *donationService) PatchDonation(donationDTO dto.DonationDTO, userID uint
 		return err
 	}
 
-	if !s.CanManageDonations(userID, existing.UserID, isAdmin) {
-		return ErrForbidden
+	next := donationDTO.Status
+	if !existing.Status.CanMoveTo(next) {
+		return ErrInvalidStatusTransition
+	}
+
+	comment := strings.TrimSpace(donationDTO.ReviewComment)
+	if comment == "" && (next == entity.StatusRejected || next == entity.StatusNeedsInfo) {
+		return ErrReviewCommentRequired
 	}
 
-	return s.repo.PatchDonation(donation)
+	// Verified for auction starts a draft auction item, the price estimate runs
+	// before the transaction since it calls out to the AI
+	var auctionItem *entity.AuctionItem
+	if next == entity.StatusVerifiedForAuction {
+		auctionItem = s.auctionDraft(existing)
+	}
+
+	changed, err := s.repo.PatchDonation(entity.Donation{ID: existing.ID, Status: next, ReviewComment: comment}, existing.Status, userID, auctionItem)
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"donation_id": existing.ID,
+			"status":      next,
+		}).Error("Failed to update donation status")
+		return err
+	}
+	if !changed {
+		return ErrDonationStatusChanged
+	}
+	return nil
+}
+
+// auctionDraft carries the donation over to an auction item an admin
+// schedules once they checked the estimated starting price
+func (s *donationService) auctionDraft(donation entity.Donation) *entity.AuctionItem {
+	price, err := s.ai.EstimateStartingPrice(repository.PriceEstimationRequest{
+		Name:        donation.Title,
+		Category:    donation.Category,
+		Condition:   donation.Condition,
+		Description: donation.Description,
+	})
+	if err != nil || price <= 0 {
+		logrus.WithError(err).WithField("donation_id", donation.ID).Warn("Failed to estimate starting price, using the default")
+		price = DefaultStartingPrice
+	}
+
+	photos := make([]entity.AuctionItemPhoto, 0, len(donation.Photos))
+	for _, p := range donation.Photos {
+		photos = append(photos, entity.AuctionItemPhoto{URL: p.URL})
+	}
+
+	return &entity.AuctionItem{
+		DonationID:    int64(donation.ID),
+		Title:         donation.Title,
+		Description:   donation.Description,
+		Category:      donation.Category,
+		Status:        entity.AuctionItemStatusDraft,
+		StartingPrice: price,
+		Photos:        photos,
+	}
+}
+
+func (s *donationService) GetStatusHistory(id uint, userID uint, isAdmin bool) ([]dto.DonationStatusHistoryDTO, error) {
+	donation, err := s.repo.GetDonationByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDonationNotFound
+		}
+		return nil, err
+	}
+
+	if !s.CanManageDonations(userID, donation.UserID, isAdmin) {
+		return nil, ErrForbidden
+	}
+
+	history, err := s.repo.GetStatusHistory(id)
+	if err != nil {
+		return nil, err
+	}
+	return dto.DonationStatusHistoryResponses(history), nil
 }
 
 func (s *donationService) CanManageDonations(userID uint, ownerID uint, isAdmin bool) bool {
@@ -153,11 +276,3 @@ func (s *donationService) UploadDonationImage(ctx context.Context, file io.Reade
 	}
 	return objectName, nil
 }
-
-func (s *donationService) GetDonationImageURL(ctx context.Context, objectName string) (string, error) {
-	url, err := s.privateStore.GenerateSignedURL(ctx, objectName, 10*time.Minute)
-	if err != nil {
-		return ErrSignedURLFailed.Error(), err
-	}
-	return url, nil
-}
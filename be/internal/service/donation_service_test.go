@@ -7,6 +7,7 @@ import (
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/entity"
 	"milestone3/be/internal/mocks"
+	"milestone3/be/internal/repository"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -19,7 +20,9 @@ func TestDonationService_CreateDonation(t *testing.T) {
 
 	mockRepo := mocks.NewMockDonationRepo(ctrl)
 	mockStorage := mocks.NewMockGCPStorageRepo(ctrl)
-	donationService := NewDonationService(mockRepo, mockStorage)
+	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
+	donationService := NewDonationService(mockRepo, mockStorage, mockAI, mockPhotos)
 
 	tests := []struct {
 		name    string
@@ -39,6 +42,24 @@ func TestDonationService_CreateDonation(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "status sent by the donor is ignored",
+			req: dto.DonationDTO{
+				Title:         "Test Donation",
+				Description:   "Test description",
+				Category:      "Electronics",
+				Status:        entity.StatusVerifiedForDonation,
+				ReviewComment: "looks fine",
+			},
+			setup: func() {
+				mockRepo.EXPECT().CreateDonation(gomock.Any()).DoAndReturn(func(d entity.Donation) error {
+					assert.Equal(t, entity.StatusPending, d.Status)
+					assert.Empty(t, d.ReviewComment)
+					return nil
+				})
+			},
+			wantErr: false,
+		},
 		{
 			name: "repository create error",
 			req: dto.DonationDTO{
@@ -74,7 +95,9 @@ func TestDonationService_GetAllDonations(t *testing.T) {
 
 	mockRepo := mocks.NewMockDonationRepo(ctrl)
 	mockStorage := mocks.NewMockGCPStorageRepo(ctrl)
-	donationService := NewDonationService(mockRepo, mockStorage)
+	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
+	donationService := NewDonationService(mockRepo, mockStorage, mockAI, mockPhotos)
 
 	tests := []struct {
 		name    string
@@ -108,6 +131,23 @@ func TestDonationService_GetAllDonations(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "photos of the page are signed in one batch",
+			userID:  1,
+			isAdmin: true,
+			setup: func() {
+				donations := []entity.Donation{
+					{ID: 1, Title: "Donation 1", UserID: 1, Photos: []entity.DonationPhoto{{URL: "donations/private/1_a.jpg"}, {URL: "donations/private/1_b.jpg"}}},
+					{ID: 2, Title: "Donation 2", UserID: 2, Photos: []entity.DonationPhoto{{URL: "donations/private/2_a.jpg"}}},
+				}
+				mockRepo.EXPECT().GetAllDonations(1, 10).Return(donations, int64(2), nil)
+				mockPhotos.EXPECT().Sign([]string{"donations/private/1_a.jpg", "donations/private/1_b.jpg", "donations/private/2_a.jpg"}).Return(map[string]dto.PhotoURL{
+					"donations/private/1_a.jpg": {URL: "https://signed/1_a"},
+					"donations/private/2_a.jpg": {URL: "https://signed/2_a"},
+				})
+			},
+			wantErr: false,
+		},
 		{
 			name:    "repository error",
 			userID:  1,
@@ -143,23 +183,54 @@ func TestDonationService_GetDonationByID(t *testing.T) {
 
 	mockRepo := mocks.NewMockDonationRepo(ctrl)
 	mockStorage := mocks.NewMockGCPStorageRepo(ctrl)
-	donationService := NewDonationService(mockRepo, mockStorage)
+	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
+	donationService := NewDonationService(mockRepo, mockStorage, mockAI, mockPhotos)
 
 	tests := []struct {
-		name    string
-		id      uint
-		setup   func()
-		wantErr bool
+		name          string
+		id            uint
+		userID        uint
+		isAdmin       bool
+		setup         func()
+		wantErr       bool
+		wantPhotoURLs []dto.PhotoURL
 	}{
 		{
-			name: "successful get donation by id",
-			id:   1,
+			name:   "successful get donation by id",
+			id:     1,
+			userID: 1,
 			setup: func() {
 				donation := entity.Donation{ID: 1, Title: "Test Donation", UserID: 1}
 				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(donation, nil)
 			},
 			wantErr: false,
 		},
+		{
+			name:    "reviewer gets signed photo urls",
+			id:      1,
+			userID:  3,
+			isAdmin: true,
+			setup: func() {
+				donation := entity.Donation{ID: 1, Title: "Test Donation", UserID: 1, Photos: []entity.DonationPhoto{{URL: "donations/private/1_a.jpg"}}}
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(donation, nil)
+				mockPhotos.EXPECT().Sign([]string{"donations/private/1_a.jpg"}).Return(map[string]dto.PhotoURL{
+					"donations/private/1_a.jpg": {URL: "https://signed/1_a"},
+				})
+			},
+			wantErr:       false,
+			wantPhotoURLs: []dto.PhotoURL{{URL: "https://signed/1_a"}},
+		},
+		{
+			name:   "forbidden - photos are not signed for others",
+			id:     1,
+			userID: 2,
+			setup: func() {
+				donation := entity.Donation{ID: 1, Title: "Test Donation", UserID: 1, Photos: []entity.DonationPhoto{{URL: "donations/private/1_a.jpg"}}}
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(donation, nil)
+			},
+			wantErr: true,
+		},
 		{
 			name: "donation not found",
 			id:   999,
@@ -174,7 +245,7 @@ func TestDonationService_GetDonationByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
 
-			result, err := donationService.GetDonationByID(tt.id)
+			result, err := donationService.GetDonationByID(tt.id, tt.userID, tt.isAdmin)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -182,6 +253,7 @@ func TestDonationService_GetDonationByID(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, uint(1), result.ID)
+				assert.Equal(t, tt.wantPhotoURLs, result.PhotoURLs)
 			}
 		})
 	}
@@ -193,7 +265,9 @@ func TestDonationService_CanManageDonations(t *testing.T) {
 
 	mockRepo := mocks.NewMockDonationRepo(ctrl)
 	mockStorage := mocks.NewMockGCPStorageRepo(ctrl)
-	donationService := NewDonationService(mockRepo, mockStorage)
+	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
+	donationService := NewDonationService(mockRepo, mockStorage, mockAI, mockPhotos)
 
 	tests := []struct {
 		name     string
@@ -239,7 +313,9 @@ func TestDonationService_UpdateDonation(t *testing.T) {
 
 	mockRepo := mocks.NewMockDonationRepo(ctrl)
 	mockStorage := mocks.NewMockGCPStorageRepo(ctrl)
-	donationService := NewDonationService(mockRepo, mockStorage)
+	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
+	donationService := NewDonationService(mockRepo, mockStorage, mockAI, mockPhotos)
 
 	tests := []struct {
 		name    string
@@ -258,11 +334,97 @@ func TestDonationService_UpdateDonation(t *testing.T) {
 			userID:  1,
 			isAdmin: false,
 			setup: func() {
-				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1}, nil)
-				mockRepo.EXPECT().UpdateDonation(gomock.Any()).Return(nil)
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1, Status: entity.StatusPending}, nil)
+				mockRepo.EXPECT().UpdateDonation(gomock.Any(), false, uint(1)).Return(true, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "status sent by the owner is ignored",
+			req: dto.DonationDTO{
+				ID:     1,
+				Title:  "Updated",
+				Status: entity.StatusVerifiedForAuction,
+			},
+			userID:  1,
+			isAdmin: false,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1, Status: entity.StatusPending}, nil)
+				mockRepo.EXPECT().UpdateDonation(gomock.Any(), false, uint(1)).DoAndReturn(func(d entity.Donation, resubmit bool, changedBy uint) (bool, error) {
+					assert.Equal(t, "Updated", d.Title)
+					return true, nil
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name: "owner answering needs_info sends it back to review",
+			req: dto.DonationDTO{
+				ID:    1,
+				Title: "Updated with more photos",
+			},
+			userID:  1,
+			isAdmin: false,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1, Status: entity.StatusNeedsInfo, ReviewComment: "add photos"}, nil)
+				mockRepo.EXPECT().UpdateDonation(gomock.Any(), true, uint(1)).Return(true, nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "reviewer editing needs_info leaves it with the donor",
+			req: dto.DonationDTO{
+				ID:    1,
+				Title: "Fixed typo",
+			},
+			userID:  9,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1, Status: entity.StatusNeedsInfo}, nil)
+				mockRepo.EXPECT().UpdateDonation(gomock.Any(), false, uint(9)).Return(true, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "review verified it while the owner was editing",
+			req: dto.DonationDTO{
+				ID:    1,
+				Title: "Updated",
+			},
+			userID:  1,
+			isAdmin: false,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1, Status: entity.StatusPending}, nil)
+				mockRepo.EXPECT().UpdateDonation(gomock.Any(), false, uint(1)).Return(false, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "admin cannot edit a verified donation either",
+			req: dto.DonationDTO{
+				ID:    1,
+				Title: "Updated",
+			},
+			userID:  9,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1, Status: entity.StatusVerifiedForAuction}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "owner cannot edit a verified donation",
+			req: dto.DonationDTO{
+				ID:    1,
+				Title: "Updated",
+			},
+			userID:  1,
+			isAdmin: false,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1, Status: entity.StatusVerifiedForDonation}, nil)
+			},
+			wantErr: true,
+		},
 		{
 			name: "forbidden - not owner",
 			req: dto.DonationDTO{
@@ -296,7 +458,9 @@ func TestDonationService_DeleteDonation(t *testing.T) {
 
 	mockRepo := mocks.NewMockDonationRepo(ctrl)
 	mockStorage := mocks.NewMockGCPStorageRepo(ctrl)
-	donationService := NewDonationService(mockRepo, mockStorage)
+	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
+	donationService := NewDonationService(mockRepo, mockStorage, mockAI, mockPhotos)
 
 	tests := []struct {
 		name    string
@@ -348,7 +512,9 @@ func TestDonationService_PatchDonation(t *testing.T) {
 
 	mockRepo := mocks.NewMockDonationRepo(ctrl)
 	mockStorage := mocks.NewMockGCPStorageRepo(ctrl)
-	donationService := NewDonationService(mockRepo, mockStorage)
+	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
+	donationService := NewDonationService(mockRepo, mockStorage, mockAI, mockPhotos)
 
 	tests := []struct {
 		name    string
@@ -356,7 +522,7 @@ func TestDonationService_PatchDonation(t *testing.T) {
 		userID  uint
 		isAdmin bool
 		setup   func()
-		wantErr bool
+		wantErr error
 	}{
 		{
 			name: "successful patch by admin",
@@ -367,10 +533,158 @@ func TestDonationService_PatchDonation(t *testing.T) {
 			userID:  1,
 			isAdmin: true,
 			setup: func() {
-				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 2}, nil)
-				mockRepo.EXPECT().PatchDonation(gomock.Any()).Return(nil)
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 2, Status: entity.StatusPending}, nil)
+				mockRepo.EXPECT().PatchDonation(entity.Donation{ID: 1, Status: entity.StatusVerifiedForDonation}, entity.StatusPending, uint(1), gomock.Nil()).Return(true, nil)
 			},
-			wantErr: false,
+		},
+		{
+			name: "needs_info keeps the reviewer comment",
+			req: dto.DonationDTO{
+				ID:            1,
+				Status:        entity.StatusNeedsInfo,
+				ReviewComment: "  please add a photo of the label ",
+			},
+			userID:  1,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 2, Status: entity.StatusPending}, nil)
+				mockRepo.EXPECT().PatchDonation(entity.Donation{ID: 1, Status: entity.StatusNeedsInfo, ReviewComment: "please add a photo of the label"}, entity.StatusPending, uint(1), gomock.Nil()).Return(true, nil)
+			},
+		},
+		{
+			name: "verified for auction drafts an auction item",
+			req: dto.DonationDTO{
+				ID:     1,
+				Status: entity.StatusVerifiedForAuction,
+			},
+			userID:  1,
+			isAdmin: true,
+			setup: func() {
+				donation := entity.Donation{
+					ID:          1,
+					UserID:      2,
+					Title:       "Vintage camera",
+					Description: "Working film camera",
+					Category:    "Electronics",
+					Condition:   "good",
+					Status:      entity.StatusPending,
+					Photos:      []entity.DonationPhoto{{ID: 7, DonationID: 1, URL: "donations/private/1_camera.jpg"}},
+				}
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(donation, nil)
+				mockAI.EXPECT().EstimateStartingPrice(repository.PriceEstimationRequest{
+					Name:        "Vintage camera",
+					Category:    "Electronics",
+					Condition:   "good",
+					Description: "Working film camera",
+				}).Return(float64(250000), nil)
+				mockRepo.EXPECT().PatchDonation(entity.Donation{ID: 1, Status: entity.StatusVerifiedForAuction}, entity.StatusPending, uint(1), &entity.AuctionItem{
+					DonationID:    1,
+					Title:         "Vintage camera",
+					Description:   "Working film camera",
+					Category:      "Electronics",
+					Status:        entity.AuctionItemStatusDraft,
+					StartingPrice: 250000,
+					Photos:        []entity.AuctionItemPhoto{{URL: "donations/private/1_camera.jpg"}},
+				}).Return(true, nil)
+			},
+		},
+		{
+			name: "failed price estimate drafts with the default price",
+			req: dto.DonationDTO{
+				ID:     1,
+				Status: entity.StatusVerifiedForAuction,
+			},
+			userID:  1,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 2, Title: "Vintage camera", Status: entity.StatusNeedsInfo}, nil)
+				mockAI.EXPECT().EstimateStartingPrice(gomock.Any()).Return(float64(0), errors.New("AI error"))
+				mockRepo.EXPECT().PatchDonation(gomock.Any(), entity.StatusNeedsInfo, uint(1), gomock.Any()).DoAndReturn(
+					func(_ entity.Donation, _ entity.StatusDonation, _ uint, item *entity.AuctionItem) (bool, error) {
+						assert.Equal(t, float64(DefaultStartingPrice), item.StartingPrice)
+						assert.Equal(t, entity.AuctionItemStatusDraft, item.Status)
+						return true, nil
+					})
+			},
+		},
+		{
+			name: "donor cannot change the status",
+			req: dto.DonationDTO{
+				ID:     1,
+				Status: entity.StatusVerifiedForDonation,
+			},
+			userID:  2,
+			isAdmin: false,
+			setup:   func() {},
+			wantErr: ErrForbidden,
+		},
+		{
+			name: "rejecting needs a comment",
+			req: dto.DonationDTO{
+				ID:            1,
+				Status:        entity.StatusRejected,
+				ReviewComment: "   ",
+			},
+			userID:  1,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 2, Status: entity.StatusPending}, nil)
+			},
+			wantErr: ErrReviewCommentRequired,
+		},
+		{
+			name: "verified donation cannot go back to pending",
+			req: dto.DonationDTO{
+				ID:     1,
+				Status: entity.StatusPending,
+			},
+			userID:  1,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 2, Status: entity.StatusVerifiedForAuction}, nil)
+			},
+			wantErr: ErrInvalidStatusTransition,
+		},
+		{
+			name: "archived donation is final",
+			req: dto.DonationDTO{
+				ID:     1,
+				Status: entity.StatusPickedUp,
+			},
+			userID:  1,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 2, Status: entity.StatusArchived}, nil)
+			},
+			wantErr: ErrInvalidStatusTransition,
+		},
+		{
+			name: "another reviewer changed it first",
+			req: dto.DonationDTO{
+				ID:     1,
+				Status: entity.StatusVerifiedForAuction,
+			},
+			userID:  1,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 2, Status: entity.StatusPending}, nil)
+				mockAI.EXPECT().EstimateStartingPrice(gomock.Any()).Return(float64(250000), nil)
+				mockRepo.EXPECT().PatchDonation(gomock.Any(), entity.StatusPending, uint(1), gomock.Any()).Return(false, nil)
+			},
+			wantErr: ErrDonationStatusChanged,
+		},
+		{
+			name: "donation not found",
+			req: dto.DonationDTO{
+				ID:     999,
+				Status: entity.StatusVerifiedForAuction,
+			},
+			userID:  1,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(999)).Return(entity.Donation{}, gorm.ErrRecordNotFound)
+			},
+			wantErr: ErrDonationNotFound,
 		},
 	}
 
@@ -378,11 +692,79 @@ func TestDonationService_PatchDonation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
 			err := donationService.PatchDonation(tt.req, tt.userID, tt.isAdmin)
-			if tt.wantErr {
-				assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
 				assert.NoError(t, err)
 			}
 		})
 	}
 }
+
+func TestDonationService_GetStatusHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockDonationRepo(ctrl)
+	mockStorage := mocks.NewMockGCPStorageRepo(ctrl)
+	mockAI := mocks.NewMockAIRepository(ctrl)
+	mockPhotos := mocks.NewMockPhotoURLSigner(ctrl)
+	donationService := NewDonationService(mockRepo, mockStorage, mockAI, mockPhotos)
+
+	pending := entity.StatusPending
+	history := []entity.DonationStatusHistory{
+		{DonationID: 1, ToStatus: entity.StatusPending, ChangedBy: 1},
+		{DonationID: 1, FromStatus: &pending, ToStatus: entity.StatusNeedsInfo, ChangedBy: 3, Comment: "add photos"},
+	}
+
+	tests := []struct {
+		name    string
+		userID  uint
+		isAdmin bool
+		setup   func()
+		wantLen int
+		wantErr error
+	}{
+		{
+			name:   "owner sees the reviewer comments",
+			userID: 1,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1}, nil)
+				mockRepo.EXPECT().GetStatusHistory(uint(1)).Return(history, nil)
+			},
+			wantLen: 2,
+		},
+		{
+			name:    "reviewer",
+			userID:  3,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1}, nil)
+				mockRepo.EXPECT().GetStatusHistory(uint(1)).Return(history, nil)
+			},
+			wantLen: 2,
+		},
+		{
+			name:   "forbidden - not owner",
+			userID: 2,
+			setup: func() {
+				mockRepo.EXPECT().GetDonationByID(uint(1)).Return(entity.Donation{ID: 1, UserID: 1}, nil)
+			},
+			wantErr: ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			res, err := donationService.GetStatusHistory(1, tt.userID, tt.isAdmin)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, res, tt.wantLen)
+			assert.Equal(t, "add photos", res[1].Comment)
+		})
+	}
+}
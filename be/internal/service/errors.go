@@ -1,6 +1,9 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	// User Errors
@@ -11,6 +14,33 @@ var (
 	ErrUserNotFoundEmail    = errors.New("user email not found")
 	ErrUserNotFoundPassword = errors.New("user password not found")
 	ErrInvalidCredential    = errors.New("invalid credentials")
+	ErrInvalidRefreshToken  = errors.New("invalid or expired refresh token")
+	ErrEmailNotVerified     = errors.New("email is not verified")
+	ErrInvalidActionToken   = errors.New("invalid or expired link")
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrAdminSelfDemotion    = errors.New("admins cannot remove their own admin role")
+	ErrEmailTaken           = errors.New("email is already registered")
+	ErrWrongPassword        = errors.New("current password is incorrect")
+	ErrResetUnavailable     = errors.New("password reset is not available, contact support")
+	ErrReauthRequired       = errors.New("log in again to confirm it is you")
+	ErrAccountDeactivated   = errors.New("account is deactivated")
+	ErrUnpaidWins           = errors.New("pay for or forfeit your won items before deleting the account")
+	ErrAccountLocked        = errors.New("too many failed login attempts, try again later")
+	ErrOIDCProviderNotFound = errors.New("login provider not found")
+	ErrInvalidOIDCState     = errors.New("invalid or expired login attempt, start again")
+	ErrOIDCLoginFailed      = errors.New("sign in with the provider failed")
+	ErrOIDCEmailNotVerified = errors.New("the provider has not verified this email")
+	ErrInvalidOIDCLink      = errors.New("linking expired, sign in with the provider again")
+	ErrTOTPAlreadyEnabled   = errors.New("two-factor authentication is already on")
+	ErrTOTPNotSetUp         = errors.New("start two-factor setup first")
+	ErrTOTPNotEnabled       = errors.New("two-factor authentication is not on")
+	ErrInvalidTOTPCode      = errors.New("invalid two-factor code")
+	ErrInvalidMFAChallenge  = errors.New("login expired, enter your password again")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrAPIKeyRevoked        = errors.New("api key is already revoked")
+	ErrInvalidAPIKey        = errors.New("invalid, expired or revoked api key")
+	ErrUnknownScope         = errors.New("unknown api key scope")
+	ErrAPIKeyOwnerInactive  = errors.New("api key owner is deactivated")
 
 	// Payment Errors
 	ErrPaymentNotFound       = errors.New("payment not found")
@@ -19,6 +49,13 @@ var (
 	ErrPaymentNotFoundAmount = errors.New("payment amount not found")
 	ErrPaymentNotFoundMethod = errors.New("payment method not found")
 	ErrPaymentNotFoundStatus = errors.New("payment status not found")
+	ErrNoWinningBid          = errors.New("item has no winner awaiting payment")
+	ErrPaymentExpired        = errors.New("payment deadline has passed")
+	ErrAlreadyPaid           = errors.New("item already paid")
+	ErrInvalidSignature      = errors.New("invalid notification signature")
+	ErrPaymentNotCancellable = errors.New("only pending payments can be cancelled")
+	ErrPaymentNotRefundable  = errors.New("only paid payments can be refunded")
+	ErrRefundExceeded        = errors.New("refund exceeds the amount paid")
 	// Auction Errors
 	ErrAuctionNotFound   = errors.New("auction not found")
 	ErrInvalidAuction    = errors.New("invalid auction data")
@@ -29,20 +66,27 @@ var (
 	ErrActiveSession     = errors.New("cannot modify an active auction session")
 	ErrAuctionFinished   = errors.New("cannot update auction item with status 'finished'")
 	ErrExpiredSession    = errors.New("cannot modify expired auction session")
+	ErrDonationAuctioned = errors.New("donation already has an auction item")
 	// Donation Errors
 	ErrDonationNotFound          = errors.New("donation not found")
 	ErrInvalidDonation           = errors.New("invalid donation data")
 	ErrDonationNotFoundID        = errors.New("donation ID not found")
 	ErrDonationNotFoundAmount    = errors.New("donation amount not found")
 	ErrDonationNotFoundDonorName = errors.New("donor name not found")
+	ErrInvalidStatusTransition   = errors.New("donation cannot move to this status")
+	ErrReviewCommentRequired     = errors.New("a comment is required to reject or ask for more information")
+	ErrDonationLocked            = errors.New("donation can no longer be edited")
+	ErrDonationStatusChanged     = errors.New("donation status was changed by someone else")
 	// Article Errors
 	ErrArticleNotFound = errors.New("article not found")
 	ErrInvalidArticle  = errors.New("invalid article data")
 	// Bidding Errors
 	ErrInvalidBidding       = errors.New("invalid bid amount")
 	ErrBidTooLow            = errors.New("bid too low")
-	ErrDuplicateBid         = errors.New("duplicate bid")
 	ErrAlreadyHighestBidder = errors.New("you are already the highest bidder")
+	ErrBuyNowUnavailable    = errors.New("item has no buy now price")
+	ErrBuyNowExceeded       = errors.New("bids already reached the buy now price")
+	ErrInvalidBuyNowPrice   = errors.New("buy now price must be above the starting and reserve price")
 	// Final Donation Errors
 	ErrFinalDonationNotFound   = errors.New("final donation not found")
 	ErrFinalDonationNotFoundID = errors.New("final donation ID not found")
@@ -55,3 +99,17 @@ var (
 	ErrUnauthorized = errors.New("unauthorized access")
 	ErrForbidden    = errors.New("forbidden access")
 )
+
+// LockoutError is returned while an account is locked after too many failed
+// logins, it matches ErrAccountLocked with errors.Is
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrAccountLocked
+}
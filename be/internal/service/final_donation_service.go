@@ -1,6 +1,8 @@
 package service
 
 import (
+	"slices"
+
 	"milestone3/be/internal/entity"
 	"milestone3/be/internal/repository"
 )
@@ -43,8 +45,8 @@ func (s *finalDonationService) UpdateNotes(donationID uint, userID uint, notes s
 		return ErrForbidden
 	}
 
-	// Check if status is verified_for_donation
-	if donation.Status != entity.StatusVerifiedForDonation {
+	// Check the donation was verified for donation, notes can still be added after pickup
+	if !slices.Contains(entity.FinalDonationStatuses, donation.Status) {
 		return ErrDonationNotVerified
 	}
 
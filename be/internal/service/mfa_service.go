@@ -0,0 +1,262 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// completeLogin issues tokens once the password (or provider) checked out, or
+// asks for the second factor when the user has one. The challenge token only
+// works for LoginMFA
+func (us *UserServ) completeLogin(user entity.Users) (dto.TokenResponse, error) {
+	if user.TotpEnabledAt == nil {
+		return us.issueTokens(user, nil, false)
+	}
+
+	token, err := utils.GenerateActionToken(user.Id, utils.PurposeMFALogin, passwordBinding(user), utils.MFAChallengeTTL)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	return dto.TokenResponse{MFARequired: true, MFAToken: token}, nil
+}
+
+// LoginMFA is the second login step with a code from the authenticator app or
+// a recovery code
+func (us *UserServ) LoginMFA(req dto.MFALoginRequest) (res dto.TokenResponse, err error) {
+	challenge, err := utils.ParseActionToken(req.MFAToken, utils.PurposeMFALogin)
+	if err != nil {
+		return dto.TokenResponse{}, ErrInvalidMFAChallenge
+	}
+
+	user, err := us.userRepo.GetById(challenge.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.TokenResponse{}, ErrInvalidMFAChallenge
+		}
+		return dto.TokenResponse{}, err
+	}
+	// a password changed since the first step ends the login
+	if challenge.Binding != passwordBinding(user) {
+		return dto.TokenResponse{}, ErrInvalidMFAChallenge
+	}
+	if user.DeactivatedAt != nil {
+		return dto.TokenResponse{}, ErrAccountDeactivated
+	}
+
+	if err := us.useSecondFactor(user, req.Code); err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	return us.issueTokens(user, nil, true)
+}
+
+func (us *UserServ) GetTwoFactorStatus(userId int) (res dto.TwoFactorStatusResponse, err error) {
+	user, err := us.userById(userId)
+	if err != nil {
+		return dto.TwoFactorStatusResponse{}, err
+	}
+	if user.TotpEnabledAt == nil {
+		return dto.TwoFactorStatusResponse{}, nil
+	}
+
+	left, err := us.userRepo.CountRecoveryCodes(userId)
+	if err != nil {
+		return dto.TwoFactorStatusResponse{}, err
+	}
+	return dto.TwoFactorStatusResponse{Enabled: true, RecoveryCodesLeft: left}, nil
+}
+
+// SetupTOTP creates the secret for the authenticator app, two-factor
+// authentication is only on once EnableTOTP confirms a code from it
+func (us *UserServ) SetupTOTP(userId int, req dto.ConfirmPasswordRequest) (res dto.TOTPSetupResponse, err error) {
+	user, err := us.reauthenticate(userId, req)
+	if err != nil {
+		return dto.TOTPSetupResponse{}, err
+	}
+	if user.TotpEnabledAt != nil {
+		return dto.TOTPSetupResponse{}, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return dto.TOTPSetupResponse{}, err
+	}
+	encrypted, err := utils.EncryptTOTPSecret(secret)
+	if err != nil {
+		return dto.TOTPSetupResponse{}, err
+	}
+	if err := us.userRepo.SetTOTPSecret(userId, encrypted); err != nil {
+		log.Printf("failed save totp secret of user %d %s", userId, err)
+		return dto.TOTPSetupResponse{}, err
+	}
+
+	return dto.TOTPSetupResponse{Secret: secret, ProvisioningURI: utils.TOTPProvisioningURI(user.Email, secret)}, nil
+}
+
+// EnableTOTP turns two-factor authentication on with the first code from the
+// app and returns the recovery codes, they are not shown again
+func (us *UserServ) EnableTOTP(userId int, code string) (res dto.RecoveryCodesResponse, err error) {
+	user, err := us.userById(userId)
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	if user.TotpEnabledAt != nil {
+		return dto.RecoveryCodesResponse{}, ErrTOTPAlreadyEnabled
+	}
+	if user.TotpSecret == nil {
+		return dto.RecoveryCodesResponse{}, ErrTOTPNotSetUp
+	}
+
+	if err := us.secondFactorLocked(userId); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	secret, err := utils.DecryptTOTPSecret(*user.TotpSecret)
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	step, ok := utils.ValidateTOTP(secret, code, time.Now(), 0)
+	if !ok {
+		return dto.RecoveryCodesResponse{}, us.secondFactorFailed(userId)
+	}
+	us.secondFactorPassed(userId)
+
+	codes, hashes, err := utils.GenerateRecoveryCodes(utils.RecoveryCodeCount)
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	if err := us.userRepo.EnableTOTP(userId, time.Now(), step, hashes); err != nil {
+		log.Printf("failed enable totp of user %d %s", userId, err)
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	return dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP needs the password (or a fresh login for accounts without one)
+// and a current code, a stolen session alone cannot turn it off
+func (us *UserServ) DisableTOTP(userId int, req dto.SecondFactorRequest) error {
+	user, err := us.reauthenticate(userId, dto.ConfirmPasswordRequest{Password: req.Password, AuthTime: req.AuthTime})
+	if err != nil {
+		return err
+	}
+	if err := us.useSecondFactor(user, req.Code); err != nil {
+		return err
+	}
+
+	return us.userRepo.DisableTOTP(userId)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not
+func (us *UserServ) RegenerateRecoveryCodes(userId int, req dto.SecondFactorRequest) (res dto.RecoveryCodesResponse, err error) {
+	user, err := us.reauthenticate(userId, dto.ConfirmPasswordRequest{Password: req.Password, AuthTime: req.AuthTime})
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	if err := us.useSecondFactor(user, req.Code); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	codes, hashes, err := utils.GenerateRecoveryCodes(utils.RecoveryCodeCount)
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+	if err := us.userRepo.ReplaceRecoveryCodes(userId, hashes); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	return dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// useSecondFactor accepts a TOTP code newer than the last one used, or an
+// unused recovery code. Wrong codes lock the second factor like wrong
+// passwords lock the login
+func (us *UserServ) useSecondFactor(user entity.Users, code string) error {
+	if user.TotpEnabledAt == nil || user.TotpSecret == nil {
+		return ErrTOTPNotEnabled
+	}
+	if err := us.secondFactorLocked(user.Id); err != nil {
+		return err
+	}
+
+	secret, err := utils.DecryptTOTPSecret(*user.TotpSecret)
+	if err != nil {
+		log.Printf("failed decrypt totp secret of user %d %s", user.Id, err)
+		return err
+	}
+
+	now := time.Now()
+	if step, ok := utils.ValidateTOTP(secret, code, now, user.TotpLastStep); ok {
+		// a concurrent login with the same code loses here
+		used, err := us.userRepo.UseTOTPStep(user.Id, step)
+		if err != nil {
+			return err
+		}
+		if used {
+			us.secondFactorPassed(user.Id)
+			return nil
+		}
+	} else {
+		used, err := us.userRepo.UseRecoveryCode(user.Id, utils.HashRecoveryCode(code), now)
+		if err != nil {
+			return err
+		}
+		if used {
+			log.Printf("user %d used a recovery code", user.Id)
+			us.secondFactorPassed(user.Id)
+			return nil
+		}
+	}
+
+	return us.secondFactorFailed(user.Id)
+}
+
+func secondFactorAccount(userId int) string {
+	return fmt.Sprintf("2fa:%d", userId)
+}
+
+func (us *UserServ) secondFactorLocked(userId int) error {
+	locked, err := us.attempts.LockedFor(secondFactorAccount(userId))
+	if err != nil {
+		log.Printf("failed check 2fa lock: %v", err)
+	}
+	if locked > 0 {
+		return &LockoutError{RetryAfter: locked}
+	}
+	return nil
+}
+
+func (us *UserServ) secondFactorFailed(userId int) error {
+	lockout, err := us.attempts.RecordFailure(secondFactorAccount(userId), utils.LoginMaxAttempts(), utils.LoginLockout(), utils.LoginLockout())
+	if err != nil {
+		log.Printf("failed record 2fa attempt: %v", err)
+	}
+	if lockout > 0 {
+		return &LockoutError{RetryAfter: lockout}
+	}
+	return ErrInvalidTOTPCode
+}
+
+func (us *UserServ) secondFactorPassed(userId int) {
+	if err := us.attempts.Reset(secondFactorAccount(userId)); err != nil {
+		log.Printf("failed reset 2fa attempts: %v", err)
+	}
+}
+
+func (us *UserServ) userById(userId int) (entity.Users, error) {
+	user, err := us.userRepo.GetById(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Users{}, ErrUserNotFound
+		}
+		return entity.Users{}, err
+	}
+	return user, nil
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/mocks"
+	"milestone3/be/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func totpUser(t *testing.T) entity.Users {
+	t.Helper()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	encrypted, err := utils.EncryptTOTPSecret(testTOTPSecret)
+	require.NoError(t, err)
+
+	verifiedAt := time.Now()
+	return entity.Users{
+		Id:              1,
+		Email:           "admin@example.com",
+		Password:        string(hashedPassword),
+		EmailVerifiedAt: &verifiedAt,
+		TotpSecret:      &encrypted,
+		TotpEnabledAt:   &verifiedAt,
+		Roles:           []entity.Role{{Name: "admin"}},
+	}
+}
+
+func currentTOTPCode(t *testing.T) string {
+	t.Helper()
+	code, err := utils.TOTPCode(testTOTPSecret, utils.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestUserService_LoginAsksForSecondFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	t.Setenv("SECRET_KEY", "secret")
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockAttempts := mocks.NewMockLoginAttemptRepository(ctrl)
+	// no refresh token is created before the second step
+	userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mockAttempts)
+
+	user := totpUser(t)
+	mockAttempts.EXPECT().LockedFor("admin@example.com").Return(time.Duration(0), nil)
+	mockAttempts.EXPECT().Reset("admin@example.com").Return(nil)
+	mockRepo.EXPECT().GetByEmail("admin@example.com").Return(user, nil)
+
+	res, err := userService.GetUserByEmail("admin@example.com", "password123")
+
+	assert.NoError(t, err)
+	assert.True(t, res.MFARequired)
+	assert.Empty(t, res.AccessToken)
+	assert.Empty(t, res.RefreshToken)
+
+	challenge, err := utils.ParseActionToken(res.MFAToken, utils.PurposeMFALogin)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, challenge.UserId)
+}
+
+func TestUserService_LoginMFA(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+	user := totpUser(t)
+
+	challenge, err := utils.GenerateActionToken(user.Id, utils.PurposeMFALogin, passwordBinding(user), time.Minute)
+	require.NoError(t, err)
+	staleChallenge, err := utils.GenerateActionToken(user.Id, utils.PurposeMFALogin, "changed", time.Minute)
+	require.NoError(t, err)
+	resetToken, err := utils.GenerateActionToken(user.Id, utils.PurposeResetPassword, passwordBinding(user), time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		req     func() dto.MFALoginRequest
+		setup   func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, attempts *mocks.MockLoginAttemptRepository)
+		wantErr error
+	}{
+		{
+			name: "authenticator code",
+			req:  func() dto.MFALoginRequest { return dto.MFALoginRequest{MFAToken: challenge, Code: currentTOTPCode(t)} },
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, attempts *mocks.MockLoginAttemptRepository) {
+				repo.EXPECT().GetById(1).Return(user, nil)
+				attempts.EXPECT().LockedFor("2fa:1").Return(time.Duration(0), nil)
+				repo.EXPECT().UseTOTPStep(1, gomock.Any()).Return(true, nil)
+				attempts.EXPECT().Reset("2fa:1").Return(nil)
+				tokens.EXPECT().Create(gomock.Any()).DoAndReturn(func(token *entity.RefreshToken) error {
+					assert.True(t, token.MFA, "refreshed tokens keep the second factor")
+					return nil
+				})
+			},
+		},
+		{
+			name: "recovery code",
+			req:  func() dto.MFALoginRequest { return dto.MFALoginRequest{MFAToken: challenge, Code: "ABCD-2345"} },
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, attempts *mocks.MockLoginAttemptRepository) {
+				repo.EXPECT().GetById(1).Return(user, nil)
+				attempts.EXPECT().LockedFor("2fa:1").Return(time.Duration(0), nil)
+				repo.EXPECT().UseRecoveryCode(1, utils.HashRecoveryCode("abcd2345"), gomock.Any()).Return(true, nil)
+				attempts.EXPECT().Reset("2fa:1").Return(nil)
+				tokens.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "code already used by another login",
+			req:  func() dto.MFALoginRequest { return dto.MFALoginRequest{MFAToken: challenge, Code: currentTOTPCode(t)} },
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, attempts *mocks.MockLoginAttemptRepository) {
+				repo.EXPECT().GetById(1).Return(user, nil)
+				attempts.EXPECT().LockedFor("2fa:1").Return(time.Duration(0), nil)
+				repo.EXPECT().UseTOTPStep(1, gomock.Any()).Return(false, nil)
+				attempts.EXPECT().RecordFailure("2fa:1", 5, 15*time.Minute, 15*time.Minute).Return(time.Duration(0), nil)
+			},
+			wantErr: ErrInvalidTOTPCode,
+		},
+		{
+			name: "wrong code is counted",
+			req:  func() dto.MFALoginRequest { return dto.MFALoginRequest{MFAToken: challenge, Code: "wrong"} },
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, attempts *mocks.MockLoginAttemptRepository) {
+				repo.EXPECT().GetById(1).Return(user, nil)
+				attempts.EXPECT().LockedFor("2fa:1").Return(time.Duration(0), nil)
+				repo.EXPECT().UseRecoveryCode(1, gomock.Any(), gomock.Any()).Return(false, nil)
+				attempts.EXPECT().RecordFailure("2fa:1", 5, 15*time.Minute, 15*time.Minute).Return(15*time.Minute, nil)
+			},
+			wantErr: ErrAccountLocked,
+		},
+		{
+			name: "locked second factor",
+			req:  func() dto.MFALoginRequest { return dto.MFALoginRequest{MFAToken: challenge, Code: currentTOTPCode(t)} },
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, attempts *mocks.MockLoginAttemptRepository) {
+				repo.EXPECT().GetById(1).Return(user, nil)
+				attempts.EXPECT().LockedFor("2fa:1").Return(time.Minute, nil)
+			},
+			wantErr: ErrAccountLocked,
+		},
+		{
+			name: "password changed since the first step",
+			req: func() dto.MFALoginRequest {
+				return dto.MFALoginRequest{MFAToken: staleChallenge, Code: currentTOTPCode(t)}
+			},
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, attempts *mocks.MockLoginAttemptRepository) {
+				repo.EXPECT().GetById(1).Return(user, nil)
+			},
+			wantErr: ErrInvalidMFAChallenge,
+		},
+		{
+			name: "token for another purpose",
+			req:  func() dto.MFALoginRequest { return dto.MFALoginRequest{MFAToken: resetToken, Code: currentTOTPCode(t)} },
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, attempts *mocks.MockLoginAttemptRepository) {
+			},
+			wantErr: ErrInvalidMFAChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			mockTokens := mocks.NewMockRefreshTokenRepository(ctrl)
+			mockAttempts := mocks.NewMockLoginAttemptRepository(ctrl)
+			tt.setup(mockRepo, mockTokens, mockAttempts)
+			userService := NewUserService(mockRepo, mockTokens, mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mockAttempts)
+
+			res, err := userService.LoginMFA(tt.req())
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, res)
+				return
+			}
+			assert.NoError(t, err)
+			assert.False(t, res.MFASetupRequired)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(res.AccessToken, jwt.MapClaims{})
+			assert.NoError(t, err)
+			assert.Equal(t, true, parsed.Claims.(jwt.MapClaims)["mfa"])
+		})
+	}
+}
+
+func TestUserService_EnableTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	t.Setenv("SECRET_KEY", "secret")
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockAttempts := mocks.NewMockLoginAttemptRepository(ctrl)
+	userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mockAttempts)
+
+	user := totpUser(t)
+	user.TotpEnabledAt = nil
+
+	var stored []string
+	mockRepo.EXPECT().GetById(1).Return(user, nil)
+	mockAttempts.EXPECT().LockedFor("2fa:1").Return(time.Duration(0), nil)
+	mockAttempts.EXPECT().Reset("2fa:1").Return(nil)
+	mockRepo.EXPECT().EnableTOTP(1, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(id int, at time.Time, step int64, hashes []string) error {
+		stored = hashes
+		return nil
+	})
+
+	res, err := userService.EnableTOTP(1, currentTOTPCode(t))
+
+	assert.NoError(t, err)
+	assert.Len(t, res.RecoveryCodes, utils.RecoveryCodeCount)
+	assert.Equal(t, utils.HashRecoveryCode(res.RecoveryCodes[0]), stored[0], "only the hashes are stored")
+}
+
+func TestUserService_SetupTOTP(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	t.Run("already on", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+		mockRepo.EXPECT().GetById(1).Return(totpUser(t), nil)
+
+		_, err := userService.SetupTOTP(1, dto.ConfirmPasswordRequest{Password: "password123"})
+		assert.ErrorIs(t, err, ErrTOTPAlreadyEnabled)
+	})
+
+	t.Run("secret is stored encrypted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+		user := totpUser(t)
+		user.TotpSecret, user.TotpEnabledAt = nil, nil
+		mockRepo.EXPECT().GetById(1).Return(user, nil)
+
+		var stored string
+		mockRepo.EXPECT().SetTOTPSecret(1, gomock.Any()).DoAndReturn(func(id int, encrypted string) error {
+			stored = encrypted
+			return nil
+		})
+
+		res, err := userService.SetupTOTP(1, dto.ConfirmPasswordRequest{Password: "password123"})
+		assert.NoError(t, err)
+		assert.Contains(t, res.ProvisioningURI, "secret="+res.Secret)
+		assert.NotEqual(t, res.Secret, stored)
+
+		decrypted, err := utils.DecryptTOTPSecret(stored)
+		assert.NoError(t, err)
+		assert.Equal(t, res.Secret, decrypted)
+	})
+
+	t.Run("account without a password", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			authTime time.Time
+			wantErr  error
+		}{
+			{name: "fresh login", authTime: time.Now().Add(-time.Minute)},
+			{name: "login too long ago", authTime: time.Now().Add(-utils.ReauthWindow - time.Minute), wantErr: ErrReauthRequired},
+			{name: "token from a refresh", wantErr: ErrReauthRequired},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockRepo := mocks.NewMockUserRepository(ctrl)
+				userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+				user := totpUser(t)
+				user.Password = "!"
+				user.TotpSecret, user.TotpEnabledAt = nil, nil
+				mockRepo.EXPECT().GetById(1).Return(user, nil)
+				if tt.wantErr == nil {
+					mockRepo.EXPECT().SetTOTPSecret(1, gomock.Any()).Return(nil)
+				}
+
+				_, err := userService.SetupTOTP(1, dto.ConfirmPasswordRequest{AuthTime: tt.authTime})
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					assert.NoError(t, err)
+				}
+			})
+		}
+	})
+}
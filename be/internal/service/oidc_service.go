@@ -0,0 +1,261 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// how long the user has to finish signing in at the provider
+const oidcLoginTTL = 10 * time.Minute
+
+type UserIdentityRepository interface {
+	GetByProviderSubject(provider, subject string) (identity entity.UserIdentity, err error)
+	Create(identity *entity.UserIdentity) error
+	TouchLogin(id int, email string, at time.Time) error
+}
+
+type OIDCServ struct {
+	users      *UserServ
+	identities UserIdentityRepository
+	states     repository.OIDCStateRepository
+	providers  map[string]repository.OIDCProvider
+}
+
+// NewOIDCService signs users in with OpenID Connect providers, tokens are
+// issued by the user service like a password login
+func NewOIDCService(us *UserServ, ir UserIdentityRepository, states repository.OIDCStateRepository, providers ...repository.OIDCProvider) *OIDCServ {
+	byName := map[string]repository.OIDCProvider{}
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &OIDCServ{users: us, identities: ir, states: states, providers: byName}
+}
+
+// LoginURL starts a sign in, the state, nonce and PKCE verifier are kept until
+// the provider sends the user back
+func (s *OIDCServ) LoginURL(provider, loginHint string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrOIDCProviderNotFound
+	}
+
+	state, nonce, verifier := oauth2.GenerateVerifier(), oauth2.GenerateVerifier(), oauth2.GenerateVerifier()
+	if err := s.states.Save(state, repository.OIDCLoginState{Provider: provider, Nonce: nonce, Verifier: verifier}, oidcLoginTTL); err != nil {
+		log.Printf("failed save oidc state: %v", err)
+		return "", err
+	}
+
+	url, err := p.AuthCodeURL(state, nonce, verifier, loginHint)
+	if err != nil {
+		log.Printf("failed build %s login url: %v", provider, err)
+		return "", ErrOIDCLoginFailed
+	}
+	return url, nil
+}
+
+// Login finishes a sign in from the provider's callback. The provider's
+// subject is linked to a user on the first sign in, by verified email. An
+// existing account that is unverified or has staff permissions is only linked
+// once its password confirms it, the response then carries a link token for
+// ConfirmLink instead of tokens
+func (s *OIDCServ) Login(provider, code, state string) (res dto.TokenResponse, err error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return dto.TokenResponse{}, ErrOIDCProviderNotFound
+	}
+
+	login, found, err := s.states.Take(state)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	if !found || login.Provider != provider {
+		return dto.TokenResponse{}, ErrInvalidOIDCState
+	}
+
+	identity, err := p.Exchange(code, login.Verifier)
+	if err != nil {
+		log.Printf("failed %s code exchange: %v", provider, err)
+		return dto.TokenResponse{}, ErrOIDCLoginFailed
+	}
+	if identity.Nonce != login.Nonce {
+		log.Printf("%s id token nonce does not match the login", provider)
+		return dto.TokenResponse{}, ErrOIDCLoginFailed
+	}
+
+	user, linkToken, err := s.userFor(provider, identity)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	if linkToken != "" {
+		return dto.TokenResponse{LinkRequired: true, LinkToken: linkToken}, nil
+	}
+
+	if user.DeactivatedAt != nil {
+		return dto.TokenResponse{}, ErrAccountDeactivated
+	}
+
+	return s.users.completeLogin(user)
+}
+
+// ConfirmLink links the provider from a link token to the existing account
+// with the account's password, then signs in like Login. Wrong passwords count
+// towards the login lockout
+func (s *OIDCServ) ConfirmLink(req dto.OIDCLinkRequest) (res dto.TokenResponse, err error) {
+	link, err := utils.ParseActionToken(req.LinkToken, utils.PurposeOIDCLink)
+	if err != nil {
+		return dto.TokenResponse{}, ErrInvalidOIDCLink
+	}
+	// password binding, provider and subject
+	parts := strings.SplitN(link.Binding, "|", 3)
+	if len(parts) != 3 {
+		return dto.TokenResponse{}, ErrInvalidOIDCLink
+	}
+	provider, subject := parts[1], parts[2]
+	if _, ok := s.providers[provider]; !ok {
+		return dto.TokenResponse{}, ErrOIDCProviderNotFound
+	}
+
+	user, err := s.users.userRepo.GetById(link.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.TokenResponse{}, ErrInvalidOIDCLink
+		}
+		return dto.TokenResponse{}, err
+	}
+	// a password changed since the provider sign in ends the link
+	if parts[0] != passwordBinding(user) {
+		return dto.TokenResponse{}, ErrInvalidOIDCLink
+	}
+
+	account := strings.ToLower(strings.TrimSpace(user.Email))
+	if err := s.users.loginLocked(account); err != nil {
+		return dto.TokenResponse{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return dto.TokenResponse{}, s.users.loginFailed(account)
+	}
+	if err := s.users.attempts.Reset(account); err != nil {
+		log.Printf("failed reset login attempts: %v", err)
+	}
+
+	if user.DeactivatedAt != nil {
+		return dto.TokenResponse{}, ErrAccountDeactivated
+	}
+
+	now := time.Now()
+	// the provider verified the email and the password proves the account
+	if user.EmailVerifiedAt == nil {
+		if err := s.users.userRepo.MarkEmailVerified(user.Id, now); err != nil {
+			return dto.TokenResponse{}, err
+		}
+	}
+	if err := s.link(user.Id, provider, subject, user.Email, now); err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	user, err = s.users.userRepo.GetById(user.Id)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	return s.users.completeLogin(user)
+}
+
+// userFor finds or creates the user of a provider identity, an existing
+// account that needs its password to confirm the link gets a link token instead
+func (s *OIDCServ) userFor(provider string, identity repository.OIDCIdentity) (entity.Users, string, error) {
+	now := time.Now()
+
+	linked, err := s.identities.GetByProviderSubject(provider, identity.Subject)
+	if err == nil {
+		if err := s.identities.TouchLogin(linked.Id, identity.Email, now); err != nil {
+			log.Printf("failed update identity %d: %v", linked.Id, err)
+		}
+		user, err := s.users.userRepo.GetById(linked.UserId)
+		return user, "", err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.Users{}, "", err
+	}
+
+	// an unverified email could belong to anyone, it is never linked
+	if identity.Email == "" || !identity.EmailVerified {
+		return entity.Users{}, "", ErrOIDCEmailNotVerified
+	}
+
+	user, err := s.users.userRepo.GetByEmail(identity.Email)
+	switch {
+	case err == nil:
+		// whoever registered an unverified account may not own the email, and a
+		// staff account is worth more than one provider's word for it
+		if user.EmailVerifiedAt == nil || staffAccount(user) {
+			token, err := utils.GenerateActionToken(user.Id, utils.PurposeOIDCLink, passwordBinding(user)+"|"+provider+"|"+identity.Subject, oidcLoginTTL)
+			if err != nil {
+				return entity.Users{}, "", err
+			}
+			return entity.Users{}, token, nil
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user = entity.Users{
+			Name:            oidcDisplayName(identity),
+			Email:           identity.Email,
+			Password:        noPassword,
+			EmailVerifiedAt: &now,
+		}
+		if err := s.users.userRepo.Create(&user); err != nil {
+			return entity.Users{}, "", err
+		}
+	default:
+		return entity.Users{}, "", err
+	}
+
+	if err := s.link(user.Id, provider, identity.Subject, identity.Email, now); err != nil {
+		return entity.Users{}, "", err
+	}
+
+	// reload for the roles the token carries
+	user, err = s.users.userRepo.GetById(user.Id)
+	return user, "", err
+}
+
+func (s *OIDCServ) link(userId int, provider, subject, email string, at time.Time) error {
+	if err := s.identities.Create(&entity.UserIdentity{
+		UserId:      userId,
+		Provider:    provider,
+		Subject:     subject,
+		Email:       email,
+		LastLoginAt: &at,
+	}); err != nil {
+		return err
+	}
+	log.Printf("linked %s identity to user %d", provider, userId)
+	return nil
+}
+
+// staffAccount tells accounts that can do more than donate and bid
+func staffAccount(user entity.Users) bool {
+	_, permissions := roleNames(user.Roles)
+	for _, p := range permissions {
+		if p != utils.PermCreateDonations && p != utils.PermPlaceBids {
+			return true
+		}
+	}
+	return false
+}
+
+func oidcDisplayName(identity repository.OIDCIdentity) string {
+	if name := strings.TrimSpace(identity.Name); name != "" {
+		return name
+	}
+	return strings.Split(identity.Email, "@")[0]
+}
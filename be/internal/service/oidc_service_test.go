@@ -0,0 +1,362 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/mocks"
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type oidcMocks struct {
+	users      *mocks.MockUserRepository
+	tokens     *mocks.MockRefreshTokenRepository
+	identities *mocks.MockUserIdentityRepository
+	states     *mocks.MockOIDCStateRepository
+	provider   *mocks.MockOIDCProvider
+	attempts   *mocks.MockLoginAttemptRepository
+}
+
+func newOIDCService(ctrl *gomock.Controller) (*OIDCServ, oidcMocks) {
+	m := oidcMocks{
+		users:      mocks.NewMockUserRepository(ctrl),
+		tokens:     mocks.NewMockRefreshTokenRepository(ctrl),
+		identities: mocks.NewMockUserIdentityRepository(ctrl),
+		states:     mocks.NewMockOIDCStateRepository(ctrl),
+		provider:   mocks.NewMockOIDCProvider(ctrl),
+		attempts:   mocks.NewMockLoginAttemptRepository(ctrl),
+	}
+	m.provider.EXPECT().Name().Return("google")
+
+	users := NewUserService(m.users, m.tokens, mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), m.attempts)
+	return NewOIDCService(users, m.identities, m.states, m.provider), m
+}
+
+func TestOIDCService_LoginURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	oidcService, m := newOIDCService(ctrl)
+
+	var saved repository.OIDCLoginState
+	var savedState string
+	m.states.EXPECT().Save(gomock.Any(), gomock.Any(), 10*time.Minute).DoAndReturn(func(state string, login repository.OIDCLoginState, ttl time.Duration) error {
+		savedState, saved = state, login
+		return nil
+	})
+	m.provider.EXPECT().AuthCodeURL(gomock.Any(), gomock.Any(), gomock.Any(), "donor@example.com").DoAndReturn(func(state, nonce, verifier, loginHint string) (string, error) {
+		assert.Equal(t, savedState, state)
+		assert.Equal(t, saved.Nonce, nonce)
+		assert.Equal(t, saved.Verifier, verifier)
+		return "https://accounts.example.com/authorize?state=" + state, nil
+	})
+
+	url, err := oidcService.LoginURL("google", "donor@example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, url, savedState)
+	assert.Equal(t, "google", saved.Provider)
+	assert.NotEqual(t, saved.Nonce, saved.Verifier)
+
+	_, err = oidcService.LoginURL("github", "")
+	assert.ErrorIs(t, err, ErrOIDCProviderNotFound)
+}
+
+func TestOIDCService_Login(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	login := repository.OIDCLoginState{Provider: "google", Nonce: "nonce-1", Verifier: "verifier-1"}
+	identity := repository.OIDCIdentity{Subject: "sub-1", Email: "donor@example.com", EmailVerified: true, Name: "Donor", Nonce: "nonce-1"}
+	verifiedAt := time.Now()
+	user := entity.Users{Id: 7, Email: "donor@example.com", EmailVerifiedAt: &verifiedAt, Roles: []entity.Role{bidderRole}}
+
+	// the callback reaches the code exchange
+	exchanged := func(m oidcMocks, identity repository.OIDCIdentity) {
+		m.states.EXPECT().Take("state-1").Return(login, true, nil)
+		m.provider.EXPECT().Exchange("code-1", "verifier-1").Return(identity, nil)
+	}
+
+	tests := []struct {
+		name     string
+		setup    func(m oidcMocks)
+		wantLink bool
+		wantErr  error
+	}{
+		{
+			name: "linked identity signs in",
+			setup: func(m oidcMocks) {
+				exchanged(m, identity)
+				m.identities.EXPECT().GetByProviderSubject("google", "sub-1").Return(entity.UserIdentity{Id: 3, UserId: 7}, nil)
+				m.identities.EXPECT().TouchLogin(3, "donor@example.com", gomock.Any()).Return(nil)
+				m.users.EXPECT().GetById(7).Return(user, nil)
+				m.tokens.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "first sign in creates the account",
+			setup: func(m oidcMocks) {
+				exchanged(m, identity)
+				m.identities.EXPECT().GetByProviderSubject("google", "sub-1").Return(entity.UserIdentity{}, gorm.ErrRecordNotFound)
+				m.users.EXPECT().GetByEmail("donor@example.com").Return(entity.Users{}, gorm.ErrRecordNotFound)
+				m.users.EXPECT().Create(gomock.Any()).DoAndReturn(func(u *entity.Users) error {
+					assert.Equal(t, "Donor", u.Name)
+					assert.Equal(t, "!", u.Password, "social accounts have no usable password")
+					assert.NotNil(t, u.EmailVerifiedAt)
+					u.Id = 7
+					return nil
+				})
+				m.identities.EXPECT().Create(gomock.Any()).DoAndReturn(func(i *entity.UserIdentity) error {
+					assert.Equal(t, entity.UserIdentity{UserId: 7, Provider: "google", Subject: "sub-1", Email: "donor@example.com", LastLoginAt: i.LastLoginAt}, *i)
+					return nil
+				})
+				m.users.EXPECT().GetById(7).Return(user, nil)
+				m.tokens.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "verified email links the existing account",
+			setup: func(m oidcMocks) {
+				exchanged(m, identity)
+				m.identities.EXPECT().GetByProviderSubject("google", "sub-1").Return(entity.UserIdentity{}, gorm.ErrRecordNotFound)
+				m.users.EXPECT().GetByEmail("donor@example.com").Return(user, nil)
+				m.identities.EXPECT().Create(gomock.Any()).Return(nil)
+				m.users.EXPECT().GetById(7).Return(user, nil)
+				m.tokens.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "unverified local account needs its password to link",
+			setup: func(m oidcMocks) {
+				exchanged(m, identity)
+				m.identities.EXPECT().GetByProviderSubject("google", "sub-1").Return(entity.UserIdentity{}, gorm.ErrRecordNotFound)
+				m.users.EXPECT().GetByEmail("donor@example.com").Return(entity.Users{Id: 7, Email: "donor@example.com", Password: "hash"}, nil)
+			},
+			wantLink: true,
+		},
+		{
+			name: "staff account needs its password to link",
+			setup: func(m oidcMocks) {
+				exchanged(m, identity)
+				admin := user
+				admin.Roles = []entity.Role{adminRole}
+				m.identities.EXPECT().GetByProviderSubject("google", "sub-1").Return(entity.UserIdentity{}, gorm.ErrRecordNotFound)
+				m.users.EXPECT().GetByEmail("donor@example.com").Return(admin, nil)
+			},
+			wantLink: true,
+		},
+		{
+			name: "email the provider has not verified is not linked",
+			setup: func(m oidcMocks) {
+				unverified := identity
+				unverified.EmailVerified = false
+				exchanged(m, unverified)
+				m.identities.EXPECT().GetByProviderSubject("google", "sub-1").Return(entity.UserIdentity{}, gorm.ErrRecordNotFound)
+			},
+			wantErr: ErrOIDCEmailNotVerified,
+		},
+		{
+			name: "deactivated account cannot sign in",
+			setup: func(m oidcMocks) {
+				exchanged(m, identity)
+				deactivated := user
+				deactivated.DeactivatedAt = &verifiedAt
+				m.identities.EXPECT().GetByProviderSubject("google", "sub-1").Return(entity.UserIdentity{Id: 3, UserId: 7}, nil)
+				m.identities.EXPECT().TouchLogin(3, "donor@example.com", gomock.Any()).Return(nil)
+				m.users.EXPECT().GetById(7).Return(deactivated, nil)
+			},
+			wantErr: ErrAccountDeactivated,
+		},
+		{
+			name: "unknown or used state",
+			setup: func(m oidcMocks) {
+				m.states.EXPECT().Take("state-1").Return(repository.OIDCLoginState{}, false, nil)
+			},
+			wantErr: ErrInvalidOIDCState,
+		},
+		{
+			name: "state started with another provider",
+			setup: func(m oidcMocks) {
+				m.states.EXPECT().Take("state-1").Return(repository.OIDCLoginState{Provider: "fake", Nonce: "nonce-1", Verifier: "verifier-1"}, true, nil)
+			},
+			wantErr: ErrInvalidOIDCState,
+		},
+		{
+			name: "code exchange fails",
+			setup: func(m oidcMocks) {
+				m.states.EXPECT().Take("state-1").Return(login, true, nil)
+				m.provider.EXPECT().Exchange("code-1", "verifier-1").Return(repository.OIDCIdentity{}, errors.New("invalid_grant"))
+			},
+			wantErr: ErrOIDCLoginFailed,
+		},
+		{
+			name: "nonce of another login",
+			setup: func(m oidcMocks) {
+				replayed := identity
+				replayed.Nonce = "nonce-2"
+				exchanged(m, replayed)
+			},
+			wantErr: ErrOIDCLoginFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			oidcService, m := newOIDCService(ctrl)
+			tt.setup(m)
+
+			res, err := oidcService.Login("google", "code-1", "state-1")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, res)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.wantLink {
+				assert.True(t, res.LinkRequired)
+				assert.NotEmpty(t, res.LinkToken)
+				assert.Empty(t, res.AccessToken)
+				return
+			}
+			assert.NotEmpty(t, res.AccessToken)
+			assert.NotEmpty(t, res.RefreshToken)
+		})
+	}
+
+	t.Run("unknown provider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		oidcService, _ := newOIDCService(ctrl)
+
+		_, err := oidcService.Login("github", "code-1", "state-1")
+		assert.ErrorIs(t, err, ErrOIDCProviderNotFound)
+	})
+}
+
+func TestOIDCService_ConfirmLink(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	verifiedAt := time.Now()
+	user := entity.Users{Id: 7, Email: "admin@example.com", Password: string(hashedPassword), EmailVerifiedAt: &verifiedAt, Roles: []entity.Role{adminRole}}
+	mfaChallenge, _ := utils.GenerateActionToken(7, utils.PurposeMFALogin, passwordBinding(user), time.Minute)
+	linkToken := func(user entity.Users, provider string) string {
+		token, _ := utils.GenerateActionToken(user.Id, utils.PurposeOIDCLink, passwordBinding(user)+"|"+provider+"|sub-1", time.Minute)
+		return token
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		setup    func(m oidcMocks)
+		wantErr  error
+	}{
+		{
+			name:     "password links the provider",
+			token:    linkToken(user, "google"),
+			password: "password123",
+			setup: func(m oidcMocks) {
+				m.users.EXPECT().GetById(7).Return(user, nil).Times(2)
+				m.attempts.EXPECT().LockedFor("admin@example.com").Return(time.Duration(0), nil)
+				m.attempts.EXPECT().Reset("admin@example.com").Return(nil)
+				m.identities.EXPECT().Create(gomock.Any()).DoAndReturn(func(i *entity.UserIdentity) error {
+					assert.Equal(t, entity.UserIdentity{UserId: 7, Provider: "google", Subject: "sub-1", Email: "admin@example.com", LastLoginAt: i.LastLoginAt}, *i)
+					return nil
+				})
+				m.tokens.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:     "unverified account is verified by the link",
+			token:    linkToken(entity.Users{Id: 7, Password: string(hashedPassword)}, "google"),
+			password: "password123",
+			setup: func(m oidcMocks) {
+				unverified := user
+				unverified.EmailVerifiedAt = nil
+				m.users.EXPECT().GetById(7).Return(unverified, nil)
+				m.attempts.EXPECT().LockedFor("admin@example.com").Return(time.Duration(0), nil)
+				m.attempts.EXPECT().Reset("admin@example.com").Return(nil)
+				m.users.EXPECT().MarkEmailVerified(7, gomock.Any()).Return(nil)
+				m.identities.EXPECT().Create(gomock.Any()).Return(nil)
+				m.users.EXPECT().GetById(7).Return(user, nil)
+				m.tokens.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:     "wrong password counts towards the lockout",
+			token:    linkToken(user, "google"),
+			password: "wrong-password",
+			setup: func(m oidcMocks) {
+				m.users.EXPECT().GetById(7).Return(user, nil)
+				m.attempts.EXPECT().LockedFor("admin@example.com").Return(time.Duration(0), nil)
+				m.attempts.EXPECT().RecordFailure("admin@example.com", gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Duration(0), nil)
+			},
+			wantErr: ErrInvalidCredential,
+		},
+		{
+			name:     "password changed since the provider sign in",
+			token:    linkToken(entity.Users{Id: 7, Password: "old-hash"}, "google"),
+			password: "password123",
+			setup: func(m oidcMocks) {
+				m.users.EXPECT().GetById(7).Return(user, nil)
+			},
+			wantErr: ErrInvalidOIDCLink,
+		},
+		{
+			name:     "deactivated account is not linked",
+			token:    linkToken(user, "google"),
+			password: "password123",
+			setup: func(m oidcMocks) {
+				deactivated := user
+				deactivated.DeactivatedAt = &verifiedAt
+				m.users.EXPECT().GetById(7).Return(deactivated, nil)
+				m.attempts.EXPECT().LockedFor("admin@example.com").Return(time.Duration(0), nil)
+				m.attempts.EXPECT().Reset("admin@example.com").Return(nil)
+			},
+			wantErr: ErrAccountDeactivated,
+		},
+		{
+			name:     "provider no longer configured",
+			token:    linkToken(user, "github"),
+			password: "password123",
+			setup:    func(m oidcMocks) {},
+			wantErr:  ErrOIDCProviderNotFound,
+		},
+		{
+			name:     "token of another purpose",
+			token:    mfaChallenge,
+			password: "password123",
+			setup:    func(m oidcMocks) {},
+			wantErr:  ErrInvalidOIDCLink,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			oidcService, m := newOIDCService(ctrl)
+			tt.setup(m)
+
+			res, err := oidcService.ConfirmLink(dto.OIDCLinkRequest{LinkToken: tt.token, Password: tt.password})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, res)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, res.AccessToken)
+		})
+	}
+}
@@ -1,102 +1,658 @@
 package service
 
 import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/entity"
+	"milestone3/be/internal/repository"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type PaymentRepository interface {
 	Create(payment *entity.Payment, orderId string) (error)
-	CreateMidtrans(payment entity.Payment, orderId string) (res dto.PaymentResponse, err error)
-	CheckPaymentStatusMidtrans(orderId string) (res dto.CheckPaymentStatusResponse, err error)
 	GetById(id int) (payment entity.Payment, err error)
-	GetAll() (payment []entity.Payment, err error)
+	GetAll(filter dto.PaymentFilter) (payment []entity.Payment, total int64, err error)
+	CountPaid(auctionItemId int, userId int) (total int64, err error)
+	GetPending(auctionItemId int, userId int) (payments []entity.Payment, err error)
+	ExpirePending(auctionItemId int, userId int) error
+	GetByOrderId(orderId string) (payment entity.Payment, err error)
+	GetPayer(userId int) (user entity.Users, err error)
+	TransitionStatus(orderId string, from string, to string, reason string) (changed bool, err error)
+	CreateRefund(refund *entity.PaymentRefund, orderId string, issue func(payment entity.Payment, remaining float64) (to string, err error)) error
+	GetStalePending(now time.Time) (payments []entity.Payment, err error)
+	GetClosedUnpaidSince(since time.Time) (payments []entity.Payment, err error)
 }
 
+// DefaultPaymentDeadlineHours is how long a winner has to pay before the item moves on
+const DefaultPaymentDeadlineHours = 24
+
+// DefaultReconcileWindowHours is how far back reconciliation re-checks payments we closed unpaid
+const DefaultReconcileWindowHours = 72
+
 type PaymentServ struct {
 	paymentRepo PaymentRepository
+	gateway     repository.PaymentGateway
+	bidRepo     repository.BidRepository
+	itemRepo    repository.AuctionItemRepository
 }
 
-func NewPaymentService(pr PaymentRepository) *PaymentServ {
-	return &PaymentServ{paymentRepo: pr}
+func NewPaymentService(pr PaymentRepository, gateway repository.PaymentGateway, br repository.BidRepository, ir repository.AuctionItemRepository) *PaymentServ {
+	return &PaymentServ{paymentRepo: pr, gateway: gateway, bidRepo: br, itemRepo: ir}
 }
 
+// CreatePayment charges the item's current winner the amount of their final bid
 func (ps *PaymentServ) CreatePayment(req dto.PaymentRequest, userId int, auctionItemId int) (res dto.PaymentResponse, err error) {
-	//get auction for auctionItemId to check if auctionitemid exist or not
+	item, err := ps.itemRepo.GetByID(int64(auctionItemId))
+	if err != nil {
+		return dto.PaymentResponse{}, ErrAuctionNotFound
+	}
+
+	if item.Status != "finished" {
+		return dto.PaymentResponse{}, ErrNoWinningBid
+	}
+
+	bid, err := ps.bidRepo.GetWinningBid(item.ID)
+	if err != nil {
+		return dto.PaymentResponse{}, ErrNoWinningBid
+	}
+
+	if bid.UserID != int64(userId) {
+		return dto.PaymentResponse{}, ErrForbidden
+	}
+
+	if bid.PaymentDeadline != nil && time.Now().After(*bid.PaymentDeadline) {
+		return dto.PaymentResponse{}, ErrPaymentExpired
+	}
+
+	paid, err := ps.paymentRepo.CountPaid(auctionItemId, userId)
+	if err != nil {
+		log.Printf("error check paid payment %s", err)
+		return dto.PaymentResponse{}, err
+	}
+	if paid > 0 {
+		return dto.PaymentResponse{}, ErrAlreadyPaid
+	}
+
+	payer, err := ps.paymentRepo.GetPayer(userId)
+	if err != nil {
+		log.Printf("error get payer %s", err)
+		return dto.PaymentResponse{}, err
+	}
+
 	//random id for order id
 	uuid := uuid.New()
 	orderId := fmt.Sprintf("YDR-%d", uuid.ID())
-	
+
+	resp, err := ps.gateway.Charge(repository.ChargeRequest{
+		OrderId:     orderId,
+		Amount:      bid.Amount,
+		PaymentType: req.PaymentType,
+		Bank:        req.Bank,
+		CardToken:   req.CardToken,
+		ExpiresAt:   bid.PaymentDeadline,
+		Customer: repository.ChargeCustomer{
+			Name:  payer.Name,
+			Email: payer.Email,
+			Phone: payer.Phone,
+		},
+	})
+	if err != nil {
+		log.Printf("error create payment charge %s", err)
+		return dto.PaymentResponse{}, err
+	}
+
 	payment := entity.Payment{
-		Amount: req.Amount,
-		UserId: userId,
-		//hard code for now 
+		Amount:        bid.Amount,
+		UserId:        userId,
 		AuctionItemId: auctionItemId,
+		PaymentType:   resp.PaymentType,
+		ExpiresAt:     bid.PaymentDeadline,
 	}
 
-	
-	log.Println("disini nih")
-	resp, _ := ps.paymentRepo.CreateMidtrans(payment, orderId)
-	
 	if err := ps.paymentRepo.Create(&payment, resp.OrderId); err != nil {
 		log.Printf("error create payment %s", err)
 		return dto.PaymentResponse{}, err
 	}
+
+	resp.Amount = bid.Amount
+	resp.PaymentDeadline = bid.PaymentDeadline
 	return resp, nil
 }
 
-func (ps *PaymentServ) CheckPaymentStatusMidtrans(orderId string) (res dto.CheckPaymentStatusResponse, err error) {
-	resp, _:= ps.paymentRepo.CheckPaymentStatusMidtrans(orderId)
-	// if err != nil {
-	// 	log.Printf("error check payment %s", err)
-	// 	return dto.CheckPaymentStatusResponse{}, err
-	// }
+// ExpireUnpaidWins (for cron job) takes items away from winners who missed the
+// payment deadline, the runner-up gets one chance before the item is rescheduled
+func (ps *PaymentServ) ExpireUnpaidWins() error {
+	now := time.Now()
+	bids, err := ps.bidRepo.GetUnpaidExpiredBids(now)
+	if err != nil {
+		return err
+	}
+
+	for _, bid := range bids {
+		if ps.paidAtGateway(bid) {
+			continue
+		}
+		ps.releaseWin(bid, now)
+	}
+
+	return nil
+}
+
+// paidAtGateway asks the gateway about the winner's pending payments before the
+// win is taken away, a settlement whose notification got lost is applied and
+// keeps the item. When the gateway cannot be asked the win is left for the next pass
+func (ps *PaymentServ) paidAtGateway(bid entity.Bid) bool {
+	pending, err := ps.paymentRepo.GetPending(int(bid.ItemID), int(bid.UserID))
+	if err != nil {
+		log.Printf("failed get pending payments for item %d %s", bid.ItemID, err)
+		return true
+	}
+
+	for _, payment := range pending {
+		resp, err := ps.gateway.Status(payment.OrderId)
+		if err != nil {
+			log.Printf("failed check payment %s before expiry %s", payment.OrderId, err)
+			return true
+		}
+		if paymentStatusFor(resp.PaymentStatus, resp.FraudStatus) != "paid" {
+			continue
+		}
+
+		if !sameAmount(resp.GrossAmount, payment.Amount) {
+			// reconciliation reports it for an admin
+			log.Printf("payment %s settled %s at the gateway, payment is %.2f", payment.OrderId, resp.GrossAmount, payment.Amount)
+			return true
+		}
+		if _, err := ps.applyTransactionStatus(payment, resp.PaymentStatus, resp.FraudStatus, "gateway reported "+resp.PaymentStatus+" before expiry"); err != nil {
+			return true
+		}
+		log.Printf("payment %s settled at the gateway before expiry", payment.OrderId)
+		return true
+	}
+
+	return false
+}
+
+// releaseWin takes the item away from a winner who did not pay, failures are
+// logged and left for the next pass
+func (ps *PaymentServ) releaseWin(bid entity.Bid, now time.Time) {
+	if err := ps.bidRepo.MarkBidLapsed(bid.ID); err != nil {
+		log.Printf("failed mark bid %d lapsed %s", bid.ID, err)
+		return
+	}
+
+	if err := ps.paymentRepo.ExpirePending(int(bid.ItemID), int(bid.UserID)); err != nil {
+		log.Printf("failed expire pending payment for item %d %s", bid.ItemID, err)
+	}
+
+	item, err := ps.itemRepo.GetByID(bid.ItemID)
+	if err != nil {
+		log.Printf("failed get auction item %d %s", bid.ItemID, err)
+		return
+	}
+
+	if ps.offerToRunnerUp(item, bid, now) {
+		return
+	}
+
+	if err := ps.rescheduleItem(item); err != nil {
+		return
+	}
+	log.Printf("auction item %d rescheduled after unpaid win", item.ID)
+}
+
+// offerToRunnerUp makes the second-highest bidder the winner if the lapsed bid
+// was the first winner and the runner-up still meets the reserve
+func (ps *PaymentServ) offerToRunnerUp(item *entity.AuctionItem, lapsed entity.Bid, now time.Time) bool {
+	winners, err := ps.bidRepo.CountWinningBids(lapsed.SessionID, lapsed.ItemID)
+	if err != nil || winners > 1 {
+		return false
+	}
+
+	runnerUp, err := ps.bidRepo.GetRunnerUpBid(lapsed.SessionID, lapsed.ItemID)
+	if err != nil {
+		return false
+	}
+
+	if item.ReservePrice != nil && runnerUp.Amount < *item.ReservePrice {
+		return false
+	}
+
+	err = ps.bidRepo.SaveFinalBid(&entity.Bid{
+		SessionID:       lapsed.SessionID,
+		ItemID:          lapsed.ItemID,
+		UserID:          runnerUp.UserID,
+		Amount:          runnerUp.Amount,
+		PaymentDeadline: paymentDeadline(now),
+	})
+	if err != nil {
+		log.Printf("failed save runner-up bid for item %d %s", lapsed.ItemID, err)
+		return false
+	}
+
+	log.Printf("auction item %d passed to runner-up %d", lapsed.ItemID, runnerUp.UserID)
+	return true
+}
+
+// CancelPayment cancels a pending charge at the gateway, the winner can still pay
+// with a new charge unless the item is reopened
+func (ps *PaymentServ) CancelPayment(id int, req dto.CancelPaymentRequest) error {
+	payment, err := ps.paymentRepo.GetById(id)
+	if err != nil {
+		return ErrPaymentNotFound
+	}
+
+	if payment.Status != "pending" {
+		return ErrPaymentNotCancellable
+	}
+
+	if err := ps.gateway.Cancel(payment.OrderId); err != nil {
+		log.Printf("error cancel payment charge %s", err)
+		return err
+	}
+
+	changed, err := ps.paymentRepo.TransitionStatus(payment.OrderId, "pending", "cancelled", req.Reason)
+	if err != nil {
+		log.Printf("error cancel payment %s", err)
+		return err
+	}
+	if !changed {
+		return ErrPaymentNotCancellable
+	}
+
+	if req.ReopenItem {
+		return ps.reopenItem(payment)
+	}
+	return nil
+}
+
+// RefundPayment refunds all or part of a paid payment through the gateway
+func (ps *PaymentServ) RefundPayment(id int, req dto.RefundRequest, adminId int) (res dto.PaymentRefundResponse, err error) {
+	payment, err := ps.paymentRepo.GetById(id)
+	if err != nil {
+		return dto.PaymentRefundResponse{}, ErrPaymentNotFound
+	}
+
+	if payment.Status != "paid" && payment.Status != "partially_refunded" {
+		return dto.PaymentRefundResponse{}, ErrPaymentNotRefundable
+	}
+
+	refund := entity.PaymentRefund{
+		PaymentId: payment.Id,
+		Reason:    req.Reason,
+		CreatedBy: adminId,
+	}
+	refunded := false
+
+	// the payment stays locked until the refund is recorded, a concurrent refund
+	// waits and then sees what is left after this one
+	err = ps.paymentRepo.CreateRefund(&refund, payment.OrderId, func(locked entity.Payment, remaining float64) (string, error) {
+		if locked.Status != "paid" && locked.Status != "partially_refunded" {
+			return "", ErrPaymentNotRefundable
+		}
+
+		refund.Amount = req.Amount
+		if refund.Amount == 0 {
+			refund.Amount = remaining
+		}
+		if refund.Amount > remaining {
+			return "", ErrRefundExceeded
+		}
+
+		if err := ps.gateway.Refund(locked.OrderId, refund.Amount, req.Reason); err != nil {
+			log.Printf("error refund payment charge %s", err)
+			return "", err
+		}
+		refunded = true
+
+		if refund.Amount == remaining {
+			return "refunded", nil
+		}
+		return "partially_refunded", nil
+	})
+	if err != nil {
+		if refunded {
+			// the gateway already refunded, the ledger has to be fixed by hand
+			log.Printf("error record refund of %.0f for payment %s %s", refund.Amount, payment.OrderId, err)
+		}
+		return dto.PaymentRefundResponse{}, err
+	}
+
+	if req.ReopenItem {
+		if err := ps.reopenItem(payment); err != nil {
+			return dto.PaymentRefundResponse{}, err
+		}
+	}
+
+	return dto.PaymentRefundResponses([]entity.PaymentRefund{refund})[0], nil
+}
+
+// reopenItem takes the item away from the payment's user and puts it back up for auction
+func (ps *PaymentServ) reopenItem(payment entity.Payment) error {
+	bid, err := ps.bidRepo.GetWinningBid(int64(payment.AuctionItemId))
+	if err == nil && bid.UserID == int64(payment.UserId) {
+		if err := ps.bidRepo.MarkBidLapsed(bid.ID); err != nil {
+			log.Printf("failed mark bid %d lapsed %s", bid.ID, err)
+			return err
+		}
+	}
+
+	item, err := ps.itemRepo.GetByID(int64(payment.AuctionItemId))
+	if err != nil {
+		return ErrAuctionNotFound
+	}
+
+	if err := ps.rescheduleItem(item); err != nil {
+		return err
+	}
+	log.Printf("auction item %d reopened after payment %s", item.ID, payment.OrderId)
+	return nil
+}
+
+// rescheduleItem sends the item back to scheduled, the old session is over so
+// admin assigns a new one to relist
+func (ps *PaymentServ) rescheduleItem(item *entity.AuctionItem) error {
+	item.Status = "scheduled"
+	item.SessionID = nil
+	item.Session = nil
+	if err := ps.itemRepo.Update(item); err != nil {
+		log.Printf("failed reschedule auction item %d %s", item.ID, err)
+		return err
+	}
+	return nil
+}
+
+// paymentDeadline is when a winner decided at now loses the item if still unpaid
+func paymentDeadline(now time.Time) *time.Time {
+	hours, err := strconv.Atoi(os.Getenv("PAYMENT_DEADLINE_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = DefaultPaymentDeadlineHours
+	}
+	deadline := now.Add(time.Duration(hours) * time.Hour)
+	return &deadline
+}
+
+// ReconcilePayments (for cron job) asks the gateway about payments still pending
+// past their expiry and applies what it reports, and re-checks payments we
+// closed unpaid in case the gateway settled them anyway. Anything that cannot be
+// fixed automatically is left in the report for an admin
+func (ps *PaymentServ) ReconcilePayments() (res dto.ReconciliationReport, err error) {
+	now := time.Now()
+	res.CheckedAt = now
+	res.Mismatches = []dto.ReconciliationMismatch{}
+
+	stale, err := ps.paymentRepo.GetStalePending(now)
+	if err != nil {
+		log.Printf("failed get stale pending payments %s", err)
+		return dto.ReconciliationReport{}, err
+	}
+
+	for _, payment := range stale {
+		res.Checked++
+		if mismatch, ok := ps.reconcilePending(payment); ok {
+			if mismatch.Resolved {
+				res.Resolved++
+			}
+			res.Mismatches = append(res.Mismatches, mismatch)
+		}
+	}
+
+	closed, err := ps.paymentRepo.GetClosedUnpaidSince(now.Add(-reconcileWindow()))
+	if err != nil {
+		log.Printf("failed get closed unpaid payments %s", err)
+		return dto.ReconciliationReport{}, err
+	}
+
+	for _, payment := range closed {
+		res.Checked++
+		if mismatch, ok := ps.reconcileClosed(payment); ok {
+			res.Mismatches = append(res.Mismatches, mismatch)
+		}
+	}
+
+	return res, nil
+}
+
+// reconcilePending settles or expires a stale pending payment from the gateway's status
+func (ps *PaymentServ) reconcilePending(payment entity.Payment) (dto.ReconciliationMismatch, bool) {
+	mismatch := dto.ReconciliationMismatch{
+		PaymentId:   payment.Id,
+		OrderId:     payment.OrderId,
+		LocalStatus: payment.Status,
+	}
+
+	resp, err := ps.gateway.Status(payment.OrderId)
+	if err != nil {
+		mismatch.Note = "gateway lookup failed: " + err.Error()
+		return mismatch, true
+	}
+	mismatch.GatewayStatus = resp.PaymentStatus
+
+	status := paymentStatusFor(resp.PaymentStatus, resp.FraudStatus)
+	switch {
+	case status == "":
+		mismatch.Note = "still pending at the gateway past its expiry"
+		return mismatch, true
+	case status == "paid" && !sameAmount(resp.GrossAmount, payment.Amount):
+		mismatch.Note = fmt.Sprintf("gateway settled %s, payment is %.2f", resp.GrossAmount, payment.Amount)
+		return mismatch, true
+	}
+
+	// same path as a notification, an expired or denied charge releases the win
+	changed, err := ps.applyTransactionStatus(payment, resp.PaymentStatus, resp.FraudStatus, "reconciled, gateway reported "+resp.PaymentStatus)
+	if err != nil {
+		mismatch.Note = "failed to apply gateway status: " + err.Error()
+		return mismatch, true
+	}
+	if !changed {
+		// a notification got there first
+		return dto.ReconciliationMismatch{}, false
+	}
+
+	mismatch.Resolved = true
+	mismatch.Note = "marked " + status
+	return mismatch, true
+}
+
+// reconcileClosed reports a payment we closed unpaid that the gateway settled,
+// the money has to be refunded or the payment restored by hand
+func (ps *PaymentServ) reconcileClosed(payment entity.Payment) (dto.ReconciliationMismatch, bool) {
+	resp, err := ps.gateway.Status(payment.OrderId)
+	if err != nil || paymentStatusFor(resp.PaymentStatus, resp.FraudStatus) != "paid" {
+		return dto.ReconciliationMismatch{}, false
+	}
+
+	return dto.ReconciliationMismatch{
+		PaymentId:     payment.Id,
+		OrderId:       payment.OrderId,
+		LocalStatus:   payment.Status,
+		GatewayStatus: resp.PaymentStatus,
+		Note:          "settled at the gateway after we closed it, refund or restore it",
+	}, true
+}
+
+// sameAmount compares a gateway gross amount with ours, a missing gross amount is trusted
+func sameAmount(grossAmount string, amount float64) bool {
+	if grossAmount == "" {
+		return true
+	}
+	gross, err := strconv.ParseFloat(grossAmount, 64)
+	return err == nil && gross == amount
+}
+
+// reconcileWindow is how long after their expiry closed payments are re-checked
+func reconcileWindow() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("PAYMENT_RECONCILE_WINDOW_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = DefaultReconcileWindowHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// CheckPaymentStatusMidtrans polls the gateway for the owner or an admin and applies the status
+func (ps *PaymentServ) CheckPaymentStatusMidtrans(orderId string, userId int, isAdmin bool) (res dto.CheckPaymentStatusResponse, err error) {
+	payment, err := ps.paymentRepo.GetByOrderId(orderId)
+	if err != nil {
+		return dto.CheckPaymentStatusResponse{}, ErrPaymentNotFound
+	}
+
+	if !isAdmin && payment.UserId != userId {
+		return dto.CheckPaymentStatusResponse{}, ErrForbidden
+	}
+
+	resp, err := ps.gateway.Status(orderId)
+	if err != nil {
+		log.Printf("error check payment %s", err)
+		return dto.CheckPaymentStatusResponse{}, err
+	}
+
+	if _, err := ps.applyTransactionStatus(payment, resp.PaymentStatus, resp.FraudStatus, "gateway reported "+resp.PaymentStatus); err != nil {
+		return dto.CheckPaymentStatusResponse{}, err
+	}
 
 	return resp, nil
 }
 
-func (ps *PaymentServ) GetPaymentById(id int) (res dto.PaymentInfoResponse, err error) {
+// HandleNotification applies a Midtrans HTTP notification, replays of the same
+// notification leave the payment as it is
+func (ps *PaymentServ) HandleNotification(req dto.MidtransNotification) error {
+	if !validSignature(req) {
+		return ErrInvalidSignature
+	}
+
+	payment, err := ps.paymentRepo.GetByOrderId(req.OrderId)
+	if err != nil {
+		return ErrPaymentNotFound
+	}
+
+	status := paymentStatusFor(req.TransactionStatus, req.FraudStatus)
+	if status == "paid" {
+		gross, err := strconv.ParseFloat(req.GrossAmount, 64)
+		if err != nil || gross != payment.Amount {
+			log.Printf("notification amount %s does not match payment %s", req.GrossAmount, req.OrderId)
+			return ErrInvalidPayment
+		}
+	}
+
+	_, err = ps.applyTransactionStatus(payment, req.TransactionStatus, req.FraudStatus, "gateway reported "+req.TransactionStatus)
+	return err
+}
+
+// applyTransactionStatus moves a pending payment to the gateway's status and the
+// item along with it, anything past pending (paid, refunded, cancelled by
+// admin...) is not the gateway's to change and an unknown status keeps the
+// payment pending. Notifications, status checks and reconciliation all go
+// through here. changed is false when nothing was applied
+func (ps *PaymentServ) applyTransactionStatus(payment entity.Payment, transactionStatus, fraudStatus, note string) (changed bool, err error) {
+	status := paymentStatusFor(transactionStatus, fraudStatus)
+	if status == "" || payment.Status != "pending" {
+		return false, nil
+	}
+
+	changed, err = ps.paymentRepo.TransitionStatus(payment.OrderId, payment.Status, status, note)
+	if err != nil {
+		log.Printf("error update payment status %s", err)
+		return false, err
+	}
+	if !changed {
+		// whoever got there first moved the item
+		return false, nil
+	}
+
+	ps.moveItem(payment, transactionStatus)
+	return true, nil
+}
+
+// moveItem follows the item after the gateway closed a payment. A settled
+// payment keeps the finished item with its winner, an expired or denied charge
+// ends the win like a missed deadline does, and after a cancel or failure the
+// winner can still pay again until the deadline
+func (ps *PaymentServ) moveItem(payment entity.Payment, transactionStatus string) {
+	if transactionStatus != "expire" && transactionStatus != "deny" {
+		return
+	}
+
+	bid, err := ps.bidRepo.GetWinningBid(int64(payment.AuctionItemId))
+	if err != nil || bid.UserID != int64(payment.UserId) {
+		// the win already moved on
+		return
+	}
+
+	ps.releaseWin(bid, time.Now())
+}
+
+// paymentStatusFor maps a Midtrans transaction status to ours. A charge that
+// lapsed is expired, the same status the payment deadline job writes
+func paymentStatusFor(transactionStatus, fraudStatus string) string {
+	switch transactionStatus {
+	case "settlement":
+		return "paid"
+	case "capture":
+		// challenged card payments wait for review in the Midtrans dashboard
+		if fraudStatus == "" || fraudStatus == "accept" {
+			return "paid"
+		}
+	case "expire":
+		return "expired"
+	case "cancel", "deny", "failure":
+		return "failed"
+	}
+	return ""
+}
+
+// validSignature checks signature_key is SHA512(order_id+status_code+gross_amount+server key)
+func validSignature(req dto.MidtransNotification) bool {
+	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
+	if serverKey == "" {
+		return false
+	}
+
+	sum := sha512.Sum512([]byte(req.OrderId + req.StatusCode + req.GrossAmount + serverKey))
+	expected := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(req.SignatureKey))) == 1
+}
+
+// GetPaymentById returns the payment to its owner or an admin
+func (ps *PaymentServ) GetPaymentById(id int, userId int, isAdmin bool) (res dto.PaymentInfoResponse, err error) {
 	resp, err := ps.paymentRepo.GetById(id)
 	if err != nil {
 		log.Printf("failed get payment by id %s", err)
-		return dto.PaymentInfoResponse{}, err
+		return dto.PaymentInfoResponse{}, ErrPaymentNotFound
 	}
 
-	res = dto.PaymentInfoResponse{
-		Id: resp.Id,
-		UserId: resp.UserId,
-		User: resp.User,
-		AuctionItemId: resp.AuctionItemId,
-		Status: resp.Status,
-		// PaymentStatus: resp.PaymentStatus,
-		Amount: resp.Amount,
+	if !isAdmin && resp.UserId != userId {
+		return dto.PaymentInfoResponse{}, ErrForbidden
 	}
 
-	return res, nil
+	return dto.PaymentInfo(resp), nil
 }
 
-func (ps *PaymentServ) GetAllPayment() (res []dto.PaymentInfoResponse, err error) {
-	resp, err := ps.paymentRepo.GetAll()
+// GetAllPayment lists every user's payments for admins
+func (ps *PaymentServ) GetAllPayment(filter dto.PaymentFilter) (res []dto.PaymentInfoResponse, total int64, err error) {
+	resp, total, err := ps.paymentRepo.GetAll(filter)
 	if err != nil {
 		log.Printf("failed get all payment info %s", err)
-		return []dto.PaymentInfoResponse{}, err
+		return []dto.PaymentInfoResponse{}, 0, err
 	}
 
+	res = []dto.PaymentInfoResponse{}
 	for _, payment := range resp {
-		res = append(res, dto.PaymentInfoResponse{
-		Id: payment.Id,
-		UserId: payment.UserId,
-		User: payment.User,
-		AuctionItemId: payment.AuctionItemId,
-		Status: payment.Status,
-		// PaymentStatus: payment.PaymentStatus,
-		Amount: payment.Amount,
-		})
+		res = append(res, dto.PaymentInfo(payment))
 	}
 
-	return res, nil
-}
\ No newline at end of file
+	return res, total, nil
+}
+
+// GetMyPayments lists the user's own payments
+func (ps *PaymentServ) GetMyPayments(userId int, page int, limit int) (res []dto.PaymentInfoResponse, total int64, err error) {
+	return ps.GetAllPayment(dto.PaymentFilter{UserId: userId, Page: page, Limit: limit})
+}
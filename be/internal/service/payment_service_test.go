@@ -1,135 +1,338 @@
 package service
 
 import (
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"testing"
+	"time"
 
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/entity"
 	"milestone3/be/internal/mocks"
+	"milestone3/be/internal/repository"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestPaymentService_CreatePayment(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockRepo := mocks.NewMockPaymentRepository(ctrl)
-	paymentService := NewPaymentService(mockRepo)
+	deadline := time.Now().Add(time.Hour)
+	passed := time.Now().Add(-time.Hour)
 
 	tests := []struct {
 		name          string
-		req           dto.PaymentRequest
 		userId        int
 		auctionItemId int
-		setup         func()
-		wantErr       bool
+		req           dto.PaymentRequest
+		setup         func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository)
+		wantErr       error
 	}{
 		{
-			name: "successful payment creation",
-			req: dto.PaymentRequest{
-				Amount: 100000,
+			name:          "successful payment creation",
+			userId:        1,
+			auctionItemId: 1,
+			req:           dto.PaymentRequest{PaymentType: "bank_transfer", Bank: "bca"},
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 1, ItemID: 1, UserID: 1, Amount: 250000, PaymentDeadline: &deadline}, nil)
+				repo.EXPECT().CountPaid(1, 1).Return(int64(0), nil)
+				repo.EXPECT().GetPayer(1).Return(entity.Users{Id: 1, Name: "Budi", Email: "budi@example.com"}, nil)
+				gateway.EXPECT().Charge(gomock.Any()).DoAndReturn(func(req repository.ChargeRequest) (dto.PaymentResponse, error) {
+					// amount comes from the winning bid, not the request
+					assert.Equal(t, 250000.0, req.Amount)
+					assert.Equal(t, &deadline, req.ExpiresAt)
+					assert.Equal(t, "bank_transfer", req.PaymentType)
+					assert.Equal(t, "bca", req.Bank)
+					assert.Equal(t, repository.ChargeCustomer{Name: "Budi", Email: "budi@example.com"}, req.Customer)
+					return dto.PaymentResponse{
+						OrderId:       "YDR-123",
+						TransactionId: "TXN-123",
+						PaymentType:   "bank_transfer",
+						VaNumber:      "12345678901",
+					}, nil
+				})
+				repo.EXPECT().Create(gomock.Any(), "YDR-123").DoAndReturn(func(payment *entity.Payment, orderId string) error {
+					assert.Equal(t, "bank_transfer", payment.PaymentType)
+					return nil
+				})
 			},
+		},
+		{
+			name:          "repository create error",
 			userId:        1,
 			auctionItemId: 1,
-			setup: func() {
-				mockRepo.EXPECT().CreateMidtrans(gomock.Any(), gomock.Any()).Return(dto.PaymentResponse{
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 1, ItemID: 1, UserID: 1, Amount: 250000, PaymentDeadline: &deadline}, nil)
+				repo.EXPECT().CountPaid(1, 1).Return(int64(0), nil)
+				repo.EXPECT().GetPayer(1).Return(entity.Users{Id: 1}, nil)
+				gateway.EXPECT().Charge(gomock.Any()).Return(dto.PaymentResponse{
 					OrderId:        "YDR-123",
 					TransactionId:  "TXN-123",
 					PaymentLinkUrl: "https://payment.link",
 				}, nil)
-				mockRepo.EXPECT().Create(gomock.Any(), "YDR-123").Return(nil)
+				repo.EXPECT().Create(gomock.Any(), "YDR-123").Return(errors.New("db error"))
 			},
-			wantErr: false,
+			wantErr: errors.New("db error"),
 		},
 		{
-			name: "repository create error",
-			req: dto.PaymentRequest{
-				Amount: 100000,
+			name:          "item still ongoing",
+			userId:        1,
+			auctionItemId: 1,
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "ongoing"}, nil)
 			},
+			wantErr: ErrNoWinningBid,
+		},
+		{
+			name:          "not the winning bidder",
+			userId:        2,
+			auctionItemId: 1,
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 1, ItemID: 1, UserID: 1, Amount: 250000, PaymentDeadline: &deadline}, nil)
+			},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:          "payment deadline passed",
 			userId:        1,
 			auctionItemId: 1,
-			setup: func() {
-				mockRepo.EXPECT().CreateMidtrans(gomock.Any(), gomock.Any()).Return(dto.PaymentResponse{
-					OrderId:        "YDR-123",
-					TransactionId:  "TXN-123",
-					PaymentLinkUrl: "https://payment.link",
-				}, nil)
-				mockRepo.EXPECT().Create(gomock.Any(), "YDR-123").Return(errors.New("db error"))
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 1, ItemID: 1, UserID: 1, Amount: 250000, PaymentDeadline: &passed}, nil)
 			},
-			wantErr: true,
+			wantErr: ErrPaymentExpired,
+		},
+		{
+			name:          "already paid",
+			userId:        1,
+			auctionItemId: 1,
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 1, ItemID: 1, UserID: 1, Amount: 250000, PaymentDeadline: &deadline}, nil)
+				repo.EXPECT().CountPaid(1, 1).Return(int64(1), nil)
+			},
+			wantErr: ErrAlreadyPaid,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setup()
-			
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockPaymentRepository(ctrl)
+			mockGateway := mocks.NewMockPaymentGateway(ctrl)
+			mockBidRepo := mocks.NewMockBidRepository(ctrl)
+			mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+			paymentService := NewPaymentService(mockRepo, mockGateway, mockBidRepo, mockItemRepo)
+
+			tt.setup(mockRepo, mockGateway, mockBidRepo, mockItemRepo)
+
 			result, err := paymentService.CreatePayment(tt.req, tt.userId, tt.auctionItemId)
-			
-			if tt.wantErr {
-				assert.Error(t, err)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, "YDR-123", result.OrderId)
 				assert.Equal(t, "TXN-123", result.TransactionId)
+				assert.Equal(t, "12345678901", result.VaNumber)
+				assert.Equal(t, 250000.0, result.Amount)
 			}
 		})
 	}
 }
 
+func TestPaymentService_ExpireUnpaidWins(t *testing.T) {
+	passed := time.Now().Add(-time.Hour)
+	reserve := 200000.0
+	lapsed := entity.Bid{ID: 1, SessionID: 1, ItemID: 1, UserID: 1, Amount: 300000, PaymentDeadline: &passed}
+
+	pending := entity.Payment{Id: 1, UserId: 1, AuctionItemId: 1, OrderId: "YDR-123", Amount: 300000, Status: "pending"}
+
+	tests := []struct {
+		name     string
+		pending  []entity.Payment
+		keepsWin bool
+		setup    func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository)
+	}{
+		{
+			name: "falls to the runner-up",
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished", ReservePrice: &reserve}, nil)
+				bidRepo.EXPECT().CountWinningBids(int64(1), int64(1)).Return(int64(1), nil)
+				bidRepo.EXPECT().GetRunnerUpBid(int64(1), int64(1)).Return(entity.BidHistory{SessionID: 1, ItemID: 1, UserID: 2, Amount: 250000}, nil)
+				bidRepo.EXPECT().SaveFinalBid(gomock.Any()).DoAndReturn(func(bid *entity.Bid) error {
+					assert.Equal(t, int64(2), bid.UserID)
+					assert.Equal(t, 250000.0, bid.Amount)
+					assert.True(t, bid.PaymentDeadline.After(time.Now()))
+					return nil
+				})
+			},
+		},
+		{
+			name: "runner-up below reserve reschedules",
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished", ReservePrice: &reserve}, nil)
+				bidRepo.EXPECT().CountWinningBids(int64(1), int64(1)).Return(int64(1), nil)
+				bidRepo.EXPECT().GetRunnerUpBid(int64(1), int64(1)).Return(entity.BidHistory{SessionID: 1, ItemID: 1, UserID: 2, Amount: 150000}, nil)
+				itemRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(item *entity.AuctionItem) error {
+					assert.Equal(t, "scheduled", item.Status)
+					assert.Nil(t, item.SessionID)
+					return nil
+				})
+			},
+		},
+		{
+			name: "runner-up already lapsed reschedules",
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().CountWinningBids(int64(1), int64(1)).Return(int64(2), nil)
+				itemRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(item *entity.AuctionItem) error {
+					assert.Equal(t, "scheduled", item.Status)
+					return nil
+				})
+			},
+		},
+		{
+			name:    "charge still pending at the gateway lapses",
+			pending: []entity.Payment{pending},
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				gateway.EXPECT().Status("YDR-123").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-123", PaymentStatus: "pending"}, nil)
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().CountWinningBids(int64(1), int64(1)).Return(int64(2), nil)
+				itemRepo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:     "settled at the gateway with a missed notification keeps the win",
+			pending:  []entity.Payment{pending},
+			keepsWin: true,
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				gateway.EXPECT().Status("YDR-123").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-123", PaymentStatus: "settlement", GrossAmount: "300000.00"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "paid", gomock.Any()).Return(true, nil)
+			},
+		},
+		{
+			name:     "settled for another amount is left for reconciliation",
+			pending:  []entity.Payment{pending},
+			keepsWin: true,
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				gateway.EXPECT().Status("YDR-123").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-123", PaymentStatus: "settlement", GrossAmount: "100000.00"}, nil)
+			},
+		},
+		{
+			name:     "gateway unreachable waits for the next pass",
+			pending:  []entity.Payment{pending},
+			keepsWin: true,
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				gateway.EXPECT().Status("YDR-123").Return(dto.CheckPaymentStatusResponse{}, errors.New("timeout"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockPaymentRepository(ctrl)
+			mockBidRepo := mocks.NewMockBidRepository(ctrl)
+			mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+			mockGateway := mocks.NewMockPaymentGateway(ctrl)
+			paymentService := NewPaymentService(mockRepo, mockGateway, mockBidRepo, mockItemRepo)
+
+			mockBidRepo.EXPECT().GetUnpaidExpiredBids(gomock.Any()).Return([]entity.Bid{lapsed}, nil)
+			mockRepo.EXPECT().GetPending(1, 1).Return(tt.pending, nil)
+			if !tt.keepsWin {
+				mockBidRepo.EXPECT().MarkBidLapsed(int64(1)).Return(nil)
+				mockRepo.EXPECT().ExpirePending(1, 1).Return(nil)
+			}
+			tt.setup(mockRepo, mockGateway, mockBidRepo, mockItemRepo)
+
+			assert.NoError(t, paymentService.ExpireUnpaidWins())
+		})
+	}
+}
+
 func TestPaymentService_GetPaymentById(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockPaymentRepository(ctrl)
-	paymentService := NewPaymentService(mockRepo)
+	paymentService := NewPaymentService(mockRepo, mocks.NewMockPaymentGateway(ctrl), mocks.NewMockBidRepository(ctrl), mocks.NewMockAuctionItemRepository(ctrl))
+
+	payment := entity.Payment{
+		Id:            1,
+		UserId:        1,
+		User:          entity.Users{Id: 1, Name: "Budi", Email: "budi@mail.com", Password: "hashed"},
+		AuctionItemId: 1,
+		Amount:        100000.0,
+		Status:        "pending",
+	}
 
 	tests := []struct {
 		name    string
 		id      int
+		userId  int
+		isAdmin bool
 		setup   func()
-		wantErr bool
+		wantErr error
 	}{
 		{
-			name: "successful get payment by id",
-			id:   1,
+			name:   "owner gets own payment",
+			id:     1,
+			userId: 1,
 			setup: func() {
-				mockRepo.EXPECT().GetById(1).Return(entity.Payment{
-					Id:            1,
-					UserId:        1,
-					AuctionItemId: 1,
-					Amount:        100000.0,
-					Status:        "pending",
-				}, nil)
+				mockRepo.EXPECT().GetById(1).Return(payment, nil)
 			},
-			wantErr: false,
 		},
 		{
-			name: "payment not found",
-			id:   999,
+			name:    "admin gets any payment",
+			id:      1,
+			userId:  9,
+			isAdmin: true,
+			setup: func() {
+				mockRepo.EXPECT().GetById(1).Return(payment, nil)
+			},
+		},
+		{
+			name:   "other user is forbidden",
+			id:     1,
+			userId: 2,
+			setup: func() {
+				mockRepo.EXPECT().GetById(1).Return(payment, nil)
+			},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:   "payment not found",
+			id:     999,
+			userId: 1,
 			setup: func() {
 				mockRepo.EXPECT().GetById(999).Return(entity.Payment{}, errors.New("payment not found"))
 			},
-			wantErr: true,
+			wantErr: ErrPaymentNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
-			
-			result, err := paymentService.GetPaymentById(tt.id)
-			
-			if tt.wantErr {
-				assert.Error(t, err)
+
+			result, err := paymentService.GetPaymentById(tt.id, tt.userId, tt.isAdmin)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 				assert.Empty(t, result)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, 1, result.Id)
 				assert.Equal(t, 100000.0, result.Amount)
+				assert.Equal(t, dto.PaymentUserResponse{Id: 1, Name: "Budi"}, result.User)
 			}
 		})
 	}
@@ -140,7 +343,9 @@ func TestPaymentService_GetAllPayment(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockPaymentRepository(ctrl)
-	paymentService := NewPaymentService(mockRepo)
+	paymentService := NewPaymentService(mockRepo, mocks.NewMockPaymentGateway(ctrl), mocks.NewMockBidRepository(ctrl), mocks.NewMockAuctionItemRepository(ctrl))
+
+	filter := dto.PaymentFilter{Status: "paid", AuctionItemId: 3, Page: 2, Limit: 10}
 
 	tests := []struct {
 		name    string
@@ -151,14 +356,14 @@ func TestPaymentService_GetAllPayment(t *testing.T) {
 			name: "successful get all",
 			setup: func() {
 				payments := []entity.Payment{{Id: 1}, {Id: 2}}
-				mockRepo.EXPECT().GetAll().Return(payments, nil)
+				mockRepo.EXPECT().GetAll(filter).Return(payments, int64(12), nil)
 			},
 			wantErr: false,
 		},
 		{
 			name: "repository error",
 			setup: func() {
-				mockRepo.EXPECT().GetAll().Return(nil, errors.New("db error"))
+				mockRepo.EXPECT().GetAll(filter).Return(nil, int64(0), errors.New("db error"))
 			},
 			wantErr: true,
 		},
@@ -167,13 +372,596 @@ func TestPaymentService_GetAllPayment(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
-			result, err := paymentService.GetAllPayment()
+			result, total, err := paymentService.GetAllPayment(filter)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.NotNil(t, result)
+				assert.Len(t, result, 2)
+				assert.Equal(t, int64(12), total)
+			}
+		})
+	}
+}
+
+func TestPaymentService_GetMyPayments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPaymentRepository(ctrl)
+	paymentService := NewPaymentService(mockRepo, mocks.NewMockPaymentGateway(ctrl), mocks.NewMockBidRepository(ctrl), mocks.NewMockAuctionItemRepository(ctrl))
+
+	mockRepo.EXPECT().GetAll(dto.PaymentFilter{UserId: 4, Page: 1, Limit: 10}).Return([]entity.Payment{{Id: 1, UserId: 4}}, int64(1), nil)
+
+	result, total, err := paymentService.GetMyPayments(4, 1, 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestPaymentService_CheckPaymentStatusMidtrans(t *testing.T) {
+	tests := []struct {
+		name    string
+		userId  int
+		isAdmin bool
+		setup   func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway)
+		wantErr error
+	}{
+		{
+			name:   "owner polls and settles",
+			userId: 1,
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, UserId: 1, OrderId: "YDR-123", Status: "pending"}, nil)
+				gateway.EXPECT().Status("YDR-123").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-123", PaymentStatus: "settlement"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "paid", gomock.Any()).Return(true, nil)
+			},
+		},
+		{
+			name:   "other user is forbidden",
+			userId: 2,
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, UserId: 1, OrderId: "YDR-123", Status: "pending"}, nil)
+			},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "unknown order",
+			isAdmin: true,
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{}, errors.New("record not found"))
+			},
+			wantErr: ErrPaymentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockPaymentRepository(ctrl)
+			mockGateway := mocks.NewMockPaymentGateway(ctrl)
+			paymentService := NewPaymentService(mockRepo, mockGateway, mocks.NewMockBidRepository(ctrl), mocks.NewMockAuctionItemRepository(ctrl))
+
+			tt.setup(mockRepo, mockGateway)
+
+			_, err := paymentService.CheckPaymentStatusMidtrans("YDR-123", tt.userId, tt.isAdmin)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// signNotification signs like Midtrans does, SHA512(order_id+status_code+gross_amount+server key)
+func signNotification(req dto.MidtransNotification, serverKey string) dto.MidtransNotification {
+	sum := sha512.Sum512([]byte(req.OrderId + req.StatusCode + req.GrossAmount + serverKey))
+	req.SignatureKey = hex.EncodeToString(sum[:])
+	return req
+}
+
+func TestPaymentService_HandleNotification(t *testing.T) {
+	t.Setenv("MIDTRANS_SERVER_KEY", "server-key")
+
+	settlement := dto.MidtransNotification{
+		OrderId:           "YDR-123",
+		StatusCode:        "200",
+		GrossAmount:       "250000.00",
+		TransactionId:     "TXN-123",
+		TransactionStatus: "settlement",
+	}
+	expire := settlement
+	expire.StatusCode = "407"
+	expire.TransactionStatus = "expire"
+	deny := settlement
+	deny.StatusCode = "202"
+	deny.TransactionStatus = "deny"
+	cancel := settlement
+	cancel.StatusCode = "202"
+	cancel.TransactionStatus = "cancel"
+
+	tests := []struct {
+		name    string
+		req     dto.MidtransNotification
+		setup   func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository)
+		wantErr error
+	}{
+		{
+			name: "settlement marks payment paid",
+			req:  signNotification(settlement, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, OrderId: "YDR-123", Amount: 250000, Status: "pending"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "paid", gomock.Any()).Return(true, nil)
+			},
+		},
+		{
+			name: "replayed settlement is a no-op",
+			req:  signNotification(settlement, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, OrderId: "YDR-123", Amount: 250000, Status: "paid"}, nil)
+			},
+		},
+		{
+			name: "late expire does not undo paid",
+			req:  signNotification(expire, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, OrderId: "YDR-123", Amount: 250000, Status: "paid"}, nil)
+			},
+		},
+		{
+			name: "expire marks payment expired and passes the item to the runner-up",
+			req:  signNotification(expire, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, OrderId: "YDR-123", UserId: 1, AuctionItemId: 1, Amount: 250000, Status: "pending"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "expired", gomock.Any()).Return(true, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 1, SessionID: 1, ItemID: 1, UserID: 1, Amount: 250000}, nil)
+				bidRepo.EXPECT().MarkBidLapsed(int64(1)).Return(nil)
+				repo.EXPECT().ExpirePending(1, 1).Return(nil)
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().CountWinningBids(int64(1), int64(1)).Return(int64(1), nil)
+				bidRepo.EXPECT().GetRunnerUpBid(int64(1), int64(1)).Return(entity.BidHistory{SessionID: 1, ItemID: 1, UserID: 2, Amount: 200000}, nil)
+				bidRepo.EXPECT().SaveFinalBid(gomock.Any()).DoAndReturn(func(bid *entity.Bid) error {
+					assert.Equal(t, int64(2), bid.UserID)
+					return nil
+				})
+			},
+		},
+		{
+			name: "deny reschedules the item without a runner-up",
+			req:  signNotification(deny, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, OrderId: "YDR-123", UserId: 1, AuctionItemId: 1, Amount: 250000, Status: "pending"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "failed", gomock.Any()).Return(true, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 1, SessionID: 1, ItemID: 1, UserID: 1, Amount: 250000}, nil)
+				bidRepo.EXPECT().MarkBidLapsed(int64(1)).Return(nil)
+				repo.EXPECT().ExpirePending(1, 1).Return(nil)
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().CountWinningBids(int64(1), int64(1)).Return(int64(1), nil)
+				bidRepo.EXPECT().GetRunnerUpBid(int64(1), int64(1)).Return(entity.BidHistory{}, errors.New("record not found"))
+				itemRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(item *entity.AuctionItem) error {
+					assert.Equal(t, "scheduled", item.Status)
+					return nil
+				})
+			},
+		},
+		{
+			name: "expire after the win moved on leaves the item",
+			req:  signNotification(expire, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, OrderId: "YDR-123", UserId: 1, AuctionItemId: 1, Amount: 250000, Status: "pending"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "expired", gomock.Any()).Return(true, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 2, SessionID: 1, ItemID: 1, UserID: 2, Amount: 200000}, nil)
+			},
+		},
+		{
+			name: "cancel keeps the item with the winner",
+			req:  signNotification(cancel, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, OrderId: "YDR-123", UserId: 1, AuctionItemId: 1, Amount: 250000, Status: "pending"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "failed", gomock.Any()).Return(true, nil)
+			},
+		},
+		{
+			name: "expire already applied elsewhere leaves the item",
+			req:  signNotification(expire, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, OrderId: "YDR-123", UserId: 1, AuctionItemId: 1, Amount: 250000, Status: "pending"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "expired", gomock.Any()).Return(false, nil)
+			},
+		},
+		{
+			name: "invalid signature",
+			req:  signNotification(settlement, "someone-else"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "amount does not match payment",
+			req:  signNotification(settlement, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{Id: 1, OrderId: "YDR-123", Amount: 300000, Status: "pending"}, nil)
+			},
+			wantErr: ErrInvalidPayment,
+		},
+		{
+			name: "unknown order",
+			req:  signNotification(settlement, "server-key"),
+			setup: func(repo *mocks.MockPaymentRepository, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetByOrderId("YDR-123").Return(entity.Payment{}, errors.New("record not found"))
+			},
+			wantErr: ErrPaymentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockPaymentRepository(ctrl)
+			mockBidRepo := mocks.NewMockBidRepository(ctrl)
+			mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+			paymentService := NewPaymentService(mockRepo, mocks.NewMockPaymentGateway(ctrl), mockBidRepo, mockItemRepo)
+
+			tt.setup(mockRepo, mockBidRepo, mockItemRepo)
+
+			err := paymentService.HandleNotification(tt.req)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPaymentService_CancelPayment(t *testing.T) {
+	pending := entity.Payment{Id: 1, UserId: 1, AuctionItemId: 1, OrderId: "YDR-123", Amount: 250000, Status: "pending"}
+
+	tests := []struct {
+		name    string
+		req     dto.CancelPaymentRequest
+		setup   func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository)
+		wantErr error
+	}{
+		{
+			name: "cancel pending payment",
+			req:  dto.CancelPaymentRequest{Reason: "duplicate charge"},
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetById(1).Return(pending, nil)
+				gateway.EXPECT().Cancel("YDR-123").Return(nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "cancelled", "duplicate charge").Return(true, nil)
+			},
+		},
+		{
+			name: "cancel and reopen item",
+			req:  dto.CancelPaymentRequest{Reason: "winner backed out", ReopenItem: true},
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetById(1).Return(pending, nil)
+				gateway.EXPECT().Cancel("YDR-123").Return(nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "cancelled", "winner backed out").Return(true, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 7, ItemID: 1, UserID: 1}, nil)
+				bidRepo.EXPECT().MarkBidLapsed(int64(7)).Return(nil)
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "sold"}, nil)
+				itemRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(item *entity.AuctionItem) error {
+					assert.Equal(t, "scheduled", item.Status)
+					assert.Nil(t, item.SessionID)
+					return nil
+				})
+			},
+		},
+		{
+			name: "paid payment cannot be cancelled",
+			req:  dto.CancelPaymentRequest{Reason: "too late"},
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				paid := pending
+				paid.Status = "paid"
+				repo.EXPECT().GetById(1).Return(paid, nil)
+			},
+			wantErr: ErrPaymentNotCancellable,
+		},
+		{
+			name: "settled while cancelling",
+			req:  dto.CancelPaymentRequest{Reason: "race"},
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetById(1).Return(pending, nil)
+				gateway.EXPECT().Cancel("YDR-123").Return(nil)
+				repo.EXPECT().TransitionStatus("YDR-123", "pending", "cancelled", "race").Return(false, nil)
+			},
+			wantErr: ErrPaymentNotCancellable,
+		},
+		{
+			name: "payment not found",
+			req:  dto.CancelPaymentRequest{Reason: "missing"},
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetById(1).Return(entity.Payment{}, errors.New("record not found"))
+			},
+			wantErr: ErrPaymentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockPaymentRepository(ctrl)
+			mockGateway := mocks.NewMockPaymentGateway(ctrl)
+			mockBidRepo := mocks.NewMockBidRepository(ctrl)
+			mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+			paymentService := NewPaymentService(mockRepo, mockGateway, mockBidRepo, mockItemRepo)
+
+			tt.setup(mockRepo, mockGateway, mockBidRepo, mockItemRepo)
+
+			err := paymentService.CancelPayment(1, tt.req)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPaymentService_RefundPayment(t *testing.T) {
+	paid := entity.Payment{Id: 1, UserId: 1, AuctionItemId: 1, OrderId: "YDR-123", Amount: 250000, Status: "paid"}
+	partiallyRefunded := paid
+	partiallyRefunded.Status = "partially_refunded"
+
+	tests := []struct {
+		name       string
+		payment    entity.Payment
+		req        dto.RefundRequest
+		locked     entity.Payment
+		remaining  float64
+		setup      func(gateway *mocks.MockPaymentGateway)
+		wantStatus string
+		wantAmount float64
+		wantErr    error
+	}{
+		{
+			name:      "full refund by default",
+			payment:   paid,
+			req:       dto.RefundRequest{Reason: "item damaged"},
+			locked:    paid,
+			remaining: 250000,
+			setup: func(gateway *mocks.MockPaymentGateway) {
+				gateway.EXPECT().Refund("YDR-123", float64(250000), "item damaged").Return(nil)
+			},
+			wantStatus: "refunded",
+			wantAmount: 250000,
+		},
+		{
+			name:      "partial refund",
+			payment:   paid,
+			req:       dto.RefundRequest{Amount: 50000, Reason: "shipping covered"},
+			locked:    paid,
+			remaining: 250000,
+			setup: func(gateway *mocks.MockPaymentGateway) {
+				gateway.EXPECT().Refund("YDR-123", float64(50000), "shipping covered").Return(nil)
+			},
+			wantStatus: "partially_refunded",
+			wantAmount: 50000,
+		},
+		{
+			name:      "rest of a partially refunded payment",
+			payment:   partiallyRefunded,
+			req:       dto.RefundRequest{Reason: "cancel sale"},
+			locked:    partiallyRefunded,
+			remaining: 200000,
+			setup: func(gateway *mocks.MockPaymentGateway) {
+				gateway.EXPECT().Refund("YDR-123", float64(200000), "cancel sale").Return(nil)
+			},
+			wantStatus: "refunded",
+			wantAmount: 200000,
+		},
+		{
+			name:      "refund more than paid",
+			payment:   paid,
+			req:       dto.RefundRequest{Amount: 300000, Reason: "typo"},
+			locked:    paid,
+			remaining: 250000,
+			setup:     func(gateway *mocks.MockPaymentGateway) {},
+			wantErr:   ErrRefundExceeded,
+		},
+		{
+			name:      "concurrent refund took the rest first",
+			payment:   paid,
+			req:       dto.RefundRequest{Amount: 100000, Reason: "second admin"},
+			locked:    partiallyRefunded,
+			remaining: 50000,
+			setup:     func(gateway *mocks.MockPaymentGateway) {},
+			wantErr:   ErrRefundExceeded,
+		},
+		{
+			name:    "payment refunded in full while waiting for the lock",
+			payment: paid,
+			req:     dto.RefundRequest{Reason: "second admin"},
+			locked: func() entity.Payment {
+				p := paid
+				p.Status = "refunded"
+				return p
+			}(),
+			setup:   func(gateway *mocks.MockPaymentGateway) {},
+			wantErr: ErrPaymentNotRefundable,
+		},
+		{
+			name: "pending payment cannot be refunded",
+			payment: func() entity.Payment {
+				p := paid
+				p.Status = "pending"
+				return p
+			}(),
+			req:     dto.RefundRequest{Reason: "not paid"},
+			setup:   func(gateway *mocks.MockPaymentGateway) {},
+			wantErr: ErrPaymentNotRefundable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockPaymentRepository(ctrl)
+			mockGateway := mocks.NewMockPaymentGateway(ctrl)
+			paymentService := NewPaymentService(mockRepo, mockGateway, mocks.NewMockBidRepository(ctrl), mocks.NewMockAuctionItemRepository(ctrl))
+
+			mockRepo.EXPECT().GetById(1).Return(tt.payment, nil)
+			if tt.locked.Id != 0 {
+				mockRepo.EXPECT().CreateRefund(gomock.Any(), "YDR-123", gomock.Any()).DoAndReturn(
+					func(refund *entity.PaymentRefund, orderId string, issue func(entity.Payment, float64) (string, error)) error {
+						status, err := issue(tt.locked, tt.remaining)
+						if err != nil {
+							return err
+						}
+						assert.Equal(t, tt.wantStatus, status)
+						return nil
+					})
+			}
+			tt.setup(mockGateway)
+
+			result, err := paymentService.RefundPayment(1, tt.req, 9)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantAmount, result.Amount)
+				assert.Equal(t, 9, result.CreatedBy)
+			}
+		})
+	}
+}
+
+func TestPaymentService_ReconcilePayments(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Hour)
+	stale := entity.Payment{Id: 1, OrderId: "YDR-1", UserId: 1, AuctionItemId: 1, Amount: 250000, Status: "pending", ExpiresAt: &expiresAt}
+
+	tests := []struct {
+		name         string
+		setup        func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository)
+		wantChecked  int
+		wantResolved int
+		wantNotes    []string
+	}{
+		{
+			name: "settled at gateway marks paid",
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetStalePending(gomock.Any()).Return([]entity.Payment{stale}, nil)
+				gateway.EXPECT().Status("YDR-1").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-1", PaymentStatus: "settlement", GrossAmount: "250000.00"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-1", "pending", "paid", "reconciled, gateway reported settlement").Return(true, nil)
+				repo.EXPECT().GetClosedUnpaidSince(gomock.Any()).Return([]entity.Payment{}, nil)
+			},
+			wantChecked:  1,
+			wantResolved: 1,
+			wantNotes:    []string{"marked paid"},
+		},
+		{
+			name: "expired at gateway releases the win like a notification",
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetStalePending(gomock.Any()).Return([]entity.Payment{stale}, nil)
+				gateway.EXPECT().Status("YDR-1").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-1", PaymentStatus: "expire"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-1", "pending", "expired", "reconciled, gateway reported expire").Return(true, nil)
+				bidRepo.EXPECT().GetWinningBid(int64(1)).Return(entity.Bid{ID: 1, SessionID: 1, ItemID: 1, UserID: 1, Amount: 250000}, nil)
+				bidRepo.EXPECT().MarkBidLapsed(int64(1)).Return(nil)
+				repo.EXPECT().ExpirePending(1, 1).Return(nil)
+				itemRepo.EXPECT().GetByID(int64(1)).Return(&entity.AuctionItem{ID: 1, Status: "finished"}, nil)
+				bidRepo.EXPECT().CountWinningBids(int64(1), int64(1)).Return(int64(1), nil)
+				bidRepo.EXPECT().GetRunnerUpBid(int64(1), int64(1)).Return(entity.BidHistory{SessionID: 1, ItemID: 1, UserID: 2, Amount: 200000}, nil)
+				bidRepo.EXPECT().SaveFinalBid(gomock.Any()).DoAndReturn(func(bid *entity.Bid) error {
+					assert.Equal(t, int64(2), bid.UserID)
+					return nil
+				})
+				repo.EXPECT().GetClosedUnpaidSince(gomock.Any()).Return([]entity.Payment{}, nil)
+			},
+			wantChecked:  1,
+			wantResolved: 1,
+			wantNotes:    []string{"marked expired"},
+		},
+		{
+			name: "notification already applied",
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetStalePending(gomock.Any()).Return([]entity.Payment{stale}, nil)
+				gateway.EXPECT().Status("YDR-1").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-1", PaymentStatus: "settlement"}, nil)
+				repo.EXPECT().TransitionStatus("YDR-1", "pending", "paid", gomock.Any()).Return(false, nil)
+				repo.EXPECT().GetClosedUnpaidSince(gomock.Any()).Return([]entity.Payment{}, nil)
+			},
+			wantChecked: 1,
+		},
+		{
+			name: "amount differs is reported",
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				repo.EXPECT().GetStalePending(gomock.Any()).Return([]entity.Payment{stale}, nil)
+				gateway.EXPECT().Status("YDR-1").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-1", PaymentStatus: "settlement", GrossAmount: "100000.00"}, nil)
+				repo.EXPECT().GetClosedUnpaidSince(gomock.Any()).Return([]entity.Payment{}, nil)
+			},
+			wantChecked: 1,
+			wantNotes:   []string{"gateway settled 100000.00, payment is 250000.00"},
+		},
+		{
+			name: "still pending and unknown at gateway are reported",
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				other := stale
+				other.Id, other.OrderId = 2, "YDR-2"
+				repo.EXPECT().GetStalePending(gomock.Any()).Return([]entity.Payment{stale, other}, nil)
+				gateway.EXPECT().Status("YDR-1").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-1", PaymentStatus: "pending"}, nil)
+				gateway.EXPECT().Status("YDR-2").Return(dto.CheckPaymentStatusResponse{}, errors.New("transaction not found"))
+				repo.EXPECT().GetClosedUnpaidSince(gomock.Any()).Return([]entity.Payment{}, nil)
+			},
+			wantChecked: 2,
+			wantNotes:   []string{"still pending at the gateway past its expiry", "gateway lookup failed: transaction not found"},
+		},
+		{
+			name: "settled after we expired it is reported",
+			setup: func(repo *mocks.MockPaymentRepository, gateway *mocks.MockPaymentGateway, bidRepo *mocks.MockBidRepository, itemRepo *mocks.MockAuctionItemRepository) {
+				expired := stale
+				expired.Status = "expired"
+				failed := stale
+				failed.Id, failed.OrderId, failed.Status = 2, "YDR-2", "failed"
+				repo.EXPECT().GetStalePending(gomock.Any()).Return([]entity.Payment{}, nil)
+				repo.EXPECT().GetClosedUnpaidSince(gomock.Any()).Return([]entity.Payment{expired, failed}, nil)
+				gateway.EXPECT().Status("YDR-1").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-1", PaymentStatus: "settlement"}, nil)
+				gateway.EXPECT().Status("YDR-2").Return(dto.CheckPaymentStatusResponse{OrderId: "YDR-2", PaymentStatus: "deny"}, nil)
+			},
+			wantChecked: 2,
+			wantNotes:   []string{"settled at the gateway after we closed it, refund or restore it"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockPaymentRepository(ctrl)
+			mockGateway := mocks.NewMockPaymentGateway(ctrl)
+			mockBidRepo := mocks.NewMockBidRepository(ctrl)
+			mockItemRepo := mocks.NewMockAuctionItemRepository(ctrl)
+			paymentService := NewPaymentService(mockRepo, mockGateway, mockBidRepo, mockItemRepo)
+
+			tt.setup(mockRepo, mockGateway, mockBidRepo, mockItemRepo)
+
+			report, err := paymentService.ReconcilePayments()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantChecked, report.Checked)
+			assert.Equal(t, tt.wantResolved, report.Resolved)
+			notes := []string{}
+			for _, m := range report.Mismatches {
+				notes = append(notes, m.Note)
+			}
+			if tt.wantNotes == nil {
+				tt.wantNotes = []string{}
 			}
+			assert.Equal(t, tt.wantNotes, notes)
 		})
 	}
 }
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/repository"
+)
+
+const (
+	// PhotoURLTTL is how long a signed photo URL works
+	PhotoURLTTL = 15 * time.Minute
+	// photoURLRefresh drops cached URLs this long before they expire, so a
+	// URL handed out still works while the client loads the photo
+	photoURLRefresh = 3 * time.Minute
+)
+
+// PhotoURLSigner turns object names in the private bucket into signed URLs
+type PhotoURLSigner interface {
+	// Sign returns the URLs by object name, photos that cannot be signed are
+	// left out
+	Sign(objectNames []string) map[string]dto.PhotoURL
+}
+
+type photoURLSigner struct {
+	store repository.GCPStorageRepo
+	cache repository.PhotoURLCache
+}
+
+// NewPhotoURLSigner signs with the private bucket, store is nil when no
+// bucket is configured and nothing gets signed
+func NewPhotoURLSigner(store repository.GCPStorageRepo, cache repository.PhotoURLCache) PhotoURLSigner {
+	return &photoURLSigner{store: store, cache: cache}
+}
+
+func (s *photoURLSigner) Sign(objectNames []string) map[string]dto.PhotoURL {
+	urls := map[string]dto.PhotoURL{}
+	if s.store == nil || len(objectNames) == 0 {
+		return urls
+	}
+
+	seen := map[string]bool{}
+	unique := make([]string, 0, len(objectNames))
+	for _, name := range objectNames {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+
+	// one round trip for the whole response, a cache outage only costs signing
+	cached, err := s.cache.GetMany(unique)
+	if err != nil {
+		log.Printf("failed get cached photo urls: %v", err)
+		cached = map[string]dto.PhotoURL{}
+	}
+
+	signed := map[string]dto.PhotoURL{}
+	for _, name := range unique {
+		if url, ok := cached[name]; ok {
+			urls[name] = url
+			continue
+		}
+
+		expiresAt := time.Now().Add(PhotoURLTTL)
+		url, err := s.store.GenerateSignedURL(context.Background(), name, PhotoURLTTL)
+		if err != nil {
+			log.Printf("failed sign photo %s: %v", name, err)
+			continue
+		}
+		urls[name] = dto.PhotoURL{URL: url, ExpiresAt: expiresAt}
+		signed[name] = urls[name]
+	}
+
+	if err := s.cache.SetMany(signed, PhotoURLTTL-photoURLRefresh); err != nil {
+		log.Printf("failed cache photo urls: %v", err)
+	}
+	return urls
+}
+
+// photoURLsOf picks the URLs of the photos in their order
+func photoURLsOf(objectNames []string, urls map[string]dto.PhotoURL) []dto.PhotoURL {
+	var res []dto.PhotoURL
+	for _, name := range objectNames {
+		if url, ok := urls[name]; ok {
+			res = append(res, url)
+		}
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhotoURLSigner_Sign(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mocks.NewMockGCPStorageRepo(ctrl)
+	mockCache := mocks.NewMockPhotoURLCache(ctrl)
+	signer := NewPhotoURLSigner(mockStorage, mockCache)
+
+	cachedURL := dto.PhotoURL{URL: "https://signed/cached", ExpiresAt: time.Now().Add(10 * time.Minute)}
+
+	tests := []struct {
+		name     string
+		objects  []string
+		setup    func()
+		wantURLs map[string]string
+	}{
+		{
+			name:    "cached urls are reused and misses are signed and cached",
+			objects: []string{"a.jpg", "b.jpg", "a.jpg"},
+			setup: func() {
+				mockCache.EXPECT().GetMany([]string{"a.jpg", "b.jpg"}).Return(map[string]dto.PhotoURL{"a.jpg": cachedURL}, nil)
+				mockStorage.EXPECT().GenerateSignedURL(gomock.Any(), "b.jpg", PhotoURLTTL).Return("https://signed/b", nil)
+				mockCache.EXPECT().SetMany(gomock.Any(), PhotoURLTTL-photoURLRefresh).DoAndReturn(func(urls map[string]dto.PhotoURL, _ time.Duration) error {
+					assert.Len(t, urls, 1, "only new urls are cached")
+					assert.Equal(t, "https://signed/b", urls["b.jpg"].URL)
+					return nil
+				})
+			},
+			wantURLs: map[string]string{"a.jpg": "https://signed/cached", "b.jpg": "https://signed/b"},
+		},
+		{
+			name:    "photos that fail to sign are left out",
+			objects: []string{"a.jpg", "b.jpg"},
+			setup: func() {
+				mockCache.EXPECT().GetMany([]string{"a.jpg", "b.jpg"}).Return(map[string]dto.PhotoURL{}, nil)
+				mockStorage.EXPECT().GenerateSignedURL(gomock.Any(), "a.jpg", PhotoURLTTL).Return("", errors.New("no credentials"))
+				mockStorage.EXPECT().GenerateSignedURL(gomock.Any(), "b.jpg", PhotoURLTTL).Return("https://signed/b", nil)
+				mockCache.EXPECT().SetMany(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantURLs: map[string]string{"b.jpg": "https://signed/b"},
+		},
+		{
+			name:    "cache outage still signs",
+			objects: []string{"a.jpg"},
+			setup: func() {
+				mockCache.EXPECT().GetMany([]string{"a.jpg"}).Return(nil, errors.New("redis down"))
+				mockStorage.EXPECT().GenerateSignedURL(gomock.Any(), "a.jpg", PhotoURLTTL).Return("https://signed/a", nil)
+				mockCache.EXPECT().SetMany(gomock.Any(), gomock.Any()).Return(errors.New("redis down"))
+			},
+			wantURLs: map[string]string{"a.jpg": "https://signed/a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			urls := signer.Sign(tt.objects)
+
+			got := map[string]string{}
+			for name, url := range urls {
+				got[name] = url.URL
+			}
+			assert.Equal(t, tt.wantURLs, got)
+		})
+	}
+}
+
+func TestPhotoURLSigner_NoBucket(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	signer := NewPhotoURLSigner(nil, mocks.NewMockPhotoURLCache(ctrl))
+	assert.Empty(t, signer.Sign([]string{"a.jpg"}))
+}
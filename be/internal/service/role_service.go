@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/repository"
+	"milestone3/be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	GetAll() (roles []entity.Role, err error)
+	GetByNames(names []string) (roles []entity.Role, err error)
+	SetUserRoles(userId int, roleIds []int) error
+}
+
+type RoleServ struct {
+	roleRepo RoleRepository
+	userRepo UserRepository
+	revoked  repository.TokenRevocationRepository
+}
+
+func NewRoleService(rr RoleRepository, ur UserRepository, revoked repository.TokenRevocationRepository) *RoleServ {
+	return &RoleServ{roleRepo: rr, userRepo: ur, revoked: revoked}
+}
+
+func (rs *RoleServ) GetRoles() (res []dto.RoleResponse, err error) {
+	roles, err := rs.roleRepo.GetAll()
+	if err != nil {
+		log.Printf("failed get roles %s", err)
+		return nil, err
+	}
+
+	res = make([]dto.RoleResponse, 0, len(roles))
+	for _, r := range roles {
+		_, permissions := roleNames([]entity.Role{r})
+		res = append(res, dto.RoleResponse{
+			Id:          r.Id,
+			Name:        r.Name,
+			Description: r.Description,
+			Permissions: permissions,
+		})
+	}
+
+	return res, nil
+}
+
+func (rs *RoleServ) GetUserRoles(userId int) (res dto.UserRolesResponse, err error) {
+	user, err := rs.userRepo.GetById(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.UserRolesResponse{}, ErrUserNotFound
+		}
+		return dto.UserRolesResponse{}, err
+	}
+
+	roles, permissions := roleNames(user.Roles)
+	return dto.UserRolesResponse{UserId: user.Id, Roles: roles, Permissions: permissions}, nil
+}
+
+// SetUserRoles replaces the roles of a user. The user's access tokens are
+// revoked so the new permissions apply from their next refresh
+func (rs *RoleServ) SetUserRoles(userId, actorId int, req dto.SetUserRolesRequest) (res dto.UserRolesResponse, err error) {
+	user, err := rs.userRepo.GetById(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.UserRolesResponse{}, ErrUserNotFound
+		}
+		return dto.UserRolesResponse{}, err
+	}
+
+	names := uniqueNames(req.Roles)
+	current, _ := roleNames(user.Roles)
+	if userId == actorId && contains(current, "admin") && !contains(names, "admin") {
+		// keep at least the caller able to undo a mistake
+		return dto.UserRolesResponse{}, ErrAdminSelfDemotion
+	}
+
+	roles, err := rs.roleRepo.GetByNames(names)
+	if err != nil {
+		log.Printf("failed get roles %s", err)
+		return dto.UserRolesResponse{}, err
+	}
+	if len(roles) != len(names) {
+		return dto.UserRolesResponse{}, ErrRoleNotFound
+	}
+
+	roleIds := make([]int, 0, len(roles))
+	for _, r := range roles {
+		roleIds = append(roleIds, r.Id)
+	}
+
+	if err := rs.roleRepo.SetUserRoles(userId, roleIds); err != nil {
+		log.Printf("failed set roles of user %d %s", userId, err)
+		return dto.UserRolesResponse{}, err
+	}
+
+	if err := rs.revoked.RevokeUser(userId, time.Now(), utils.AccessTokenTTL()); err != nil {
+		log.Printf("failed revoke access tokens of user %d %s", userId, err)
+		return dto.UserRolesResponse{}, err
+	}
+
+	log.Printf("user %d set roles of user %d to %v", actorId, userId, names)
+	return rs.GetUserRoles(userId)
+}
+
+func uniqueNames(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		if !contains(out, n) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"milestone3/be/internal/dto"
+	"milestone3/be/internal/entity"
+	"milestone3/be/internal/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var (
+	adminRole  = entity.Role{Id: 1, Name: "admin", Permissions: []entity.Permission{{Name: "users.manage"}, {Name: "auctions.manage"}}}
+	auctioneer = entity.Role{Id: 3, Name: "auctioneer", Permissions: []entity.Permission{{Name: "auctions.manage"}}}
+	bidderRole = entity.Role{Id: 5, Name: "bidder", Permissions: []entity.Permission{{Name: "bids.place"}}}
+)
+
+func TestRoleService_GetRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoles := mocks.NewMockRoleRepository(ctrl)
+	roleService := NewRoleService(mockRoles, mocks.NewMockUserRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl))
+
+	mockRoles.EXPECT().GetAll().Return([]entity.Role{adminRole, bidderRole}, nil)
+
+	res, err := roleService.GetRoles()
+
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "admin", res[0].Name)
+	assert.Equal(t, []string{"users.manage", "auctions.manage"}, res[0].Permissions)
+}
+
+func TestRoleService_GetUserRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	roleService := NewRoleService(mocks.NewMockRoleRepository(ctrl), mockUsers, mocks.NewMockTokenRevocationRepository(ctrl))
+
+	mockUsers.EXPECT().GetById(2).Return(entity.Users{Id: 2, Roles: []entity.Role{adminRole, auctioneer}}, nil)
+	mockUsers.EXPECT().GetById(9).Return(entity.Users{}, gorm.ErrRecordNotFound)
+
+	res, err := roleService.GetUserRoles(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin", "auctioneer"}, res.Roles)
+	assert.Equal(t, []string{"users.manage", "auctions.manage"}, res.Permissions, "permissions shared by roles are listed once")
+
+	_, err = roleService.GetUserRoles(9)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestRoleService_SetUserRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		userId  int
+		req     dto.SetUserRolesRequest
+		setup   func(roles *mocks.MockRoleRepository, users *mocks.MockUserRepository, revoked *mocks.MockTokenRevocationRepository)
+		wantErr error
+	}{
+		{
+			name:   "assigns roles and revokes the user's access tokens",
+			userId: 2,
+			req:    dto.SetUserRolesRequest{Roles: []string{"auctioneer", "bidder", "auctioneer"}},
+			setup: func(roles *mocks.MockRoleRepository, users *mocks.MockUserRepository, revoked *mocks.MockTokenRevocationRepository) {
+				users.EXPECT().GetById(2).Return(entity.Users{Id: 2, Roles: []entity.Role{bidderRole}}, nil)
+				roles.EXPECT().GetByNames([]string{"auctioneer", "bidder"}).Return([]entity.Role{auctioneer, bidderRole}, nil)
+				roles.EXPECT().SetUserRoles(2, []int{3, 5}).Return(nil)
+				revoked.EXPECT().RevokeUser(2, gomock.Any(), gomock.Any()).Return(nil)
+				users.EXPECT().GetById(2).Return(entity.Users{Id: 2, Roles: []entity.Role{auctioneer, bidderRole}}, nil)
+			},
+		},
+		{
+			name:   "unknown role",
+			userId: 2,
+			req:    dto.SetUserRolesRequest{Roles: []string{"superuser"}},
+			setup: func(roles *mocks.MockRoleRepository, users *mocks.MockUserRepository, revoked *mocks.MockTokenRevocationRepository) {
+				users.EXPECT().GetById(2).Return(entity.Users{Id: 2}, nil)
+				roles.EXPECT().GetByNames([]string{"superuser"}).Return([]entity.Role{}, nil)
+			},
+			wantErr: ErrRoleNotFound,
+		},
+		{
+			name:   "admin cannot drop their own admin role",
+			userId: 1,
+			req:    dto.SetUserRolesRequest{Roles: []string{"bidder"}},
+			setup: func(roles *mocks.MockRoleRepository, users *mocks.MockUserRepository, revoked *mocks.MockTokenRevocationRepository) {
+				users.EXPECT().GetById(1).Return(entity.Users{Id: 1, Roles: []entity.Role{adminRole}}, nil)
+			},
+			wantErr: ErrAdminSelfDemotion,
+		},
+		{
+			name:   "user not found",
+			userId: 9,
+			req:    dto.SetUserRolesRequest{Roles: []string{"bidder"}},
+			setup: func(roles *mocks.MockRoleRepository, users *mocks.MockUserRepository, revoked *mocks.MockTokenRevocationRepository) {
+				users.EXPECT().GetById(9).Return(entity.Users{}, gorm.ErrRecordNotFound)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:   "repository error",
+			userId: 2,
+			req:    dto.SetUserRolesRequest{Roles: []string{"bidder"}},
+			setup: func(roles *mocks.MockRoleRepository, users *mocks.MockUserRepository, revoked *mocks.MockTokenRevocationRepository) {
+				users.EXPECT().GetById(2).Return(entity.Users{Id: 2}, nil)
+				roles.EXPECT().GetByNames([]string{"bidder"}).Return([]entity.Role{bidderRole}, nil)
+				roles.EXPECT().SetUserRoles(2, []int{5}).Return(errors.New("db error"))
+			},
+			wantErr: errors.New("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRoles := mocks.NewMockRoleRepository(ctrl)
+			mockUsers := mocks.NewMockUserRepository(ctrl)
+			mockRevoked := mocks.NewMockTokenRevocationRepository(ctrl)
+			roleService := NewRoleService(mockRoles, mockUsers, mockRevoked)
+
+			tt.setup(mockRoles, mockUsers, mockRevoked)
+
+			res, err := roleService.SetUserRoles(tt.userId, 1, tt.req)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Empty(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"auctioneer", "bidder"}, res.Roles)
+			}
+		})
+	}
+}
@@ -2,14 +2,21 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/entity"
+	"milestone3/be/internal/repository"
 	"milestone3/be/internal/utils"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -17,14 +24,40 @@ type UserRepository interface {
 	Create(user *entity.Users) error
 	GetByEmail(email string) (user entity.Users, err error)
 	GetById(id int) (user entity.Users, err error)
+	UpdatePassword(id int, passwordHash string) error
+	MarkEmailVerified(id int, at time.Time) error
+	UpdateProfile(user *entity.Users) error
+	ConfirmEmailChange(id int, email string, at time.Time) error
+	Deactivate(id int, at time.Time) error
+	Anonymize(id int, at time.Time) error
+	HasUnpaidWins(id int) (bool, error)
+	SetTOTPSecret(id int, encryptedSecret string) error
+	EnableTOTP(id int, at time.Time, step int64, codeHashes []string) error
+	DisableTOTP(id int) error
+	ReplaceRecoveryCodes(id int, codeHashes []string) error
+	UseTOTPStep(id int, step int64) (bool, error)
+	UseRecoveryCode(id int, codeHash string, at time.Time) (bool, error)
+	CountRecoveryCodes(id int) (int, error)
+}
+
+type RefreshTokenRepository interface {
+	Create(token *entity.RefreshToken) error
+	GetByHash(hash string) (token entity.RefreshToken, err error)
+	Rotate(oldId int, next *entity.RefreshToken) (rotated bool, err error)
+	RevokeFamily(familyId string) error
+	RevokeAllForUser(userId int) error
 }
 
 type UserServ struct {
-	userRepo UserRepository
+	userRepo  UserRepository
+	tokenRepo RefreshTokenRepository
+	revoked   repository.TokenRevocationRepository
+	mailer    repository.Mailer
+	attempts  repository.LoginAttemptRepository
 }
 
-func NewUserService(ur UserRepository) *UserServ {
-	return &UserServ{userRepo: ur}
+func NewUserService(ur UserRepository, tr RefreshTokenRepository, revoked repository.TokenRevocationRepository, mailer repository.Mailer, attempts repository.LoginAttemptRepository) *UserServ {
+	return &UserServ{userRepo: ur, tokenRepo: tr, revoked: revoked, mailer: mailer, attempts: attempts}
 }
 
 func (us *UserServ) CreateUser(req dto.UserRequest) (res dto.UserResponse, err error) {
@@ -46,6 +79,9 @@ func (us *UserServ) CreateUser(req dto.UserRequest) (res dto.UserResponse, err e
 		return dto.UserResponse{}, err
 	}
 
+	// the account exists either way, a lost email can be resent by logging in
+	us.sendVerificationEmail(user)
+
 	//get user id to show in the response
 	userInfo, err := us.GetUserById(user.Id)
 	if err != nil {
@@ -63,33 +99,621 @@ func (us *UserServ) GetUserById(id int) (res dto.UserResponse, err error) {
 		return dto.UserResponse{}, err
 	}
 
+	roles, _ := roleNames(user.Roles)
 	userInfo := dto.UserResponse{
 		Id:    user.Id,
 		Name:  user.Name,
 		Email: user.Email,
 		Role:  user.Role,
+
+		Roles:         roles,
+		EmailVerified: user.EmailVerifiedAt != nil,
 	}
 
 	return userInfo, nil
 }
 
-func (us *UserServ) GetUserByEmail(email, password string) (accessToken string, err error) {
+// GetUserByEmail logs the user in with a new access token and refresh token.
+// Too many wrong passwords lock the account for a while, unknown emails count
+// too so a locked response does not tell which accounts exist
+func (us *UserServ) GetUserByEmail(email, password string) (res dto.TokenResponse, err error) {
+	account := strings.ToLower(strings.TrimSpace(email))
+
+	if err := us.loginLocked(account); err != nil {
+		return dto.TokenResponse{}, err
+	}
+
 	user, err := us.userRepo.GetByEmail(email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", ErrInvalidCredential
+			return dto.TokenResponse{}, us.loginFailed(account)
 		}
-		return "", err
+		return dto.TokenResponse{}, err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", ErrInvalidCredential
+		return dto.TokenResponse{}, us.loginFailed(account)
+	}
+
+	if err := us.attempts.Reset(account); err != nil {
+		log.Printf("failed reset login attempts: %v", err)
+	}
+
+	if user.DeactivatedAt != nil {
+		return dto.TokenResponse{}, ErrAccountDeactivated
+	}
+
+	if user.EmailVerifiedAt == nil {
+		us.sendVerificationEmail(user)
+		return dto.TokenResponse{}, ErrEmailNotVerified
+	}
+
+	return us.completeLogin(user)
+}
+
+// loginLocked answers with the lockout while the account has one
+func (us *UserServ) loginLocked(account string) error {
+	locked, err := us.attempts.LockedFor(account)
+	if err != nil {
+		// the lockout is a guard, a redis outage should not stop every login
+		log.Printf("failed check login lock: %v", err)
+	}
+	if locked > 0 {
+		return &LockoutError{RetryAfter: locked}
+	}
+	return nil
+}
+
+// loginFailed counts a wrong password, the failure that reaches the limit
+// already answers with the lockout
+func (us *UserServ) loginFailed(account string) error {
+	lockout, err := us.attempts.RecordFailure(account, utils.LoginMaxAttempts(), utils.LoginLockout(), utils.LoginLockout())
+	if err != nil {
+		log.Printf("failed record login attempt: %v", err)
+	}
+	if lockout > 0 {
+		return &LockoutError{RetryAfter: lockout}
+	}
+	return ErrInvalidCredential
+}
+
+// RefreshToken trades a refresh token for a new pair, the old refresh token is
+// revoked. A refresh token used twice means it leaked, so its whole family and
+// the user's access tokens are revoked
+func (us *UserServ) RefreshToken(refreshToken string) (res dto.TokenResponse, err error) {
+	stored, err := us.tokenRepo.GetByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		return dto.TokenResponse{}, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		us.revokeReusedFamily(stored)
+		return dto.TokenResponse{}, ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return dto.TokenResponse{}, ErrInvalidRefreshToken
+	}
+
+	user, err := us.userRepo.GetById(stored.UserId)
+	if err != nil || user.DeactivatedAt != nil {
+		return dto.TokenResponse{}, ErrInvalidRefreshToken
+	}
+
+	return us.issueTokens(user, &stored, stored.MFA)
+}
+
+// Logout revokes the access token it was called with and the given refresh
+// token's session, or every session of the user
+func (us *UserServ) Logout(userId int, jti string, expiresAt time.Time, req dto.LogoutRequest) error {
+	if err := us.revoked.RevokeToken(jti, time.Until(expiresAt)); err != nil {
+		log.Printf("failed revoke access token %s", err)
+		return err
+	}
+
+	if req.AllSessions {
+		return us.revokeAllSessions(userId)
+	}
+
+	if req.RefreshToken == "" {
+		return nil
+	}
+
+	stored, err := us.tokenRepo.GetByHash(utils.HashToken(req.RefreshToken))
+	if err != nil || stored.UserId != userId {
+		// unknown or someone else's token, nothing of this user to revoke
+		return nil
+	}
+
+	return us.tokenRepo.RevokeFamily(stored.FamilyId)
+}
+
+// VerifyEmail marks the email of the token's user as verified, or confirms a
+// changed email when the link was sent to the new address
+func (us *UserServ) VerifyEmail(token string) error {
+	claims, err := utils.ParseActionToken(token, utils.PurposeVerifyEmail)
+	if err != nil {
+		return us.confirmEmailChange(token)
+	}
+
+	user, err := us.userRepo.GetById(claims.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidActionToken
+		}
+		return err
+	}
+
+	if claims.Binding != emailBinding(user.Email) {
+		// the email changed after the link was sent
+		return ErrInvalidActionToken
+	}
+
+	if err := us.userRepo.MarkEmailVerified(user.Id, time.Now()); err != nil {
+		log.Printf("failed mark email verified of user %d %s", user.Id, err)
+		return err
+	}
+
+	return nil
+}
+
+// ForgotPassword emails a reset link when the account exists, it reports
+// success either way so it cannot be used to find registered emails
+func (us *UserServ) ForgotPassword(email string) error {
+	// without the frontend page the email would carry a broken link, checked
+	// before the lookup so the answer is the same for every email
+	if os.Getenv("PASSWORD_RESET_URL") == "" {
+		log.Printf("password reset requested but PASSWORD_RESET_URL is not set")
+		return ErrResetUnavailable
 	}
 
-	token, err := utils.GenerateJwtToken(email, user.Role, user.Id)
+	user, err := us.userRepo.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if user.DeactivatedAt != nil {
+		return nil
+	}
+
+	token, err := utils.GenerateActionToken(user.Id, utils.PurposeResetPassword, passwordBinding(user), utils.PasswordResetTTL())
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password, it expires in %s.\n\n%s\n\nIf you did not ask for this you can ignore this email.",
+		user.Name, utils.PasswordResetTTL(), resetLink(token))
+	if err := us.mailer.Send(user.Email, "Reset your password", body); err != nil {
+		log.Printf("failed send password reset email to user %d %s", user.Id, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password from a reset link. The link only works
+// once because it is bound to the old password, and every session is revoked
+func (us *UserServ) ResetPassword(req dto.ResetPasswordRequest) error {
+	claims, err := utils.ParseActionToken(req.Token, utils.PurposeResetPassword)
+	if err != nil {
+		return ErrInvalidActionToken
+	}
+
+	user, err := us.userRepo.GetById(claims.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidActionToken
+		}
+		return err
+	}
+
+	if claims.Binding != passwordBinding(user) {
+		return ErrInvalidActionToken
+	}
+	// the link may predate the deactivation
+	if user.DeactivatedAt != nil {
+		return ErrAccountDeactivated
+	}
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Println("error encrypt password")
+		return err
+	}
+
+	if err := us.userRepo.UpdatePassword(user.Id, string(passHash)); err != nil {
+		log.Printf("failed update password of user %d %s", user.Id, err)
+		return err
+	}
+
+	// the reset link reached the inbox, so the email is proven as well
+	if err := us.userRepo.MarkEmailVerified(user.Id, time.Now()); err != nil {
+		log.Printf("failed mark email verified of user %d %s", user.Id, err)
+	}
+
+	return us.revokeAllSessions(user.Id)
+}
+
+func (us *UserServ) GetProfile(userId int) (res dto.ProfileResponse, err error) {
+	user, err := us.userRepo.GetById(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ProfileResponse{}, ErrUserNotFound
+		}
+		return dto.ProfileResponse{}, err
+	}
+
+	return profileResponse(user), nil
+}
+
+// UpdateProfile changes the given fields. A new email is kept as pending and
+// only replaces the current one when the link sent to it is opened
+func (us *UserServ) UpdateProfile(userId int, req dto.UpdateProfileRequest) (res dto.ProfileResponse, err error) {
+	user, err := us.userRepo.GetById(userId)
 	if err != nil {
-		return "", err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ProfileResponse{}, ErrUserNotFound
+		}
+		return dto.ProfileResponse{}, err
+	}
+
+	if req.Name != nil {
+		user.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Phone != nil {
+		user.Phone = *req.Phone
+	}
+	if req.Address != nil {
+		user.Address = strings.TrimSpace(*req.Address)
+	}
+	if req.City != nil {
+		user.City = strings.TrimSpace(*req.City)
+	}
+	if req.PostalCode != nil {
+		user.PostalCode = *req.PostalCode
+	}
+
+	emailChanged := false
+	if req.Email != nil {
+		email := strings.TrimSpace(*req.Email)
+		if strings.EqualFold(email, user.Email) {
+			// changing back to the current email cancels a pending change
+			user.PendingEmail = nil
+		} else {
+			if err := us.ensureEmailFree(email, user.Id); err != nil {
+				return dto.ProfileResponse{}, err
+			}
+			user.PendingEmail = &email
+			emailChanged = true
+		}
+	}
+
+	if err := us.userRepo.UpdateProfile(&user); err != nil {
+		log.Printf("failed update profile of user %d %s", user.Id, err)
+		return dto.ProfileResponse{}, err
+	}
+
+	if emailChanged {
+		us.sendEmailChangeEmail(user)
+	}
+
+	return profileResponse(user), nil
+}
+
+// ChangePassword checks the current password, then logs out every session
+func (us *UserServ) ChangePassword(userId int, req dto.ChangePasswordRequest) error {
+	user, err := us.userRepo.GetById(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return ErrWrongPassword
+	}
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Println("error encrypt password")
+		return err
+	}
+
+	if err := us.userRepo.UpdatePassword(user.Id, string(passHash)); err != nil {
+		log.Printf("failed update password of user %d %s", user.Id, err)
+		return err
+	}
+
+	if err := us.mailer.Send(user.Email, "Your password was changed", fmt.Sprintf(
+		"Hi %s,\n\nThe password of your account was just changed and every session was logged out. If this was not you, reset your password right away.", user.Name)); err != nil {
+		log.Printf("failed send password changed email to user %d %s", user.Id, err)
+	}
+
+	return us.revokeAllSessions(user.Id)
+}
+
+// DeactivateAccount blocks logging in and logs out every session, the data is kept
+func (us *UserServ) DeactivateAccount(userId int, req dto.ConfirmPasswordRequest) error {
+	user, err := us.reauthenticate(userId, req)
+	if err != nil {
+		return err
+	}
+
+	if err := us.userRepo.Deactivate(user.Id, time.Now()); err != nil {
+		log.Printf("failed deactivate user %d %s", user.Id, err)
+		return err
+	}
+
+	return us.revokeAllSessions(user.Id)
+}
+
+// DeleteAccount anonymises the user. Donations, bids and payments stay for the
+// auction records but no longer identify anyone
+func (us *UserServ) DeleteAccount(userId int, req dto.ConfirmPasswordRequest) error {
+	user, err := us.reauthenticate(userId, req)
+	if err != nil {
+		return err
+	}
+
+	unpaid, err := us.userRepo.HasUnpaidWins(user.Id)
+	if err != nil {
+		log.Printf("failed check unpaid wins of user %d %s", user.Id, err)
+		return err
+	}
+	if unpaid {
+		return ErrUnpaidWins
+	}
+
+	if err := us.userRepo.Anonymize(user.Id, time.Now()); err != nil {
+		log.Printf("failed anonymize user %d %s", user.Id, err)
+		return err
+	}
+
+	return us.revokeAllSessions(user.Id)
+}
+
+// issueTokens signs an access token and stores a new refresh token, rotating
+// from a previous one when given. Only a login stamps the access token with
+// auth_time, a refresh does not count as logging in again
+func (us *UserServ) issueTokens(user entity.Users, rotateFrom *entity.RefreshToken, mfa bool) (dto.TokenResponse, error) {
+	var authTime time.Time
+	if rotateFrom == nil {
+		authTime = time.Now()
+	}
+	roles, permissions := roleNames(user.Roles)
+	accessToken, err := utils.GenerateJwtToken(user.Email, user.Id, roles, permissions, mfa, authTime)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	refreshToken, hash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	next := entity.RefreshToken{
+		UserId:    user.Id,
+		TokenHash: hash,
+		FamilyId:  uuid.New().String(),
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+		MFA:       mfa,
+	}
+
+	if rotateFrom == nil {
+		if err := us.tokenRepo.Create(&next); err != nil {
+			log.Printf("failed create refresh token %s", err)
+			return dto.TokenResponse{}, err
+		}
+	} else {
+		next.FamilyId = rotateFrom.FamilyId
+		rotated, err := us.tokenRepo.Rotate(rotateFrom.Id, &next)
+		if err != nil {
+			log.Printf("failed rotate refresh token %s", err)
+			return dto.TokenResponse{}, err
+		}
+		if !rotated {
+			// another refresh with the same token won the race
+			us.revokeReusedFamily(*rotateFrom)
+			return dto.TokenResponse{}, ErrInvalidRefreshToken
+		}
+	}
+
+	return dto.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
+
+		MFASetupRequired: !mfa && utils.MFARequired(roles),
+	}, nil
+}
+
+func (us *UserServ) revokeReusedFamily(token entity.RefreshToken) {
+	log.Printf("refresh token reuse detected for user %d, revoking family %s", token.UserId, token.FamilyId)
+
+	if err := us.tokenRepo.RevokeFamily(token.FamilyId); err != nil {
+		log.Printf("failed revoke refresh token family %s %s", token.FamilyId, err)
+	}
+	if err := us.revoked.RevokeUser(token.UserId, time.Now(), utils.AccessTokenTTL()); err != nil {
+		log.Printf("failed revoke access tokens of user %d %s", token.UserId, err)
+	}
+}
+
+// roleNames flattens roles into the role names and the union of their permissions
+func roleNames(roles []entity.Role) (names []string, permissions []string) {
+	names = make([]string, 0, len(roles))
+	permissions = []string{}
+	seen := map[string]bool{}
+	for _, r := range roles {
+		names = append(names, r.Name)
+		for _, p := range r.Permissions {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				permissions = append(permissions, p.Name)
+			}
+		}
+	}
+	return names, permissions
+}
+
+func (us *UserServ) confirmEmailChange(token string) error {
+	claims, err := utils.ParseActionToken(token, utils.PurposeChangeEmail)
+	if err != nil {
+		return ErrInvalidActionToken
+	}
+
+	user, err := us.userRepo.GetById(claims.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidActionToken
+		}
+		return err
+	}
+
+	if user.PendingEmail == nil || claims.Binding != emailBinding(*user.PendingEmail) {
+		// cancelled or replaced by a newer change
+		return ErrInvalidActionToken
+	}
+
+	newEmail := *user.PendingEmail
+	if err := us.ensureEmailFree(newEmail, user.Id); err != nil {
+		return err
+	}
+
+	if err := us.userRepo.ConfirmEmailChange(user.Id, newEmail, time.Now()); err != nil {
+		log.Printf("failed confirm email change of user %d %s", user.Id, err)
+		return err
+	}
+
+	if err := us.mailer.Send(user.Email, "Your email was changed", fmt.Sprintf(
+		"Hi %s,\n\nYour account email was changed to %s. If this was not you, contact support right away.", user.Name, newEmail)); err != nil {
+		log.Printf("failed send email changed notice to user %d %s", user.Id, err)
+	}
+
+	return nil
+}
+
+func (us *UserServ) ensureEmailFree(email string, userId int) error {
+	other, err := us.userRepo.GetByEmail(email)
+	if err == nil && other.Id != userId {
+		return ErrEmailTaken
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// noPassword is stored for accounts created through a login provider, no
+// bcrypt hash matches it
+const noPassword = "!"
+
+// reauthenticate makes sure the user behind the token is at the keyboard. It
+// takes the current password, accounts without one have to have logged in
+// within utils.ReauthWindow instead
+func (us *UserServ) reauthenticate(userId int, req dto.ConfirmPasswordRequest) (entity.Users, error) {
+	user, err := us.userRepo.GetById(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Users{}, ErrUserNotFound
+		}
+		return entity.Users{}, err
+	}
+
+	if user.Password == noPassword {
+		if req.AuthTime.IsZero() || time.Since(req.AuthTime) > utils.ReauthWindow {
+			return entity.Users{}, ErrReauthRequired
+		}
+		return user, nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return entity.Users{}, ErrWrongPassword
+	}
+
+	return user, nil
+}
+
+func (us *UserServ) revokeAllSessions(userId int) error {
+	if err := us.tokenRepo.RevokeAllForUser(userId); err != nil {
+		log.Printf("failed revoke refresh tokens of user %d %s", userId, err)
+		return err
+	}
+
+	return us.revoked.RevokeUser(userId, time.Now(), utils.AccessTokenTTL())
+}
+
+func profileResponse(user entity.Users) dto.ProfileResponse {
+	roles, _ := roleNames(user.Roles)
+	return dto.ProfileResponse{
+		Id:            user.Id,
+		Name:          user.Name,
+		Email:         user.Email,
+		PendingEmail:  user.PendingEmail,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Phone:         user.Phone,
+		Address:       user.Address,
+		City:          user.City,
+		PostalCode:    user.PostalCode,
+		Roles:         roles,
+
+		TwoFactorEnabled: user.TotpEnabledAt != nil,
+	}
+}
+
+func (us *UserServ) sendEmailChangeEmail(user entity.Users) {
+	token, err := utils.GenerateActionToken(user.Id, utils.PurposeChangeEmail, emailBinding(*user.PendingEmail), utils.EmailVerificationTTL())
+	if err != nil {
+		log.Printf("failed generate email change token for user %d %s", user.Id, err)
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nOpen the link below to use this address for your account, it expires in %s.\n\n%s",
+		user.Name, utils.EmailVerificationTTL(), appLink("/auth/verify", token))
+	if err := us.mailer.Send(*user.PendingEmail, "Confirm your new email", body); err != nil {
+		log.Printf("failed send email change link to user %d %s", user.Id, err)
+	}
+}
+
+// sendVerificationEmail only logs failures, the user can ask for a new link by logging in
+func (us *UserServ) sendVerificationEmail(user entity.Users) {
+	token, err := utils.GenerateActionToken(user.Id, utils.PurposeVerifyEmail, emailBinding(user.Email), utils.EmailVerificationTTL())
+	if err != nil {
+		log.Printf("failed generate verification token for user %d %s", user.Id, err)
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below, it expires in %s.\n\n%s",
+		user.Name, utils.EmailVerificationTTL(), appLink("/auth/verify", token))
+	if err := us.mailer.Send(user.Email, "Verify your email", body); err != nil {
+		log.Printf("failed send verification email to user %d %s", user.Id, err)
+	}
+}
+
+// emailBinding invalidates verification links when the email changes
+func emailBinding(email string) string {
+	return utils.HashToken(strings.ToLower(email))[:16]
+}
+
+// passwordBinding invalidates reset links once the password changes
+func passwordBinding(user entity.Users) string {
+	return utils.HashToken(user.Password)[:16]
+}
+
+// resetLink points at the frontend page (PASSWORD_RESET_URL) that asks for the
+// new password and posts it to /auth/reset-password
+func resetLink(token string) string {
+	return os.Getenv("PASSWORD_RESET_URL") + "?token=" + url.QueryEscape(token)
+}
+
+func appLink(path, token string) string {
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
 	}
 
-	return token, nil
+	return strings.TrimRight(baseURL, "/") + path + "?token=" + url.QueryEscape(token)
 }
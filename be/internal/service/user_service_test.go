@@ -3,14 +3,18 @@ package service
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"milestone3/be/internal/dto"
 	"milestone3/be/internal/entity"
 	"milestone3/be/internal/mocks"
+	"milestone3/be/internal/utils"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 func TestUserService_CreateUser(t *testing.T) {
@@ -18,7 +22,8 @@ func TestUserService_CreateUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockUserRepository(ctrl)
-	userService := NewUserService(mockRepo)
+	mockMailer := mocks.NewMockMailer(ctrl)
+	userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mockMailer, mocks.NewMockLoginAttemptRepository(ctrl))
 
 	tests := []struct {
 		name    string
@@ -35,6 +40,7 @@ func TestUserService_CreateUser(t *testing.T) {
 			},
 			setup: func() {
 				mockRepo.EXPECT().Create(gomock.Any()).Return(nil)
+				mockMailer.EXPECT().Send("test@example.com", "Verify your email", gomock.Any()).Return(nil)
 				mockRepo.EXPECT().GetById(gomock.Any()).Return(entity.Users{
 					Id:    1,
 					Name:  "Test User",
@@ -65,6 +71,7 @@ func TestUserService_CreateUser(t *testing.T) {
 			},
 			setup: func() {
 				mockRepo.EXPECT().Create(gomock.Any()).Return(nil)
+				mockMailer.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("smtp down"))
 				mockRepo.EXPECT().GetById(gomock.Any()).Return(entity.Users{}, errors.New("user not found"))
 			},
 			wantErr: true,
@@ -94,7 +101,7 @@ func TestUserService_GetUserById(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockUserRepository(ctrl)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
 
 	tests := []struct {
 		name    string
@@ -149,7 +156,8 @@ func TestUserService_GetUserByEmail(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockUserRepository(ctrl)
-	userService := NewUserService(mockRepo)
+	mockAttempts := mocks.NewMockLoginAttemptRepository(ctrl)
+	userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mockAttempts)
 
 	// Create a hashed password for testing
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
@@ -166,6 +174,7 @@ func TestUserService_GetUserByEmail(t *testing.T) {
 			email:    "notfound@example.com",
 			password: "password123",
 			setup: func() {
+				mockAttempts.EXPECT().LockedFor("notfound@example.com").Return(time.Duration(0), nil)
 				mockRepo.EXPECT().GetByEmail("notfound@example.com").Return(entity.Users{}, errors.New("user not found"))
 			},
 			wantErr: true,
@@ -182,7 +191,9 @@ func TestUserService_GetUserByEmail(t *testing.T) {
 					Password: string(hashedPassword),
 					Role:     "donor",
 				}
+				mockAttempts.EXPECT().LockedFor("test@example.com").Return(time.Duration(0), nil)
 				mockRepo.EXPECT().GetByEmail("test@example.com").Return(user, nil)
+				mockAttempts.EXPECT().RecordFailure("test@example.com", 5, 15*time.Minute, 15*time.Minute).Return(time.Duration(0), nil)
 			},
 			wantErr: true,
 		},
@@ -203,4 +214,722 @@ func TestUserService_GetUserByEmail(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestUserService_Login(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	t.Setenv("SECRET_KEY", "secret")
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockTokens := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockAttempts := mocks.NewMockLoginAttemptRepository(ctrl)
+	userService := NewUserService(mockRepo, mockTokens, mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mockAttempts)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	verifiedAt := time.Now()
+	mockAttempts.EXPECT().LockedFor("test@example.com").Return(time.Duration(0), nil)
+	mockAttempts.EXPECT().Reset("test@example.com").Return(nil)
+	mockRepo.EXPECT().GetByEmail("test@example.com").Return(entity.Users{Id: 1, Email: "test@example.com", Password: string(hashedPassword), Role: "donor", EmailVerifiedAt: &verifiedAt, Roles: []entity.Role{auctioneer, bidderRole}}, nil)
+
+	var stored entity.RefreshToken
+	mockTokens.EXPECT().Create(gomock.Any()).DoAndReturn(func(token *entity.RefreshToken) error {
+		stored = *token
+		return nil
+	})
+
+	res, err := userService.GetUserByEmail("test@example.com", "password123")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, res.AccessToken)
+	assert.Equal(t, "Bearer", res.TokenType)
+	assert.Equal(t, 15*60, res.ExpiresIn)
+	assert.Equal(t, 1, stored.UserId)
+	assert.NotEmpty(t, stored.FamilyId)
+	assert.Equal(t, utils.HashToken(res.RefreshToken), stored.TokenHash, "only the hash is stored")
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(res.AccessToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	claims := parsed.Claims.(jwt.MapClaims)
+	assert.Equal(t, "auctioneer", claims["role"])
+	assert.Equal(t, []interface{}{"auctioneer", "bidder"}, claims["roles"])
+	assert.Equal(t, []interface{}{"auctions.manage", "bids.place"}, claims["permissions"])
+	assert.Contains(t, claims, "auth_time", "a login is stamped for re-authentication")
+}
+
+func TestUserService_LoginLockout(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := entity.Users{Id: 1, Email: "test@example.com", Password: string(hashedPassword)}
+
+	tests := []struct {
+		name       string
+		email      string
+		password   string
+		setup      func(repo *mocks.MockUserRepository, attempts *mocks.MockLoginAttemptRepository)
+		retryAfter time.Duration
+	}{
+		{
+			name:     "locked account is refused before the password is checked",
+			email:    "Test@Example.com",
+			password: "password123",
+			setup: func(repo *mocks.MockUserRepository, attempts *mocks.MockLoginAttemptRepository) {
+				attempts.EXPECT().LockedFor("test@example.com").Return(10*time.Minute, nil)
+			},
+			retryAfter: 10 * time.Minute,
+		},
+		{
+			name:     "failure that reaches the limit locks the account",
+			email:    "test@example.com",
+			password: "wrongpassword",
+			setup: func(repo *mocks.MockUserRepository, attempts *mocks.MockLoginAttemptRepository) {
+				attempts.EXPECT().LockedFor("test@example.com").Return(time.Duration(0), nil)
+				repo.EXPECT().GetByEmail("test@example.com").Return(user, nil)
+				attempts.EXPECT().RecordFailure("test@example.com", 3, 5*time.Minute, 5*time.Minute).Return(5*time.Minute, nil)
+			},
+			retryAfter: 5 * time.Minute,
+		},
+		{
+			name:     "unknown emails are counted too",
+			email:    "ghost@example.com",
+			password: "password123",
+			setup: func(repo *mocks.MockUserRepository, attempts *mocks.MockLoginAttemptRepository) {
+				attempts.EXPECT().LockedFor("ghost@example.com").Return(time.Duration(0), nil)
+				repo.EXPECT().GetByEmail("ghost@example.com").Return(entity.Users{}, gorm.ErrRecordNotFound)
+				attempts.EXPECT().RecordFailure("ghost@example.com", 3, 5*time.Minute, 5*time.Minute).Return(5*time.Minute, nil)
+			},
+			retryAfter: 5 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			t.Setenv("LOGIN_MAX_ATTEMPTS", "3")
+			t.Setenv("LOGIN_LOCKOUT_MINUTES", "5")
+
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			mockAttempts := mocks.NewMockLoginAttemptRepository(ctrl)
+			tt.setup(mockRepo, mockAttempts)
+			userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mockAttempts)
+
+			res, err := userService.GetUserByEmail(tt.email, tt.password)
+
+			assert.Empty(t, res)
+			assert.ErrorIs(t, err, ErrAccountLocked)
+			var lockout *LockoutError
+			if assert.ErrorAs(t, err, &lockout) {
+				assert.Equal(t, tt.retryAfter, lockout.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestUserService_RefreshToken(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	user := entity.Users{Id: 1, Email: "test@example.com", Role: "donor"}
+	active := entity.RefreshToken{Id: 7, UserId: 1, TokenHash: utils.HashToken("old-token"), FamilyId: "family-1", ExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name    string
+		setup   func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository)
+		wantErr error
+	}{
+		{
+			name: "rotates the refresh token",
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository) {
+				tokens.EXPECT().GetByHash(utils.HashToken("old-token")).Return(active, nil)
+				repo.EXPECT().GetById(1).Return(user, nil)
+				tokens.EXPECT().Rotate(7, gomock.Any()).DoAndReturn(func(oldId int, next *entity.RefreshToken) (bool, error) {
+					assert.Equal(t, "family-1", next.FamilyId)
+					assert.NotEqual(t, active.TokenHash, next.TokenHash)
+					return true, nil
+				})
+			},
+		},
+		{
+			name: "unknown token",
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository) {
+				tokens.EXPECT().GetByHash(gomock.Any()).Return(entity.RefreshToken{}, errors.New("record not found"))
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "expired token",
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository) {
+				expired := active
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				tokens.EXPECT().GetByHash(gomock.Any()).Return(expired, nil)
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "reused token revokes the family and access tokens",
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository) {
+				revokedAt := time.Now().Add(-time.Minute)
+				reused := active
+				reused.RevokedAt = &revokedAt
+				tokens.EXPECT().GetByHash(gomock.Any()).Return(reused, nil)
+				tokens.EXPECT().RevokeFamily("family-1").Return(nil)
+				revoked.EXPECT().RevokeUser(1, gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "concurrent refresh loses the race",
+			setup: func(repo *mocks.MockUserRepository, tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository) {
+				tokens.EXPECT().GetByHash(gomock.Any()).Return(active, nil)
+				repo.EXPECT().GetById(1).Return(user, nil)
+				tokens.EXPECT().Rotate(7, gomock.Any()).Return(false, nil)
+				tokens.EXPECT().RevokeFamily("family-1").Return(nil)
+				revoked.EXPECT().RevokeUser(1, gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			mockTokens := mocks.NewMockRefreshTokenRepository(ctrl)
+			mockRevoked := mocks.NewMockTokenRevocationRepository(ctrl)
+			userService := NewUserService(mockRepo, mockTokens, mockRevoked, mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+			tt.setup(mockRepo, mockTokens, mockRevoked)
+
+			res, err := userService.RefreshToken("old-token")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, res.AccessToken)
+				assert.NotEqual(t, "old-token", res.RefreshToken)
+
+				parsed, _, err := jwt.NewParser().ParseUnverified(res.AccessToken, jwt.MapClaims{})
+				assert.NoError(t, err)
+				assert.NotContains(t, parsed.Claims.(jwt.MapClaims), "auth_time", "a refresh is not a fresh login")
+			}
+		})
+	}
+}
+
+func TestUserService_Logout(t *testing.T) {
+	expiresAt := time.Now().Add(10 * time.Minute)
+
+	tests := []struct {
+		name  string
+		req   dto.LogoutRequest
+		setup func(tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository)
+	}{
+		{
+			name: "revokes access token and refresh session",
+			req:  dto.LogoutRequest{RefreshToken: "refresh"},
+			setup: func(tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository) {
+				revoked.EXPECT().RevokeToken("jti-1", gomock.Any()).Return(nil)
+				tokens.EXPECT().GetByHash(utils.HashToken("refresh")).Return(entity.RefreshToken{Id: 7, UserId: 1, FamilyId: "family-1"}, nil)
+				tokens.EXPECT().RevokeFamily("family-1").Return(nil)
+			},
+		},
+		{
+			name: "someone else's refresh token is left alone",
+			req:  dto.LogoutRequest{RefreshToken: "refresh"},
+			setup: func(tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository) {
+				revoked.EXPECT().RevokeToken("jti-1", gomock.Any()).Return(nil)
+				tokens.EXPECT().GetByHash(gomock.Any()).Return(entity.RefreshToken{Id: 8, UserId: 2, FamilyId: "family-2"}, nil)
+			},
+		},
+		{
+			name: "all sessions",
+			req:  dto.LogoutRequest{AllSessions: true},
+			setup: func(tokens *mocks.MockRefreshTokenRepository, revoked *mocks.MockTokenRevocationRepository) {
+				revoked.EXPECT().RevokeToken("jti-1", gomock.Any()).Return(nil)
+				tokens.EXPECT().RevokeAllForUser(1).Return(nil)
+				revoked.EXPECT().RevokeUser(1, gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTokens := mocks.NewMockRefreshTokenRepository(ctrl)
+			mockRevoked := mocks.NewMockTokenRevocationRepository(ctrl)
+			userService := NewUserService(mocks.NewMockUserRepository(ctrl), mockTokens, mockRevoked, mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+			tt.setup(mockTokens, mockRevoked)
+
+			assert.NoError(t, userService.Logout(1, "jti-1", expiresAt, tt.req))
+		})
+	}
+}
+
+func TestUserService_LoginUnverified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	t.Setenv("SECRET_KEY", "secret")
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockMailer := mocks.NewMockMailer(ctrl)
+	mockAttempts := mocks.NewMockLoginAttemptRepository(ctrl)
+	userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mockMailer, mockAttempts)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	mockAttempts.EXPECT().LockedFor("test@example.com").Return(time.Duration(0), nil)
+	mockAttempts.EXPECT().Reset("test@example.com").Return(nil)
+	mockRepo.EXPECT().GetByEmail("test@example.com").Return(entity.Users{Id: 1, Email: "test@example.com", Password: string(hashedPassword)}, nil)
+	mockMailer.EXPECT().Send("test@example.com", "Verify your email", gomock.Any()).Return(nil)
+
+	res, err := userService.GetUserByEmail("test@example.com", "password123")
+
+	assert.ErrorIs(t, err, ErrEmailNotVerified)
+	assert.Empty(t, res)
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	user := entity.Users{Id: 1, Email: "test@example.com"}
+	valid, _ := utils.GenerateActionToken(1, utils.PurposeVerifyEmail, emailBinding(user.Email), time.Hour)
+	expired, _ := utils.GenerateActionToken(1, utils.PurposeVerifyEmail, emailBinding(user.Email), -time.Minute)
+	reset, _ := utils.GenerateActionToken(1, utils.PurposeResetPassword, emailBinding(user.Email), time.Hour)
+	access, _ := utils.GenerateJwtToken(user.Email, user.Id, []string{"donor"}, nil, false, time.Time{})
+
+	tests := []struct {
+		name    string
+		token   string
+		setup   func(repo *mocks.MockUserRepository)
+		wantErr error
+	}{
+		{
+			name:  "verifies the email",
+			token: valid,
+			setup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetById(1).Return(user, nil)
+				repo.EXPECT().MarkEmailVerified(1, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "expired link",
+			token:   expired,
+			setup:   func(repo *mocks.MockUserRepository) {},
+			wantErr: ErrInvalidActionToken,
+		},
+		{
+			name:    "reset token cannot verify",
+			token:   reset,
+			setup:   func(repo *mocks.MockUserRepository) {},
+			wantErr: ErrInvalidActionToken,
+		},
+		{
+			name:    "access token cannot verify",
+			token:   access,
+			setup:   func(repo *mocks.MockUserRepository) {},
+			wantErr: ErrInvalidActionToken,
+		},
+		{
+			name:  "email changed since the link was sent",
+			token: valid,
+			setup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetById(1).Return(entity.Users{Id: 1, Email: "new@example.com"}, nil)
+			},
+			wantErr: ErrInvalidActionToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+			tt.setup(mockRepo)
+
+			err := userService.VerifyEmail(tt.token)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserService_ForgotPassword(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+	t.Setenv("PASSWORD_RESET_URL", "https://app.example.com/reset")
+
+	t.Run("emails a reset link", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		mockMailer := mocks.NewMockMailer(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mockMailer, mocks.NewMockLoginAttemptRepository(ctrl))
+
+		user := entity.Users{Id: 1, Name: "Test User", Email: "test@example.com", Password: "hash"}
+		mockRepo.EXPECT().GetByEmail("test@example.com").Return(user, nil)
+		mockMailer.EXPECT().Send("test@example.com", "Reset your password", gomock.Any()).DoAndReturn(func(to, subject, body string) error {
+			assert.Contains(t, body, "https://app.example.com/reset?token=")
+			return nil
+		})
+
+		assert.NoError(t, userService.ForgotPassword("test@example.com"))
+	})
+
+	t.Run("unknown email looks the same", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetByEmail("nobody@example.com").Return(entity.Users{}, gorm.ErrRecordNotFound)
+
+		assert.NoError(t, userService.ForgotPassword("nobody@example.com"))
+	})
+
+	t.Run("no reset page configured", func(t *testing.T) {
+		t.Setenv("PASSWORD_RESET_URL", "")
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userService := NewUserService(mocks.NewMockUserRepository(ctrl), mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		assert.ErrorIs(t, userService.ForgotPassword("test@example.com"), ErrResetUnavailable)
+	})
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	user := entity.Users{Id: 1, Email: "test@example.com", Password: "old-hash"}
+	valid, _ := utils.GenerateActionToken(1, utils.PurposeResetPassword, passwordBinding(user), time.Hour)
+
+	t.Run("sets the password and revokes every session", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		mockTokens := mocks.NewMockRefreshTokenRepository(ctrl)
+		mockRevoked := mocks.NewMockTokenRevocationRepository(ctrl)
+		userService := NewUserService(mockRepo, mockTokens, mockRevoked, mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetById(1).Return(user, nil)
+		mockRepo.EXPECT().UpdatePassword(1, gomock.Any()).DoAndReturn(func(id int, hash string) error {
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("new-password")))
+			return nil
+		})
+		mockRepo.EXPECT().MarkEmailVerified(1, gomock.Any()).Return(nil)
+		mockTokens.EXPECT().RevokeAllForUser(1).Return(nil)
+		mockRevoked.EXPECT().RevokeUser(1, gomock.Any(), gomock.Any()).Return(nil)
+
+		assert.NoError(t, userService.ResetPassword(dto.ResetPasswordRequest{Token: valid, Password: "new-password"}))
+	})
+
+	t.Run("link already used", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		changed := user
+		changed.Password = "new-hash"
+		mockRepo.EXPECT().GetById(1).Return(changed, nil)
+
+		err := userService.ResetPassword(dto.ResetPasswordRequest{Token: valid, Password: "new-password"})
+		assert.ErrorIs(t, err, ErrInvalidActionToken)
+	})
+
+	t.Run("deactivated account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		deactivatedAt := time.Now()
+		deactivated := user
+		deactivated.DeactivatedAt = &deactivatedAt
+		mockRepo.EXPECT().GetById(1).Return(deactivated, nil)
+
+		err := userService.ResetPassword(dto.ResetPasswordRequest{Token: valid, Password: "new-password"})
+		assert.ErrorIs(t, err, ErrAccountDeactivated)
+	})
+}
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	name := "New Name"
+	city := "Bandung"
+	newEmail := "new@example.com"
+	takenEmail := "taken@example.com"
+	sameEmail := "TEST@example.com"
+
+	tests := []struct {
+		name    string
+		req     dto.UpdateProfileRequest
+		setup   func(repo *mocks.MockUserRepository, mailer *mocks.MockMailer)
+		check   func(t *testing.T, res dto.ProfileResponse)
+		wantErr error
+	}{
+		{
+			name: "updates only the fields sent",
+			req:  dto.UpdateProfileRequest{Name: &name, City: &city},
+			setup: func(repo *mocks.MockUserRepository, mailer *mocks.MockMailer) {
+				repo.EXPECT().GetById(1).Return(entity.Users{Id: 1, Name: "Old", Email: "test@example.com", Phone: "0812345678"}, nil)
+				repo.EXPECT().UpdateProfile(gomock.Any()).DoAndReturn(func(user *entity.Users) error {
+					assert.Equal(t, "New Name", user.Name)
+					assert.Equal(t, "Bandung", user.City)
+					assert.Equal(t, "0812345678", user.Phone)
+					return nil
+				})
+			},
+			check: func(t *testing.T, res dto.ProfileResponse) {
+				assert.Equal(t, "New Name", res.Name)
+				assert.Nil(t, res.PendingEmail)
+			},
+		},
+		{
+			name: "new email waits for confirmation",
+			req:  dto.UpdateProfileRequest{Email: &newEmail},
+			setup: func(repo *mocks.MockUserRepository, mailer *mocks.MockMailer) {
+				repo.EXPECT().GetById(1).Return(entity.Users{Id: 1, Email: "test@example.com"}, nil)
+				repo.EXPECT().GetByEmail(newEmail).Return(entity.Users{}, gorm.ErrRecordNotFound)
+				repo.EXPECT().UpdateProfile(gomock.Any()).Return(nil)
+				mailer.EXPECT().Send(newEmail, "Confirm your new email", gomock.Any()).Return(nil)
+			},
+			check: func(t *testing.T, res dto.ProfileResponse) {
+				assert.Equal(t, "test@example.com", res.Email, "the current email is kept until confirmed")
+				assert.Equal(t, newEmail, *res.PendingEmail)
+			},
+		},
+		{
+			name: "current email cancels a pending change",
+			req:  dto.UpdateProfileRequest{Email: &sameEmail},
+			setup: func(repo *mocks.MockUserRepository, mailer *mocks.MockMailer) {
+				repo.EXPECT().GetById(1).Return(entity.Users{Id: 1, Email: "test@example.com", PendingEmail: &newEmail}, nil)
+				repo.EXPECT().UpdateProfile(gomock.Any()).Return(nil)
+			},
+			check: func(t *testing.T, res dto.ProfileResponse) {
+				assert.Nil(t, res.PendingEmail)
+			},
+		},
+		{
+			name: "email taken",
+			req:  dto.UpdateProfileRequest{Email: &takenEmail},
+			setup: func(repo *mocks.MockUserRepository, mailer *mocks.MockMailer) {
+				repo.EXPECT().GetById(1).Return(entity.Users{Id: 1, Email: "test@example.com"}, nil)
+				repo.EXPECT().GetByEmail(takenEmail).Return(entity.Users{Id: 2, Email: takenEmail}, nil)
+			},
+			wantErr: ErrEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			mockMailer := mocks.NewMockMailer(ctrl)
+			userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mockMailer, mocks.NewMockLoginAttemptRepository(ctrl))
+
+			tt.setup(mockRepo, mockMailer)
+
+			res, err := userService.UpdateProfile(1, tt.req)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, res)
+		})
+	}
+}
+
+func TestUserService_ConfirmEmailChange(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	newEmail := "new@example.com"
+	otherEmail := "other@example.com"
+	token, _ := utils.GenerateActionToken(1, utils.PurposeChangeEmail, emailBinding(newEmail), time.Hour)
+
+	t.Run("moves the pending email into place", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		mockMailer := mocks.NewMockMailer(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mockMailer, mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetById(1).Return(entity.Users{Id: 1, Email: "old@example.com", PendingEmail: &newEmail}, nil)
+		mockRepo.EXPECT().GetByEmail(newEmail).Return(entity.Users{}, gorm.ErrRecordNotFound)
+		mockRepo.EXPECT().ConfirmEmailChange(1, newEmail, gomock.Any()).Return(nil)
+		mockMailer.EXPECT().Send("old@example.com", "Your email was changed", gomock.Any()).Return(nil)
+
+		assert.NoError(t, userService.VerifyEmail(token))
+	})
+
+	t.Run("pending email replaced since the link was sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetById(1).Return(entity.Users{Id: 1, Email: "old@example.com", PendingEmail: &otherEmail}, nil)
+
+		assert.ErrorIs(t, userService.VerifyEmail(token), ErrInvalidActionToken)
+	})
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := entity.Users{Id: 1, Email: "test@example.com", Password: string(hashedPassword)}
+
+	t.Run("wrong current password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetById(1).Return(user, nil)
+
+		err := userService.ChangePassword(1, dto.ChangePasswordRequest{CurrentPassword: "wrong-password", NewPassword: "new-password"})
+		assert.ErrorIs(t, err, ErrWrongPassword)
+	})
+
+	t.Run("changes the password and logs out every session", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		mockTokens := mocks.NewMockRefreshTokenRepository(ctrl)
+		mockRevoked := mocks.NewMockTokenRevocationRepository(ctrl)
+		mockMailer := mocks.NewMockMailer(ctrl)
+		userService := NewUserService(mockRepo, mockTokens, mockRevoked, mockMailer, mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetById(1).Return(user, nil)
+		mockRepo.EXPECT().UpdatePassword(1, gomock.Any()).Return(nil)
+		mockMailer.EXPECT().Send("test@example.com", "Your password was changed", gomock.Any()).Return(nil)
+		mockTokens.EXPECT().RevokeAllForUser(1).Return(nil)
+		mockRevoked.EXPECT().RevokeUser(1, gomock.Any(), gomock.Any()).Return(nil)
+
+		err := userService.ChangePassword(1, dto.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "new-password"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestUserService_DeactivateAndDelete(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := entity.Users{Id: 1, Email: "test@example.com", Password: string(hashedPassword)}
+
+	t.Run("deactivated accounts cannot log in", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		mockAttempts := mocks.NewMockLoginAttemptRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mockAttempts)
+
+		mockAttempts.EXPECT().LockedFor("test@example.com").Return(time.Duration(0), nil)
+		mockAttempts.EXPECT().Reset("test@example.com").Return(nil)
+		deactivatedAt := time.Now()
+		deactivated := user
+		deactivated.DeactivatedAt = &deactivatedAt
+		mockRepo.EXPECT().GetByEmail("test@example.com").Return(deactivated, nil)
+
+		_, err := userService.GetUserByEmail("test@example.com", "password123")
+		assert.ErrorIs(t, err, ErrAccountDeactivated)
+	})
+
+	t.Run("deactivate logs out every session", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		mockTokens := mocks.NewMockRefreshTokenRepository(ctrl)
+		mockRevoked := mocks.NewMockTokenRevocationRepository(ctrl)
+		userService := NewUserService(mockRepo, mockTokens, mockRevoked, mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetById(1).Return(user, nil)
+		mockRepo.EXPECT().Deactivate(1, gomock.Any()).Return(nil)
+		mockTokens.EXPECT().RevokeAllForUser(1).Return(nil)
+		mockRevoked.EXPECT().RevokeUser(1, gomock.Any(), gomock.Any()).Return(nil)
+
+		assert.NoError(t, userService.DeactivateAccount(1, dto.ConfirmPasswordRequest{Password: "password123"}))
+	})
+
+	t.Run("delete is refused with unpaid wins", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetById(1).Return(user, nil)
+		mockRepo.EXPECT().HasUnpaidWins(1).Return(true, nil)
+
+		assert.ErrorIs(t, userService.DeleteAccount(1, dto.ConfirmPasswordRequest{Password: "password123"}), ErrUnpaidWins)
+	})
+
+	t.Run("delete anonymises the account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		mockTokens := mocks.NewMockRefreshTokenRepository(ctrl)
+		mockRevoked := mocks.NewMockTokenRevocationRepository(ctrl)
+		userService := NewUserService(mockRepo, mockTokens, mockRevoked, mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetById(1).Return(user, nil)
+		mockRepo.EXPECT().HasUnpaidWins(1).Return(false, nil)
+		mockRepo.EXPECT().Anonymize(1, gomock.Any()).Return(nil)
+		mockTokens.EXPECT().RevokeAllForUser(1).Return(nil)
+		mockRevoked.EXPECT().RevokeUser(1, gomock.Any(), gomock.Any()).Return(nil)
+
+		assert.NoError(t, userService.DeleteAccount(1, dto.ConfirmPasswordRequest{Password: "password123"}))
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		mockRepo.EXPECT().GetById(1).Return(user, nil)
+
+		assert.ErrorIs(t, userService.DeleteAccount(1, dto.ConfirmPasswordRequest{Password: "wrong-password"}), ErrWrongPassword)
+	})
+
+	t.Run("account without a password needs a fresh login", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		userService := NewUserService(mockRepo, mocks.NewMockRefreshTokenRepository(ctrl), mocks.NewMockTokenRevocationRepository(ctrl), mocks.NewMockMailer(ctrl), mocks.NewMockLoginAttemptRepository(ctrl))
+
+		social := user
+		social.Password = "!"
+		mockRepo.EXPECT().GetById(1).Return(social, nil)
+
+		stale := dto.ConfirmPasswordRequest{Password: "!", AuthTime: time.Now().Add(-time.Hour)}
+		assert.ErrorIs(t, userService.DeleteAccount(1, stale), ErrReauthRequired)
+	})
+}
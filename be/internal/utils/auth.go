@@ -1,6 +1,12 @@
 package utils
 
-import "github.com/labstack/echo/v4"
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
 
 // GetUserID reads user id from context (set by auth middleware).
 // Returns (0,false) when not present/invalid.
@@ -20,6 +26,13 @@ func GetUserID(c echo.Context) (uint, bool) {
 	}
 }
 
+// GetAuthTime reads when the user last logged in (set by auth middleware),
+// zero when the token came from a refresh
+func GetAuthTime(c echo.Context) time.Time {
+	t, _ := c.Get("auth_time").(time.Time)
+	return t
+}
+
 // IsAdmin reads "is_admin" flag from context (set by auth middleware).
 func IsAdmin(c echo.Context) bool {
 	v := c.Get("is_admin")
@@ -28,3 +41,70 @@ func IsAdmin(c echo.Context) bool {
 	}
 	return false
 }
+
+// permission names, they match the permissions table
+const (
+	PermManageUsers     = "users.manage"
+	PermViewDashboard   = "dashboard.view"
+	PermCreateDonations = "donations.create"
+	PermReviewDonations = "donations.review"
+	PermManageAuctions  = "auctions.manage"
+	PermPlaceBids       = "bids.place"
+	PermManageArticles  = "articles.manage"
+	PermManagePayments  = "payments.manage"
+)
+
+// API key scopes, a key only reaches the read-only routes that accept one of
+// its scopes. They are checked like permissions
+const (
+	ScopeReadFinalDonations = "final_donations.read"
+	ScopeReadAuctions       = "auctions.read"
+)
+
+// APIKeyScopes are the scopes a key can be issued with
+var APIKeyScopes = []string{ScopeReadFinalDonations, ScopeReadAuctions}
+
+// HasPermission reads the "permissions" granted by the token (set by auth middleware).
+func HasPermission(c echo.Context, permission string) bool {
+	perms, _ := c.Get("permissions").([]string)
+	for _, p := range perms {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// DefaultLoginMaxAttempts is how many wrong passwords an account takes before it is locked
+	DefaultLoginMaxAttempts = 5
+	// DefaultLoginLockoutMinutes is how long the lock lasts, failures older than this are forgotten
+	DefaultLoginLockoutMinutes = 15
+)
+
+func LoginMaxAttempts() int {
+	attempts, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS"))
+	if err != nil || attempts <= 0 {
+		attempts = DefaultLoginMaxAttempts
+	}
+	return attempts
+}
+
+func LoginLockout() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("LOGIN_LOCKOUT_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = DefaultLoginLockoutMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// DefaultAPIKeyRateLimit is the requests per minute of a key issued without its own limit
+const DefaultAPIKeyRateLimit = 60
+
+func APIKeyRateLimit() int {
+	limit, err := strconv.Atoi(os.Getenv("API_KEY_RATE_LIMIT_PER_MINUTE"))
+	if err != nil || limit <= 0 {
+		limit = DefaultAPIKeyRateLimit
+	}
+	return limit
+}
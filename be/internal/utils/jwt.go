@@ -1,31 +1,50 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"log"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// for generate and validate jwt token
-func GenerateJwtToken(email, role string, id int) (string, error) {
-	expired_jwt := os.Getenv("EXPIRED_JWT")
-	expired_jwt_int, err := strconv.Atoi(expired_jwt)
-	if err != nil {
-		log.Println("error parsing expired_jwt to int")
-		return "", err
-	}
-	expired := time.Now().Add(time.Hour * time.Duration(expired_jwt_int)).Unix()
-	jwt_claim := jwt.NewWithClaims(jwt.SigningMethodHS256, 
-	jwt.MapClaims{
+const (
+	// DefaultAccessTokenTTLMinutes keeps access tokens short-lived, clients renew them with a refresh token
+	DefaultAccessTokenTTLMinutes = 15
+	// DefaultRefreshTokenTTLHours is how long a refresh token can be used if it is never rotated
+	DefaultRefreshTokenTTLHours = 720
+)
+
+// for generate and validate jwt token, every token gets its own jti so it can be revoked.
+// Roles and permissions are copied into the token, role changes apply on the next refresh.
+// mfa records that the login passed the second factor, authTime is when the user
+// logged in and is left out of tokens handed out by a refresh
+func GenerateJwtToken(email string, id int, roles, permissions []string, mfa bool, authTime time.Time) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
 		"id": id,
 		"email": email,
-		"role": role,
-		"exp": expired,
-	})
-		
+		"role": primaryRole(roles),
+		"roles": roles,
+		"permissions": permissions,
+		"mfa": mfa,
+		"jti": uuid.New().String(),
+		"iat": now.Unix(),
+		// iat in milliseconds, checked against the user's revoke-all time
+		"iat_ms": now.UnixMilli(),
+		"exp": now.Add(AccessTokenTTL()).Unix(),
+	}
+	if !authTime.IsZero() {
+		claims["auth_time"] = authTime.Unix()
+	}
+	jwt_claim := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
 	secret_key := os.Getenv("SECRET_KEY")
 	tokenString, err := jwt_claim.SignedString([]byte(secret_key))
 	if err != nil {
@@ -34,4 +53,147 @@ func GenerateJwtToken(email, role string, id int) (string, error) {
 	}
 
 	return tokenString, nil
-}
\ No newline at end of file
+}
+
+// primaryRole fills the legacy "role" claim for clients that still read it
+func primaryRole(roles []string) string {
+	for _, r := range roles {
+		if r == "admin" {
+			return r
+		}
+	}
+	if len(roles) > 0 {
+		return roles[0]
+	}
+	return ""
+}
+
+func AccessTokenTTL() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = DefaultAccessTokenTTLMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func RefreshTokenTTL() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = DefaultRefreshTokenTTLHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// GenerateRefreshToken returns an opaque random token for the client and the
+// hash that is stored, the token itself is never saved
+func GenerateRefreshToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// APIKeyPrefix marks API keys so they are easy to spot in logs and secret scanners
+const APIKeyPrefix = "ydr_"
+
+// GenerateAPIKey returns a new key, the prefix shown in key lists and the hash
+// that is stored
+func GenerateAPIKey() (key string, prefix string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:len(APIKeyPrefix)+8], HashToken(key), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+const (
+	// purposes tell action tokens apart so one cannot be used for the other
+	PurposeVerifyEmail   = "verify_email"
+	PurposeChangeEmail   = "change_email"
+	PurposeResetPassword = "reset_password"
+	PurposeMFALogin      = "mfa_login"
+	PurposeOIDCLink      = "oidc_link"
+
+	DefaultEmailVerificationTTLHours = 24
+	DefaultPasswordResetTTLMinutes   = 30
+)
+
+// ActionToken is what a verification or reset link carries, Binding ties the
+// token to the user's state at issue time so it stops working once used
+type ActionToken struct {
+	UserId  int
+	Purpose string
+	Binding string
+}
+
+// GenerateActionToken signs a short-lived token for emailed links, it is signed
+// with a key derived per purpose so it is never accepted as an access token
+func GenerateActionToken(userId int, purpose, binding string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claim := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":     strconv.Itoa(userId),
+		"purpose": purpose,
+		"bind":    binding,
+		"iat":     now.Unix(),
+		"exp":     now.Add(ttl).Unix(),
+	})
+
+	return claim.SignedString(actionKey(purpose))
+}
+
+// ParseActionToken validates signature, expiry and purpose of an action token
+func ParseActionToken(tokenString, purpose string) (ActionToken, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return actionKey(purpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return ActionToken{}, err
+	}
+
+	if p, _ := claims["purpose"].(string); p != purpose {
+		return ActionToken{}, jwt.ErrTokenInvalidClaims
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil {
+		return ActionToken{}, err
+	}
+	userId, err := strconv.Atoi(sub)
+	if err != nil {
+		return ActionToken{}, jwt.ErrTokenInvalidClaims
+	}
+
+	binding, _ := claims["bind"].(string)
+	return ActionToken{UserId: userId, Purpose: purpose, Binding: binding}, nil
+}
+
+func EmailVerificationTTL() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_TTL_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = DefaultEmailVerificationTTLHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func PasswordResetTTL() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_TTL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = DefaultPasswordResetTTLMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func actionKey(purpose string) []byte {
+	return []byte(os.Getenv("SECRET_KEY") + ":" + purpose)
+}
@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
@@ -127,6 +130,22 @@ func UnprocessableEntityResponse(c echo.Context, message string) error {
 	return sendResponse(c, http.StatusUnprocessableEntity, "error", message, nil)
 }
 
+// TooManyRequestsResponse sends a standard error response with HTTP status 429 Too Many Requests
+// and a Retry-After header in whole seconds
+// Example usage:
+// return utils.TooManyRequestsResponse(c, "Too many requests", time.Minute)
+func TooManyRequestsResponse(c echo.Context, message string, retryAfter time.Duration) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return sendResponse(c, http.StatusTooManyRequests, "error", message, nil)
+}
+
+// ServiceUnavailableResponse sends a standard error response with HTTP status 503 Service Unavailable
+// Example usage:
+// return utils.ServiceUnavailableResponse(c, "Service unavailable")
+func ServiceUnavailableResponse(c echo.Context, message string) error {
+	return sendResponse(c, http.StatusServiceUnavailable, "error", message, nil)
+}
+
 // InternalServerErrorResponse sends a standard error response with HTTP status 500 Internal Server Error
 // Example usage:
 // return utils.InternalServerErrorResponse(c, "Internal server error")
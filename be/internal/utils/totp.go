@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// TOTP follows RFC 6238 with the defaults authenticator apps expect:
+// SHA1, 6 digits and a 30 second step
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+	// TOTPSkew accepts codes from one step before and after, for clock drift
+	TOTPSkew = 1
+
+	// MFAChallengeTTL is how long the second login step may take
+	MFAChallengeTTL = 5 * time.Minute
+	// ReauthWindow is how recent a login must be to stand in for the password
+	// of an account that has none
+	ReauthWindow = 5 * time.Minute
+	// RecoveryCodeCount is how many single-use recovery codes a user gets
+	RecoveryCodeCount = 10
+
+	DefaultTOTPIssuer = "YourDonateRise"
+)
+
+var ErrInvalidTOTPSecret = errors.New("invalid totp secret")
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32 secret for an authenticator app
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI is the otpauth:// URI authenticator apps scan as a QR code
+func TOTPProvisioningURI(account, secret string) string {
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = DefaultTOTPIssuer
+	}
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(TOTPDigits))
+	params.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPStep is the time step a code is generated for
+func TOTPStep(at time.Time) int64 {
+	return at.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// TOTPCode is the code of the secret for one time step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(key) == 0 {
+		return "", ErrInvalidTOTPSecret
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000), nil
+}
+
+// ValidateTOTP returns the step the code belongs to. Steps up to lastStep were
+// already used, so a code is accepted once
+func ValidateTOTP(secret, code string, at time.Time, lastStep int64) (step int64, ok bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(at)
+	for s := current - TOTPSkew; s <= current+TOTPSkew; s++ {
+		if s <= lastStep {
+			continue
+		}
+		expected, err := TOTPCode(secret, s)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes returns codes like "k7q2-9xmd" for the user and the
+// hashes that are stored
+func GenerateRecoveryCodes(n int) (codes []string, hashes []string, err error) {
+	// 32 characters without l, o, 0 and 1, so every random byte maps evenly
+	const alphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+	for i := 0; i < n; i++ {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		for j := range b {
+			b[j] = alphabet[b[j]&31]
+		}
+		code := string(b[:4]) + "-" + string(b[4:])
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode ignores case, spaces and the dash so codes can be typed loosely
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashToken(normalized)
+}
+
+// EncryptTOTPSecret seals the secret with a key derived from SECRET_KEY, a
+// leaked users table does not give away second factors
+func EncryptTOTPSecret(secret string) (string, error) {
+	gcm, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func DecryptTOTPSecret(encrypted string) (string, error) {
+	gcm, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", ErrInvalidTOTPSecret
+	}
+	secret, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalidTOTPSecret
+	}
+	return string(secret), nil
+}
+
+func totpCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(os.Getenv("SECRET_KEY") + ":totp"))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// MFARequired reports whether any of the roles must sign in with a second
+// factor, MFA_REQUIRED_ROLES lists them (admin by default)
+func MFARequired(roles []string) bool {
+	required := os.Getenv("MFA_REQUIRED_ROLES")
+	if required == "" {
+		required = "admin"
+	}
+
+	for _, r := range strings.Split(required, ",") {
+		r = strings.TrimSpace(r)
+		for _, role := range roles {
+			if r != "" && role == r {
+				return true
+			}
+		}
+	}
+	return false
+}
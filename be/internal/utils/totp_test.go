@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the SHA1 vector of RFC 6238, cut to six digits
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	code, err := TOTPCode(rfcTOTPSecret, TOTPStep(time.Unix(59, 0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	code, err = TOTPCode(rfcTOTPSecret, TOTPStep(time.Unix(1111111109, 0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "081804", code)
+
+	_, err = TOTPCode("not base32!", 1)
+	assert.ErrorIs(t, err, ErrInvalidTOTPSecret)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	step := TOTPStep(at)
+
+	got, ok := ValidateTOTP(rfcTOTPSecret, "081 804", at, 0)
+	assert.True(t, ok)
+	assert.Equal(t, step, got)
+
+	_, ok = ValidateTOTP(rfcTOTPSecret, "081804", at.Add(TOTPPeriod), 0)
+	assert.True(t, ok, "the previous step is accepted for clock drift")
+
+	_, ok = ValidateTOTP(rfcTOTPSecret, "081804", at.Add(3*TOTPPeriod), 0)
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(rfcTOTPSecret, "081804", at, step)
+	assert.False(t, ok, "a used step is not accepted again")
+}
+
+func TestTOTPSecretEncryption(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+
+	encrypted, err := EncryptTOTPSecret(rfcTOTPSecret)
+	assert.NoError(t, err)
+	assert.NotContains(t, encrypted, rfcTOTPSecret)
+
+	secret, err := DecryptTOTPSecret(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, rfcTOTPSecret, secret)
+
+	t.Setenv("SECRET_KEY", "another")
+	_, err = DecryptTOTPSecret(encrypted)
+	assert.ErrorIs(t, err, ErrInvalidTOTPSecret)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	assert.NoError(t, err)
+	assert.Len(t, codes, RecoveryCodeCount)
+	assert.Regexp(t, `^[a-z2-9]{4}-[a-z2-9]{4}$`, codes[0])
+	assert.Equal(t, hashes[0], HashRecoveryCode(" "+codes[0][:4]+codes[0][5:]+" "), "codes can be typed without the dash")
+}
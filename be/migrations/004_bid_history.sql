@@ -0,0 +1,16 @@
+CREATE TYPE bid_source AS ENUM ('manual', 'proxy');
+
+-- every accepted bid, amounts only ever rise per item so they are unique
+CREATE TABLE bid_history (
+    id SERIAL PRIMARY KEY,
+    auction_session_id INT NOT NULL REFERENCES auction_sessions(id),
+    auction_item_id INT NOT NULL REFERENCES auction_items(id),
+    user_id INT NOT NULL REFERENCES users(id),
+    amount INT NOT NULL,
+    source bid_source NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (auction_session_id, auction_item_id, amount)
+);
+
+CREATE INDEX idx_bid_history_item ON bid_history (auction_item_id, created_at DESC);
+CREATE INDEX idx_bid_history_user ON bid_history (user_id, created_at DESC);
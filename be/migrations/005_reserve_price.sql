@@ -0,0 +1,4 @@
+-- items whose highest bid stays under the reserve close as unsold
+ALTER TYPE auction_item_status ADD VALUE 'unsold';
+
+ALTER TABLE auction_items ADD COLUMN reserve_price INT;
@@ -0,0 +1,4 @@
+-- buying at this price ends the item's auction right away
+ALTER TABLE auction_items ADD COLUMN buy_now_price INT;
+
+ALTER TYPE bid_source ADD VALUE 'buy_now';
@@ -0,0 +1,13 @@
+-- the repository already writes 'failed', 'expired' marks a lapsed deadline
+ALTER TYPE payment_status ADD VALUE 'failed';
+ALTER TYPE payment_status ADD VALUE 'expired';
+
+ALTER TABLE payments ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE payments ADD COLUMN expires_at TIMESTAMP;
+
+-- a bids row is a winner owing payment, a lapsed winner hands the item to the runner-up
+ALTER TABLE bids ADD COLUMN auction_session_id INT REFERENCES auction_sessions(id);
+ALTER TABLE bids ADD COLUMN payment_deadline TIMESTAMP;
+ALTER TABLE bids ADD COLUMN lapsed BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_bids_payment_deadline ON bids (payment_deadline) WHERE lapsed = FALSE;
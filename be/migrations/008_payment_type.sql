@@ -0,0 +1,2 @@
+-- how the winner paid, charges used to be QRIS only
+ALTER TABLE payments ADD COLUMN payment_type VARCHAR(50) NOT NULL DEFAULT 'qris';
@@ -0,0 +1,27 @@
+ALTER TYPE payment_status ADD VALUE 'cancelled';
+ALTER TYPE payment_status ADD VALUE 'refunded';
+ALTER TYPE payment_status ADD VALUE 'partially_refunded';
+
+-- every refund issued through the gateway, a payment can be refunded in parts
+CREATE TABLE payment_refunds (
+    id SERIAL PRIMARY KEY,
+    payment_id INT NOT NULL REFERENCES payments(id),
+    amount INT NOT NULL,
+    reason TEXT,
+    created_by INT REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_payment_refunds_payment ON payment_refunds (payment_id);
+
+-- every status a payment went through, from_status is null for the first one
+CREATE TABLE payment_status_history (
+    id SERIAL PRIMARY KEY,
+    payment_id INT NOT NULL REFERENCES payments(id),
+    from_status payment_status,
+    to_status payment_status NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_payment_status_history_payment ON payment_status_history (payment_id, created_at);
@@ -0,0 +1,5 @@
+-- accounts must verify their email before they can log in, existing accounts
+-- are treated as verified so nobody gets locked out by this migration
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+UPDATE users SET email_verified_at = NOW() WHERE email_verified_at IS NULL;
@@ -0,0 +1,79 @@
+-- roles hold named permissions and users can hold several roles, this replaces
+-- the single users.role column for authorization
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL
+);
+
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
+
+CREATE TABLE permissions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access, manages users and their roles'),
+    ('verifier', 'Reviews donations and records final donations'),
+    ('auctioneer', 'Manages auction items and sessions'),
+    ('donor', 'Donates goods'),
+    ('bidder', 'Bids on and pays for auction items')
+ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description;
+
+INSERT INTO permissions (name, description) VALUES
+    ('users.manage', 'Assign roles to users'),
+    ('dashboard.view', 'View the admin dashboard'),
+    ('donations.create', 'Submit donations'),
+    ('donations.review', 'See every donation, verify donations and manage final donations'),
+    ('auctions.manage', 'Create, update and delete auction items and sessions'),
+    ('bids.place', 'Bid, buy now and pay for won items'),
+    ('articles.manage', 'Write transparency articles'),
+    ('payments.manage', 'See every payment, cancel, refund and reconcile payments');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON (r.name, p.name) IN (
+    ('admin', 'users.manage'),
+    ('admin', 'dashboard.view'),
+    ('admin', 'donations.create'),
+    ('admin', 'donations.review'),
+    ('admin', 'auctions.manage'),
+    ('admin', 'bids.place'),
+    ('admin', 'articles.manage'),
+    ('admin', 'payments.manage'),
+    ('verifier', 'donations.review'),
+    ('auctioneer', 'auctions.manage'),
+    ('donor', 'donations.create'),
+    ('bidder', 'bids.place')
+);
+
+-- every existing account keeps donating and bidding
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id
+FROM users u
+JOIN roles r ON r.name IN ('donor', 'bidder')
+ON CONFLICT DO NOTHING;
+
+-- users.role holds either the role id or, on older databases, the role name
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id
+FROM users u
+JOIN roles r ON r.name = 'admin'
+WHERE u.role::text IN (r.name, r.id::text)
+ON CONFLICT DO NOTHING;
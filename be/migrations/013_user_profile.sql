@@ -0,0 +1,12 @@
+-- shipping details for won items, email changes wait in pending_email until
+-- the new address is confirmed
+ALTER TABLE users ADD COLUMN phone VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN address TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN city VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN postal_code VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN pending_email VARCHAR(255);
+
+-- a deactivated account cannot log in, a deleted one is also anonymised so its
+-- donations and bids no longer point at a person
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
@@ -0,0 +1,19 @@
+-- TOTP two-factor authentication, the secret is encrypted by the API and
+-- totp_last_step stops a code from being used twice
+ALTER TABLE users
+    ADD COLUMN totp_secret TEXT,
+    ADD COLUMN totp_enabled_at TIMESTAMP,
+    ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- sessions started with a second factor keep it when the refresh token rotates
+ALTER TABLE refresh_tokens ADD COLUMN mfa BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE user_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_recovery_codes_user ON user_recovery_codes (user_id);
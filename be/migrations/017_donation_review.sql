@@ -0,0 +1,21 @@
+ALTER TYPE donation_status ADD VALUE 'rejected';
+ALTER TYPE donation_status ADD VALUE 'needs_info';
+ALTER TYPE donation_status ADD VALUE 'picked_up';
+ALTER TYPE donation_status ADD VALUE 'archived';
+
+-- the reviewer's latest comment, shown to the donor with the donation
+ALTER TABLE donations ADD COLUMN review_comment TEXT;
+
+-- every status a donation went through and who moved it there, from_status is
+-- null for the submission
+CREATE TABLE donation_status_history (
+    id SERIAL PRIMARY KEY,
+    donation_id INT NOT NULL REFERENCES donations(id) ON DELETE CASCADE,
+    from_status donation_status,
+    to_status donation_status NOT NULL,
+    changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    comment TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_donation_status_history_donation ON donation_status_history (donation_id, created_at);
@@ -0,0 +1,15 @@
+-- items created from a verified donation wait as drafts until an admin
+-- schedules them
+ALTER TYPE auction_item_status ADD VALUE 'draft';
+
+-- a donation is auctioned at most once
+CREATE UNIQUE INDEX idx_auction_items_donation ON auction_items (donation_id);
+
+-- photos carried over from the donation, object names in the private bucket
+CREATE TABLE auction_item_photos (
+    id SERIAL PRIMARY KEY,
+    auction_item_id INT NOT NULL REFERENCES auction_items(id) ON DELETE CASCADE,
+    url VARCHAR(255)
+);
+
+CREATE INDEX idx_auction_item_photos_item ON auction_item_photos (auction_item_id);